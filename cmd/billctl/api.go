@@ -0,0 +1,94 @@
+package main
+
+import (
+	"coding-challenge/pkg/client"
+	"context"
+	"time"
+)
+
+// Sends the commands to the REST API, as the customer of the token.
+type apiBackend struct {
+	client *client.Client
+}
+
+var _ backend = &apiBackend{}
+
+func newApiBackend(baseUrl string, token string) *apiBackend {
+	return &apiBackend{client: client.New(baseUrl, token)}
+}
+
+func (a *apiBackend) OpenBill(ctx context.Context, request *client.OpenNewBillRequest) (string, error) {
+	response, err := a.client.OpenNewBill(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	return response.Id, nil
+}
+
+func (a *apiBackend) GetBill(ctx context.Context, billId string) (bill, error) {
+	response, err := a.client.GetBill(ctx, billId)
+	if err != nil {
+		return bill{}, err
+	}
+	return bill{
+		Id:            response.Id,
+		CurrencyCode:  response.CurrencyCode,
+		Status:        response.Status,
+		LineItemCount: response.LineItemCount,
+		Total:         response.Total,
+		CreatedAt:     response.CreatedAt,
+		CloseTime:     response.CloseTime,
+		ClosedAt:      response.ClosedAt,
+	}, nil
+}
+
+func (a *apiBackend) ListLineItems(ctx context.Context, billId string) ([]lineItem, error) {
+	response, err := a.client.ListBillLineItems(ctx, billId)
+	if err != nil {
+		return nil, err
+	}
+	lineItems := make([]lineItem, 0, len(response.LineItems))
+	for _, item := range response.LineItems {
+		lineItems = append(lineItems, lineItem{
+			BillId:       billId,
+			Id:           item.Id,
+			Description:  item.Description,
+			Amount:       item.Amount,
+			CurrencyCode: item.CurrencyCode,
+			CreatedAt:    item.CreatedAt,
+			Quantity:     item.Quantity,
+			UnitPrice:    item.UnitPrice,
+		})
+	}
+	return lineItems, nil
+}
+
+func (a *apiBackend) AddLineItem(ctx context.Context, billId string, request *client.AddBillLineItemRequest) (string, error) {
+	response, err := a.client.AddBillLineItem(ctx, billId, request)
+	if err != nil {
+		return "", err
+	}
+	return response.Id, nil
+}
+
+func (a *apiBackend) CloseBill(ctx context.Context, billId string) error {
+	_, err := a.client.CloseBill(ctx, billId)
+	return err
+}
+
+func (a *apiBackend) ExtendBill(ctx context.Context, billId string, closeTime time.Time) error {
+	_, err := a.client.ExtendBill(ctx, billId, &client.ExtendBillRequest{CloseTime: closeTime})
+	return err
+}
+
+func (a *apiBackend) PublishBill(ctx context.Context, billId string) error {
+	_, err := a.client.PublishBill(ctx, billId)
+	return err
+}
+
+func (a *apiBackend) VoidBill(ctx context.Context, billId string) error {
+	_, err := a.client.VoidBill(ctx, billId)
+	return err
+}
+
+func (a *apiBackend) Shutdown() {}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"coding-challenge/pkg/client"
+	"context"
+	"time"
+)
+
+// What is shown of a bill, the same whether read from the API or from Temporal and Postgres.
+type bill struct {
+	Id            string `json:"id"`
+	CurrencyCode  string `json:"currency_code"`
+	Status        string `json:"status"`
+	LineItemCount uint64 `json:"line_item_count"`
+	// Decimal string in minor units.
+	Total     string     `json:"total"`
+	CreatedAt time.Time  `json:"created_at"`
+	CloseTime time.Time  `json:"close_time"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+}
+
+type lineItem struct {
+	BillId       string    `json:"bill_id"`
+	Id           string    `json:"id"`
+	Description  string    `json:"description"`
+	Amount       int64     `json:"amount"`
+	CurrencyCode string    `json:"currency_code"`
+	CreatedAt    time.Time `json:"created_at"`
+	Quantity     string    `json:"quantity,omitempty"`
+	UnitPrice    string    `json:"unit_price,omitempty"`
+}
+
+// The operations of the commands, through the REST API or directly on Temporal and Postgres.
+type backend interface {
+	// Returns the id of the bill.
+	OpenBill(ctx context.Context, request *client.OpenNewBillRequest) (string, error)
+	GetBill(ctx context.Context, billId string) (bill, error)
+	ListLineItems(ctx context.Context, billId string) ([]lineItem, error)
+	// Returns the id of the line item.
+	AddLineItem(ctx context.Context, billId string, request *client.AddBillLineItemRequest) (string, error)
+	// Waits until the bill is closed.
+	CloseBill(ctx context.Context, billId string) error
+	ExtendBill(ctx context.Context, billId string, closeTime time.Time) error
+	PublishBill(ctx context.Context, billId string) error
+	VoidBill(ctx context.Context, billId string) error
+	Shutdown()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const completionCommand = "completion"
+
+// Completes the commands, then the flags of the command being typed, from the flags billctl defines.
+const bashCompletion = `# bash completion for billctl, load with: source <(billctl completion bash)
+_billctl() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" command="" i
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case "${COMP_WORDS[i]}" in
+		-*) ;;
+		*)
+			case "${COMP_WORDS[i-1]}" in
+			%[1]s) ;;
+			*) command="${COMP_WORDS[i]}"; break ;;
+			esac
+			;;
+		esac
+	done
+	case "$command" in
+	"") COMPREPLY=($(compgen -W "%[2]s %[3]s" -- "$cur")) ;;
+%[4]s	esac
+}
+complete -F _billctl billctl
+`
+
+func runCompletion(args []string, flags *flag.FlagSet, stdout io.Writer, stderr io.Writer) int {
+	if len(args) != 1 || (args[0] != "bash" && args[0] != "zsh") {
+		fmt.Fprintf(stderr, "Usage: billctl %s bash|zsh\n", completionCommand)
+		return exitUsage
+	}
+	if args[0] == "zsh" {
+		fmt.Fprintln(stdout, "# zsh completion for billctl, load with: source <(billctl completion zsh)")
+		fmt.Fprintln(stdout, "autoload -U +X bashcompinit && bashcompinit")
+	}
+	fmt.Fprint(stdout, bashScript(flags))
+	return 0
+}
+
+func bashScript(flags *flag.FlagSet) string {
+	// The global flags that take a value, so that the value is not taken for the command
+	var valueFlags, globalFlags []string
+	flags.VisitAll(func(f *flag.Flag) {
+		globalFlags = append(globalFlags, "-"+f.Name)
+		if !isBoolFlag(f) {
+			valueFlags = append(valueFlags, "-"+f.Name)
+		}
+	})
+	names := []string{completionCommand}
+	var cases strings.Builder
+	for _, c := range commands {
+		names = append(names, c.name)
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		c.setup(fs)
+		var commandFlags []string
+		fs.VisitAll(func(f *flag.Flag) { commandFlags = append(commandFlags, "-"+f.Name) })
+		fmt.Fprintf(&cases, "\t%s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", c.name, strings.Join(commandFlags, " "))
+	}
+	fmt.Fprintf(&cases, "\t%s) COMPREPLY=($(compgen -W \"bash zsh\" -- \"$cur\")) ;;\n", completionCommand)
+	return fmt.Sprintf(bashCompletion, strings.Join(valueFlags, "|"), strings.Join(names, " "), strings.Join(globalFlags, " "), cases.String())
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"coding-challenge/pkg/client"
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	temporal "go.temporal.io/sdk/client"
+)
+
+// Drives the bill workflows on Temporal and reads the bills in Postgres, as an operator acting for a customer.
+// Nothing is authenticated, it is meant for those who already have access to both.
+type directBackend struct {
+	temporal    temporal.Client
+	sql         *sql.DB
+	sqlBillDb   *db.SqlBillDatabase
+	billDb      db.BillDatabase
+	taskQueue   string
+	customerId  model.CustomerId
+	operator    model.Actor
+	idGenerator model.BillIdGenerator
+}
+
+var _ backend = &directBackend{}
+
+type directOptions struct {
+	TemporalHostPort string
+	DatabaseDsn      string
+	TaskQueue        string
+	ArchiveDir       string
+	CustomerId       string
+	Operator         string
+}
+
+func newDirectBackend(options directOptions) (*directBackend, error) {
+	if options.CustomerId == "" {
+		return nil, fmt.Errorf("-%s is required with -%s", customerFlag, directFlag)
+	}
+	temporalClient, err := temporal.Dial(temporal.Options{HostPort: options.TemporalHostPort})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Temporal client: %w", err)
+	}
+	sqlDb, err := sql.Open("postgres", options.DatabaseDsn)
+	if err != nil {
+		temporalClient.Close()
+		return nil, fmt.Errorf("unable to open the database: %w", err)
+	}
+	sqlBillDb := db.NewSqlBillDatabase(sqlDb)
+	return &directBackend{
+		temporal:    temporalClient,
+		sql:         sqlDb,
+		sqlBillDb:   sqlBillDb,
+		billDb:      db.NewArchivedBillDatabase(sqlBillDb, sqlBillDb, db.NewLocalArchiveStore(options.ArchiveDir)),
+		taskQueue:   options.TaskQueue,
+		customerId:  model.CustomerId(options.CustomerId),
+		operator:    model.NewOperatorActor(options.Operator),
+		idGenerator: &model.UuidBillIdGenerator{},
+	}, nil
+}
+
+func (d *directBackend) billId(id string) model.BillId {
+	return model.BillId{CustomerId: d.customerId, Id: id}
+}
+
+func (d *directBackend) OpenBill(ctx context.Context, request *client.OpenNewBillRequest) (string, error) {
+	billId := d.idGenerator.New()
+	options := temporal.StartWorkflowOptions{
+		ID:        workflow.BillingWorkflowId(billId),
+		TaskQueue: d.taskQueue,
+	}
+	if request.IdempotencyKey != "" {
+		// The same id as the API would give, so that both find the bill of the other
+		billId = model.NewIdempotentId(d.customerId, request.IdempotencyKey)
+		options.ID = workflow.BillingWorkflowId(billId)
+		options.WorkflowIDReusePolicy = enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE
+	}
+	currencyCode := model.CurrencyCode(request.CurrencyCode)
+	billInfo := model.BillInfo{
+		Id:              d.billId(billId),
+		CurrencyCode:    currencyCode,
+		Status:          model.Open,
+		TaxJurisdiction: model.TaxJurisdiction(request.TaxJurisdiction),
+		SpendingCap:     model.NewSpendingCap(request.SpendingCap, currencyCode, request.AlertThresholds),
+	}
+	if request.Draft {
+		billInfo.Status = model.Draft
+	}
+	if err := billInfo.SpendingCap.Check(currencyCode); err != nil {
+		return "", fmt.Errorf("invalid spending cap: %w", err)
+	}
+	_, err := d.temporal.ExecuteWorkflow(ctx, options, workflow.BillingWorkflow, billInfo, time.Until(request.CloseTime), d.operator)
+	var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+	if request.IdempotencyKey != "" && errors.As(err, &alreadyStarted) {
+		return billId, nil
+	} else if err != nil {
+		return "", fmt.Errorf("unable to start the bill workflow: %w", err)
+	}
+	return billId, nil
+}
+
+// The state of the running workflow, nil once the workflow is gone.
+func (d *directBackend) queryBillingState(ctx context.Context, billId string) (*workflow.BillingState, error) {
+	encodedResult, err := d.temporal.QueryWorkflow(ctx, workflow.BillingWorkflowId(billId), "", workflow.GetPendingBillStateQuery)
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to query the bill workflow: %w", err)
+	}
+	var state workflow.BillingState
+	if err := encodedResult.Get(&state); err != nil {
+		return nil, fmt.Errorf("unable to decode the bill workflow state: %w", err)
+	} else if state.BillInfo.Id != d.billId(billId) {
+		return nil, fmt.Errorf("bill %s is not a bill of customer %s", billId, d.customerId)
+	}
+	return &state, nil
+}
+
+func newBill(billInfo model.BillInfo, lineItemCount uint64, total model.TotalAmount) bill {
+	b := bill{
+		Id:            billInfo.Id.Id,
+		CurrencyCode:  string(billInfo.CurrencyCode),
+		Status:        billInfo.Status.String(),
+		LineItemCount: lineItemCount,
+		Total:         total.Number,
+		CreatedAt:     billInfo.CreatedAt,
+		CloseTime:     billInfo.CloseTime,
+	}
+	if !billInfo.ClosedAt.IsZero() {
+		closedAt := billInfo.ClosedAt
+		b.ClosedAt = &closedAt
+	}
+	return b
+}
+
+func (d *directBackend) GetBill(ctx context.Context, billId string) (bill, error) {
+	state, err := d.queryBillingState(ctx, billId)
+	if err != nil {
+		return bill{}, err
+	} else if state != nil {
+		return newBill(state.BillInfo, state.BillLineItemCount, state.Total), nil
+	}
+	saved, err := d.billDb.GetBill(ctx, d.billId(billId))
+	if err != nil {
+		return bill{}, fmt.Errorf("unable to get bill %s from the workflow or the database: %w", billId, err)
+	}
+	return newBill(saved.BillInfo, saved.LineItemCount, saved.Total), nil
+}
+
+func (d *directBackend) ListLineItems(ctx context.Context, billId string) ([]lineItem, error) {
+	items, err := d.billDb.GetLineItems(ctx, d.billId(billId))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the line items of bill %s: %w", billId, err)
+	}
+	lineItems := make([]lineItem, 0, len(items))
+	for _, item := range items {
+		lineItems = append(lineItems, lineItem{
+			BillId:       billId,
+			Id:           item.Id.Id,
+			Description:  item.Description,
+			Amount:       item.Amount.Number,
+			CurrencyCode: string(item.Amount.CurrencyCode),
+			CreatedAt:    item.CreatedAt,
+			Quantity:     item.Quantity,
+			UnitPrice:    item.UnitPrice,
+		})
+	}
+	return lineItems, nil
+}
+
+func (d *directBackend) AddLineItem(ctx context.Context, billId string, request *client.AddBillLineItemRequest) (string, error) {
+	var updateId, lineItemId string
+	if request.IdempotencyKey != "" {
+		updateId = model.NewIdempotentId(d.customerId, request.IdempotencyKey)
+		lineItemId = updateId
+	} else {
+		updateId = d.idGenerator.New()
+		lineItemId = d.idGenerator.New()
+	}
+	lineItem := model.BillLineItem{
+		Id:          model.BillLineItemId{BillId: d.billId(billId), Id: lineItemId},
+		Description: request.Description,
+		Amount: model.Amount{
+			CurrencyCode: model.CurrencyCode(request.CurrencyCode),
+			Number:       request.Amount,
+		},
+		TaxCategory:  model.TaxCategory(request.TaxCategory),
+		TaxInclusive: request.TaxInclusive,
+		Quantity:     request.Quantity,
+		UnitPrice:    request.UnitPrice,
+		Rounding:     model.RoundingMode(request.Rounding),
+	}
+	updateHandle, err := d.temporal.UpdateWorkflow(ctx, temporal.UpdateWorkflowOptions{
+		UpdateID:   updateId,
+		WorkflowID: workflow.BillingWorkflowId(billId),
+		UpdateName: workflow.AddBillLineItemUpdate,
+		Args: []interface{}{
+			workflow.AddBillLineItemArgs{LineItem: lineItem, Actor: d.operator, RequestId: updateId},
+		},
+		WaitForStage: temporal.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to add the line item: %w", err)
+	}
+	if err := updateHandle.Get(ctx, nil); err != nil {
+		return "", fmt.Errorf("unable to add the line item: %w", err)
+	}
+	return lineItemId, nil
+}
+
+func (d *directBackend) CloseBill(ctx context.Context, billId string) error {
+	err := d.updateBill(ctx, billId, workflow.CloseBillUpdate, func(updateId string) any {
+		return workflow.CloseBillEarlyArgs{Actor: d.operator, RequestId: updateId}
+	})
+	if err != nil {
+		return fmt.Errorf("unable to close the bill: %w", err)
+	}
+	var finalState workflow.BillingState
+	if err := d.temporal.GetWorkflow(ctx, workflow.BillingWorkflowId(billId), "").Get(ctx, &finalState); err != nil {
+		return fmt.Errorf("unable to wait for the bill to close: %w", err)
+	}
+	return nil
+}
+
+func (d *directBackend) ExtendBill(ctx context.Context, billId string, closeTime time.Time) error {
+	updateId := d.idGenerator.New()
+	updateHandle, err := d.temporal.UpdateWorkflow(ctx, temporal.UpdateWorkflowOptions{
+		UpdateID:   updateId,
+		WorkflowID: workflow.BillingWorkflowId(billId),
+		UpdateName: workflow.ExtendBillUpdate,
+		Args: []interface{}{
+			workflow.ExtendBillArgs{CloseTime: closeTime, Actor: d.operator, RequestId: updateId},
+		},
+		WaitForStage: temporal.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to extend the bill: %w", err)
+	}
+	if err := updateHandle.Get(ctx, nil); err != nil {
+		return fmt.Errorf("unable to extend the bill: %w", err)
+	}
+	return nil
+}
+
+func (d *directBackend) PublishBill(ctx context.Context, billId string) error {
+	if err := d.updateBill(ctx, billId, workflow.PublishBillUpdate, func(updateId string) any {
+		return workflow.PublishBillArgs{Actor: d.operator, RequestId: updateId}
+	}); err != nil {
+		return fmt.Errorf("unable to publish the bill: %w", err)
+	}
+	return nil
+}
+
+func (d *directBackend) VoidBill(ctx context.Context, billId string) error {
+	if err := d.updateBill(ctx, billId, workflow.VoidBillUpdate, func(updateId string) any {
+		return workflow.VoidBillArgs{Actor: d.operator, RequestId: updateId}
+	}); err != nil {
+		return fmt.Errorf("unable to void the bill: %w", err)
+	}
+	return nil
+}
+
+// Sends the update and waits until the workflow completed it.
+func (d *directBackend) updateBill(ctx context.Context, billId string, updateName string, args func(updateId string) any) error {
+	updateId := d.idGenerator.New()
+	updateHandle, err := d.temporal.UpdateWorkflow(ctx, temporal.UpdateWorkflowOptions{
+		UpdateID:     updateId,
+		WorkflowID:   workflow.BillingWorkflowId(billId),
+		UpdateName:   updateName,
+		Args:         []interface{}{args(updateId)},
+		WaitForStage: temporal.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		return err
+	}
+	return updateHandle.Get(ctx, nil)
+}
+
+func (d *directBackend) Shutdown() {
+	d.temporal.Close()
+	d.sql.Close()
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+	"coding-challenge/pkg/client"
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/workflow"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const apiFlag = "api"
+const tokenFlag = "token"
+const directFlag = "direct"
+const temporalFlag = "temporal"
+const databaseFlag = "database"
+const billingQueueFlag = "task-queue"
+const archiveDirFlag = "archive-dir"
+const customerFlag = "customer"
+const operatorFlag = "operator"
+const outputFlag = "o"
+const timeoutFlag = "timeout"
+
+const apiDefault = "http://127.0.0.1:4000"
+const temporalDefault = "localhost:7233"
+
+// HACK the same local database as the worker
+const databaseDefault = "host=localhost port=53339 user=encore-write password=write dbname=rest sslmode=disable"
+
+const exitFailure = 1
+const exitUsage = 2
+
+// Reported without the usage, the command line was understood.
+var errDiscrepancies = errors.New("the workflows and the database disagree")
+
+type environment struct {
+	backend backend
+	stdout  io.Writer
+	output  string
+}
+
+type command struct {
+	name string
+	// The arguments after the flags of the command.
+	arguments string
+	summary   string
+	// Defines the flags of the command, the returned function runs it once they are parsed.
+	setup func(fs *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func envOrDefault(key string, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("billctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	apiUrl := flags.String(apiFlag, envOrDefault("BILLCTL_API", apiDefault), "Specify the base URL of the billing API")
+	token := flags.String(tokenFlag, os.Getenv("BILLCTL_TOKEN"), "Specify the API token of the customer, defaults to $BILLCTL_TOKEN")
+	direct := flags.Bool(directFlag, false, "Talk to Temporal and Postgres directly instead of the API")
+	temporalHostPort := flags.String(temporalFlag, temporalDefault, "Specify the Temporal frontend with -"+directFlag)
+	databaseDsn := flags.String(databaseFlag, envOrDefault("BILLCTL_DATABASE", databaseDefault), "Specify the Postgres connection string with -"+directFlag)
+	taskQueue := flags.String(billingQueueFlag, workflow.BillingQueueDefault, "Specify the billing task queue name with -"+directFlag)
+	archiveDir := flags.String(archiveDirFlag, db.LocalArchiveDirDefault, "Specify the directory of the archived bills with -"+directFlag)
+	customerId := flags.String(customerFlag, "", "Specify the customer of the bills, required with -"+directFlag)
+	operator := flags.String(operatorFlag, os.Getenv("USER"), "Specify the operator recorded in the audit log with -"+directFlag)
+	output := flags.String(outputFlag, tableOutput, "Print a table or json")
+	timeout := flags.Duration(timeoutFlag, time.Minute, "Give up the command after this duration")
+	flags.Usage = func() { printUsage(flags) }
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if err := checkOutput(*output); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	name := flags.Arg(0)
+	if name == completionCommand {
+		return runCompletion(flags.Args()[1:], flags, stdout, stderr)
+	}
+	c := findCommand(name)
+	if c == nil {
+		fmt.Fprintf(stderr, "unknown command %q\n", name)
+		flags.Usage()
+		return exitUsage
+	}
+	commandFlags := flag.NewFlagSet("billctl "+c.name, flag.ContinueOnError)
+	commandFlags.SetOutput(stderr)
+	commandFlags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: billctl [flags] %s [flags] %s\n\n%s\n\n", c.name, c.arguments, c.summary)
+		commandFlags.PrintDefaults()
+	}
+	runCommand := c.setup(commandFlags)
+	commandArgs, err := parseCommandFlags(commandFlags, flags.Args()[1:])
+	if err != nil {
+		return exitUsage
+	}
+
+	var b backend
+	if *direct {
+		b, err = newDirectBackend(directOptions{
+			TemporalHostPort: *temporalHostPort,
+			DatabaseDsn:      *databaseDsn,
+			TaskQueue:        *taskQueue,
+			ArchiveDir:       *archiveDir,
+			CustomerId:       *customerId,
+			Operator:         *operator,
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailure
+		}
+	} else {
+		if *token == "" {
+			fmt.Fprintf(stderr, "-%s or $BILLCTL_TOKEN is required without -%s\n", tokenFlag, directFlag)
+			return exitUsage
+		}
+		b = newApiBackend(*apiUrl, *token)
+	}
+	defer b.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	err = runCommand(ctx, &environment{backend: b, stdout: stdout, output: *output}, commandArgs)
+	var usage usageError
+	if errors.As(err, &usage) {
+		fmt.Fprintln(stderr, err)
+		commandFlags.Usage()
+		return exitUsage
+	} else if errors.Is(err, errDiscrepancies) {
+		fmt.Fprintln(stderr, err)
+		return exitFailure
+	} else if err != nil {
+		fmt.Fprintf(stderr, "billctl %s: %v\n", c.name, err)
+		return exitFailure
+	}
+	return 0
+}
+
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// The flags of a command are accepted before or after its first argument, such as the bill id.
+func parseCommandFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var leading []string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		leading, args = args[:1], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return append(leading, fs.Args()...), nil
+}
+
+func printUsage(flags *flag.FlagSet) {
+	w := flags.Output()
+	fmt.Fprintf(w, "Usage: billctl [flags] <command> [flags] [arguments]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, "  %-11s %s\n\nFlags:\n", completionCommand, "Print the shell completion script, of bash or zsh")
+	flags.PrintDefaults()
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func exactlyOneBillId(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", usageError{"expected a single bill id"}
+	}
+	return args[0], nil
+}
+
+func parseCloseTime(closeTime string) (time.Time, error) {
+	if closeTime == "" {
+		return time.Time{}, usageError{"-close-time is required"}
+	}
+	t, err := time.Parse(time.RFC3339, closeTime)
+	if err != nil {
+		return time.Time{}, usageError{fmt.Sprintf("invalid -close-time, expected RFC 3339 such as 2025-04-01T00:00:00Z: %v", err)}
+	}
+	return t, nil
+}
+
+func parseAlertThresholds(alertThresholds string) ([]uint32, error) {
+	if alertThresholds == "" {
+		return nil, nil
+	}
+	var thresholds []uint32
+	for _, text := range strings.Split(alertThresholds, ",") {
+		threshold, err := strconv.ParseUint(strings.TrimSpace(text), 10, 32)
+		if err != nil {
+			return nil, usageError{fmt.Sprintf("invalid -alert-thresholds %q: %v", alertThresholds, err)}
+		}
+		thresholds = append(thresholds, uint32(threshold))
+	}
+	return thresholds, nil
+}
+
+func printBill(ctx context.Context, env *environment, billId string) error {
+	b, err := env.backend.GetBill(ctx, billId)
+	if err != nil {
+		return err
+	}
+	return printBills(env.stdout, env.output, []bill{b})
+}
+
+var commands = []command{
+	{
+		name:    "open",
+		summary: "Open a new bill, then print it",
+		setup: func(fs *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+			currencyCode := fs.String("currency", "", "Specify the currency of the bill, such as USD")
+			closeTime := fs.String("close-time", "", "Specify when the bill closes, in RFC 3339")
+			taxJurisdiction := fs.String("tax-jurisdiction", "", "Specify the tax jurisdiction, none for an untaxed bill")
+			spendingCap := fs.Int64("spending-cap", 0, "Specify the spending cap in minor units, 0 for an uncapped bill")
+			alertThresholds := fs.String("alert-thresholds", "", "Specify the alert thresholds in percents of the spending cap, such as 50,80,100")
+			draft := fs.Bool("draft", false, "Open the bill as a draft, which refuses line items until it is published")
+			idempotencyKey := fs.String("idempotency-key", "", "Open a single bill whatever the number of times the command runs with this key")
+			return func(ctx context.Context, env *environment, args []string) error {
+				if len(args) != 0 {
+					return usageError{"unexpected arguments"}
+				} else if *currencyCode == "" {
+					return usageError{"-currency is required"}
+				}
+				closeAt, err := parseCloseTime(*closeTime)
+				if err != nil {
+					return err
+				}
+				thresholds, err := parseAlertThresholds(*alertThresholds)
+				if err != nil {
+					return err
+				}
+				billId, err := env.backend.OpenBill(ctx, &client.OpenNewBillRequest{
+					CurrencyCode:    *currencyCode,
+					CloseTime:       closeAt,
+					TaxJurisdiction: *taxJurisdiction,
+					SpendingCap:     *spendingCap,
+					AlertThresholds: thresholds,
+					Draft:           *draft,
+					IdempotencyKey:  *idempotencyKey,
+				})
+				if err != nil {
+					return err
+				}
+				return printBill(ctx, env, billId)
+			}
+		},
+	},
+	{
+		name:      "get",
+		arguments: "<bill id>...",
+		summary:   "Print bills",
+		setup: func(fs *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+			return func(ctx context.Context, env *environment, args []string) error {
+				if len(args) == 0 {
+					return usageError{"expected bill ids"}
+				}
+				bills := make([]bill, 0, len(args))
+				for _, billId := range args {
+					b, err := env.backend.GetBill(ctx, billId)
+					if err != nil {
+						return err
+					}
+					bills = append(bills, b)
+				}
+				return printBills(env.stdout, env.output, bills)
+			}
+		},
+	},
+	{
+		name:      "list",
+		arguments: "<bill id>",
+		summary:   "Print the line items of a bill",
+		setup: func(fs *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+			return func(ctx context.Context, env *environment, args []string) error {
+				billId, err := exactlyOneBillId(args)
+				if err != nil {
+					return err
+				}
+				lineItems, err := env.backend.ListLineItems(ctx, billId)
+				if err != nil {
+					return err
+				}
+				return printLineItems(env.stdout, env.output, lineItems)
+			}
+		},
+	},
+	{
+		name:      "add-item",
+		arguments: "<bill id>",
+		summary:   "Add a line item to an open bill, then print the bill",
+		setup: func(fs *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+			description := fs.String("description", "", "Specify the description of the line item")
+			amount := fs.Int64("amount", 0, "Specify the amount in minor units, ignored with -quantity")
+			currencyCode := fs.String("currency", "", "Specify the currency of the amount, converted when not the one of the bill")
+			quantity := fs.String("quantity", "", "Compute the amount as this decimal quantity times -unit-price")
+			unitPrice := fs.String("unit-price", "", "Specify the decimal unit price in major units")
+			rounding := fs.String("rounding", "", "Specify the rounding of the computed amount, half_even, half_up or down")
+			taxCategory := fs.String("tax-category", "", "Specify the tax category, the standard one when empty")
+			taxInclusive := fs.Bool("tax-inclusive", false, "The amount includes the tax")
+			idempotencyKey := fs.String("idempotency-key", "", "Add a single line item whatever the number of times the command runs with this key")
+			return func(ctx context.Context, env *environment, args []string) error {
+				billId, err := exactlyOneBillId(args)
+				if err != nil {
+					return err
+				} else if *currencyCode == "" {
+					return usageError{"-currency is required"}
+				}
+				_, err = env.backend.AddLineItem(ctx, billId, &client.AddBillLineItemRequest{
+					Description:    *description,
+					Amount:         *amount,
+					CurrencyCode:   *currencyCode,
+					TaxCategory:    *taxCategory,
+					TaxInclusive:   *taxInclusive,
+					Quantity:       *quantity,
+					UnitPrice:      *unitPrice,
+					Rounding:       *rounding,
+					IdempotencyKey: *idempotencyKey,
+				})
+				if err != nil {
+					return err
+				}
+				return printBill(ctx, env, billId)
+			}
+		},
+	},
+	{
+		name:      "close",
+		arguments: "<bill id>",
+		summary:   "Close a bill now, then print it",
+		setup: func(fs *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+			return func(ctx context.Context, env *environment, args []string) error {
+				billId, err := exactlyOneBillId(args)
+				if err != nil {
+					return err
+				}
+				if err := env.backend.CloseBill(ctx, billId); err != nil {
+					return err
+				}
+				return printBill(ctx, env, billId)
+			}
+		},
+	},
+	{
+		name:      "extend",
+		arguments: "<bill id>",
+		summary:   "Postpone the close of an open bill, then print it",
+		setup: func(fs *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+			closeTime := fs.String("close-time", "", "Specify the new close time, in RFC 3339")
+			return func(ctx context.Context, env *environment, args []string) error {
+				billId, err := exactlyOneBillId(args)
+				if err != nil {
+					return err
+				}
+				closeAt, err := parseCloseTime(*closeTime)
+				if err != nil {
+					return err
+				}
+				if err := env.backend.ExtendBill(ctx, billId, closeAt); err != nil {
+					return err
+				}
+				return printBill(ctx, env, billId)
+			}
+		},
+	},
+	{
+		name:      "publish",
+		arguments: "<bill id>",
+		summary:   "Open a draft to line items, then print it",
+		setup: func(fs *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+			return func(ctx context.Context, env *environment, args []string) error {
+				billId, err := exactlyOneBillId(args)
+				if err != nil {
+					return err
+				}
+				if err := env.backend.PublishBill(ctx, billId); err != nil {
+					return err
+				}
+				return printBill(ctx, env, billId)
+			}
+		},
+	},
+	{
+		name:      "void",
+		arguments: "<bill id>",
+		summary:   "Cancel a draft or an open bill, then print it",
+		setup: func(fs *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+			return func(ctx context.Context, env *environment, args []string) error {
+				billId, err := exactlyOneBillId(args)
+				if err != nil {
+					return err
+				}
+				if err := env.backend.VoidBill(ctx, billId); err != nil {
+					return err
+				}
+				return printBill(ctx, env, billId)
+			}
+		},
+	},
+	{
+		name:      "reconcile",
+		arguments: "[bill id...]",
+		summary:   "Compare the bill workflows with the database, the running bills of the customer by default",
+		setup: func(fs *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+			return func(ctx context.Context, env *environment, args []string) error {
+				direct, ok := env.backend.(*directBackend)
+				if !ok {
+					return usageError{"reconcile reads Temporal and Postgres, it requires -" + directFlag}
+				}
+				discrepancies, err := direct.Reconcile(ctx, args)
+				if err != nil {
+					return err
+				}
+				if err := printDiscrepancies(env.stdout, env.output, discrepancies); err != nil {
+					return err
+				} else if len(discrepancies) > 0 {
+					return errDiscrepancies
+				}
+				return nil
+			}
+		},
+	},
+	{
+		name:      "export",
+		arguments: "<bill id>...",
+		summary:   "Write bills and their line items as csv or json",
+		setup: func(fs *flag.FlagSet) func(ctx context.Context, env *environment, args []string) error {
+			format := fs.String("format", csvFormat, "Write csv, one row per line item, or json")
+			return func(ctx context.Context, env *environment, args []string) error {
+				if len(args) == 0 {
+					return usageError{"expected bill ids"}
+				} else if *format != csvFormat && *format != jsonFormat {
+					return usageError{fmt.Sprintf("unknown -format %q, expected %s or %s", *format, csvFormat, jsonFormat)}
+				}
+				bills := make([]exportedBill, 0, len(args))
+				for _, billId := range args {
+					b, err := env.backend.GetBill(ctx, billId)
+					if err != nil {
+						return err
+					}
+					lineItems, err := env.backend.ListLineItems(ctx, billId)
+					if err != nil {
+						return err
+					}
+					bills = append(bills, exportedBill{bill: b, LineItems: lineItems})
+				}
+				if *format == jsonFormat {
+					return printJson(env.stdout, bills)
+				}
+				return exportCsv(env.stdout, bills)
+			}
+		},
+	},
+}
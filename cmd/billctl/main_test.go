@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const billJson = `{"id":"ca06186a-1f96-4398-9244-fbddf4ef2642","currency_code":"USD","status":"open","line_item_count":1,` +
+	`"total":"100","created_at":"2025-03-01T00:00:00Z","close_time":"2025-04-01T00:00:00Z"}`
+
+func runBillctl(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	exitCode := run(args, &stdout, &stderr)
+	return exitCode, stdout.String(), stderr.String()
+}
+
+func TestAddItemThenPrintsBill(t *testing.T) {
+	// Arrange
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token-alice", r.Header.Get("Authorization"))
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPost {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"description":"Matchbox","amount":100,"currency_code":"USD"}`, string(body))
+			fmt.Fprint(w, `{"id":"a579a2e5-9c31-473e-94ed-577c7cd14acd","currency_code":"USD","line_item_count":1,"total":"100"}`)
+			return
+		}
+		fmt.Fprint(w, billJson)
+	}))
+	defer server.Close()
+
+	// Act
+	exitCode, stdout, stderr := runBillctl("-api", server.URL, "-token", "token-alice",
+		"add-item", "ca06186a-1f96-4398-9244-fbddf4ef2642", "-description", "Matchbox", "-amount", "100", "-currency", "USD")
+
+	// Assert
+	assert.Equal(t, 0, exitCode, stderr)
+	assert.Equal(t, []string{
+		"POST /bill/ca06186a-1f96-4398-9244-fbddf4ef2642/line-items",
+		"GET /bill/ca06186a-1f96-4398-9244-fbddf4ef2642",
+	}, paths)
+	assert.Equal(t, ""+
+		"ID                                    STATUS  CURRENCY  LINE ITEMS  TOTAL  CREATED               CLOSES                CLOSED\n"+
+		"ca06186a-1f96-4398-9244-fbddf4ef2642  open    USD       1           100    2025-03-01T00:00:00Z  2025-04-01T00:00:00Z  \n",
+		stdout)
+}
+
+func TestGetPrintsJson(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, billJson)
+	}))
+	defer server.Close()
+
+	// Act
+	exitCode, stdout, stderr := runBillctl("-api", server.URL, "-token", "token-alice", "-o", "json", "get", "ca06186a-1f96-4398-9244-fbddf4ef2642")
+
+	// Assert
+	assert.Equal(t, 0, exitCode, stderr)
+	assert.JSONEq(t, billJson, stdout)
+}
+
+func TestExportWritesCsv(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bill/ca06186a-1f96-4398-9244-fbddf4ef2642/line-items" {
+			fmt.Fprint(w, `{"line_items":[{"id":"a579a2e5-9c31-473e-94ed-577c7cd14acd","description":"Matchbox","amount":100,`+
+				`"currency_code":"USD","created_at":"2025-03-02T00:00:00Z"}]}`)
+			return
+		}
+		fmt.Fprint(w, billJson)
+	}))
+	defer server.Close()
+
+	// Act
+	exitCode, stdout, stderr := runBillctl("-api", server.URL, "-token", "token-alice", "export", "ca06186a-1f96-4398-9244-fbddf4ef2642")
+
+	// Assert
+	assert.Equal(t, 0, exitCode, stderr)
+	assert.Equal(t, ""+
+		"bill_id,bill_status,bill_currency_code,bill_total,bill_created_at,bill_closed_at,line_item_id,description,amount,currency_code,quantity,unit_price,created_at\n"+
+		"ca06186a-1f96-4398-9244-fbddf4ef2642,open,USD,100,2025-03-01T00:00:00Z,,a579a2e5-9c31-473e-94ed-577c7cd14acd,Matchbox,100,USD,,,2025-03-02T00:00:00Z\n",
+		stdout)
+}
+
+func TestApiErrorExitsWithFailure(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"code":"invalid_argument","message":"close time is not later than the current one","details":null}`)
+	}))
+	defer server.Close()
+
+	// Act
+	exitCode, _, stderr := runBillctl("-api", server.URL, "-token", "token-alice",
+		"extend", "ca06186a-1f96-4398-9244-fbddf4ef2642", "-close-time", "2025-03-15T00:00:00Z")
+
+	// Assert
+	assert.Equal(t, exitFailure, exitCode)
+	assert.Contains(t, stderr, "close time is not later than the current one")
+}
+
+func TestReconcileRequiresDirect(t *testing.T) {
+	// Act
+	exitCode, _, stderr := runBillctl("-token", "token-alice", "reconcile")
+
+	// Assert
+	assert.Equal(t, exitUsage, exitCode)
+	assert.Contains(t, stderr, "requires -direct")
+}
+
+func TestOpenRequiresCloseTime(t *testing.T) {
+	// Act
+	exitCode, _, stderr := runBillctl("-token", "token-alice", "open", "-currency", "USD")
+
+	// Assert
+	assert.Equal(t, exitUsage, exitCode)
+	assert.Contains(t, stderr, "-close-time is required")
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const tableOutput = "table"
+const jsonOutput = "json"
+
+const csvFormat = "csv"
+const jsonFormat = "json"
+
+func checkOutput(output string) error {
+	if output != tableOutput && output != jsonOutput {
+		return fmt.Errorf("unknown -%s %q, expected %s or %s", outputFlag, output, tableOutput, jsonOutput)
+	}
+	return nil
+}
+
+func printJson(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func printTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatClosedAt(closedAt *time.Time) string {
+	if closedAt == nil {
+		return ""
+	}
+	return formatTime(*closedAt)
+}
+
+func printBills(w io.Writer, output string, bills []bill) error {
+	if output == jsonOutput {
+		if len(bills) == 1 {
+			return printJson(w, bills[0])
+		}
+		return printJson(w, bills)
+	}
+	rows := make([][]string, 0, len(bills))
+	for _, b := range bills {
+		rows = append(rows, []string{
+			b.Id, b.Status, b.CurrencyCode, fmt.Sprint(b.LineItemCount), b.Total,
+			formatTime(b.CreatedAt), formatTime(b.CloseTime), formatClosedAt(b.ClosedAt),
+		})
+	}
+	return printTable(w, []string{"ID", "STATUS", "CURRENCY", "LINE ITEMS", "TOTAL", "CREATED", "CLOSES", "CLOSED"}, rows)
+}
+
+func printLineItems(w io.Writer, output string, lineItems []lineItem) error {
+	if output == jsonOutput {
+		return printJson(w, lineItems)
+	}
+	rows := make([][]string, 0, len(lineItems))
+	for _, item := range lineItems {
+		rows = append(rows, []string{
+			item.Id, item.Description, fmt.Sprint(item.Amount), item.CurrencyCode,
+			item.Quantity, item.UnitPrice, formatTime(item.CreatedAt),
+		})
+	}
+	return printTable(w, []string{"ID", "DESCRIPTION", "AMOUNT", "CURRENCY", "QUANTITY", "UNIT PRICE", "CREATED"}, rows)
+}
+
+func printDiscrepancies(w io.Writer, output string, discrepancies []discrepancy) error {
+	if output == jsonOutput {
+		return printJson(w, discrepancies)
+	}
+	rows := make([][]string, 0, len(discrepancies))
+	for _, d := range discrepancies {
+		rows = append(rows, []string{d.BillId, d.Field, d.Workflow, d.Database})
+	}
+	return printTable(w, []string{"BILL", "FIELD", "WORKFLOW", "DATABASE"}, rows)
+}
+
+type exportedBill struct {
+	bill
+	LineItems []lineItem `json:"line_items"`
+}
+
+// One row per line item, the bill columns repeated, and a row without line item for a bill without any.
+func exportCsv(w io.Writer, bills []exportedBill) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{
+		"bill_id", "bill_status", "bill_currency_code", "bill_total", "bill_created_at", "bill_closed_at",
+		"line_item_id", "description", "amount", "currency_code", "quantity", "unit_price", "created_at",
+	})
+	for _, b := range bills {
+		billColumns := []string{b.Id, b.Status, b.CurrencyCode, b.Total, formatTime(b.CreatedAt), formatClosedAt(b.ClosedAt)}
+		if len(b.LineItems) == 0 {
+			writer.Write(append(billColumns, "", "", "", "", "", "", ""))
+		}
+		for _, item := range b.LineItems {
+			writer.Write(append(slices.Clone(billColumns),
+				item.Id, item.Description, fmt.Sprint(item.Amount), item.CurrencyCode, item.Quantity, item.UnitPrice, formatTime(item.CreatedAt),
+			))
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
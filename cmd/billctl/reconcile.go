@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// A field of a bill on which the workflow and the database disagree.
+type discrepancy struct {
+	BillId   string `json:"bill_id"`
+	Field    string `json:"field"`
+	Workflow string `json:"workflow"`
+	Database string `json:"database"`
+}
+
+// Compares the running bill workflows with their bills in the database, those of the customer when no id is given.
+// The workflow saves each change before it answers, they only disagree when an activity keeps failing.
+func (d *directBackend) Reconcile(ctx context.Context, billIds []string) ([]discrepancy, error) {
+	if len(billIds) == 0 {
+		running, err := d.sqlBillDb.GetRunningBillIds(ctx, d.customerId)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the running bills: %w", err)
+		}
+		for _, billId := range running {
+			billIds = append(billIds, billId.Id)
+		}
+	}
+	discrepancies := []discrepancy{}
+	for _, billId := range billIds {
+		state, err := d.queryBillingState(ctx, billId)
+		if err != nil {
+			return nil, err
+		}
+		saved, err := d.billDb.GetBill(ctx, d.billId(billId))
+		if err != nil {
+			return nil, fmt.Errorf("unable to get bill %s from the database: %w", billId, err)
+		}
+		if state == nil {
+			// Open or closing, the workflow should still run
+			if saved.BillInfo.Status.AcceptsLineItems() {
+				discrepancies = append(discrepancies, discrepancy{billId, "workflow", "not found", saved.BillInfo.Status.String()})
+			}
+			continue
+		}
+		fields := []struct {
+			name     string
+			workflow string
+			database string
+		}{
+			{"status", state.BillInfo.Status.String(), saved.BillInfo.Status.String()},
+			{"line_item_count", fmt.Sprint(state.BillLineItemCount), fmt.Sprint(saved.LineItemCount)},
+			{"total", state.Total.Number, saved.Total.Number},
+			{"close_time", state.BillInfo.CloseTime.UTC().String(), saved.BillInfo.CloseTime.UTC().String()},
+		}
+		for _, field := range fields {
+			if field.workflow != field.database {
+				discrepancies = append(discrepancies, discrepancy{billId, field.name, field.workflow, field.database})
+			}
+		}
+	}
+	return discrepancies, nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"coding-challenge/pkg/openapi"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+const moduleFlag = "module"
+const outFlag = "out"
+
+func main() {
+	moduleDir := flag.String(moduleFlag, ".", "Specify the directory of the module, with its go.mod")
+	out := flag.String(outFlag, "openapi.json", "Specify the file to write the OpenAPI document to")
+	flag.Parse()
+
+	document, err := openapi.Generate(*moduleDir)
+	if err != nil {
+		log.Fatalf("unable to describe the endpoints: %v", err)
+	}
+	data, err := document.Marshal()
+	if err != nil {
+		log.Fatalf("unable to encode the OpenAPI document: %v", err)
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		log.Fatalf("unable to write --%s: %v", outFlag, err)
+	}
+	fmt.Printf("Wrote %d paths to %s\n", len(document.Paths), *out)
+}
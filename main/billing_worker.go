@@ -2,30 +2,64 @@ package main
 
 import (
 	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/gateway"
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/telemetry"
 	"coding-challenge/pkg/workflow"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"time"
 
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 )
 
 const billingQueueFlag = "task-queue"
+const archiveDirFlag = "archive-dir"
+const archiveAfterDaysFlag = "archive-after-days"
+const fxRatesFileFlag = "fx-rates-file"
+const fxRatesSqlFlag = "fx-rates-sql"
+const fakePaymentOutcomesFlag = "fake-payment-outcomes"
+const dunningSchedulesFlag = "dunning-schedules"
+const telemetryExporterFlag = "telemetry-exporter"
 
 func main() {
 	// Define a flag for the task queue
 	taskQueue := flag.String(billingQueueFlag, workflow.BillingQueueDefault, "Specify the billing task queue name")
+	archiveDir := flag.String(archiveDirFlag, db.LocalArchiveDirDefault, "Specify the directory of the archived bills")
+	archiveAfterDays := flag.Int(archiveAfterDaysFlag, 0, "Archive daily the bills closed for more than this number of days, 0 to not archive")
+	fxRatesFile := flag.String(fxRatesFileFlag, "", "Specify a CSV file of fx rates to accept line items in a foreign currency")
+	fxRatesSql := flag.Bool(fxRatesSqlFlag, false, "Read the fx rates from the FxRate table to accept line items in a foreign currency")
+	fakePaymentOutcomes := flag.String(fakePaymentOutcomesFlag, "", "Specify the outcomes of the fake payment gateway charges in order, such as fail,decline,succeed, then they succeed")
+	dunningSchedules := flag.String(dunningSchedulesFlag, "", "Specify the dunning schedule of customer tiers in days since the payment failed, such as standard=1,3,7,14;premium=3,7,14,30")
+	telemetryExporter := flag.String(telemetryExporterFlag, telemetry.NoExporter, "Specify the exporter of the traces and metrics, stdout, or otlp to send them to OTEL_EXPORTER_OTLP_ENDPOINT")
 	flag.Parse()
 
 	fmt.Printf("Starting worker for task queue: %s\n", *taskQueue)
 
-	// Create Temporal client
-	client, err := client.Dial(client.Options{})
+	shutdownTelemetry, err := telemetry.Setup(context.Background(), "billing-worker", *telemetryExporter)
+	if err != nil {
+		log.Fatalf("unable to set up telemetry: %v", err)
+	}
+	defer func() {
+		if err := shutdownTelemetry(context.Background()); err != nil {
+			log.Printf("unable to flush telemetry: %v", err)
+		}
+	}()
+
+	// Create Temporal client, its interceptors trace and measure the workflows and activities of the worker too
+	client, err := client.Dial(client.Options{Interceptors: telemetry.TemporalInterceptors()})
 	if err != nil {
 		log.Fatalf("unable to create Temporal client: %v", err)
 	}
 	defer client.Close()
+	registerSearchAttributes(client)
 
 	// Create a worker for a specific task queue
 	w := worker.New(client, *taskQueue, worker.Options{})
@@ -33,20 +67,91 @@ func main() {
 	// Register your workflow and activities
 	w.RegisterWorkflow(workflow.BillingWorkflow)
 
-	activityHolder, err := activity.NewPostgreSqlActivityHost(activity.PostgreSqlConnection{
+	postgreSqlConnection := activity.PostgreSqlConnection{
 		Host: "localhost",
 		// HACK find better way to get these values
 		Port:   53339,
 		User:   "encore-write",
 		Pass:   "write",
 		DbName: "rest",
-	})
+	}
+	activityHolder, err := activity.NewPostgreSqlActivityHost(postgreSqlConnection)
 	if err != nil {
 		log.Fatalf("unable to create activity host: %v", err)
 	}
 	w.RegisterActivity(activityHolder.CreateBillIfNotExistActivity)
 	w.RegisterActivity(activityHolder.AddBillLineItemIfNotExistActivity)
+	w.RegisterActivity(activityHolder.AddBillLineItemsIfNotExistActivity)
 	w.RegisterActivity(activityHolder.CloseBillActivity)
+	w.RegisterActivity(activityHolder.SetBillStatusActivity)
+	w.RegisterActivity(activityHolder.VoidBillActivity)
+	w.RegisterActivity(activityHolder.SetBillCloseTimeActivity)
+	w.RegisterActivity(activityHolder.SetBillSpendingCapActivity)
+	w.RegisterActivity(activityHolder.RecordAuditEntryActivity)
+
+	// Registered even without rates so that the bills refuse foreign currency line items up front
+	fxRates, err := newFxRateProvider(postgreSqlConnection, *fxRatesFile, *fxRatesSql)
+	if err != nil {
+		log.Fatalf("unable to create fx rate provider: %v", err)
+	}
+	fxConverter := activity.NewFxConverter(fxRates)
+	w.RegisterActivity(fxConverter.ConvertLineItemActivity)
+	w.RegisterActivity(fxConverter.FxAvailableActivity)
+	taxCalculator, err := activity.NewPostgreSqlTaxCalculator(postgreSqlConnection)
+	if err != nil {
+		log.Fatalf("unable to create tax calculator: %v", err)
+	}
+	w.RegisterActivity(taxCalculator.ComputeBillTaxActivity)
+	couponRedeemer, err := activity.NewPostgreSqlCouponRedeemer(postgreSqlConnection)
+	if err != nil {
+		log.Fatalf("unable to create coupon redeemer: %v", err)
+	}
+	w.RegisterActivity(couponRedeemer.RedeemCouponActivity)
+	w.RegisterActivity(couponRedeemer.ApplyBillDiscountsActivity)
+	usageAggregator, err := activity.NewPostgreSqlUsageAggregator(postgreSqlConnection)
+	if err != nil {
+		log.Fatalf("unable to create usage aggregator: %v", err)
+	}
+	w.RegisterActivity(usageAggregator.AggregateUsageActivity)
+
+	w.RegisterWorkflow(workflow.PaymentWorkflow)
+	outcomes, err := gateway.ParseFakeOutcomes(*fakePaymentOutcomes)
+	if err != nil {
+		log.Fatalf("unable to parse --%s: %v", fakePaymentOutcomesFlag, err)
+	}
+	paymentCollector, err := activity.NewPostgreSqlPaymentCollector(postgreSqlConnection, gateway.NewFakePaymentGateway(outcomes...))
+	if err != nil {
+		log.Fatalf("unable to create payment collector: %v", err)
+	}
+	w.RegisterActivity(paymentCollector.ChargeBillActivity)
+	w.RegisterActivity(paymentCollector.RecordPaymentAttemptActivity)
+
+	w.RegisterWorkflow(workflow.DunningWorkflow)
+	schedules, err := model.ParseDunningSchedules(*dunningSchedules)
+	if err != nil {
+		log.Fatalf("unable to parse --%s: %v", dunningSchedulesFlag, err)
+	}
+	dunner, err := activity.NewPostgreSqlDunner(postgreSqlConnection, schedules, gateway.LogNotifier{})
+	if err != nil {
+		log.Fatalf("unable to create dunner: %v", err)
+	}
+	w.RegisterActivity(dunner.GetDunningScheduleActivity)
+	w.RegisterActivity(dunner.NotifyCustomerActivity)
+	w.RegisterActivity(activity.NewSpendingAlerter(gateway.LogNotifier{}).NotifySpendingAlertActivity)
+
+	w.RegisterWorkflow(workflow.SplitChargeWorkflow)
+	w.RegisterActivity(activity.NewSplitCharger(workflow.NewBillLineItemAdder(client)).AddSplitLineItemActivity)
+
+	w.RegisterWorkflow(workflow.ArchiveBillsWorkflow)
+	archiver, err := activity.NewPostgreSqlBillArchiver(postgreSqlConnection, db.NewLocalArchiveStore(*archiveDir))
+	if err != nil {
+		log.Fatalf("unable to create bill archiver: %v", err)
+	}
+	w.RegisterActivity(archiver.ListBillsToArchiveActivity)
+	w.RegisterActivity(archiver.ArchiveBillsActivity)
+	if 0 < *archiveAfterDays {
+		scheduleArchival(client, *taskQueue, time.Duration(*archiveAfterDays)*24*time.Hour)
+	}
 
 	// Start the worker
 	err = w.Run(worker.InterruptCh())
@@ -54,3 +159,49 @@ func main() {
 		log.Fatalf("unable to start worker: %v", err)
 	}
 }
+
+func scheduleArchival(c client.Client, taskQueue string, olderThan time.Duration) {
+	_, err := c.ExecuteWorkflow(
+		context.Background(),
+		client.StartWorkflowOptions{
+			ID:           workflow.ArchiveBillsWorkflowId,
+			TaskQueue:    taskQueue,
+			CronSchedule: "@daily",
+		},
+		workflow.ArchiveBillsWorkflow,
+		olderThan,
+		workflow.ArchiveBatchSizeDefault)
+	var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+	if errors.As(err, &alreadyStarted) {
+		fmt.Println("Archival already scheduled")
+	} else if err != nil {
+		log.Fatalf("unable to schedule archival: %v", err)
+	}
+}
+
+// The bill workflows fail their tasks when they upsert search attributes that the namespace does not have.
+func registerSearchAttributes(c client.Client) {
+	_, err := c.OperatorService().AddSearchAttributes(context.Background(), &operatorservice.AddSearchAttributesRequest{
+		Namespace:        client.DefaultNamespace,
+		SearchAttributes: workflow.SearchAttributeTypes(),
+	})
+	var alreadyExists *serviceerror.AlreadyExists
+	if errors.As(err, &alreadyExists) {
+		fmt.Println("Search attributes already registered")
+	} else if err != nil {
+		log.Fatalf("unable to register search attributes: %v", err)
+	}
+}
+
+func newFxRateProvider(conn activity.PostgreSqlConnection, file string, useSql bool) (db.FxRateProvider, error) {
+	switch {
+	case file != "" && useSql:
+		return nil, fmt.Errorf("only one of --%s and --%s can be given", fxRatesFileFlag, fxRatesSqlFlag)
+	case file != "":
+		return db.LoadFileFxRateProvider(file)
+	case useSql:
+		return activity.NewPostgreSqlFxRateProvider(conn)
+	default:
+		return nil, nil
+	}
+}
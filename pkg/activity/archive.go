@@ -0,0 +1,75 @@
+package activity
+
+import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"time"
+)
+
+const ArchiveActivityTimeout = time.Minute
+
+type ArchiveActivityHost interface {
+	ListBillsToArchiveActivity(ctx context.Context, closedBefore time.Time, limit int) ([]model.BillId, error)
+	ArchiveBillsActivity(ctx context.Context, billIds []model.BillId, segmentKey string, archivedAt time.Time) (uint64, error)
+}
+
+type DummyArchiveActivityHost struct {
+}
+
+var _ ArchiveActivityHost = &DummyArchiveActivityHost{}
+
+func (d *DummyArchiveActivityHost) ListBillsToArchiveActivity(ctx context.Context, closedBefore time.Time, limit int) ([]model.BillId, error) {
+	panic("Not implemented")
+}
+
+func (d *DummyArchiveActivityHost) ArchiveBillsActivity(ctx context.Context, billIds []model.BillId, segmentKey string, archivedAt time.Time) (uint64, error) {
+	panic("Not implemented")
+}
+
+type BillArchiver struct {
+	db    db.BillArchiveDatabase
+	store db.ArchiveStore
+}
+
+var _ ArchiveActivityHost = &BillArchiver{}
+
+func NewPostgreSqlBillArchiver(conn PostgreSqlConnection, store db.ArchiveStore) (*BillArchiver, error) {
+	sql, err := openPostgreSql(conn)
+	if err != nil {
+		return nil, err
+	}
+	return NewBillArchiver(db.NewSqlBillDatabase(sql), store), nil
+}
+
+func NewBillArchiver(billDb db.BillArchiveDatabase, store db.ArchiveStore) *BillArchiver {
+	return &BillArchiver{db: billDb, store: store}
+}
+
+func (a *BillArchiver) ListBillsToArchiveActivity(ctx context.Context, closedBefore time.Time, limit int) ([]model.BillId, error) {
+	return a.db.ListBillsClosedBefore(ctx, closedBefore, limit)
+}
+
+// The segment is written before the bills are tombstoned so that a bill is never lost.
+// When retried after the tombstoning, there is nothing left to archive and the segment is left untouched.
+func (a *BillArchiver) ArchiveBillsActivity(ctx context.Context, billIds []model.BillId, segmentKey string, archivedAt time.Time) (uint64, error) {
+	bills, err := a.db.GetBillsToArchive(ctx, billIds)
+	if err != nil {
+		return 0, err
+	}
+	if len(bills) == 0 {
+		return 0, nil
+	}
+	segment, err := db.EncodeArchiveSegment(bills)
+	if err != nil {
+		return 0, err
+	}
+	if err := a.store.Put(segmentKey, segment); err != nil {
+		return 0, err
+	}
+	archivedIds := make([]model.BillId, 0, len(bills))
+	for _, bill := range bills {
+		archivedIds = append(archivedIds, bill.Bill.BillInfo.Id)
+	}
+	return a.db.TombstoneBills(ctx, archivedIds, segmentKey, archivedAt)
+}
@@ -0,0 +1,145 @@
+package activity_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchivedBillIsReadTransparently(t *testing.T) {
+	// Arrange
+	billDb := db.NewInMemoryBillDatabase()
+	store := db.NewInMemoryArchiveStore()
+	archiver := activity.NewBillArchiver(billDb, store)
+	decorated := db.NewArchivedBillDatabase(billDb, billDb, store)
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	closedAt := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	oldBill := model.BillInfo{
+		Id:           model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"},
+		CurrencyCode: "USD",
+		Status:       model.Open,
+		CreatedAt:    createdAt,
+		CloseTime:    closedAt,
+	}
+	recentBill := model.BillInfo{
+		Id:           model.BillId{CustomerId: "alice", Id: "0f9b3f0e-5a47-4b43-b8a4-76f6b7c0c3c4"},
+		CurrencyCode: "USD",
+		Status:       model.Open,
+	}
+	lineItem := model.BillLineItem{
+		Id:          model.BillLineItemId{BillId: oldBill.Id, Id: "5a61aae5-e120-4ddb-a15a-34cdfa74a1b6"},
+		Description: "Matchbox",
+		Amount:      model.Amount{Number: 100, CurrencyCode: "USD"},
+		CreatedAt:   createdAt.Add(time.Hour),
+	}
+	_, err := decorated.CreateBill(context.Background(), oldBill)
+	assert.NoError(t, err)
+	_, err = decorated.CreateBill(context.Background(), recentBill)
+	assert.NoError(t, err)
+	_, err = decorated.AddLineItem(context.Background(), lineItem, model.TotalAmount{})
+	assert.NoError(t, err)
+	_, err = decorated.SetBillStatus(context.Background(), oldBill.Id, model.Closing)
+	assert.NoError(t, err)
+	_, err = decorated.CloseBill(context.Background(), oldBill.Id, closedAt)
+	assert.NoError(t, err)
+	_, err = decorated.SetBillStatus(context.Background(), recentBill.Id, model.Closing)
+	assert.NoError(t, err)
+	_, err = decorated.CloseBill(context.Background(), recentBill.Id, closedAt.Add(90*24*time.Hour))
+	assert.NoError(t, err)
+	before, err := decorated.GetBill(context.Background(), oldBill.Id)
+	assert.NoError(t, err)
+
+	// Act
+	billIds, err := archiver.ListBillsToArchiveActivity(context.Background(), closedAt.Add(30*24*time.Hour), 10)
+	assert.NoError(t, err)
+	archived, err := archiver.ArchiveBillsActivity(context.Background(), billIds, "2025/03/02/run-0000.jsonl.gz", closedAt.Add(30*24*time.Hour))
+	assert.NoError(t, err)
+	archivedAgain, err := archiver.ArchiveBillsActivity(context.Background(), billIds, "2025/03/02/run-0000.jsonl.gz", closedAt.Add(30*24*time.Hour))
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, []model.BillId{oldBill.Id}, billIds)
+	assert.Equal(t, uint64(1), archived)
+	assert.Equal(t, uint64(0), archivedAgain)
+	_, err = billDb.GetBill(context.Background(), oldBill.Id)
+	assert.ErrorIs(t, err, db.ErrBillNotFound)
+	after, err := decorated.GetBill(context.Background(), oldBill.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+	lineItems, err := decorated.GetLineItems(context.Background(), oldBill.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.BillLineItem{lineItem}, lineItems)
+	_, err = decorated.AddLineItem(context.Background(), lineItem, model.TotalAmount{})
+	assert.ErrorIs(t, err, db.ErrBillClosed)
+	_, err = decorated.GetBill(context.Background(), recentBill.Id)
+	assert.NoError(t, err)
+}
+
+// The tombstones of an unreachable database.
+type failingTombstones struct {
+	db.BillArchiveDatabase
+	err error
+}
+
+func (f failingTombstones) GetTombstone(ctx context.Context, billId model.BillId) (string, error) {
+	return "", f.err
+}
+
+func TestArchivedBillDatabaseReturnsTombstoneErrors(t *testing.T) {
+	// Arrange
+	billDb := db.NewInMemoryBillDatabase()
+	unreachable := errors.New("connection refused")
+	decorated := db.NewArchivedBillDatabase(billDb, failingTombstones{err: unreachable}, db.NewInMemoryArchiveStore())
+	billId := model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}
+
+	// Act
+	_, createErr := decorated.CreateBill(context.Background(), model.BillInfo{Id: billId, CurrencyCode: "USD", Status: model.Open})
+	_, closeErr := decorated.CloseBill(context.Background(), billId, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC))
+	_, getErr := decorated.GetBill(context.Background(), billId)
+
+	// Assert
+	assert.ErrorIs(t, createErr, unreachable)
+	assert.ErrorIs(t, closeErr, unreachable)
+	assert.ErrorIs(t, getErr, unreachable)
+}
+
+func TestArchivedBillDatabaseMissingBillIsNotFound(t *testing.T) {
+	// Arrange
+	billDb := db.NewInMemoryBillDatabase()
+	decorated := db.NewArchivedBillDatabase(billDb, billDb, db.NewInMemoryArchiveStore())
+
+	// Act
+	_, err := decorated.GetBill(context.Background(), model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"})
+
+	// Assert
+	assert.ErrorIs(t, err, db.ErrBillNotFound)
+}
+
+func TestArchiveSegmentRoundTrip(t *testing.T) {
+	// Arrange
+	bills := []db.ArchivedBill{
+		{
+			Bill: db.BillInfoAndMetadata{
+				BillInfo:      model.BillInfo{Id: model.BillId{CustomerId: "bob", Id: "91c05476-2ae1-4fcf-a25c-f1851847aafe"}, CurrencyCode: "GEL", Status: model.Closed},
+				LineItemCount: 0,
+				Total:         model.NewTotalAmount("GEL"),
+			},
+			LineItems: []model.BillLineItem{},
+		},
+	}
+
+	// Act
+	segment, err := db.EncodeArchiveSegment(bills)
+	assert.NoError(t, err)
+	decoded, err := db.DecodeArchiveSegment(segment)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, bills, decoded)
+}
@@ -2,12 +2,20 @@ package activity
 
 import (
 	"coding-challenge/pkg/model"
+	"context"
+	"time"
 )
 
 type ActivityHost interface {
-	CreateBillIfNotExistActivity(bill model.BillInfo) (uint64, error)
-	AddBillLineItemIfNotExistActivity(lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error)
-	CloseBillActivity(bill model.BillInfo) (uint64, error)
+	CreateBillIfNotExistActivity(ctx context.Context, bill model.BillInfo) (uint64, error)
+	AddBillLineItemIfNotExistActivity(ctx context.Context, lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error)
+	AddBillLineItemsIfNotExistActivity(ctx context.Context, billId model.BillId, lineItems []model.BillLineItem, totalBefore model.TotalAmount) ([]bool, error)
+	CloseBillActivity(ctx context.Context, bill model.BillInfo) (uint64, error)
+	SetBillStatusActivity(ctx context.Context, billId model.BillId, status model.BillStatus) (uint64, error)
+	VoidBillActivity(ctx context.Context, billId model.BillId, total model.TotalAmount) (uint64, error)
+	SetBillCloseTimeActivity(ctx context.Context, billId model.BillId, closeTime time.Time) (uint64, error)
+	SetBillSpendingCapActivity(ctx context.Context, billId model.BillId, spendingCap model.SpendingCap) (uint64, error)
+	RecordAuditEntryActivity(ctx context.Context, entry model.AuditEntry) (uint64, error)
 }
 
 type DummyActivityHost struct {
@@ -15,14 +23,38 @@ type DummyActivityHost struct {
 
 var _ ActivityHost = &DummyActivityHost{}
 
-func (d *DummyActivityHost) CreateBillIfNotExistActivity(bill model.BillInfo) (uint64, error) {
+func (d *DummyActivityHost) CreateBillIfNotExistActivity(ctx context.Context, bill model.BillInfo) (uint64, error) {
 	panic("Not implemented")
 }
 
-func (d *DummyActivityHost) AddBillLineItemIfNotExistActivity(lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error) {
+func (d *DummyActivityHost) AddBillLineItemIfNotExistActivity(ctx context.Context, lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error) {
 	panic("Not implemented")
 }
 
-func (d *DummyActivityHost) CloseBillActivity(bill model.BillInfo) (uint64, error) {
+func (d *DummyActivityHost) AddBillLineItemsIfNotExistActivity(ctx context.Context, billId model.BillId, lineItems []model.BillLineItem, totalBefore model.TotalAmount) ([]bool, error) {
+	panic("Not implemented")
+}
+
+func (d *DummyActivityHost) CloseBillActivity(ctx context.Context, bill model.BillInfo) (uint64, error) {
+	panic("Not implemented")
+}
+
+func (d *DummyActivityHost) SetBillStatusActivity(ctx context.Context, billId model.BillId, status model.BillStatus) (uint64, error) {
+	panic("Not implemented")
+}
+
+func (d *DummyActivityHost) VoidBillActivity(ctx context.Context, billId model.BillId, total model.TotalAmount) (uint64, error) {
+	panic("Not implemented")
+}
+
+func (d *DummyActivityHost) SetBillCloseTimeActivity(ctx context.Context, billId model.BillId, closeTime time.Time) (uint64, error) {
+	panic("Not implemented")
+}
+
+func (d *DummyActivityHost) SetBillSpendingCapActivity(ctx context.Context, billId model.BillId, spendingCap model.SpendingCap) (uint64, error) {
+	panic("Not implemented")
+}
+
+func (d *DummyActivityHost) RecordAuditEntryActivity(ctx context.Context, entry model.AuditEntry) (uint64, error) {
 	panic("Not implemented")
 }
@@ -0,0 +1,84 @@
+package activity
+
+import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+const CouponNotRedeemableErrorType = "CouponNotRedeemable"
+
+type CouponActivityHost interface {
+	RedeemCouponActivity(ctx context.Context, bill model.BillInfo, code string, at time.Time) (model.Coupon, error)
+	ApplyBillDiscountsActivity(ctx context.Context, bill model.BillInfo, discounts []model.DiscountLine) (uint64, error)
+}
+
+type DummyCouponActivityHost struct {
+}
+
+var _ CouponActivityHost = &DummyCouponActivityHost{}
+
+func (d *DummyCouponActivityHost) RedeemCouponActivity(ctx context.Context, bill model.BillInfo, code string, at time.Time) (model.Coupon, error) {
+	panic("Not implemented")
+}
+
+func (d *DummyCouponActivityHost) ApplyBillDiscountsActivity(ctx context.Context, bill model.BillInfo, discounts []model.DiscountLine) (uint64, error) {
+	panic("Not implemented")
+}
+
+type CouponRedeemer struct {
+	coupons db.CouponDatabase
+	ledger  db.LedgerDatabase
+}
+
+var _ CouponActivityHost = &CouponRedeemer{}
+
+func NewPostgreSqlCouponRedeemer(conn PostgreSqlConnection) (*CouponRedeemer, error) {
+	sql, err := openPostgreSql(conn)
+	if err != nil {
+		return nil, err
+	}
+	return NewCouponRedeemer(db.NewSqlCouponDatabase(sql), db.NewSqlLedgerDatabase(sql)), nil
+}
+
+func NewCouponRedeemer(couponDb db.CouponDatabase, ledgerDb db.LedgerDatabase) *CouponRedeemer {
+	return &CouponRedeemer{coupons: couponDb, ledger: ledgerDb}
+}
+
+// The coupon is checked as of the workflow time so that a retry decides the same.
+// Errors about the coupon itself are not retryable.
+func (r *CouponRedeemer) RedeemCouponActivity(ctx context.Context, bill model.BillInfo, code string, at time.Time) (model.Coupon, error) {
+	coupon, err := r.coupons.GetCoupon(ctx, code)
+	if errors.Is(err, db.ErrCouponNotFound) {
+		return model.Coupon{}, temporal.NewNonRetryableApplicationError(err.Error(), CouponNotRedeemableErrorType, err)
+	} else if err != nil {
+		return model.Coupon{}, err
+	}
+	if err := coupon.CheckRedeemable(at, bill.CurrencyCode); err != nil {
+		return model.Coupon{}, temporal.NewNonRetryableApplicationError(err.Error(), CouponNotRedeemableErrorType, err)
+	}
+	_, err = r.coupons.RedeemCoupon(ctx, code, bill.Id)
+	if errors.Is(err, db.ErrCouponExhausted) {
+		return model.Coupon{}, temporal.NewNonRetryableApplicationError(err.Error(), CouponNotRedeemableErrorType, err)
+	} else if err != nil {
+		return model.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+func (r *CouponRedeemer) ApplyBillDiscountsActivity(ctx context.Context, bill model.BillInfo, discounts []model.DiscountLine) (uint64, error) {
+	updateCount, err := r.coupons.SaveBillDiscounts(ctx, bill.Id, discounts)
+	if err != nil {
+		return 0, err
+	}
+	if transaction := model.NewBillDiscountLedgerTransaction(bill.Id, discounts); len(transaction.Postings) != 0 {
+		if _, err := r.ledger.PostTransaction(ctx, transaction); err != nil {
+			return 0, err
+		}
+	}
+	return updateCount, nil
+}
@@ -0,0 +1,56 @@
+package activity_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedeemCouponRespectsLimit(t *testing.T) {
+	// Arrange
+	couponDb := db.NewInMemoryCouponDatabase()
+	couponDb.AddCoupon(model.Coupon{Code: "ONCE", Type: model.PercentageCoupon, Value: "10", MaxRedemptions: 1})
+	redeemer := activity.NewCouponRedeemer(couponDb, db.NewInMemoryLedgerDatabase())
+	bill1 := model.BillInfo{Id: model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}, CurrencyCode: "USD"}
+	bill2 := model.BillInfo{Id: model.BillId{CustomerId: "bob", Id: "0f9b3f0e-5a47-4b43-b8a4-76f6b7c0c3c4"}, CurrencyCode: "USD"}
+	at := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	_, err1 := redeemer.RedeemCouponActivity(context.Background(), bill1, "ONCE", at)
+	_, errRetried := redeemer.RedeemCouponActivity(context.Background(), bill1, "ONCE", at)
+	_, err2 := redeemer.RedeemCouponActivity(context.Background(), bill2, "ONCE", at)
+	_, errUnknown := redeemer.RedeemCouponActivity(context.Background(), bill2, "NONE", at)
+
+	// Assert
+	assert.NoError(t, err1)
+	assert.NoError(t, errRetried)
+	assert.ErrorIs(t, err2, db.ErrCouponExhausted)
+	assert.ErrorIs(t, errUnknown, db.ErrCouponNotFound)
+}
+
+func TestApplyBillDiscountsReducesReceivable(t *testing.T) {
+	// Arrange
+	ledgerDb := db.NewInMemoryLedgerDatabase()
+	redeemer := activity.NewCouponRedeemer(db.NewInMemoryCouponDatabase(), ledgerDb)
+	bill := model.BillInfo{Id: model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}, CurrencyCode: "USD"}
+	discounts := []model.DiscountLine{{CouponCode: "TEN", Amount: model.TotalAmount{Number: "10", CurrencyCode: "USD"}}}
+	receivable := model.LedgerAccount{CustomerId: "alice", Type: model.Receivable, CurrencyCode: "USD"}
+
+	// Act
+	updateCount, err := redeemer.ApplyBillDiscountsActivity(context.Background(), bill, discounts)
+	assert.NoError(t, err)
+	retriedCount, err := redeemer.ApplyBillDiscountsActivity(context.Background(), bill, discounts)
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, uint64(1), updateCount)
+	assert.Equal(t, uint64(0), retriedCount)
+	balance, err := ledgerDb.GetBalance(context.Background(), receivable)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TotalAmount{Number: "-10", CurrencyCode: "USD"}, balance)
+}
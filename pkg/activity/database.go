@@ -3,15 +3,21 @@ package activity
 import (
 	"coding-challenge/pkg/db"
 	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/telemetry"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	_ "github.com/lib/pq"
+	"go.temporal.io/sdk/temporal"
 )
 
 const SaveToDatabaseActivityTimeout = time.Second
 
+const OpenBillQuotaExceededErrorType = "OpenBillQuotaExceededError"
+
 type PostgreSqlConnection struct {
 	Host   string
 	Port   int
@@ -21,30 +27,130 @@ type PostgreSqlConnection struct {
 }
 
 type PostgreSqlActivityHost struct {
-	db db.BillDatabase
+	db     db.BillDatabase
+	ledger db.LedgerDatabase
+	audit  db.AuditDatabase
 }
 
 var _ ActivityHost = &PostgreSqlActivityHost{}
 
-func NewPostgreSqlActivityHost(conn PostgreSqlConnection) (*PostgreSqlActivityHost, error) {
+func openPostgreSql(conn PostgreSqlConnection) (*sql.DB, error) {
 	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s "+
 		"password=%s dbname=%s sslmode=disable",
 		conn.Host, conn.Port, conn.User, conn.Pass, conn.DbName)
-	sql, err := sql.Open("postgres", psqlInfo)
+	return telemetry.OpenPostgreSql(psqlInfo)
+}
+
+func NewPostgreSqlActivityHost(conn PostgreSqlConnection) (*PostgreSqlActivityHost, error) {
+	sql, err := openPostgreSql(conn)
 	if err != nil {
 		return nil, err
 	}
-	return &PostgreSqlActivityHost{db: db.NewSqlBillDatabase(sql)}, nil
+	return NewActivityHost(db.NewSqlBillDatabase(sql), db.NewSqlLedgerDatabase(sql), db.NewSqlAuditDatabase(sql)), nil
+}
+
+func NewActivityHost(billDb db.BillDatabase, ledgerDb db.LedgerDatabase, auditDb db.AuditDatabase) *PostgreSqlActivityHost {
+	return &PostgreSqlActivityHost{db: billDb, ledger: ledgerDb, audit: auditDb}
+}
+
+// A customer with the maximum of open bills is not retryable, the workflow fails with the quota.
+func (a *PostgreSqlActivityHost) CreateBillIfNotExistActivity(ctx context.Context, bill model.BillInfo) (uint64, error) {
+	updateCount, err := a.db.CreateBill(ctx, bill)
+	var quotaExceeded model.OpenBillQuotaExceededError
+	if errors.As(err, &quotaExceeded) {
+		return 0, temporal.NewNonRetryableApplicationError(err.Error(), OpenBillQuotaExceededErrorType, err)
+	} else if err != nil {
+		return 0, err
+	}
+	if updateCount != 0 {
+		telemetry.BillOpened(ctx, bill.CurrencyCode)
+	}
+	return updateCount, nil
+}
+
+// The ledger transaction is posted even when the line item already exists, in case a previous attempt failed in between.
+func (a *PostgreSqlActivityHost) AddBillLineItemIfNotExistActivity(ctx context.Context, lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error) {
+	updateCount, err := a.db.AddLineItem(ctx, lineItem, totalBefore)
+	if err != nil {
+		return 0, err
+	}
+	if updateCount != 0 {
+		telemetry.LineItemsAdded(ctx, lineItem.Amount.CurrencyCode, 1)
+	}
+	if _, err := a.ledger.PostTransaction(ctx, model.NewLineItemLedgerTransaction(lineItem)); err != nil {
+		return 0, err
+	}
+	return updateCount, nil
+}
+
+// Like AddBillLineItemIfNotExistActivity, the line items are saved in one transaction but posted to the ledger one by one.
+func (a *PostgreSqlActivityHost) AddBillLineItemsIfNotExistActivity(ctx context.Context, billId model.BillId, lineItems []model.BillLineItem, totalBefore model.TotalAmount) ([]bool, error) {
+	added, err := a.db.AddLineItems(ctx, billId, lineItems, totalBefore)
+	if err != nil {
+		return nil, err
+	}
+	telemetry.LineItemsAdded(ctx, totalBefore.CurrencyCode, countAdded(added))
+	for _, lineItem := range lineItems {
+		if _, err := a.ledger.PostTransaction(ctx, model.NewLineItemLedgerTransaction(lineItem)); err != nil {
+			return nil, err
+		}
+	}
+	return added, nil
+}
+
+func (a *PostgreSqlActivityHost) CloseBillActivity(ctx context.Context, bill model.BillInfo) (uint64, error) {
+	updateCount, err := a.db.CloseBill(ctx, bill.Id, bill.ClosedAt)
+	if err != nil {
+		return 0, err
+	}
+	closed, err := a.db.GetBill(ctx, bill.Id)
+	if err != nil {
+		return 0, err
+	}
+	if updateCount != 0 {
+		telemetry.BillClosed(ctx, closed.Total)
+	}
+	if _, err := a.ledger.PostTransaction(ctx, model.NewCloseBillLedgerTransaction(bill.Id, closed.Total)); err != nil {
+		return 0, err
+	}
+	return updateCount, nil
+}
+
+func countAdded(added []bool) int {
+	count := 0
+	for _, ok := range added {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
+
+func (a *PostgreSqlActivityHost) SetBillStatusActivity(ctx context.Context, billId model.BillId, status model.BillStatus) (uint64, error) {
+	return a.db.SetBillStatus(ctx, billId, status)
+}
+
+// The line items of the bill are reversed in the ledger even when it is already void, in case a previous attempt
+// failed in between.
+func (a *PostgreSqlActivityHost) VoidBillActivity(ctx context.Context, billId model.BillId, total model.TotalAmount) (uint64, error) {
+	updateCount, err := a.db.SetBillStatus(ctx, billId, model.Void)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := a.ledger.PostTransaction(ctx, model.NewVoidBillLedgerTransaction(billId, total)); err != nil {
+		return 0, err
+	}
+	return updateCount, nil
 }
 
-func (a *PostgreSqlActivityHost) CreateBillIfNotExistActivity(bill model.BillInfo) (uint64, error) {
-	return a.db.CreateBill(bill)
+func (a *PostgreSqlActivityHost) SetBillCloseTimeActivity(ctx context.Context, billId model.BillId, closeTime time.Time) (uint64, error) {
+	return a.db.SetCloseTime(ctx, billId, closeTime)
 }
 
-func (a *PostgreSqlActivityHost) AddBillLineItemIfNotExistActivity(lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error) {
-	return a.db.AddLineItem(lineItem, totalBefore)
+func (a *PostgreSqlActivityHost) SetBillSpendingCapActivity(ctx context.Context, billId model.BillId, spendingCap model.SpendingCap) (uint64, error) {
+	return a.db.SetSpendingCap(ctx, billId, spendingCap)
 }
 
-func (a *PostgreSqlActivityHost) CloseBillActivity(bill model.BillInfo) (uint64, error) {
-	return a.db.CloseBill(bill.Id)
+func (a *PostgreSqlActivityHost) RecordAuditEntryActivity(ctx context.Context, entry model.AuditEntry) (uint64, error) {
+	return a.audit.RecordEntry(ctx, entry)
 }
@@ -0,0 +1,166 @@
+package activity_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestReceivableEqualsSumOfClosedBills(t *testing.T) {
+	// Arrange
+	ledgerDb := db.NewInMemoryLedgerDatabase()
+	host := activity.NewActivityHost(db.NewInMemoryBillDatabase(), ledgerDb, db.NewInMemoryAuditDatabase())
+	bill1 := model.BillInfo{Id: model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}, CurrencyCode: "USD", Status: model.Open}
+	bill2 := model.BillInfo{Id: model.BillId{CustomerId: "alice", Id: "0f9b3f0e-5a47-4b43-b8a4-76f6b7c0c3c4"}, CurrencyCode: "USD", Status: model.Open}
+	lineItem1 := model.BillLineItem{
+		Id:          model.BillLineItemId{BillId: bill1.Id, Id: "5a61aae5-e120-4ddb-a15a-34cdfa74a1b6"},
+		Description: "Matchbox",
+		Amount:      model.Amount{Number: 100, CurrencyCode: "USD"},
+	}
+	lineItem2 := model.BillLineItem{
+		Id:          model.BillLineItemId{BillId: bill2.Id, Id: "9497a0e4-f59d-4382-a978-6728ab62e7f5"},
+		Description: "Candle",
+		Amount:      model.Amount{Number: 200, CurrencyCode: "USD"},
+	}
+	receivable := model.LedgerAccount{CustomerId: "alice", Type: model.Receivable, CurrencyCode: "USD"}
+	accrued := model.LedgerAccount{CustomerId: "alice", Type: model.AccruedReceivable, CurrencyCode: "USD"}
+
+	// Act
+	_, err := host.CreateBillIfNotExistActivity(context.Background(), bill1)
+	assert.NoError(t, err)
+	_, err = host.CreateBillIfNotExistActivity(context.Background(), bill2)
+	assert.NoError(t, err)
+	_, err = host.AddBillLineItemIfNotExistActivity(context.Background(), lineItem1, model.TotalAmount{})
+	assert.NoError(t, err)
+	_, err = host.AddBillLineItemIfNotExistActivity(context.Background(), lineItem2, model.TotalAmount{})
+	assert.NoError(t, err)
+	_, err = host.SetBillStatusActivity(context.Background(), bill1.Id, model.Closing)
+	assert.NoError(t, err)
+	_, err = host.CloseBillActivity(context.Background(), bill1)
+	assert.NoError(t, err)
+	_, err = host.CloseBillActivity(context.Background(), bill1) // Retried activity
+	assert.NoError(t, err)
+
+	// Assert
+	balance, err := ledgerDb.GetBalance(context.Background(), receivable)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TotalAmount{Number: "100", CurrencyCode: "USD"}, balance)
+	balance, err = ledgerDb.GetBalance(context.Background(), accrued)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TotalAmount{Number: "200", CurrencyCode: "USD"}, balance)
+}
+
+func TestReceivableBeyondInt64(t *testing.T) {
+	// Arrange
+	ledgerDb := db.NewInMemoryLedgerDatabase()
+	billIds := []model.BillId{
+		{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"},
+		{CustomerId: "alice", Id: "0f9b3f0e-5a47-4b43-b8a4-76f6b7c0c3c4"},
+	}
+	total := model.TotalAmount{Number: "9223372036854775807", CurrencyCode: "USD"}
+	receivable := model.LedgerAccount{CustomerId: "alice", Type: model.Receivable, CurrencyCode: "USD"}
+
+	// Act
+	for _, billId := range billIds {
+		_, err := ledgerDb.PostTransaction(context.Background(), model.NewCloseBillLedgerTransaction(billId, total))
+		assert.NoError(t, err)
+	}
+
+	// Assert
+	balance, err := ledgerDb.GetBalance(context.Background(), receivable)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TotalAmount{Number: "18446744073709551614", CurrencyCode: "USD"}, balance)
+}
+
+func TestCreateBillOverOpenBillQuotaIsNotRetryable(t *testing.T) {
+	// Arrange
+	host := activity.NewActivityHost(db.NewInMemoryBillDatabase(), db.NewInMemoryLedgerDatabase(), db.NewInMemoryAuditDatabase())
+	bill1 := model.BillInfo{Id: model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}, CurrencyCode: "USD", Status: model.Open, MaxOpenBills: 1}
+	bill2 := model.BillInfo{Id: model.BillId{CustomerId: "alice", Id: "0f9b3f0e-5a47-4b43-b8a4-76f6b7c0c3c4"}, CurrencyCode: "USD", Status: model.Open, MaxOpenBills: 1}
+
+	// Act
+	_, err1 := host.CreateBillIfNotExistActivity(context.Background(), bill1)
+	_, err2 := host.CreateBillIfNotExistActivity(context.Background(), bill2)
+
+	// Assert
+	assert.NoError(t, err1)
+	var applicationError *temporal.ApplicationError
+	assert.ErrorAs(t, err2, &applicationError)
+	assert.True(t, applicationError.NonRetryable())
+	assert.Equal(t, activity.OpenBillQuotaExceededErrorType, applicationError.Type())
+}
+
+func TestSpendingCapIsSaved(t *testing.T) {
+	// Arrange
+	billDb := db.NewInMemoryBillDatabase()
+	host := activity.NewActivityHost(billDb, db.NewInMemoryLedgerDatabase(), db.NewInMemoryAuditDatabase())
+	bill := model.BillInfo{
+		Id:           model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"},
+		CurrencyCode: "USD",
+		Status:       model.Open,
+		SpendingCap:  model.NewSpendingCap(250, "USD", []uint32{50, 80}),
+	}
+	_, err := host.CreateBillIfNotExistActivity(context.Background(), bill)
+	assert.NoError(t, err)
+	created, err := billDb.GetBill(context.Background(), bill.Id)
+	assert.NoError(t, err)
+
+	// Act
+	_, err = host.SetBillSpendingCapActivity(context.Background(), bill.Id, model.NewSpendingCap(500, "USD", []uint32{100}))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, bill.SpendingCap, created.BillInfo.SpendingCap)
+	updated, err := billDb.GetBill(context.Background(), bill.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, model.NewSpendingCap(500, "USD", []uint32{100}), updated.BillInfo.SpendingCap)
+}
+
+func TestAddBillLineItemsSkipsExisting(t *testing.T) {
+	// Arrange
+	ledgerDb := db.NewInMemoryLedgerDatabase()
+	billDb := db.NewInMemoryBillDatabase()
+	host := activity.NewActivityHost(billDb, ledgerDb, db.NewInMemoryAuditDatabase())
+	bill := model.BillInfo{Id: model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}, CurrencyCode: "USD", Status: model.Open}
+	lineItem1 := model.BillLineItem{
+		Id:          model.BillLineItemId{BillId: bill.Id, Id: "5a61aae5-e120-4ddb-a15a-34cdfa74a1b6"},
+		Description: "Matchbox",
+		Amount:      model.Amount{Number: 100, CurrencyCode: "USD"},
+	}
+	lineItem2 := model.BillLineItem{
+		Id:          model.BillLineItemId{BillId: bill.Id, Id: "9497a0e4-f59d-4382-a978-6728ab62e7f5"},
+		Description: "Candle",
+		Amount:      model.Amount{Number: 200, CurrencyCode: "USD"},
+	}
+	foreign := model.BillLineItem{
+		Id:     model.BillLineItemId{BillId: bill.Id, Id: "0f9b3f0e-5a47-4b43-b8a4-76f6b7c0c3c4"},
+		Amount: model.Amount{Number: 300, CurrencyCode: "GEL"},
+	}
+	accrued := model.LedgerAccount{CustomerId: "alice", Type: model.AccruedReceivable, CurrencyCode: "USD"}
+	_, err := host.CreateBillIfNotExistActivity(context.Background(), bill)
+	assert.NoError(t, err)
+
+	// Act
+	first, errFirst := host.AddBillLineItemsIfNotExistActivity(context.Background(), bill.Id, []model.BillLineItem{lineItem1}, model.NewTotalAmount("USD"))
+	second, errSecond := host.AddBillLineItemsIfNotExistActivity(context.Background(), bill.Id, []model.BillLineItem{lineItem1, lineItem2}, model.TotalAmount{Number: "100", CurrencyCode: "USD"})
+	_, errForeign := host.AddBillLineItemsIfNotExistActivity(context.Background(), bill.Id, []model.BillLineItem{foreign}, model.TotalAmount{Number: "300", CurrencyCode: "USD"})
+
+	// Assert
+	assert.NoError(t, errFirst)
+	assert.Equal(t, []bool{true}, first)
+	assert.NoError(t, errSecond)
+	assert.Equal(t, []bool{false, true}, second)
+	assert.ErrorIs(t, errForeign, db.ErrCurrencyMismatch)
+	saved, err := billDb.GetBill(context.Background(), bill.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), saved.LineItemCount)
+	assert.Equal(t, model.TotalAmount{Number: "300", CurrencyCode: "USD"}, saved.Total)
+	balance, err := ledgerDb.GetBalance(context.Background(), accrued)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TotalAmount{Number: "300", CurrencyCode: "USD"}, balance)
+}
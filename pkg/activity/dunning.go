@@ -0,0 +1,72 @@
+package activity
+
+import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/gateway"
+	"coding-challenge/pkg/model"
+	"context"
+)
+
+type DunningActivityHost interface {
+	GetDunningScheduleActivity(ctx context.Context, customerId model.CustomerId) (model.DunningSchedule, error)
+	NotifyCustomerActivity(ctx context.Context, notification model.DunningNotification) (uint64, error)
+}
+
+type DummyDunningActivityHost struct {
+}
+
+var _ DunningActivityHost = &DummyDunningActivityHost{}
+
+func (d *DummyDunningActivityHost) GetDunningScheduleActivity(ctx context.Context, customerId model.CustomerId) (model.DunningSchedule, error) {
+	panic("Not implemented")
+}
+
+func (d *DummyDunningActivityHost) NotifyCustomerActivity(ctx context.Context, notification model.DunningNotification) (uint64, error) {
+	panic("Not implemented")
+}
+
+type Dunner struct {
+	schedules map[model.CustomerTier]model.DunningSchedule
+	customers db.CustomerDatabase
+	payments  db.PaymentDatabase
+	notifier  gateway.Notifier
+}
+
+var _ DunningActivityHost = &Dunner{}
+
+func NewPostgreSqlDunner(conn PostgreSqlConnection, schedules map[model.CustomerTier]model.DunningSchedule, notifier gateway.Notifier) (*Dunner, error) {
+	sql, err := openPostgreSql(conn)
+	if err != nil {
+		return nil, err
+	}
+	return NewDunner(schedules, db.NewSqlCustomerDatabase(sql), db.NewSqlPaymentDatabase(sql), notifier), nil
+}
+
+func NewDunner(
+	schedules map[model.CustomerTier]model.DunningSchedule,
+	customerDb db.CustomerDatabase,
+	paymentDb db.PaymentDatabase,
+	notifier gateway.Notifier,
+) *Dunner {
+	return &Dunner{schedules: schedules, customers: customerDb, payments: paymentDb, notifier: notifier}
+}
+
+// A tier without a schedule is dunned as the standard tier.
+func (d *Dunner) GetDunningScheduleActivity(ctx context.Context, customerId model.CustomerId) (model.DunningSchedule, error) {
+	tier, err := d.customers.GetCustomerTier(ctx, customerId)
+	if err != nil {
+		return model.DunningSchedule{}, err
+	}
+	if schedule, ok := d.schedules[tier]; ok {
+		return schedule, nil
+	}
+	return d.schedules[model.TierStandard], nil
+}
+
+// The notification is sent before it is recorded, so a retry may send it again but never loses it.
+func (d *Dunner) NotifyCustomerActivity(ctx context.Context, notification model.DunningNotification) (uint64, error) {
+	if err := d.notifier.Notify(notification); err != nil {
+		return 0, err
+	}
+	return d.payments.RecordDunningNotification(ctx, notification)
+}
@@ -0,0 +1,63 @@
+package activity_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/gateway"
+	"coding-challenge/pkg/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDunningScheduleFallsBackToStandard(t *testing.T) {
+	// Arrange
+	customerDb := db.NewInMemoryCustomerDatabase()
+	customerDb.SetCustomerTier("alice", "premium")
+	customerDb.SetCustomerTier("bob", "gold")
+	schedules, err := model.ParseDunningSchedules("premium=3,30")
+	assert.NoError(t, err)
+	dunner := activity.NewDunner(schedules, customerDb, db.NewInMemoryPaymentDatabase(), gateway.LogNotifier{})
+
+	// Act
+	alice, errAlice := dunner.GetDunningScheduleActivity(context.Background(), "alice")
+	bob, errBob := dunner.GetDunningScheduleActivity(context.Background(), "bob")
+	carol, errCarol := dunner.GetDunningScheduleActivity(context.Background(), "carol")
+
+	// Assert
+	assert.NoError(t, errAlice)
+	assert.Equal(t, []time.Duration{3 * 24 * time.Hour, 30 * 24 * time.Hour}, alice.Steps)
+	assert.NoError(t, errBob)
+	assert.Equal(t, model.TierStandard, bob.Tier)
+	assert.NoError(t, errCarol)
+	assert.Equal(t, model.DefaultDunningSchedules()[model.TierStandard], carol)
+}
+
+func TestNotifyCustomerRecordsOnce(t *testing.T) {
+	// Arrange
+	paymentDb := db.NewInMemoryPaymentDatabase()
+	dunner := activity.NewDunner(model.DefaultDunningSchedules(), db.NewInMemoryCustomerDatabase(), paymentDb, gateway.LogNotifier{})
+	billId := model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}
+	notification := model.DunningNotification{
+		BillId:    billId,
+		Step:      1,
+		Kind:      model.DunningReminder,
+		AmountDue: model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		SentAt:    time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	updateCount, err := dunner.NotifyCustomerActivity(context.Background(), notification)
+	assert.NoError(t, err)
+	retriedCount, err := dunner.NotifyCustomerActivity(context.Background(), notification) // Retried activity
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, uint64(1), updateCount)
+	assert.Equal(t, uint64(0), retriedCount)
+	notifications, err := paymentDb.GetDunningNotifications(context.Background(), billId)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.DunningNotification{notification}, notifications)
+}
@@ -0,0 +1,38 @@
+package activity
+
+import (
+	"coding-challenge/pkg/gateway"
+	"coding-challenge/pkg/model"
+	"time"
+)
+
+// Live subscribers are not worth waiting for, an event that misses it is not published.
+const PublishBillEventTimeout = 10 * time.Second
+
+type BillEventActivityHost interface {
+	PublishBillEventActivity(event model.BillEvent) error
+}
+
+type DummyBillEventActivityHost struct {
+}
+
+var _ BillEventActivityHost = &DummyBillEventActivityHost{}
+
+func (d *DummyBillEventActivityHost) PublishBillEventActivity(event model.BillEvent) error {
+	panic("Not implemented")
+}
+
+// Runs in the process of the subscribers, since the publisher is in memory.
+type BillEventRelay struct {
+	publisher gateway.BillEventPublisher
+}
+
+var _ BillEventActivityHost = &BillEventRelay{}
+
+func NewBillEventRelay(publisher gateway.BillEventPublisher) *BillEventRelay {
+	return &BillEventRelay{publisher: publisher}
+}
+
+func (r *BillEventRelay) PublishBillEventActivity(event model.BillEvent) error {
+	return r.publisher.Publish(event)
+}
@@ -0,0 +1,70 @@
+package activity_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/gateway"
+	"coding-challenge/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func billEvent(billId model.BillId, sequence uint64, kind model.BillEventKind, total string) model.BillEvent {
+	return model.BillEvent{
+		BillId:        billId,
+		Sequence:      sequence,
+		Kind:          kind,
+		Status:        model.Open,
+		LineItemCount: sequence - 1,
+		Total:         model.TotalAmount{Number: total, CurrencyCode: "USD"},
+		At:            time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestPublishBillEventResumesAfterLastEvent(t *testing.T) {
+	// Arrange
+	billId := model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}
+	broker := gateway.NewInMemoryBillEventBroker()
+	relay := activity.NewBillEventRelay(broker)
+	event1 := billEvent(billId, 1, model.BillLineItemAdded, "100")
+	event2 := billEvent(billId, 2, model.BillLineItemAdded, "300")
+	event3 := billEvent(billId, 3, model.BillStatusChanged, "300")
+	assert.NoError(t, relay.PublishBillEventActivity(event1))
+	assert.NoError(t, relay.PublishBillEventActivity(event2))
+
+	// Act
+	missed, events, unsubscribe := broker.Subscribe(billId, 1)
+	// Retried and late events are dropped
+	assert.NoError(t, relay.PublishBillEventActivity(event2))
+	assert.NoError(t, relay.PublishBillEventActivity(event1))
+	assert.NoError(t, relay.PublishBillEventActivity(event3))
+	unsubscribe()
+
+	// Assert
+	assert.Equal(t, []model.BillEvent{event2}, missed)
+	var received []model.BillEvent
+	for event := range events {
+		received = append(received, event)
+	}
+	assert.Equal(t, []model.BillEvent{event3}, received)
+}
+
+func TestPublishBillEventToOtherBill(t *testing.T) {
+	// Arrange
+	billId := model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}
+	otherBillId := model.BillId{CustomerId: "bob", Id: billId.Id}
+	broker := gateway.NewInMemoryBillEventBroker()
+	relay := activity.NewBillEventRelay(broker)
+	missed, events, unsubscribe := broker.Subscribe(billId, 0)
+
+	// Act
+	err := relay.PublishBillEventActivity(billEvent(otherBillId, 1, model.BillLineItemAdded, "100"))
+	unsubscribe()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, missed)
+	_, ok := <-events
+	assert.False(t, ok)
+}
@@ -0,0 +1,84 @@
+package activity
+
+import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+const FxNotConfiguredErrorType = "FxNotConfigured"
+const FxRateNotFoundErrorType = "FxRateNotFound"
+const FxConversionErrorType = "FxConversion"
+
+type FxActivityHost interface {
+	ConvertLineItemActivity(ctx context.Context, lineItem model.BillLineItem, to model.CurrencyCode) (model.BillLineItem, error)
+	// Whether line items in another currency than the one of their bill can be converted.
+	FxAvailableActivity(ctx context.Context) (bool, error)
+}
+
+type DummyFxActivityHost struct {
+}
+
+var _ FxActivityHost = &DummyFxActivityHost{}
+
+func (d *DummyFxActivityHost) ConvertLineItemActivity(ctx context.Context, lineItem model.BillLineItem, to model.CurrencyCode) (model.BillLineItem, error) {
+	panic("Not implemented")
+}
+
+func (d *DummyFxActivityHost) FxAvailableActivity(ctx context.Context) (bool, error) {
+	panic("Not implemented")
+}
+
+type FxConverter struct {
+	rates db.FxRateProvider
+}
+
+var _ FxActivityHost = &FxConverter{}
+
+func NewPostgreSqlFxRateProvider(conn PostgreSqlConnection) (*db.SqlFxRateProvider, error) {
+	sql, err := openPostgreSql(conn)
+	if err != nil {
+		return nil, err
+	}
+	return db.NewSqlFxRateProvider(sql), nil
+}
+
+// Pass a nil provider when foreign currency line items are not accepted.
+func NewFxConverter(rates db.FxRateProvider) *FxConverter {
+	return &FxConverter{rates: rates}
+}
+
+func (c *FxConverter) FxAvailableActivity(ctx context.Context) (bool, error) {
+	return c.rates != nil, nil
+}
+
+// Uses the rate as of the line item creation so that a retry converts identically.
+// Errors are not retryable because a retry would not find a different rate.
+func (c *FxConverter) ConvertLineItemActivity(ctx context.Context, lineItem model.BillLineItem, to model.CurrencyCode) (model.BillLineItem, error) {
+	if lineItem.Amount.CurrencyCode == to {
+		return lineItem, nil
+	}
+	if c.rates == nil {
+		return model.BillLineItem{}, temporal.NewNonRetryableApplicationError("no fx rate provider is configured", FxNotConfiguredErrorType, nil)
+	}
+	rate, err := c.rates.GetRate(ctx, lineItem.Amount.CurrencyCode, to, lineItem.CreatedAt)
+	if errors.Is(err, db.ErrFxRateNotFound) {
+		return model.BillLineItem{}, temporal.NewNonRetryableApplicationError(err.Error(), FxRateNotFoundErrorType, err)
+	} else if err != nil {
+		return model.BillLineItem{}, err
+	}
+	converted, err := rate.Convert(lineItem.Amount)
+	if err != nil {
+		return model.BillLineItem{}, temporal.NewNonRetryableApplicationError(err.Error(), FxConversionErrorType, err)
+	}
+	lineItem.Conversion = &model.FxConversion{
+		OriginalAmount: lineItem.Amount,
+		Rate:           rate.Rate,
+		AsOf:           rate.AsOf,
+	}
+	lineItem.Amount = converted
+	return lineItem, nil
+}
@@ -0,0 +1,83 @@
+package activity_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/sdk/temporal"
+)
+
+const testFxRates = `from,to,rate,as_of
+GEL,USD,0.36,2025-01-01T00:00:00Z
+GEL,USD,0.37,2025-02-01T00:00:00Z
+`
+
+func TestConvertLineItemUsesRateAsOfCreation(t *testing.T) {
+	// Arrange
+	rates, err := db.ReadFxRates(strings.NewReader(testFxRates))
+	assert.NoError(t, err)
+	converter := activity.NewFxConverter(rates)
+	lineItem := model.BillLineItem{
+		Id:          model.BillLineItemId{BillId: model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}, Id: "5a61aae5-e120-4ddb-a15a-34cdfa74a1b6"},
+		Description: "Matchbox",
+		Amount:      model.Amount{Number: 1000, CurrencyCode: "GEL"},
+		CreatedAt:   time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	converted, err := converter.ConvertLineItemActivity(context.Background(), lineItem, "USD")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, model.Amount{Number: 360, CurrencyCode: "USD"}, converted.Amount)
+	assert.Equal(t, &model.FxConversion{
+		OriginalAmount: model.Amount{Number: 1000, CurrencyCode: "GEL"},
+		Rate:           "0.36",
+		AsOf:           time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, converted.Conversion)
+}
+
+func TestFxAvailableOnlyWithProvider(t *testing.T) {
+	// Arrange
+	rates, err := db.ReadFxRates(strings.NewReader(testFxRates))
+	assert.NoError(t, err)
+
+	// Act
+	withoutProvider, errWithout := activity.NewFxConverter(nil).FxAvailableActivity(context.Background())
+	withProvider, errWith := activity.NewFxConverter(rates).FxAvailableActivity(context.Background())
+
+	// Assert
+	assert.NoError(t, errWithout)
+	assert.NoError(t, errWith)
+	assert.False(t, withoutProvider)
+	assert.True(t, withProvider)
+}
+
+func TestConvertLineItemFailsWithoutProviderOrRate(t *testing.T) {
+	// Arrange
+	rates, err := db.ReadFxRates(strings.NewReader(testFxRates))
+	assert.NoError(t, err)
+	lineItem := model.BillLineItem{
+		Amount:    model.Amount{Number: 1000, CurrencyCode: "GEL"},
+		CreatedAt: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	_, errNoProvider := activity.NewFxConverter(nil).ConvertLineItemActivity(context.Background(), lineItem, "USD")
+	_, errNoRate := activity.NewFxConverter(rates).ConvertLineItemActivity(context.Background(), lineItem, "USD")
+
+	// Assert
+	var appErr *temporal.ApplicationError
+	assert.ErrorAs(t, errNoProvider, &appErr)
+	assert.True(t, appErr.NonRetryable())
+	assert.Equal(t, activity.FxNotConfiguredErrorType, appErr.Type())
+	assert.ErrorAs(t, errNoRate, &appErr)
+	assert.Equal(t, activity.FxRateNotFoundErrorType, appErr.Type())
+	assert.ErrorIs(t, errNoRate, db.ErrFxRateNotFound)
+}
@@ -0,0 +1,86 @@
+package activity
+
+import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/gateway"
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+	"time"
+)
+
+// Long enough for a gateway round trip.
+const ChargeActivityTimeout = 30 * time.Second
+
+type PaymentActivityHost interface {
+	ChargeBillActivity(attempt model.PaymentAttempt) (model.PaymentAttempt, error)
+	RecordPaymentAttemptActivity(ctx context.Context, attempt model.PaymentAttempt) (uint64, error)
+}
+
+type DummyPaymentActivityHost struct {
+}
+
+var _ PaymentActivityHost = &DummyPaymentActivityHost{}
+
+func (d *DummyPaymentActivityHost) ChargeBillActivity(attempt model.PaymentAttempt) (model.PaymentAttempt, error) {
+	panic("Not implemented")
+}
+
+func (d *DummyPaymentActivityHost) RecordPaymentAttemptActivity(ctx context.Context, attempt model.PaymentAttempt) (uint64, error) {
+	panic("Not implemented")
+}
+
+type PaymentCollector struct {
+	gateway  gateway.PaymentGateway
+	payments db.PaymentDatabase
+	ledger   db.LedgerDatabase
+}
+
+var _ PaymentActivityHost = &PaymentCollector{}
+
+func NewPostgreSqlPaymentCollector(conn PostgreSqlConnection, paymentGateway gateway.PaymentGateway) (*PaymentCollector, error) {
+	sql, err := openPostgreSql(conn)
+	if err != nil {
+		return nil, err
+	}
+	return NewPaymentCollector(paymentGateway, db.NewSqlPaymentDatabase(sql), db.NewSqlLedgerDatabase(sql)), nil
+}
+
+func NewPaymentCollector(paymentGateway gateway.PaymentGateway, paymentDb db.PaymentDatabase, ledgerDb db.LedgerDatabase) *PaymentCollector {
+	return &PaymentCollector{gateway: paymentGateway, payments: paymentDb, ledger: ledgerDb}
+}
+
+// A decline is an outcome of the attempt rather than an error. Other gateway errors are retried with the same
+// idempotency key, so the customer is charged at most once per attempt.
+func (c *PaymentCollector) ChargeBillActivity(attempt model.PaymentAttempt) (model.PaymentAttempt, error) {
+	reference, err := c.gateway.Charge(gateway.ChargeRequest{
+		IdempotencyKey: attempt.IdempotencyKey(),
+		CustomerId:     attempt.BillId.CustomerId,
+		Amount:         attempt.Amount,
+	})
+	var declined gateway.DeclinedError
+	if errors.As(err, &declined) {
+		attempt.Status = model.PaymentDeclined
+		attempt.FailureReason = declined.Reason
+		return attempt, nil
+	} else if err != nil {
+		return model.PaymentAttempt{}, err
+	}
+	attempt.Status = model.PaymentSucceeded
+	attempt.Reference = reference
+	return attempt, nil
+}
+
+// The ledger transaction is posted even when the attempt is already recorded, in case a previous attempt failed in between.
+func (c *PaymentCollector) RecordPaymentAttemptActivity(ctx context.Context, attempt model.PaymentAttempt) (uint64, error) {
+	updateCount, err := c.payments.RecordPaymentAttempt(ctx, attempt)
+	if err != nil {
+		return 0, err
+	}
+	if attempt.Status == model.PaymentSucceeded {
+		if _, err := c.ledger.PostTransaction(ctx, model.NewPaymentLedgerTransaction(attempt)); err != nil {
+			return 0, err
+		}
+	}
+	return updateCount, nil
+}
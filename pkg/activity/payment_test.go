@@ -0,0 +1,123 @@
+package activity_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/gateway"
+	"coding-challenge/pkg/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChargeBillPlaysGatewayOutcomes(t *testing.T) {
+	// Arrange
+	fakeGateway := gateway.NewFakePaymentGateway(gateway.FakeFail, gateway.FakeDecline)
+	collector := activity.NewPaymentCollector(fakeGateway, db.NewInMemoryPaymentDatabase(), db.NewInMemoryLedgerDatabase())
+	billId := model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}
+	first := model.PaymentAttempt{BillId: billId, Number: 1, Amount: model.TotalAmount{Number: "100", CurrencyCode: "USD"}}
+	second := first
+	second.Number = 2
+
+	// Act
+	_, errFailed := collector.ChargeBillActivity(first)
+	declined, errDeclined := collector.ChargeBillActivity(first) // Retried activity
+	succeeded, errSucceeded := collector.ChargeBillActivity(second)
+	retried, errRetried := collector.ChargeBillActivity(second) // Retried activity
+
+	// Assert
+	assert.ErrorIs(t, errFailed, gateway.ErrFakeUnavailable)
+	assert.NoError(t, errDeclined)
+	assert.Equal(t, model.PaymentDeclined, declined.Status)
+	assert.Equal(t, "scripted decline", declined.FailureReason)
+	assert.NoError(t, errSucceeded)
+	assert.Equal(t, model.PaymentSucceeded, succeeded.Status)
+	assert.Equal(t, "fake-charge-1", succeeded.Reference)
+	assert.NoError(t, errRetried)
+	assert.Equal(t, succeeded, retried)
+	assert.Len(t, fakeGateway.Requests(), 4)
+}
+
+func TestRecordSucceededPaymentSettlesReceivable(t *testing.T) {
+	// Arrange
+	paymentDb := db.NewInMemoryPaymentDatabase()
+	ledgerDb := db.NewInMemoryLedgerDatabase()
+	collector := activity.NewPaymentCollector(gateway.NewFakePaymentGateway(), paymentDb, ledgerDb)
+	billId := model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}
+	attemptedAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	declined := model.PaymentAttempt{
+		BillId:        billId,
+		Number:        1,
+		Amount:        model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Status:        model.PaymentDeclined,
+		FailureReason: "insufficient funds",
+		AttemptedAt:   attemptedAt,
+	}
+	succeeded := model.PaymentAttempt{
+		BillId:      billId,
+		Number:      2,
+		Amount:      model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Status:      model.PaymentSucceeded,
+		Reference:   "ch_1",
+		AttemptedAt: attemptedAt.Add(time.Hour),
+	}
+	receivable := model.LedgerAccount{CustomerId: "alice", Type: model.Receivable, CurrencyCode: "USD"}
+
+	// Act
+	_, err := collector.RecordPaymentAttemptActivity(context.Background(), succeeded)
+	assert.NoError(t, err)
+	_, err = collector.RecordPaymentAttemptActivity(context.Background(), declined)
+	assert.NoError(t, err)
+	retriedCount, err := collector.RecordPaymentAttemptActivity(context.Background(), succeeded) // Retried activity
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, uint64(0), retriedCount)
+	attempts, err := paymentDb.GetPaymentAttempts(context.Background(), billId)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.PaymentAttempt{declined, succeeded}, attempts)
+	balance, err := ledgerDb.GetBalance(context.Background(), receivable)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TotalAmount{Number: "-100", CurrencyCode: "USD"}, balance)
+}
+
+func TestRecordPaymentRetryReplacesErroredAttempt(t *testing.T) {
+	// Arrange
+	paymentDb := db.NewInMemoryPaymentDatabase()
+	collector := activity.NewPaymentCollector(gateway.NewFakePaymentGateway(), paymentDb, db.NewInMemoryLedgerDatabase())
+	billId := model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}
+	attemptedAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	errored := model.PaymentAttempt{
+		BillId:        billId,
+		Number:        1,
+		Amount:        model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Status:        model.PaymentErrored,
+		FailureReason: "gateway unavailable",
+		AttemptedAt:   attemptedAt,
+	}
+	succeeded := model.PaymentAttempt{
+		BillId:      billId,
+		Number:      1,
+		Amount:      model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Status:      model.PaymentSucceeded,
+		Reference:   "ch_1",
+		AttemptedAt: attemptedAt.Add(24 * time.Hour),
+	}
+
+	// Act
+	_, err := collector.RecordPaymentAttemptActivity(context.Background(), errored)
+	assert.NoError(t, err)
+	retriedCount, err := collector.RecordPaymentAttemptActivity(context.Background(), succeeded)
+	assert.NoError(t, err)
+	erroredAgainCount, err := collector.RecordPaymentAttemptActivity(context.Background(), errored)
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, uint64(1), retriedCount)
+	assert.Equal(t, uint64(0), erroredAgainCount)
+	attempts, err := paymentDb.GetPaymentAttempts(context.Background(), billId)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.PaymentAttempt{succeeded}, attempts)
+}
@@ -0,0 +1,34 @@
+package activity
+
+import (
+	"coding-challenge/pkg/gateway"
+	"coding-challenge/pkg/model"
+)
+
+type SpendingActivityHost interface {
+	NotifySpendingAlertActivity(alert model.SpendingAlert) error
+}
+
+type DummySpendingActivityHost struct {
+}
+
+var _ SpendingActivityHost = &DummySpendingActivityHost{}
+
+func (d *DummySpendingActivityHost) NotifySpendingAlertActivity(alert model.SpendingAlert) error {
+	panic("Not implemented")
+}
+
+type SpendingAlerter struct {
+	notifier gateway.Notifier
+}
+
+var _ SpendingActivityHost = &SpendingAlerter{}
+
+func NewSpendingAlerter(notifier gateway.Notifier) *SpendingAlerter {
+	return &SpendingAlerter{notifier: notifier}
+}
+
+// The alert is recorded in the audit log by the workflow, a retry may send it again.
+func (a *SpendingAlerter) NotifySpendingAlertActivity(alert model.SpendingAlert) error {
+	return a.notifier.NotifySpendingAlert(alert)
+}
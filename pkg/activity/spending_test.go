@@ -0,0 +1,44 @@
+package activity_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingNotifier struct {
+	alerts []model.SpendingAlert
+}
+
+func (n *recordingNotifier) Notify(notification model.DunningNotification) error {
+	return nil
+}
+
+func (n *recordingNotifier) NotifySpendingAlert(alert model.SpendingAlert) error {
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func TestNotifySpendingAlert(t *testing.T) {
+	// Arrange
+	notifier := &recordingNotifier{}
+	alerter := activity.NewSpendingAlerter(notifier)
+	alert := model.SpendingAlert{
+		BillId:    model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"},
+		Threshold: 80,
+		Cap:       model.Amount{Number: 1000, CurrencyCode: "USD"},
+		Total:     model.TotalAmount{Number: "850", CurrencyCode: "USD"},
+		SentAt:    time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	err := alerter.NotifySpendingAlertActivity(alert)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []model.SpendingAlert{alert}, notifier.alerts)
+	assert.Equal(t, "80%-of-1000", alert.RequestId())
+}
@@ -0,0 +1,56 @@
+package activity
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/temporal"
+)
+
+const BillNotRunningErrorType = "BillNotRunning"
+
+// Adds a line item through the workflow of the bill, which validates it. The update id deduplicates a retry. A
+// reversal is exempt from the line item quota and the spending cap of the bill.
+type BillLineItemAdder interface {
+	AddBillLineItem(ctx context.Context, updateId string, lineItem model.BillLineItem, actor model.Actor, reversal bool) error
+}
+
+type SplitActivityHost interface {
+	AddSplitLineItemActivity(ctx context.Context, lineItem model.BillLineItem, actor model.Actor, reversal bool) error
+}
+
+type DummySplitActivityHost struct {
+}
+
+var _ SplitActivityHost = &DummySplitActivityHost{}
+
+func (d *DummySplitActivityHost) AddSplitLineItemActivity(ctx context.Context, lineItem model.BillLineItem, actor model.Actor, reversal bool) error {
+	panic("Not implemented")
+}
+
+type SplitCharger struct {
+	bills BillLineItemAdder
+}
+
+var _ SplitActivityHost = &SplitCharger{}
+
+func NewSplitCharger(bills BillLineItemAdder) *SplitCharger {
+	return &SplitCharger{bills: bills}
+}
+
+// The line item id is the update id. A line item that the bill refuses, or a bill no longer running, is not
+// retryable, and the error keeps the type of the refusal. The activities scheduled before reversals were exempt decode
+// reversal as false.
+func (c *SplitCharger) AddSplitLineItemActivity(ctx context.Context, lineItem model.BillLineItem, actor model.Actor, reversal bool) error {
+	err := c.bills.AddBillLineItem(ctx, lineItem.Id.Id, lineItem, actor, reversal)
+	var applicationError *temporal.ApplicationError
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &applicationError) {
+		return temporal.NewNonRetryableApplicationError(err.Error(), applicationError.Type(), err)
+	} else if errors.As(err, &notFound) {
+		return temporal.NewNonRetryableApplicationError(err.Error(), BillNotRunningErrorType, err)
+	}
+	return err
+}
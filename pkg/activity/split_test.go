@@ -0,0 +1,69 @@
+package activity_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/temporal"
+)
+
+type fakeBillLineItemAdder struct {
+	err       error
+	updateIds []string
+}
+
+func (f *fakeBillLineItemAdder) AddBillLineItem(ctx context.Context, updateId string, lineItem model.BillLineItem, actor model.Actor, reversal bool) error {
+	f.updateIds = append(f.updateIds, updateId)
+	return f.err
+}
+
+func splitLineItem() model.BillLineItem {
+	return model.BillLineItem{
+		Id: model.BillLineItemId{
+			BillId: model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"},
+			Id:     "5a61aae5-e120-4ddb-a15a-34cdfa74a1b6",
+		},
+		Description: "Dinner (1/2)",
+		Amount:      model.Amount{Number: 501, CurrencyCode: "USD"},
+	}
+}
+
+func TestAddSplitLineItemUsesLineItemIdAsUpdateId(t *testing.T) {
+	// Arrange
+	bills := &fakeBillLineItemAdder{}
+	charger := activity.NewSplitCharger(bills)
+
+	// Act
+	err := charger.AddSplitLineItemActivity(context.Background(), splitLineItem(), model.NewSystemActor(), false)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"5a61aae5-e120-4ddb-a15a-34cdfa74a1b6"}, bills.updateIds)
+}
+
+func TestAddSplitLineItemRefusedIsNotRetryable(t *testing.T) {
+	// Arrange
+	refused := activity.NewSplitCharger(&fakeBillLineItemAdder{err: temporal.NewApplicationError("over the cap", "SpendingCapExceededError")})
+	notRunning := activity.NewSplitCharger(&fakeBillLineItemAdder{err: serviceerror.NewNotFound("workflow execution already completed")})
+	unavailable := activity.NewSplitCharger(&fakeBillLineItemAdder{err: errors.New("unavailable")})
+
+	// Act
+	errRefused := refused.AddSplitLineItemActivity(context.Background(), splitLineItem(), model.NewSystemActor(), false)
+	errNotRunning := notRunning.AddSplitLineItemActivity(context.Background(), splitLineItem(), model.NewSystemActor(), false)
+	errUnavailable := unavailable.AddSplitLineItemActivity(context.Background(), splitLineItem(), model.NewSystemActor(), false)
+
+	// Assert
+	var applicationError *temporal.ApplicationError
+	assert.ErrorAs(t, errRefused, &applicationError)
+	assert.True(t, applicationError.NonRetryable())
+	assert.Equal(t, "SpendingCapExceededError", applicationError.Type())
+	assert.ErrorAs(t, errNotRunning, &applicationError)
+	assert.True(t, applicationError.NonRetryable())
+	assert.Equal(t, activity.BillNotRunningErrorType, applicationError.Type())
+	assert.False(t, errors.As(errUnavailable, &applicationError))
+}
@@ -0,0 +1,89 @@
+package activity
+
+import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+const TaxRateNotFoundErrorType = "TaxRateNotFound"
+const TaxComputationErrorType = "TaxComputation"
+
+type TaxActivityHost interface {
+	ComputeBillTaxActivity(ctx context.Context, bill model.BillInfo) (model.BillTax, error)
+}
+
+type DummyTaxActivityHost struct {
+}
+
+var _ TaxActivityHost = &DummyTaxActivityHost{}
+
+func (d *DummyTaxActivityHost) ComputeBillTaxActivity(ctx context.Context, bill model.BillInfo) (model.BillTax, error) {
+	panic("Not implemented")
+}
+
+type TaxCalculator struct {
+	bills  db.BillDatabase
+	taxes  db.TaxDatabase
+	ledger db.LedgerDatabase
+}
+
+var _ TaxActivityHost = &TaxCalculator{}
+
+func NewPostgreSqlTaxCalculator(conn PostgreSqlConnection) (*TaxCalculator, error) {
+	sql, err := openPostgreSql(conn)
+	if err != nil {
+		return nil, err
+	}
+	return NewTaxCalculator(db.NewSqlBillDatabase(sql), db.NewSqlTaxDatabase(sql), db.NewSqlLedgerDatabase(sql)), nil
+}
+
+func NewTaxCalculator(billDb db.BillDatabase, taxDb db.TaxDatabase, ledgerDb db.LedgerDatabase) *TaxCalculator {
+	return &TaxCalculator{bills: billDb, taxes: taxDb, ledger: ledgerDb}
+}
+
+// When retried, the tax saved first is returned so that the ledger and the bill agree.
+func (c *TaxCalculator) ComputeBillTaxActivity(ctx context.Context, bill model.BillInfo) (model.BillTax, error) {
+	tax, err := c.taxes.GetBillTax(ctx, bill.Id)
+	if err != nil {
+		return model.BillTax{}, err
+	}
+	if !tax.IsComputed() {
+		if tax, err = c.computeBillTax(ctx, bill); err != nil {
+			return model.BillTax{}, err
+		}
+		if _, err = c.taxes.SaveBillTax(ctx, bill.Id, tax); err != nil {
+			return model.BillTax{}, err
+		}
+		if tax, err = c.taxes.GetBillTax(ctx, bill.Id); err != nil {
+			return model.BillTax{}, err
+		}
+	}
+	if transaction := model.NewBillTaxLedgerTransaction(bill.Id, tax); len(transaction.Postings) != 0 {
+		if _, err := c.ledger.PostTransaction(ctx, transaction); err != nil {
+			return model.BillTax{}, err
+		}
+	}
+	return tax, nil
+}
+
+// Errors are retryable since the bill is already closing, an operator adds or fixes the rate of the jurisdiction
+// and the next attempt computes the tax.
+func (c *TaxCalculator) computeBillTax(ctx context.Context, bill model.BillInfo) (model.BillTax, error) {
+	lineItems, err := c.bills.GetLineItems(ctx, bill.Id)
+	if err != nil {
+		return model.BillTax{}, err
+	}
+	tax, err := model.ComputeBillTax(bill.CurrencyCode, lineItems, func(category model.TaxCategory) (model.TaxRate, error) {
+		return c.taxes.GetTaxRate(ctx, bill.TaxJurisdiction, category)
+	})
+	if errors.Is(err, db.ErrTaxRateNotFound) {
+		return model.BillTax{}, temporal.NewApplicationErrorWithCause(err.Error(), TaxRateNotFoundErrorType, err)
+	} else if err != nil {
+		return model.BillTax{}, temporal.NewApplicationErrorWithCause(err.Error(), TaxComputationErrorType, err)
+	}
+	return tax, nil
+}
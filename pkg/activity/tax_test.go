@@ -0,0 +1,87 @@
+package activity_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestComputeBillTaxPostsToLedgerOnce(t *testing.T) {
+	// Arrange
+	billDb, taxDb, ledgerDb := db.NewInMemoryBillDatabase(), db.NewInMemoryTaxDatabase(), db.NewInMemoryLedgerDatabase()
+	taxDb.AddTaxRate(model.TaxRate{Jurisdiction: "GE", Category: model.TaxCategoryStandard, Rate: "0.18"})
+	host := activity.NewActivityHost(billDb, ledgerDb, db.NewInMemoryAuditDatabase())
+	calculator := activity.NewTaxCalculator(billDb, taxDb, ledgerDb)
+	bill := model.BillInfo{Id: model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}, CurrencyCode: "USD", Status: model.Open, TaxJurisdiction: "GE"}
+	exclusive := model.BillLineItem{
+		Id:          model.BillLineItemId{BillId: bill.Id, Id: "5a61aae5-e120-4ddb-a15a-34cdfa74a1b6"},
+		Description: "Matchbox",
+		Amount:      model.Amount{Number: 100, CurrencyCode: "USD"},
+	}
+	inclusive := model.BillLineItem{
+		Id:           model.BillLineItemId{BillId: bill.Id, Id: "9497a0e4-f59d-4382-a978-6728ab62e7f5"},
+		Description:  "Candle",
+		Amount:       model.Amount{Number: 118, CurrencyCode: "USD"},
+		TaxInclusive: true,
+	}
+	receivable := model.LedgerAccount{CustomerId: "alice", Type: model.Receivable, CurrencyCode: "USD"}
+	taxPayable := model.LedgerAccount{CustomerId: "alice", Type: model.TaxPayable, CurrencyCode: "USD"}
+	_, err := host.CreateBillIfNotExistActivity(context.Background(), bill)
+	assert.NoError(t, err)
+	_, err = host.AddBillLineItemIfNotExistActivity(context.Background(), exclusive, model.TotalAmount{})
+	assert.NoError(t, err)
+	_, err = host.AddBillLineItemIfNotExistActivity(context.Background(), inclusive, model.TotalAmount{})
+	assert.NoError(t, err)
+
+	// Act
+	tax, err := calculator.ComputeBillTaxActivity(context.Background(), bill)
+	assert.NoError(t, err)
+	retried, err := calculator.ComputeBillTaxActivity(context.Background(), bill)
+	assert.NoError(t, err)
+	_, err = host.SetBillStatusActivity(context.Background(), bill.Id, model.Closing)
+	assert.NoError(t, err)
+	_, err = host.CloseBillActivity(context.Background(), bill)
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, tax, retried)
+	assert.Equal(t, model.TotalAmount{Number: "200", CurrencyCode: "USD"}, tax.Subtotal)
+	assert.Equal(t, model.TotalAmount{Number: "36", CurrencyCode: "USD"}, tax.TaxTotal)
+	assert.Equal(t, model.TotalAmount{Number: "236", CurrencyCode: "USD"}, tax.GrandTotal)
+	balance, err := ledgerDb.GetBalance(context.Background(), receivable)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TotalAmount{Number: "236", CurrencyCode: "USD"}, balance)
+	balance, err = ledgerDb.GetBalance(context.Background(), taxPayable)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TotalAmount{Number: "-36", CurrencyCode: "USD"}, balance)
+}
+
+func TestComputeBillTaxFailsWithoutRate(t *testing.T) {
+	// Arrange
+	billDb := db.NewInMemoryBillDatabase()
+	calculator := activity.NewTaxCalculator(billDb, db.NewInMemoryTaxDatabase(), db.NewInMemoryLedgerDatabase())
+	bill := model.BillInfo{Id: model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}, CurrencyCode: "USD", Status: model.Open, TaxJurisdiction: "GE"}
+	lineItem := model.BillLineItem{
+		Id:          model.BillLineItemId{BillId: bill.Id, Id: "5a61aae5-e120-4ddb-a15a-34cdfa74a1b6"},
+		Description: "Matchbox",
+		Amount:      model.Amount{Number: 100, CurrencyCode: "USD"},
+	}
+	_, err := billDb.CreateBill(context.Background(), bill)
+	assert.NoError(t, err)
+	_, err = billDb.AddLineItem(context.Background(), lineItem, model.TotalAmount{})
+	assert.NoError(t, err)
+
+	// Act
+	_, err = calculator.ComputeBillTaxActivity(context.Background(), bill)
+
+	// Assert
+	assert.ErrorIs(t, err, db.ErrTaxRateNotFound)
+	var applicationError *temporal.ApplicationError
+	assert.ErrorAs(t, err, &applicationError)
+	assert.False(t, applicationError.NonRetryable())
+}
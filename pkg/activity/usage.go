@@ -0,0 +1,61 @@
+package activity
+
+import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+const UsageNotPriceableErrorType = "UsageNotPriceable"
+
+type UsageActivityHost interface {
+	AggregateUsageActivity(ctx context.Context, bill model.BillInfo) ([]model.BillLineItem, error)
+}
+
+type DummyUsageActivityHost struct {
+}
+
+var _ UsageActivityHost = &DummyUsageActivityHost{}
+
+func (d *DummyUsageActivityHost) AggregateUsageActivity(ctx context.Context, bill model.BillInfo) ([]model.BillLineItem, error) {
+	panic("Not implemented")
+}
+
+type UsageAggregator struct {
+	usage db.UsageDatabase
+}
+
+var _ UsageActivityHost = &UsageAggregator{}
+
+func NewPostgreSqlUsageAggregator(conn PostgreSqlConnection) (*UsageAggregator, error) {
+	sql, err := openPostgreSql(conn)
+	if err != nil {
+		return nil, err
+	}
+	return NewUsageAggregator(db.NewSqlUsageDatabase(sql)), nil
+}
+
+func NewUsageAggregator(usageDb db.UsageDatabase) *UsageAggregator {
+	return &UsageAggregator{usage: usageDb}
+}
+
+// One priced line item per meter and unit price, in the bill currency. The bill must be closing so that no event
+// is recorded after the aggregation. The events were checked when recorded, so a usage that cannot be priced is not
+// retryable.
+func (a *UsageAggregator) AggregateUsageActivity(ctx context.Context, bill model.BillInfo) ([]model.BillLineItem, error) {
+	aggregates, err := a.usage.AggregateUsage(ctx, bill.Id)
+	if err != nil {
+		return nil, err
+	}
+	lineItems := make([]model.BillLineItem, 0, len(aggregates))
+	for _, aggregate := range aggregates {
+		aggregateLineItems, err := aggregate.LineItems(bill)
+		if err != nil {
+			return nil, temporal.NewNonRetryableApplicationError(err.Error(), UsageNotPriceableErrorType, err)
+		}
+		lineItems = append(lineItems, aggregateLineItems...)
+	}
+	return lineItems, nil
+}
@@ -0,0 +1,55 @@
+package activity_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateUsageOfClosingBill(t *testing.T) {
+	// Arrange
+	billDb := db.NewInMemoryBillDatabase()
+	usageDb := db.NewInMemoryUsageDatabase(billDb)
+	host := activity.NewActivityHost(billDb, db.NewInMemoryLedgerDatabase(), db.NewInMemoryAuditDatabase())
+	aggregator := activity.NewUsageAggregator(usageDb)
+	bill := model.BillInfo{Id: model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}, CurrencyCode: "USD", Status: model.Open}
+	at := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	events := []model.UsageEvent{
+		{Id: "e1", BillId: bill.Id, Meter: "api_calls", Quantity: "600", UnitPrice: "0.01", RecordedAt: at},
+		{Id: "e2", BillId: bill.Id, Meter: "api_calls", Quantity: "634", UnitPrice: "0.01", RecordedAt: at},
+	}
+	late := model.UsageEvent{Id: "e3", BillId: bill.Id, Meter: "api_calls", Quantity: "1", UnitPrice: "0.01", RecordedAt: at}
+	_, err := host.CreateBillIfNotExistActivity(context.Background(), bill)
+	assert.NoError(t, err)
+
+	// Act
+	recorded, err := usageDb.RecordUsageEvents(context.Background(), bill.Id, events)
+	assert.NoError(t, err)
+	recordedAgain, err := usageDb.RecordUsageEvents(context.Background(), bill.Id, events[1:]) // Sent again
+	assert.NoError(t, err)
+	_, err = host.SetBillStatusActivity(context.Background(), bill.Id, model.Closing)
+	assert.NoError(t, err)
+	_, errLate := usageDb.RecordUsageEvents(context.Background(), bill.Id, []model.UsageEvent{late})
+	lineItems, err := aggregator.AggregateUsageActivity(context.Background(), bill)
+	assert.NoError(t, err)
+	added, errAdded := host.AddBillLineItemIfNotExistActivity(context.Background(), lineItems[0], model.NewTotalAmount("USD"))
+
+	// Assert
+	assert.Equal(t, uint64(2), recorded)
+	assert.Equal(t, uint64(0), recordedAgain)
+	assert.ErrorIs(t, errLate, db.ErrBillClosed)
+	assert.Equal(t, []model.BillLineItem{{
+		Id:          model.BillLineItemId{BillId: bill.Id, Id: "usage-api_calls-0.01"},
+		Description: "api_calls usage, 2 events",
+		Amount:      model.Amount{Number: 1234, CurrencyCode: "USD"},
+		Quantity:    "1234",
+		UnitPrice:   "0.01",
+	}}, lineItems)
+	assert.NoError(t, errAdded)
+	assert.Equal(t, uint64(1), added)
+}
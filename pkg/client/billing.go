@@ -0,0 +1,257 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// By operation id of openapi.json.
+var operations = map[string]operation{
+	"OpenNewBill":       {http.MethodPost, "/bills", true},
+	"SearchBills":       {http.MethodGet, "/bills", true},
+	"GetBill":           {http.MethodGet, "/bill/{id}", true},
+	"CloseBill":         {http.MethodPatch, "/bill/{id}/close", false},
+	"ExtendBill":        {http.MethodPut, "/bill/{id}/close-time", false},
+	"PublishBill":       {http.MethodPost, "/bill/{id}/publish", true},
+	"VoidBill":          {http.MethodPost, "/bill/{id}/void", true},
+	"AddBillLineItem":   {http.MethodPost, "/bill/{id}/line-items", true},
+	"AddBillLineItems":  {http.MethodPost, "/bill/{id}/line-items/batch", true},
+	"ListBillLineItems": {http.MethodGet, "/bill/{id}/line-items", true},
+	"AttachCoupon":      {http.MethodPost, "/bill/{id}/coupons", false},
+	"GetBillHistory":    {http.MethodGet, "/bill/{id}/history", true},
+	"GetBalance":        {http.MethodGet, "/balance/{currencyCode}", true},
+	"RecordPayment":     {http.MethodPost, "/bill/{id}/payments", false},
+	"SetSpendingCap":    {http.MethodPut, "/bill/{id}/spending-cap", true},
+	"SplitCharge":       {http.MethodPost, "/split-charges", false},
+	"RecordUsage":       {http.MethodPost, "/bill/{id}/usage", true},
+	"StreamBillEvents":  {http.MethodGet, "/bill/{id}/events", false},
+	"ListMembers":       {http.MethodGet, "/org/members", true},
+	"SetMember":         {http.MethodPut, "/org/members/{userId}", true},
+	"RemoveMember":      {http.MethodDelete, "/org/members/{userId}", false},
+}
+
+func idempotencyHeader(key string) http.Header {
+	if key == "" {
+		key = NewIdempotencyKey()
+	}
+	return http.Header{idempotencyKeyHeader: {key}}
+}
+
+// The bill closes at the close time unless closed before.
+func (c *Client) OpenNewBill(ctx context.Context, request *OpenNewBillRequest) (*OpenNewBillResponse, error) {
+	var response OpenNewBillResponse
+	err := c.do(ctx, operations["OpenNewBill"], nil, request, idempotencyHeader(request.IdempotencyKey), &response)
+	return &response, err
+}
+
+func (c *Client) GetBill(ctx context.Context, billId string) (*GetBillResponse, error) {
+	var response GetBillResponse
+	err := c.do(ctx, operations["GetBill"], []string{billId}, nil, nil, &response)
+	return &response, err
+}
+
+// Not retried, since a bill closed by a first attempt cannot be closed again.
+func (c *Client) CloseBill(ctx context.Context, billId string) (*CloseBillResponse, error) {
+	var response CloseBillResponse
+	err := c.do(ctx, operations["CloseBill"], []string{billId}, struct{}{}, nil, &response)
+	return &response, err
+}
+
+// Not retried, since a bill extended by a first attempt cannot be extended to the same close time again.
+func (c *Client) ExtendBill(ctx context.Context, billId string, request *ExtendBillRequest) (*ExtendBillResponse, error) {
+	var response ExtendBillResponse
+	err := c.do(ctx, operations["ExtendBill"], []string{billId}, request, nil, &response)
+	return &response, err
+}
+
+// Retried, since publishing an open bill again is a no-op.
+func (c *Client) PublishBill(ctx context.Context, billId string) (*PublishBillResponse, error) {
+	var response PublishBillResponse
+	err := c.do(ctx, operations["PublishBill"], []string{billId}, struct{}{}, nil, &response)
+	return &response, err
+}
+
+// Retried, since voiding a void bill again is a no-op.
+func (c *Client) VoidBill(ctx context.Context, billId string) (*VoidBillResponse, error) {
+	var response VoidBillResponse
+	err := c.do(ctx, operations["VoidBill"], []string{billId}, struct{}{}, nil, &response)
+	return &response, err
+}
+
+func (c *Client) AddBillLineItem(ctx context.Context, billId string, request *AddBillLineItemRequest) (*AddBillLineItemResponse, error) {
+	var response AddBillLineItemResponse
+	err := c.do(ctx, operations["AddBillLineItem"], []string{billId}, request, idempotencyHeader(request.IdempotencyKey), &response)
+	return &response, err
+}
+
+// The line items without id are given one, in the request, so that a retry finds them added.
+func (c *Client) AddBillLineItems(ctx context.Context, billId string, request *AddBillLineItemsRequest) (*AddBillLineItemsResponse, error) {
+	for i := range request.LineItems {
+		if request.LineItems[i].Id == "" {
+			request.LineItems[i].Id = NewIdempotencyKey()
+		}
+	}
+	var response AddBillLineItemsResponse
+	err := c.do(ctx, operations["AddBillLineItems"], []string{billId}, request, nil, &response)
+	return &response, err
+}
+
+func (c *Client) ListBillLineItems(ctx context.Context, billId string) (*ListBillLineItemsResponse, error) {
+	var response ListBillLineItemsResponse
+	err := c.do(ctx, operations["ListBillLineItems"], []string{billId}, nil, nil, &response)
+	return &response, err
+}
+
+func (c *Client) AttachCoupon(ctx context.Context, billId string, request *AttachCouponRequest) (*AttachCouponResponse, error) {
+	var response AttachCouponResponse
+	err := c.do(ctx, operations["AttachCoupon"], []string{billId}, request, nil, &response)
+	return &response, err
+}
+
+func (c *Client) GetBillHistory(ctx context.Context, billId string) (*GetBillHistoryResponse, error) {
+	var response GetBillHistoryResponse
+	err := c.do(ctx, operations["GetBillHistory"], []string{billId}, nil, nil, &response)
+	return &response, err
+}
+
+// The bills of the organization of the token from the Temporal visibility store, which may lag the bills by a moment.
+func (c *Client) SearchBills(ctx context.Context, request *SearchBillsRequest) (*SearchBillsResponse, error) {
+	query := url.Values{}
+	for name, value := range map[string]string{"status": request.Status, "currency_code": request.CurrencyCode, "page_token": request.PageToken} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	for name, value := range map[string]time.Time{"close_after": request.CloseAfter, "close_before": request.CloseBefore} {
+		if !value.IsZero() {
+			query.Set(name, value.Format(time.RFC3339Nano))
+		}
+	}
+	if request.PageSize != 0 {
+		query.Set("page_size", strconv.FormatInt(int64(request.PageSize), 10))
+	}
+	var response SearchBillsResponse
+	err := c.do(ctx, operations["SearchBills"].withQuery(query), nil, nil, nil, &response)
+	return &response, err
+}
+
+func (c *Client) GetBalance(ctx context.Context, currencyCode string) (*GetBalanceResponse, error) {
+	var response GetBalanceResponse
+	err := c.do(ctx, operations["GetBalance"], []string{currencyCode}, nil, nil, &response)
+	return &response, err
+}
+
+func (c *Client) RecordPayment(ctx context.Context, billId string, request *RecordPaymentRequest) (*RecordPaymentResponse, error) {
+	var response RecordPaymentResponse
+	err := c.do(ctx, operations["RecordPayment"], []string{billId}, request, nil, &response)
+	return &response, err
+}
+
+func (c *Client) SetSpendingCap(ctx context.Context, billId string, request *SetSpendingCapRequest) (*SetSpendingCapResponse, error) {
+	var response SetSpendingCapResponse
+	err := c.do(ctx, operations["SetSpendingCap"], []string{billId}, request, nil, &response)
+	return &response, err
+}
+
+func (c *Client) SplitCharge(ctx context.Context, request *SplitChargeRequest) (*SplitChargeResponse, error) {
+	var response SplitChargeResponse
+	err := c.do(ctx, operations["SplitCharge"], nil, request, nil, &response)
+	return &response, err
+}
+
+// Retried, since the events sent again are ignored by their id.
+func (c *Client) RecordUsage(ctx context.Context, billId string, request *RecordUsageRequest) (*RecordUsageResponse, error) {
+	var response RecordUsageResponse
+	err := c.do(ctx, operations["RecordUsage"], []string{billId}, request, nil, &response)
+	return &response, err
+}
+
+// Calls handle with each event of the bill after the given event id, zero for the next events only, until the
+// context ends, the stream ends or handle fails. Resume with the id of the last event handled.
+func (c *Client) StreamBillEvents(ctx context.Context, billId string, lastEventId uint64, handle func(BillEvent) error) error {
+	o := operations["StreamBillEvents"]
+	var header http.Header
+	if lastEventId != 0 {
+		header = http.Header{"Last-Event-ID": {strconv.FormatUint(lastEventId, 10)}}
+	}
+	request, err := c.newRequest(ctx, o.method, o.url(c.baseUrl, billId), nil, header)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "text/event-stream")
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return err
+		}
+		return newError(response.StatusCode, body)
+	}
+	var id uint64
+	var data strings.Builder
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, ":") {
+			// A comment, such as the heartbeat
+			continue
+		} else if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "id":
+				if id, err = strconv.ParseUint(value, 10, 64); err != nil {
+					return err
+				}
+			case "data":
+				data.WriteString(value)
+			}
+			continue
+		} else if data.Len() == 0 {
+			continue
+		}
+		// A blank line ends the event
+		event := BillEvent{Id: id}
+		if err = json.Unmarshal([]byte(data.String()), &event); err != nil {
+			return err
+		}
+		if err = handle(event); err != nil {
+			return err
+		}
+		data.Reset()
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+// The members of the organization of the token, for its owners only.
+func (c *Client) ListMembers(ctx context.Context) (*ListMembersResponse, error) {
+	var response ListMembersResponse
+	err := c.do(ctx, operations["ListMembers"], nil, nil, nil, &response)
+	return &response, err
+}
+
+// Retried, since setting the same role again changes nothing.
+func (c *Client) SetMember(ctx context.Context, userId string, request *SetMemberRequest) (*SetMemberResponse, error) {
+	var response SetMemberResponse
+	err := c.do(ctx, operations["SetMember"], []string{userId}, request, nil, &response)
+	return &response, err
+}
+
+// Not retried, since a member removed by a first attempt is not found by the next ones.
+func (c *Client) RemoveMember(ctx context.Context, userId string) error {
+	return c.do(ctx, operations["RemoveMember"], []string{userId}, nil, nil, nil)
+}
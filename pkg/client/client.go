@@ -0,0 +1,196 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Of the SDK, equal to the version of openapi.json it was written against.
+const Version = "1.7.0"
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// Only the requests safe to send again are retried: the reads, the puts, and the writes made idempotent by a key or
+// by the ids of what they add.
+type RetryPolicy struct {
+	// Including the first, 1 to not retry.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 4, InitialBackoff: 200 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+// The backoff before the attempt after the given one, from 1, with jitter so that clients do not retry together.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff << (attempt - 1)
+	if backoff <= 0 || p.MaxBackoff < backoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+type Client struct {
+	baseUrl     string
+	token       string
+	httpClient  *http.Client
+	retryPolicy RetryPolicy
+}
+
+type Option func(*Client)
+
+func WithHttpClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+func WithRetryPolicy(retryPolicy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = retryPolicy
+	}
+}
+
+// Sends the token as the bearer of every request, such as token-alice in the local environment.
+func New(baseUrl string, token string, options ...Option) *Client {
+	c := &Client{
+		baseUrl:     strings.TrimSuffix(baseUrl, "/"),
+		token:       token,
+		httpClient:  http.DefaultClient,
+		retryPolicy: DefaultRetryPolicy,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// A fresh key for a request that must not be applied twice.
+func NewIdempotencyKey() string {
+	return uuid.New().String()
+}
+
+type operation struct {
+	method string
+	// With the path parameters in braces, as in openapi.json.
+	path string
+	// Whether the request can be sent again without being applied twice.
+	idempotent bool
+}
+
+// Appends the query to the path, where it is left as is since its braces are escaped.
+func (o operation) withQuery(query url.Values) operation {
+	if 0 < len(query) {
+		o.path += "?" + query.Encode()
+	}
+	return o
+}
+
+// The path of the operation with its parameters in order.
+func (o operation) url(baseUrl string, parameters ...string) string {
+	path := o.path
+	for _, parameter := range parameters {
+		start, end := strings.Index(path, "{"), strings.Index(path, "}")
+		path = path[:start] + url.PathEscape(parameter) + path[end+1:]
+	}
+	return baseUrl + path
+}
+
+func (c *Client) newRequest(ctx context.Context, method string, url string, body []byte, header http.Header) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	request.Header.Set("Authorization", "Bearer "+c.token)
+	request.Header.Set("User-Agent", "billing-go-client/"+Version)
+	return request, nil
+}
+
+// Sends the request, retrying it when it is idempotent and the failure may not happen again, then decodes the
+// response into the given value unless nil.
+func (c *Client) do(ctx context.Context, o operation, parameters []string, request any, header http.Header, response any) error {
+	var body []byte
+	if request != nil {
+		var err error
+		if body, err = json.Marshal(request); err != nil {
+			return err
+		}
+	}
+	url := o.url(c.baseUrl, parameters...)
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := c.attempt(ctx, o.method, url, body, header, response)
+		if err == nil || !o.idempotent || c.retryPolicy.MaxAttempts <= attempt || !retryable(ctx, err) {
+			return err
+		}
+		backoff := c.retryPolicy.backoff(attempt)
+		if backoff < retryAfter {
+			backoff = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// The transport errors and some API errors may not happen again, unless the context ended.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiError *Error
+	if errors.As(err, &apiError) {
+		return apiError.retryable()
+	}
+	return true
+}
+
+// Returns how long the server asked to wait before the next attempt, if it did.
+func (c *Client) attempt(ctx context.Context, method string, url string, body []byte, header http.Header, response any) (time.Duration, error) {
+	request, err := c.newRequest(ctx, method, url, body, header)
+	if err != nil {
+		return 0, err
+	}
+	httpResponse, err := c.httpClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer httpResponse.Body.Close()
+	data, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return 0, err
+	}
+	if httpResponse.StatusCode < 200 || 300 <= httpResponse.StatusCode {
+		var retryAfter time.Duration
+		if seconds, err := strconv.Atoi(httpResponse.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return retryAfter, newError(httpResponse.StatusCode, data)
+	}
+	if response == nil {
+		return 0, nil
+	}
+	return 0, json.Unmarshal(data, response)
+}
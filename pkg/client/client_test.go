@@ -0,0 +1,192 @@
+package client_test
+
+import (
+	"coding-challenge/pkg/client"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastRetries = client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+func writeError(w http.ResponseWriter, statusCode int, code string) {
+	w.WriteHeader(statusCode)
+	fmt.Fprintf(w, `{"code":%q,"message":"failed","details":null}`, code)
+}
+
+func TestAddBillLineItemRetriesWithSameIdempotencyKey(t *testing.T) {
+	// Arrange
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/bill/ca06186a-1f96-4398-9244-fbddf4ef2642/line-items", r.URL.Path)
+		assert.Equal(t, "Bearer token-alice", r.Header.Get("Authorization"))
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			writeError(w, http.StatusServiceUnavailable, "unavailable")
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"description":"Matchbox","amount":100,"currency_code":"USD"}`, string(body))
+		fmt.Fprint(w, `{"id":"a579a2e5-9c31-473e-94ed-577c7cd14acd","currency_code":"USD","line_item_count":1,"total":"100"}`)
+	}))
+	defer server.Close()
+	c := client.New(server.URL, "token-alice", fastRetries)
+
+	// Act
+	response, err := c.AddBillLineItem(context.Background(), "ca06186a-1f96-4398-9244-fbddf4ef2642", &client.AddBillLineItemRequest{
+		Description:  "Matchbox",
+		Amount:       100,
+		CurrencyCode: "USD",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &client.AddBillLineItemResponse{Id: "a579a2e5-9c31-473e-94ed-577c7cd14acd", CurrencyCode: "USD", LineItemCount: 1, Total: "100"}, response)
+	require.Len(t, keys, 2)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1])
+}
+
+func TestAttachCouponReturnsTypedErrorWithoutRetry(t *testing.T) {
+	// Arrange
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeError(w, http.StatusServiceUnavailable, "unavailable")
+	}))
+	defer server.Close()
+	c := client.New(server.URL, "token-alice", fastRetries)
+
+	// Act
+	_, err := c.AttachCoupon(context.Background(), "ca06186a-1f96-4398-9244-fbddf4ef2642", &client.AttachCouponRequest{Code: "WELCOME10"})
+
+	// Assert
+	assert.Equal(t, client.Unavailable, client.ErrorCodeOf(err))
+	assert.Equal(t, 1, calls)
+}
+
+func TestGetBillGivesUpAfterMaxAttempts(t *testing.T) {
+	// Arrange
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		// Not the error body of the API, such as from a proxy
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	c := client.New(server.URL, "token-alice", fastRetries)
+
+	// Act
+	_, err := c.GetBill(context.Background(), "ca06186a-1f96-4398-9244-fbddf4ef2642")
+
+	// Assert
+	var apiError *client.Error
+	require.ErrorAs(t, err, &apiError)
+	assert.Equal(t, http.StatusBadGateway, apiError.StatusCode)
+	assert.Equal(t, client.Unavailable, apiError.Code)
+	assert.Equal(t, 3, calls)
+}
+
+func TestGetBillDoesNotRetryNotFound(t *testing.T) {
+	// Arrange
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeError(w, http.StatusNotFound, "not_found")
+	}))
+	defer server.Close()
+	c := client.New(server.URL, "token-alice", fastRetries)
+
+	// Act
+	_, err := c.GetBill(context.Background(), "ca06186a-1f96-4398-9244-fbddf4ef2642")
+
+	// Assert
+	assert.Equal(t, client.NotFound, client.ErrorCodeOf(err))
+	assert.Equal(t, 1, calls)
+}
+
+func TestSearchBillsSendsQuery(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bills", r.URL.Path)
+		assert.Equal(t, "close_before=2025-04-01T00%3A00%3A00Z&page_size=10&page_token=bmV4dA&status=closing", r.URL.RawQuery)
+		fmt.Fprint(w, `{"bills":[{"id":"ca06186a-1f96-4398-9244-fbddf4ef2642","currency_code":"USD","status":"closing",`+
+			`"close_time":"2025-03-31T00:00:00Z","total":"100"}],"next_page_token":""}`)
+	}))
+	defer server.Close()
+	c := client.New(server.URL, "token-alice")
+
+	// Act
+	response, err := c.SearchBills(context.Background(), &client.SearchBillsRequest{
+		Status:      "closing",
+		CloseBefore: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		PageSize:    10,
+		PageToken:   "bmV4dA",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &client.SearchBillsResponse{
+		Bills: []client.BillSearchResult{{
+			Id:           "ca06186a-1f96-4398-9244-fbddf4ef2642",
+			CurrencyCode: "USD",
+			Status:       "closing",
+			CloseTime:    time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+			Total:        "100",
+		}},
+	}, response)
+}
+
+func TestStreamBillEventsResumesAfterLastEvent(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bill/ca06186a-1f96-4398-9244-fbddf4ef2642/events", r.URL.Path)
+		assert.Equal(t, "1", r.Header.Get("Last-Event-ID"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "id: 2\nevent: line_item_added\ndata: {\"kind\":\"line_item_added\",\"line_item_id\":\"candle-1\",\"status\":\"open\",\"currency_code\":\"USD\",\"line_item_count\":2,\"total\":\"300\",\"at\":\"2025-03-01T00:00:01Z\"}\n\n")
+		fmt.Fprint(w, ": heartbeat\n\n")
+		fmt.Fprint(w, "id: 3\nevent: status_changed\ndata: {\"kind\":\"status_changed\",\"status\":\"closing\",\"currency_code\":\"USD\",\"line_item_count\":2,\"total\":\"300\",\"at\":\"2025-03-01T00:00:02Z\"}\n\n")
+	}))
+	defer server.Close()
+	c := client.New(server.URL, "token-alice")
+	var events []client.BillEvent
+
+	// Act
+	err := c.StreamBillEvents(context.Background(), "ca06186a-1f96-4398-9244-fbddf4ef2642", 1, func(event client.BillEvent) error {
+		events = append(events, event)
+		return nil
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []client.BillEvent{
+		{Id: 2, Kind: "line_item_added", LineItemId: "candle-1", Status: "open", CurrencyCode: "USD", LineItemCount: 2, Total: "300", At: time.Date(2025, 3, 1, 0, 0, 1, 0, time.UTC)},
+		{Id: 3, Kind: "status_changed", Status: "closing", CurrencyCode: "USD", LineItemCount: 2, Total: "300", At: time.Date(2025, 3, 1, 0, 0, 2, 0, time.UTC)},
+	}, events)
+}
+
+func TestErrorIsReadFromApiBody(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{"code": "invalid_argument", "message": "invalid line item", "details": nil})
+	}))
+	defer server.Close()
+	c := client.New(server.URL, "token-alice", fastRetries)
+
+	// Act
+	_, err := c.OpenNewBill(context.Background(), &client.OpenNewBillRequest{CurrencyCode: "USD", CloseTime: time.Now()})
+
+	// Assert
+	assert.EqualError(t, err, "billing api: invalid_argument: invalid line item")
+}
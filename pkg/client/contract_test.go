@@ -0,0 +1,142 @@
+package client
+
+import (
+	"coding-challenge/pkg/openapi"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The request and response types of each operation, nil when it has none.
+var operationTypes = map[string][2]any{
+	"OpenNewBill":       {OpenNewBillRequest{}, OpenNewBillResponse{}},
+	"SearchBills":       {nil, SearchBillsResponse{}},
+	"GetBill":           {nil, GetBillResponse{}},
+	"CloseBill":         {nil, CloseBillResponse{}},
+	"ExtendBill":        {ExtendBillRequest{}, ExtendBillResponse{}},
+	"PublishBill":       {nil, PublishBillResponse{}},
+	"VoidBill":          {nil, VoidBillResponse{}},
+	"AddBillLineItem":   {AddBillLineItemRequest{}, AddBillLineItemResponse{}},
+	"AddBillLineItems":  {AddBillLineItemsRequest{}, AddBillLineItemsResponse{}},
+	"ListBillLineItems": {nil, ListBillLineItemsResponse{}},
+	"AttachCoupon":      {AttachCouponRequest{}, AttachCouponResponse{}},
+	"GetBillHistory":    {nil, GetBillHistoryResponse{}},
+	"GetBalance":        {nil, GetBalanceResponse{}},
+	"RecordPayment":     {RecordPaymentRequest{}, RecordPaymentResponse{}},
+	"SetSpendingCap":    {SetSpendingCapRequest{}, SetSpendingCapResponse{}},
+	"SplitCharge":       {SplitChargeRequest{}, SplitChargeResponse{}},
+	"RecordUsage":       {RecordUsageRequest{}, RecordUsageResponse{}},
+	"StreamBillEvents":  {nil, nil},
+	"ListMembers":       {nil, ListMembersResponse{}},
+	"SetMember":         {SetMemberRequest{}, SetMemberResponse{}},
+	"RemoveMember":      {nil, nil},
+}
+
+func loadDocument(t *testing.T) *openapi.Document {
+	data, err := os.ReadFile("../../openapi.json")
+	require.NoError(t, err)
+	document, err := openapi.Unmarshal(data)
+	require.NoError(t, err)
+	return document
+}
+
+func TestVersionMatchesDocument(t *testing.T) {
+	assert.Equal(t, loadDocument(t).Info.Version, Version)
+}
+
+func TestOperationsMatchDocument(t *testing.T) {
+	// Arrange
+	document := loadDocument(t)
+
+	// Act
+	documented := make(map[string]operation)
+	for path, pathItem := range document.Paths {
+		for method, o := range pathItem {
+			documented[o.OperationId] = operation{method: strings.ToUpper(method), path: path}
+		}
+	}
+
+	// Assert
+	for operationId, o := range operations {
+		assert.Equal(t, documented[operationId], operation{method: o.method, path: o.path}, operationId)
+	}
+	for operationId := range documented {
+		assert.Contains(t, operations, operationId)
+		assert.Contains(t, operationTypes, operationId)
+	}
+}
+
+func TestTypesMatchDocument(t *testing.T) {
+	document := loadDocument(t)
+	for operationId, types := range operationTypes {
+		_, _, o := document.FindOperation(operationId)
+		require.NotNil(t, o, operationId)
+		if types[0] == nil {
+			assert.Nil(t, o.RequestBody, operationId)
+		} else if assert.NotNil(t, o.RequestBody, operationId) {
+			checkType(t, document, operationId+" request", reflect.TypeOf(types[0]), o.RequestBody.Content["application/json"].Schema)
+		}
+		if types[1] != nil {
+			checkType(t, document, operationId+" response", reflect.TypeOf(types[1]), o.Responses["200"].Content["application/json"].Schema)
+		}
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// The JSON encoding of the Go type has the properties and types of the schema.
+func checkType(t *testing.T, document *openapi.Document, at string, goType reflect.Type, schema *openapi.Schema) {
+	schema = document.Resolve(schema)
+	if goType.Kind() == reflect.Pointer {
+		goType = goType.Elem()
+	}
+	switch {
+	case goType == timeType:
+		assert.Equal(t, "date-time", schema.Format, at)
+	case goType.Kind() == reflect.Struct:
+		properties := make(map[string]reflect.Type)
+		for i := range goType.NumField() {
+			field := goType.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			} else if name == "" {
+				name = field.Name
+			}
+			properties[name] = field.Type
+		}
+		assert.ElementsMatch(t, keys(schema.Properties), keys(properties), at)
+		for name, property := range schema.Properties {
+			if fieldType, ok := properties[name]; ok {
+				checkType(t, document, at+"."+name, fieldType, property)
+			}
+		}
+	case goType.Kind() == reflect.Slice:
+		if assert.Equal(t, "array", schema.Type, at) {
+			checkType(t, document, at+"[]", goType.Elem(), schema.Items)
+		}
+	case goType.Kind() == reflect.String:
+		assert.Equal(t, "string", schema.Type, at)
+	case goType.Kind() == reflect.Bool:
+		assert.Equal(t, "boolean", schema.Type, at)
+	case goType.Kind() == reflect.Int || goType.Kind() == reflect.Int64 || goType.Kind() == reflect.Uint64:
+		assert.Equal(t, openapi.Schema{Type: "integer", Format: "int64"}, openapi.Schema{Type: schema.Type, Format: schema.Format}, at)
+	case goType.Kind() == reflect.Int32 || goType.Kind() == reflect.Uint32:
+		assert.Equal(t, openapi.Schema{Type: "integer", Format: "int32"}, openapi.Schema{Type: schema.Type, Format: schema.Format}, at)
+	default:
+		assert.Fail(t, "unexpected type", "%s: %v", at, goType)
+	}
+}
+
+func keys[V any](m map[string]V) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
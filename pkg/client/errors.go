@@ -0,0 +1,95 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// The code of an error of the API, such as not_found.
+type ErrorCode string
+
+const (
+	Canceled           ErrorCode = "canceled"
+	Unknown            ErrorCode = "unknown"
+	InvalidArgument    ErrorCode = "invalid_argument"
+	DeadlineExceeded   ErrorCode = "deadline_exceeded"
+	NotFound           ErrorCode = "not_found"
+	AlreadyExists      ErrorCode = "already_exists"
+	PermissionDenied   ErrorCode = "permission_denied"
+	ResourceExhausted  ErrorCode = "resource_exhausted"
+	FailedPrecondition ErrorCode = "failed_precondition"
+	Aborted            ErrorCode = "aborted"
+	OutOfRange         ErrorCode = "out_of_range"
+	Unimplemented      ErrorCode = "unimplemented"
+	Internal           ErrorCode = "internal"
+	Unavailable        ErrorCode = "unavailable"
+	DataLoss           ErrorCode = "data_loss"
+	Unauthenticated    ErrorCode = "unauthenticated"
+)
+
+// An error returned by the API.
+type Error struct {
+	StatusCode int
+	Code       ErrorCode
+	Message    string
+	Details    json.RawMessage
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("billing api: %s: %s", e.Code, e.Message)
+}
+
+// The code of the API error, empty when the error did not come from the API.
+func ErrorCodeOf(err error) ErrorCode {
+	var apiError *Error
+	if errors.As(err, &apiError) {
+		return apiError.Code
+	}
+	return ""
+}
+
+// The errors that may not happen again.
+func (e *Error) retryable() bool {
+	return e.Code == Unavailable || e.Code == ResourceExhausted || e.Code == DeadlineExceeded
+}
+
+// The body is read as the error of the API, or its status is mapped to a code when it is not, such as from a proxy.
+func newError(statusCode int, body []byte) *Error {
+	var decoded struct {
+		Code    ErrorCode       `json:"code"`
+		Message string          `json:"message"`
+		Details json.RawMessage `json:"details"`
+	}
+	if err := json.Unmarshal(body, &decoded); err == nil && decoded.Code != "" {
+		return &Error{StatusCode: statusCode, Code: decoded.Code, Message: decoded.Message, Details: decoded.Details}
+	}
+	return &Error{StatusCode: statusCode, Code: codeOfStatus(statusCode), Message: http.StatusText(statusCode)}
+}
+
+func codeOfStatus(statusCode int) ErrorCode {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return InvalidArgument
+	case http.StatusUnauthorized:
+		return Unauthenticated
+	case http.StatusForbidden:
+		return PermissionDenied
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return AlreadyExists
+	case http.StatusTooManyRequests:
+		return ResourceExhausted
+	case http.StatusNotImplemented:
+		return Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return Unavailable
+	case http.StatusGatewayTimeout:
+		return DeadlineExceeded
+	case http.StatusInternalServerError:
+		return Internal
+	}
+	return Unknown
+}
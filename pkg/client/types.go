@@ -0,0 +1,359 @@
+package client
+
+import "time"
+
+// The requests and responses of the endpoints, kept in sync with openapi.json by the contract tests.
+// Amounts and totals are in minor units of their currency, totals as exact decimal strings.
+
+type OpenNewBillRequest struct {
+	CurrencyCode string    `json:"currency_code"`
+	CloseTime    time.Time `json:"close_time"`
+	// Leave empty for an untaxed bill.
+	TaxJurisdiction string `json:"tax_jurisdiction"`
+	// Leave zero for an uncapped bill.
+	SpendingCap int64 `json:"spending_cap"`
+	// In percents of the spending cap, such as [50, 80, 100].
+	AlertThresholds []uint32 `json:"alert_thresholds"`
+	// The bill refuses line items until it is published.
+	Draft bool `json:"draft"`
+	// Generated when empty, so that a retried request opens a single bill.
+	IdempotencyKey string `json:"-"`
+}
+
+type OpenNewBillResponse struct {
+	Id string `json:"id"`
+}
+
+type BillId struct {
+	CustomerId string
+	Id         string
+}
+
+type Amount struct {
+	Number       int64
+	CurrencyCode string
+}
+
+type TotalAmount struct {
+	Number       string
+	CurrencyCode string
+}
+
+type PaymentAttempt struct {
+	BillId        BillId
+	Number        uint32
+	Amount        TotalAmount
+	Status        string
+	Reference     string
+	FailureReason string
+	AttemptedAt   time.Time
+}
+
+type DunningNotification struct {
+	BillId    BillId
+	Step      uint32
+	Kind      string
+	AmountDue TotalAmount
+	SentAt    time.Time
+}
+
+type SpendingAlert struct {
+	BillId    BillId
+	Threshold uint32
+	Cap       Amount
+	Total     TotalAmount
+	SentAt    time.Time
+}
+
+type GetBillResponse struct {
+	Id              string                `json:"id"`
+	CurrencyCode    string                `json:"currency_code"`
+	Status          string                `json:"status"`
+	LineItemCount   uint64                `json:"line_item_count"`
+	Total           string                `json:"total"`
+	CreatedAt       time.Time             `json:"created_at"`
+	CloseTime       time.Time             `json:"close_time"`
+	ClosedAt        *time.Time            `json:"closed_at,omitempty"`
+	Subtotal        string                `json:"subtotal"`
+	TaxTotal        string                `json:"tax_total"`
+	GrandTotal      string                `json:"grand_total"`
+	DiscountTotal   string                `json:"discount_total"`
+	AmountDue       string                `json:"amount_due"`
+	Payments        []PaymentAttempt      `json:"payments"`
+	Notifications   []DunningNotification `json:"notifications"`
+	SpendingCap     int64                 `json:"spending_cap"`
+	AlertThresholds []uint32              `json:"alert_thresholds"`
+	SpendingAlerts  []SpendingAlert       `json:"spending_alerts"`
+}
+
+type CloseBillResponse struct {
+	CurrencyCode  string     `json:"currency_code"`
+	LineItemCount uint64     `json:"line_item_count"`
+	Total         string     `json:"total"`
+	CreatedAt     time.Time  `json:"created_at"`
+	CloseTime     time.Time  `json:"close_time"`
+	ClosedAt      *time.Time `json:"closed_at,omitempty"`
+	Subtotal      string     `json:"subtotal"`
+	TaxTotal      string     `json:"tax_total"`
+	GrandTotal    string     `json:"grand_total"`
+	DiscountTotal string     `json:"discount_total"`
+	AmountDue     string     `json:"amount_due"`
+}
+
+type ExtendBillRequest struct {
+	// Later than the current close time.
+	CloseTime time.Time `json:"close_time"`
+}
+
+type ExtendBillResponse struct {
+	Id        string    `json:"id"`
+	CloseTime time.Time `json:"close_time"`
+}
+
+type PublishBillResponse struct {
+	Id     string `json:"id"`
+	Status string `json:"status"`
+}
+
+type VoidBillResponse struct {
+	Id     string `json:"id"`
+	Status string `json:"status"`
+}
+
+type AddBillLineItemRequest struct {
+	Description  string `json:"description"`
+	Amount       int64  `json:"amount"`
+	CurrencyCode string `json:"currency_code"`
+	// Empty means the standard category.
+	TaxCategory  string `json:"tax_category,omitempty"`
+	TaxInclusive bool   `json:"tax_inclusive,omitempty"`
+	// When set, the amount is computed as quantity times unit_price, in major units.
+	Quantity  string `json:"quantity,omitempty"`
+	UnitPrice string `json:"unit_price,omitempty"`
+	// half_even (the default), half_up or down.
+	Rounding string `json:"rounding,omitempty"`
+	// Generated when empty, so that a retried request adds the line item once.
+	IdempotencyKey string `json:"-"`
+}
+
+type AddBillLineItemResponse struct {
+	Id            string `json:"id"`
+	CurrencyCode  string `json:"currency_code"`
+	LineItemCount uint64 `json:"line_item_count"`
+	Total         string `json:"total"`
+}
+
+type BatchLineItemRequest struct {
+	// Generated when empty, so that a retried batch adds each line item once.
+	Id           string `json:"id"`
+	Description  string `json:"description"`
+	Amount       int64  `json:"amount"`
+	CurrencyCode string `json:"currency_code"`
+	TaxCategory  string `json:"tax_category,omitempty"`
+	TaxInclusive bool   `json:"tax_inclusive,omitempty"`
+	Quantity     string `json:"quantity,omitempty"`
+	UnitPrice    string `json:"unit_price,omitempty"`
+	Rounding     string `json:"rounding,omitempty"`
+}
+
+type AddBillLineItemsRequest struct {
+	LineItems []BatchLineItemRequest `json:"line_items"`
+	// When set, no line item is added unless all of them can be.
+	AllOrNothing bool `json:"all_or_nothing"`
+}
+
+type BatchLineItemResult struct {
+	Id string `json:"id"`
+	// added, already_added or refused.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type AddBillLineItemsResponse struct {
+	Id            string                `json:"id"`
+	CurrencyCode  string                `json:"currency_code"`
+	LineItemCount uint64                `json:"line_item_count"`
+	Total         string                `json:"total"`
+	Results       []BatchLineItemResult `json:"results"`
+}
+
+type FxConversionResponse struct {
+	OriginalAmount       int64     `json:"original_amount"`
+	OriginalCurrencyCode string    `json:"original_currency_code"`
+	Rate                 string    `json:"rate"`
+	RateAsOf             time.Time `json:"rate_as_of"`
+}
+
+type BillLineItemResponse struct {
+	Id           string                `json:"id"`
+	Description  string                `json:"description"`
+	Amount       int64                 `json:"amount"`
+	CurrencyCode string                `json:"currency_code"`
+	CreatedAt    time.Time             `json:"created_at"`
+	Conversion   *FxConversionResponse `json:"conversion,omitempty"`
+	TaxCategory  string                `json:"tax_category,omitempty"`
+	TaxInclusive bool                  `json:"tax_inclusive,omitempty"`
+	Quantity     string                `json:"quantity,omitempty"`
+	UnitPrice    string                `json:"unit_price,omitempty"`
+	Rounding     string                `json:"rounding,omitempty"`
+}
+
+type ListBillLineItemsResponse struct {
+	Id        string                 `json:"id"`
+	LineItems []BillLineItemResponse `json:"line_items"`
+}
+
+type AttachCouponRequest struct {
+	Code string `json:"code"`
+}
+
+type AttachCouponResponse struct {
+	Id      string   `json:"id"`
+	Coupons []string `json:"coupons"`
+}
+
+type BillHistoryEntry struct {
+	Action       string    `json:"action"`
+	ActorType    string    `json:"actor_type"`
+	ActorId      string    `json:"actor_id"`
+	RequestId    string    `json:"request_id"`
+	TotalBefore  string    `json:"total_before"`
+	TotalAfter   string    `json:"total_after"`
+	WorkflowTime time.Time `json:"workflow_time"`
+}
+
+type GetBillHistoryResponse struct {
+	Id      string             `json:"id"`
+	Entries []BillHistoryEntry `json:"entries"`
+}
+
+// Sent as the query, the zero fields are left out.
+type SearchBillsRequest struct {
+	// Draft, open or closing, open when empty.
+	Status       string
+	CurrencyCode string
+	CloseAfter   time.Time
+	CloseBefore  time.Time
+	PageSize     int32
+	// The next_page_token of the previous page.
+	PageToken string
+}
+
+type BillSearchResult struct {
+	Id           string    `json:"id"`
+	CurrencyCode string    `json:"currency_code"`
+	Status       string    `json:"status"`
+	CloseTime    time.Time `json:"close_time"`
+	Total        string    `json:"total"`
+}
+
+type SearchBillsResponse struct {
+	Bills []BillSearchResult `json:"bills"`
+	// Empty on the last page.
+	NextPageToken string `json:"next_page_token"`
+}
+
+type GetBalanceResponse struct {
+	CurrencyCode string `json:"currency_code"`
+	// In minor units as decimal integer strings, like the totals of the bills.
+	Receivable        string `json:"receivable"`
+	AccruedReceivable string `json:"accrued_receivable"`
+}
+
+type RecordPaymentRequest struct {
+	// Of the payment received out of band, such as the id of a bank transfer.
+	Reference string `json:"reference"`
+}
+
+type RecordPaymentResponse struct {
+	Id       string           `json:"id"`
+	Status   string           `json:"status"`
+	Payments []PaymentAttempt `json:"payments"`
+}
+
+type SetSpendingCapRequest struct {
+	// Zero removes the cap.
+	SpendingCap     int64    `json:"spending_cap"`
+	AlertThresholds []uint32 `json:"alert_thresholds"`
+}
+
+type SetSpendingCapResponse struct {
+	Id              string          `json:"id"`
+	SpendingCap     int64           `json:"spending_cap"`
+	AlertThresholds []uint32        `json:"alert_thresholds"`
+	Total           string          `json:"total"`
+	SpendingAlerts  []SpendingAlert `json:"spending_alerts"`
+}
+
+type SplitChargeRequest struct {
+	Description  string   `json:"description"`
+	Amount       int64    `json:"amount"`
+	CurrencyCode string   `json:"currency_code"`
+	BillIds      []string `json:"bill_ids"`
+	// One weight per bill. Empty splits the amount evenly.
+	Ratios []uint64 `json:"ratios"`
+}
+
+type SplitChargeLineItem struct {
+	BillId     string `json:"bill_id"`
+	LineItemId string `json:"line_item_id"`
+	Amount     int64  `json:"amount"`
+}
+
+type SplitChargeResponse struct {
+	Id           string                `json:"id"`
+	CurrencyCode string                `json:"currency_code"`
+	LineItems    []SplitChargeLineItem `json:"line_items"`
+}
+
+type UsageEventRequest struct {
+	// Unique per customer, an event sent again is ignored.
+	Id        string `json:"id"`
+	Meter     string `json:"meter"`
+	Quantity  string `json:"quantity"`
+	UnitPrice string `json:"unit_price"`
+	// Now when zero.
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+type RecordUsageRequest struct {
+	Events []UsageEventRequest `json:"events"`
+}
+
+type RecordUsageResponse struct {
+	Id       string `json:"id"`
+	Received int    `json:"received"`
+	Recorded uint64 `json:"recorded"`
+}
+
+// Received from StreamBillEvents.
+type BillEvent struct {
+	// Sent back to resume after it.
+	Id            uint64    `json:"-"`
+	Kind          string    `json:"kind"`
+	LineItemId    string    `json:"line_item_id,omitempty"`
+	Status        string    `json:"status"`
+	CurrencyCode  string    `json:"currency_code"`
+	LineItemCount uint64    `json:"line_item_count"`
+	Total         string    `json:"total"`
+	At            time.Time `json:"at"`
+}
+
+type Member struct {
+	UserId string `json:"user_id"`
+	// owner, billing-admin or viewer.
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListMembersResponse struct {
+	Members []Member `json:"members"`
+}
+
+type SetMemberRequest struct {
+	Role string `json:"role"`
+}
+
+type SetMemberResponse struct {
+	Member Member `json:"member"`
+}
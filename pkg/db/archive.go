@@ -0,0 +1,83 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"coding-challenge/pkg/model"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type ArchivedBill struct {
+	Bill      BillInfoAndMetadata
+	LineItems []model.BillLineItem
+}
+
+// Archiving moves a bill and its line items out of the database, leaving only a tombstone that points to the segment.
+type BillArchiveDatabase interface {
+	// Returns at most limit closed or paid bills, oldest closed first. Bills whose payment failed are kept.
+	ListBillsClosedBefore(ctx context.Context, closedBefore time.Time, limit int) ([]model.BillId, error)
+	// Bills already archived are skipped.
+	GetBillsToArchive(ctx context.Context, billIds []model.BillId) ([]ArchivedBill, error)
+	// Deletes the bills and their line items and leaves a tombstone. Returns the number of bills tombstoned.
+	TombstoneBills(ctx context.Context, billIds []model.BillId, segmentKey string, archivedAt time.Time) (uint64, error)
+	// Returns ErrTombstoneNotFound when the bill has not been archived.
+	GetTombstone(ctx context.Context, billId model.BillId) (segmentKey string, err error)
+}
+
+// A minimal object store, so that segments can live on a local disk or in a bucket.
+type ArchiveStore interface {
+	Put(key string, data []byte) error
+	// Returns ErrArchiveSegmentNotFound when there is nothing at key.
+	Get(key string) ([]byte, error)
+}
+
+// ErrTombstoneNotFound is returned when a bill has not been archived.
+var ErrTombstoneNotFound = errors.New("tombstone not found")
+
+// ErrArchiveSegmentNotFound is returned when an archive segment is not found.
+var ErrArchiveSegmentNotFound = errors.New("archive segment not found")
+
+// A segment is gzip-compressed JSON lines, one bill per line.
+func EncodeArchiveSegment(bills []ArchivedBill) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	encoder := json.NewEncoder(writer)
+	for _, bill := range bills {
+		if err := encoder.Encode(bill); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func DecodeArchiveSegment(data []byte) ([]ArchivedBill, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	bills := []ArchivedBill{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var bill ArchivedBill
+		if err := json.Unmarshal(scanner.Bytes(), &bill); err != nil {
+			return nil, err
+		}
+		// Segments archived before totals could not overflow have no total, the line items have it
+		if bill.Bill.Total.Number == "" {
+			if bill.Bill.Total, err = model.SumLineItems(bill.Bill.BillInfo.CurrencyCode, bill.LineItems); err != nil {
+				return nil, err
+			}
+		}
+		bills = append(bills, bill)
+	}
+	return bills, scanner.Err()
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const LocalArchiveDirDefault = "archive"
+
+type LocalArchiveStore struct {
+	dir string
+}
+
+var _ ArchiveStore = LocalArchiveStore{}
+
+func NewLocalArchiveStore(dir string) *LocalArchiveStore {
+	return &LocalArchiveStore{dir: dir}
+}
+
+// Writes to a temporary file first so that a reader never sees a partial segment.
+func (s LocalArchiveStore) Put(key string, data []byte) error {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".segment-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s LocalArchiveStore) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrArchiveSegmentNotFound
+	}
+	return data, err
+}
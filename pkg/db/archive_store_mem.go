@@ -0,0 +1,36 @@
+package db
+
+import "sync"
+
+type InMemoryArchiveStore struct {
+	segments map[string][]byte
+	mu       *sync.RWMutex
+}
+
+var _ ArchiveStore = InMemoryArchiveStore{}
+
+func NewInMemoryArchiveStore() *InMemoryArchiveStore {
+	return &InMemoryArchiveStore{
+		segments: make(map[string][]byte),
+		mu:       &sync.RWMutex{},
+	}
+}
+
+func (s InMemoryArchiveStore) Put(key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := make([]byte, len(data))
+	copy(stored, data)
+	s.segments[key] = stored
+	return nil
+}
+
+func (s InMemoryArchiveStore) Get(key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.segments[key]
+	if !ok {
+		return nil, ErrArchiveSegmentNotFound
+	}
+	return data, nil
+}
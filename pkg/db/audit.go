@@ -0,0 +1,14 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+)
+
+// Entries are never updated nor deleted.
+type AuditDatabase interface {
+	// Returns 0 when the entry was already recorded.
+	RecordEntry(ctx context.Context, entry model.AuditEntry) (uint64, error)
+	// Returns the entries in the order they were recorded.
+	GetEntries(ctx context.Context, billId model.BillId) ([]model.AuditEntry, error)
+}
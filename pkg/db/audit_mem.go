@@ -0,0 +1,64 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"fmt"
+	"sync"
+)
+
+type auditEntryKey struct {
+	action    model.AuditAction
+	requestId string
+}
+
+type storedAuditEntries struct {
+	keys    map[auditEntryKey]struct{}
+	entries []model.AuditEntry
+}
+
+type InMemoryAuditDatabase struct {
+	entries map[model.BillId]*storedAuditEntries
+	mu      *sync.RWMutex
+}
+
+var _ AuditDatabase = InMemoryAuditDatabase{}
+
+func NewInMemoryAuditDatabase() *InMemoryAuditDatabase {
+	return &InMemoryAuditDatabase{
+		entries: make(map[model.BillId]*storedAuditEntries),
+		mu:      &sync.RWMutex{},
+	}
+}
+
+func (m InMemoryAuditDatabase) RecordEntry(ctx context.Context, entry model.AuditEntry) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.entries[entry.BillId]
+	if !ok {
+		stored = &storedAuditEntries{keys: make(map[auditEntryKey]struct{})}
+		m.entries[entry.BillId] = stored
+	}
+	key := auditEntryKey{action: entry.Action, requestId: entry.RequestId}
+	if _, ok := stored.keys[key]; ok {
+		return 0, nil
+	}
+	stored.keys[key] = struct{}{}
+	stored.entries = append(stored.entries, entry)
+	fmt.Printf("In Memory Auditing: %v\n", entry)
+	return 1, nil
+}
+
+func (m InMemoryAuditDatabase) GetEntries(ctx context.Context, billId model.BillId) ([]model.AuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.entries[billId]
+	if !ok {
+		return []model.AuditEntry{}, nil
+	}
+	entries := make([]model.AuditEntry, len(stored.entries))
+	copy(entries, stored.entries)
+	return entries, nil
+}
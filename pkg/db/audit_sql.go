@@ -0,0 +1,92 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type SqlAuditDatabase struct {
+	sql *sql.DB
+}
+
+var _ AuditDatabase = SqlAuditDatabase{}
+
+func NewSqlAuditDatabase(sql *sql.DB) *SqlAuditDatabase {
+	return &SqlAuditDatabase{
+		sql: sql,
+	}
+}
+
+func (m SqlAuditDatabase) RecordEntry(ctx context.Context, entry model.AuditEntry) (uint64, error) {
+	res, err := m.sql.ExecContext(ctx, `
+		INSERT INTO BillAudit (
+			CustomerId, BillId, Action, RequestId, ActorType, ActorId,
+			TotalBefore, TotalAfter, WorkflowTime, CurrencyCode)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (CustomerId, BillId, Action, RequestId) DO NOTHING;
+	`, string(entry.BillId.CustomerId),
+		entry.BillId.Id,
+		string(entry.Action),
+		entry.RequestId,
+		string(entry.Actor.Type),
+		entry.Actor.Id,
+		entry.TotalBefore.Number,
+		entry.TotalAfter.Number,
+		entry.WorkflowTime,
+		string(entry.TotalAfter.CurrencyCode))
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	fmt.Printf("Sql auditing: %v, rows %d\n", entry, rowsAffected)
+	return uint64(rowsAffected), nil
+}
+
+func (m SqlAuditDatabase) GetEntries(ctx context.Context, billId model.BillId) ([]model.AuditEntry, error) {
+	rows, err := m.sql.QueryContext(ctx, `
+		SELECT Action, RequestId, ActorType, ActorId,
+			TotalBefore, TotalAfter, WorkflowTime, CurrencyCode
+		FROM BillAudit
+		WHERE CustomerId = $1 AND BillId = $2
+		ORDER BY Seq;
+	`, string(billId.CustomerId), billId.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := []model.AuditEntry{}
+	for rows.Next() {
+		var (
+			action       string
+			requestId    string
+			actorType    string
+			actorId      string
+			totalBefore  sql.NullString
+			totalAfter   sql.NullString
+			workflowTime time.Time
+			currencyCode string
+		)
+		err = rows.Scan(&action, &requestId, &actorType, &actorId,
+			&totalBefore, &totalAfter, &workflowTime, &currencyCode)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, model.AuditEntry{
+			BillId:    billId,
+			Action:    model.AuditAction(action),
+			Actor:     model.Actor{Type: model.ActorType(actorType), Id: actorId},
+			RequestId: requestId,
+			// An empty Number is a total that overflowed before totals were NUMERIC
+			TotalBefore:  model.TotalAmount{Number: totalBefore.String, CurrencyCode: model.CurrencyCode(currencyCode)},
+			TotalAfter:   model.TotalAmount{Number: totalAfter.String, CurrencyCode: model.CurrencyCode(currencyCode)},
+			WorkflowTime: workflowTime,
+		})
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+)
+
+type CouponDatabase interface {
+	GetCoupon(ctx context.Context, code string) (model.Coupon, error)
+	// Returns 0 when the bill already redeemed the coupon.
+	RedeemCoupon(ctx context.Context, code string, billId model.BillId) (uint64, error)
+	// Returns 0 when the discounts of the bill were already saved, the first ones are kept.
+	SaveBillDiscounts(ctx context.Context, billId model.BillId, discounts []model.DiscountLine) (uint64, error)
+	// In the order the coupons were attached.
+	GetBillDiscounts(ctx context.Context, billId model.BillId) ([]model.DiscountLine, error)
+}
+
+// ErrCouponNotFound is returned when no coupon has the code.
+var ErrCouponNotFound = errors.New("coupon not found")
+
+// ErrCouponExhausted is returned when the coupon reached its maximum number of redemptions.
+var ErrCouponExhausted = errors.New("coupon has no redemptions left")
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"fmt"
+	"sync"
+)
+
+type storedCoupon struct {
+	coupon      model.Coupon
+	redemptions map[model.BillId]struct{}
+}
+
+type InMemoryCouponDatabase struct {
+	coupons   map[string]*storedCoupon
+	discounts map[model.BillId][]model.DiscountLine
+	mu        *sync.RWMutex
+}
+
+var _ CouponDatabase = InMemoryCouponDatabase{}
+
+func NewInMemoryCouponDatabase() *InMemoryCouponDatabase {
+	return &InMemoryCouponDatabase{
+		coupons:   make(map[string]*storedCoupon),
+		discounts: make(map[model.BillId][]model.DiscountLine),
+		mu:        &sync.RWMutex{},
+	}
+}
+
+func (m InMemoryCouponDatabase) AddCoupon(coupon model.Coupon) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.coupons[coupon.Code] = &storedCoupon{coupon: coupon, redemptions: make(map[model.BillId]struct{})}
+}
+
+func (m InMemoryCouponDatabase) GetCoupon(ctx context.Context, code string) (model.Coupon, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stored, ok := m.coupons[code]
+	if !ok {
+		return model.Coupon{}, ErrCouponNotFound
+	}
+	return stored.coupon, nil
+}
+
+func (m InMemoryCouponDatabase) RedeemCoupon(ctx context.Context, code string, billId model.BillId) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.coupons[code]
+	if !ok {
+		return 0, ErrCouponNotFound
+	}
+	if _, ok := stored.redemptions[billId]; ok {
+		return 0, nil
+	}
+	if stored.coupon.MaxRedemptions != 0 && stored.coupon.MaxRedemptions <= uint64(len(stored.redemptions)) {
+		return 0, ErrCouponExhausted
+	}
+	stored.redemptions[billId] = struct{}{}
+	fmt.Printf("In Memory Redeeming: %v %v\n", code, billId)
+	return 1, nil
+}
+
+func (m InMemoryCouponDatabase) SaveBillDiscounts(ctx context.Context, billId model.BillId, discounts []model.DiscountLine) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.discounts[billId]; ok {
+		return 0, nil
+	}
+	m.discounts[billId] = append([]model.DiscountLine{}, discounts...)
+	return 1, nil
+}
+
+func (m InMemoryCouponDatabase) GetBillDiscounts(ctx context.Context, billId model.BillId) ([]model.DiscountLine, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]model.DiscountLine{}, m.discounts[billId]...), nil
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type SqlCouponDatabase struct {
+	sql *sql.DB
+}
+
+var _ CouponDatabase = SqlCouponDatabase{}
+
+func NewSqlCouponDatabase(sql *sql.DB) *SqlCouponDatabase {
+	return &SqlCouponDatabase{
+		sql: sql,
+	}
+}
+
+func (m SqlCouponDatabase) GetCoupon(ctx context.Context, code string) (model.Coupon, error) {
+	var (
+		coupon       model.Coupon
+		couponType   string
+		currencyCode string
+		validUntil   sql.NullTime
+	)
+	err := m.sql.QueryRowContext(ctx, `
+		SELECT Code, Type, Value, CurrencyCode, ValidFrom, ValidUntil, MaxRedemptions
+		FROM Coupon
+		WHERE Code = $1;
+	`, code).Scan(&coupon.Code, &couponType, &coupon.Value, &currencyCode, &coupon.ValidFrom, &validUntil, &coupon.MaxRedemptions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Coupon{}, ErrCouponNotFound
+	} else if err != nil {
+		return model.Coupon{}, err
+	}
+	coupon.Type = model.CouponType(couponType)
+	coupon.CurrencyCode = model.CurrencyCode(currencyCode)
+	coupon.ValidUntil = validUntil.Time
+	return coupon, nil
+}
+
+// Locks the coupon row so that concurrent redemptions do not exceed the limit.
+func (m SqlCouponDatabase) RedeemCoupon(ctx context.Context, code string, billId model.BillId) (uint64, error) {
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var maxRedemptions, redemptions uint64
+	err = tx.QueryRowContext(ctx, `
+		SELECT MaxRedemptions, Redemptions
+		FROM Coupon
+		WHERE Code = $1
+		FOR UPDATE;
+	`, code).Scan(&maxRedemptions, &redemptions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrCouponNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO CouponRedemption (Code, CustomerId, BillId)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (Code, CustomerId, BillId) DO NOTHING;
+	`, code, string(billId.CustomerId), billId.Id)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected == 0 {
+		return 0, nil
+	}
+	if maxRedemptions != 0 && maxRedemptions <= redemptions {
+		return 0, ErrCouponExhausted
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE Coupon
+		SET Redemptions = Redemptions + 1
+		WHERE Code = $1;
+	`, code)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(rowsAffected), tx.Commit()
+}
+
+func (m SqlCouponDatabase) SaveBillDiscounts(ctx context.Context, billId model.BillId, discounts []model.DiscountLine) (uint64, error) {
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var rowsAffected int64
+	for i, discount := range discounts {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO BillDiscountLine (CustomerId, BillId, Seq, CouponCode, Amount, CurrencyCode)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (CustomerId, BillId, Seq) DO NOTHING;
+		`, string(billId.CustomerId),
+			billId.Id,
+			i,
+			discount.CouponCode,
+			discount.Amount.Number,
+			string(discount.Amount.CurrencyCode))
+		if err != nil {
+			return 0, err
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected += inserted
+	}
+	return uint64(rowsAffected), tx.Commit()
+}
+
+func (m SqlCouponDatabase) GetBillDiscounts(ctx context.Context, billId model.BillId) ([]model.DiscountLine, error) {
+	rows, err := m.sql.QueryContext(ctx, `
+		SELECT CouponCode, Amount, CurrencyCode
+		FROM BillDiscountLine
+		WHERE CustomerId = $1 AND BillId = $2
+		ORDER BY Seq;
+	`, string(billId.CustomerId), billId.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	discounts := []model.DiscountLine{}
+	for rows.Next() {
+		var (
+			discount     model.DiscountLine
+			currencyCode string
+		)
+		if err = rows.Scan(&discount.CouponCode, &discount.Amount.Number, &currencyCode); err != nil {
+			return nil, err
+		}
+		discount.Amount.CurrencyCode = model.CurrencyCode(currencyCode)
+		discounts = append(discounts, discount)
+	}
+	return discounts, rows.Err()
+}
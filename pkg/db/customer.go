@@ -0,0 +1,11 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+)
+
+type CustomerDatabase interface {
+	// A customer without a tier is in the standard tier.
+	GetCustomerTier(ctx context.Context, customerId model.CustomerId) (model.CustomerTier, error)
+}
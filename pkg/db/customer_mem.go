@@ -0,0 +1,36 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"sync"
+)
+
+type InMemoryCustomerDatabase struct {
+	tiers map[model.CustomerId]model.CustomerTier
+	mu    *sync.RWMutex
+}
+
+var _ CustomerDatabase = InMemoryCustomerDatabase{}
+
+func NewInMemoryCustomerDatabase() *InMemoryCustomerDatabase {
+	return &InMemoryCustomerDatabase{
+		tiers: make(map[model.CustomerId]model.CustomerTier),
+		mu:    &sync.RWMutex{},
+	}
+}
+
+func (m InMemoryCustomerDatabase) SetCustomerTier(customerId model.CustomerId, tier model.CustomerTier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tiers[customerId] = tier
+}
+
+func (m InMemoryCustomerDatabase) GetCustomerTier(ctx context.Context, customerId model.CustomerId) (model.CustomerTier, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if tier, ok := m.tiers[customerId]; ok {
+		return tier, nil
+	}
+	return model.TierStandard, nil
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type SqlCustomerDatabase struct {
+	sql *sql.DB
+}
+
+var _ CustomerDatabase = SqlCustomerDatabase{}
+
+func NewSqlCustomerDatabase(sql *sql.DB) *SqlCustomerDatabase {
+	return &SqlCustomerDatabase{
+		sql: sql,
+	}
+}
+
+func (m SqlCustomerDatabase) GetCustomerTier(ctx context.Context, customerId model.CustomerId) (model.CustomerTier, error) {
+	var tier string
+	err := m.sql.QueryRowContext(ctx, `
+		SELECT Tier
+		FROM CustomerTier
+		WHERE CustomerId = $1;
+	`, string(customerId)).Scan(&tier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.TierStandard, nil
+	} else if err != nil {
+		return "", err
+	}
+	return model.CustomerTier(tier), nil
+}
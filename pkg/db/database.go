@@ -2,21 +2,40 @@ package db
 
 import (
 	"coding-challenge/pkg/model"
+	"context"
 	"errors"
+	"time"
 )
 
 type BillInfoAndMetadata struct {
 	BillInfo      model.BillInfo
 	LineItemCount uint64
-	TotalAmount   model.Amount
-	TotalOk       bool
+	Total         model.TotalAmount
 }
 
 type BillDatabase interface {
-	CreateBill(bill model.BillInfo) (uint64, error)
-	AddLineItem(lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error)
-	CloseBill(billId model.BillId) (uint64, error)
-	GetBill(billId model.BillId) (BillInfoAndMetadata, error)
+	// The bill must be a draft or open. It is refused with model.OpenBillQuotaExceededError when the customer
+	// already has bill.MaxOpenBills running bills, counted and saved atomically.
+	CreateBill(ctx context.Context, bill model.BillInfo) (uint64, error)
+	// The bill must be open, or closing, see model.BillStatus.AcceptsLineItems. The total of the bill is incremented by
+	// the amount, totalBefore is the total the workflow added it to.
+	AddLineItem(ctx context.Context, lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error)
+	// Like AddLineItem for line items of the bill, in one transaction. Those that already exist are skipped, and
+	// the result tells whether each one was added.
+	AddLineItems(ctx context.Context, billId model.BillId, lineItems []model.BillLineItem, totalBefore model.TotalAmount) ([]bool, error)
+	// The bill must be closing. Returns 0 when it is already closed.
+	CloseBill(ctx context.Context, billId model.BillId, closedAt time.Time) (uint64, error)
+	// The bill must be open. Returns 0 when it already closes at the close time.
+	SetCloseTime(ctx context.Context, billId model.BillId, closeTime time.Time) (uint64, error)
+	// The bill must be open.
+	SetSpendingCap(ctx context.Context, billId model.BillId, spendingCap model.SpendingCap) (uint64, error)
+	// Refuses a transition that model.BillStatus does not allow. Returns 0 when the bill already has the status.
+	SetBillStatus(ctx context.Context, billId model.BillId, status model.BillStatus) (uint64, error)
+	GetBill(ctx context.Context, billId model.BillId) (BillInfoAndMetadata, error)
+	// Sorted by creation time.
+	GetLineItems(ctx context.Context, billId model.BillId) ([]model.BillLineItem, error)
+	// Drafts, open or closing, see model.BillStatus.IsRunning.
+	CountRunningBills(ctx context.Context, customerId model.CustomerId) (uint64, error)
 }
 
 // ErrBillNotFound is returned when a bill is not found.
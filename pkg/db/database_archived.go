@@ -0,0 +1,170 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Reads archived bills from their segment when they are no longer in the inner database.
+type ArchivedBillDatabase struct {
+	inner      BillDatabase
+	tombstones BillArchiveDatabase
+	store      ArchiveStore
+}
+
+var _ BillDatabase = ArchivedBillDatabase{}
+
+func NewArchivedBillDatabase(inner BillDatabase, tombstones BillArchiveDatabase, store ArchiveStore) *ArchivedBillDatabase {
+	return &ArchivedBillDatabase{
+		inner:      inner,
+		tombstones: tombstones,
+		store:      store,
+	}
+}
+
+func (m ArchivedBillDatabase) CreateBill(ctx context.Context, bill model.BillInfo) (uint64, error) {
+	if archived, err := m.isArchived(ctx, bill.Id); err != nil {
+		return 0, err
+	} else if archived {
+		return 0, ErrBillAlreadyExists
+	}
+	return m.inner.CreateBill(ctx, bill)
+}
+
+func (m ArchivedBillDatabase) AddLineItem(ctx context.Context, lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error) {
+	updateCount, err := m.inner.AddLineItem(ctx, lineItem, totalBefore)
+	if err == ErrBillNotFound {
+		if archived, tombstoneErr := m.isArchived(ctx, lineItem.Id.BillId); tombstoneErr != nil {
+			return 0, tombstoneErr
+		} else if archived {
+			return 0, ErrBillClosed
+		}
+	}
+	return updateCount, err
+}
+
+func (m ArchivedBillDatabase) AddLineItems(ctx context.Context, billId model.BillId, lineItems []model.BillLineItem, totalBefore model.TotalAmount) ([]bool, error) {
+	added, err := m.inner.AddLineItems(ctx, billId, lineItems, totalBefore)
+	if err == ErrBillNotFound {
+		if archived, tombstoneErr := m.isArchived(ctx, billId); tombstoneErr != nil {
+			return nil, tombstoneErr
+		} else if archived {
+			return nil, ErrBillClosed
+		}
+	}
+	return added, err
+}
+
+func (m ArchivedBillDatabase) CloseBill(ctx context.Context, billId model.BillId, closedAt time.Time) (uint64, error) {
+	updateCount, err := m.inner.CloseBill(ctx, billId, closedAt)
+	if err == ErrBillNotFound {
+		if archived, tombstoneErr := m.isArchived(ctx, billId); tombstoneErr != nil {
+			return 0, tombstoneErr
+		} else if archived {
+			return 0, nil
+		}
+	}
+	return updateCount, err
+}
+
+// An archived bill is closed.
+func (m ArchivedBillDatabase) SetCloseTime(ctx context.Context, billId model.BillId, closeTime time.Time) (uint64, error) {
+	updateCount, err := m.inner.SetCloseTime(ctx, billId, closeTime)
+	if err == ErrBillNotFound {
+		if archived, tombstoneErr := m.isArchived(ctx, billId); tombstoneErr != nil {
+			return 0, tombstoneErr
+		} else if archived {
+			return 0, ErrBillClosed
+		}
+	}
+	return updateCount, err
+}
+
+// An archived bill is closed.
+func (m ArchivedBillDatabase) SetSpendingCap(ctx context.Context, billId model.BillId, spendingCap model.SpendingCap) (uint64, error) {
+	updateCount, err := m.inner.SetSpendingCap(ctx, billId, spendingCap)
+	if err == ErrBillNotFound {
+		if archived, tombstoneErr := m.isArchived(ctx, billId); tombstoneErr != nil {
+			return 0, tombstoneErr
+		} else if archived {
+			return 0, ErrBillClosed
+		}
+	}
+	return updateCount, err
+}
+
+func (m ArchivedBillDatabase) SetBillStatus(ctx context.Context, billId model.BillId, status model.BillStatus) (uint64, error) {
+	updateCount, err := m.inner.SetBillStatus(ctx, billId, status)
+	if err == ErrBillNotFound {
+		if archived, tombstoneErr := m.isArchived(ctx, billId); tombstoneErr != nil {
+			return 0, tombstoneErr
+		} else if archived {
+			return 0, nil
+		}
+	}
+	return updateCount, err
+}
+
+func (m ArchivedBillDatabase) GetBill(ctx context.Context, billId model.BillId) (BillInfoAndMetadata, error) {
+	bill, err := m.inner.GetBill(ctx, billId)
+	if err != ErrBillNotFound {
+		return bill, err
+	}
+	archived, err := m.getArchivedBill(ctx, billId)
+	if err != nil {
+		return BillInfoAndMetadata{}, err
+	}
+	return archived.Bill, nil
+}
+
+func (m ArchivedBillDatabase) GetLineItems(ctx context.Context, billId model.BillId) ([]model.BillLineItem, error) {
+	lineItems, err := m.inner.GetLineItems(ctx, billId)
+	if err != ErrBillNotFound {
+		return lineItems, err
+	}
+	archived, err := m.getArchivedBill(ctx, billId)
+	if err != nil {
+		return nil, err
+	}
+	return archived.LineItems, nil
+}
+
+// Only closed bills are archived.
+func (m ArchivedBillDatabase) CountRunningBills(ctx context.Context, customerId model.CustomerId) (uint64, error) {
+	return m.inner.CountRunningBills(ctx, customerId)
+}
+
+// Errors other than a missing tombstone are returned, so that a bill is not taken for absent when it may be archived.
+func (m ArchivedBillDatabase) isArchived(ctx context.Context, billId model.BillId) (bool, error) {
+	_, err := m.tombstones.GetTombstone(ctx, billId)
+	if err == ErrTombstoneNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (m ArchivedBillDatabase) getArchivedBill(ctx context.Context, billId model.BillId) (ArchivedBill, error) {
+	segmentKey, err := m.tombstones.GetTombstone(ctx, billId)
+	if err == ErrTombstoneNotFound {
+		return ArchivedBill{}, ErrBillNotFound
+	} else if err != nil {
+		return ArchivedBill{}, err
+	}
+	data, err := m.store.Get(segmentKey)
+	if err != nil {
+		return ArchivedBill{}, err
+	}
+	bills, err := DecodeArchiveSegment(data)
+	if err != nil {
+		return ArchivedBill{}, err
+	}
+	for _, bill := range bills {
+		if bill.Bill.BillInfo.Id == billId {
+			fmt.Printf("Read archived bill: %v from %s\n", billId, segmentKey)
+			return bill, nil
+		}
+	}
+	return ArchivedBill{}, ErrBillNotFound
+}
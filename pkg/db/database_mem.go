@@ -2,16 +2,18 @@ package db
 
 import (
 	"coding-challenge/pkg/model"
+	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 )
 
 type storedBillAndItems struct {
 	bill          model.BillInfo
 	lineItems     map[string]*model.BillLineItem
 	lineItemCount uint64
-	totalAmount   model.Amount
-	totalOk       bool
+	total         model.TotalAmount
 }
 
 type customerBills struct {
@@ -21,19 +23,26 @@ type customerBills struct {
 type InMemoryBillDatabase struct {
 	// customerId -> Id -> bill info
 	bills map[model.CustomerId]*customerBills
-	mu    *sync.RWMutex
+	// bill id -> segment key
+	tombstones map[model.BillId]string
+	mu         *sync.RWMutex
 }
 
 var _ BillDatabase = InMemoryBillDatabase{}
+var _ BillArchiveDatabase = InMemoryBillDatabase{}
 
 func NewInMemoryBillDatabase() *InMemoryBillDatabase {
 	return &InMemoryBillDatabase{
-		bills: make(map[model.CustomerId]*customerBills),
-		mu:    &sync.RWMutex{},
+		bills:      make(map[model.CustomerId]*customerBills),
+		tombstones: make(map[model.BillId]string),
+		mu:         &sync.RWMutex{},
 	}
 }
 
-func (m InMemoryBillDatabase) CreateBill(bill model.BillInfo) (uint64, error) {
+func (m InMemoryBillDatabase) CreateBill(ctx context.Context, bill model.BillInfo) (uint64, error) {
+	if !bill.Status.IsInitial() {
+		return 0, model.InvalidBillStatusError{Status: bill.Status.String()}
+	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -45,16 +54,20 @@ func (m InMemoryBillDatabase) CreateBill(bill model.BillInfo) (uint64, error) {
 	} else if _, ok := m.bills[customerId].bills[billId]; ok {
 		return 0, ErrBillAlreadyExists
 	}
+	if err := bill.CheckOpenBillCount(m.bills[customerId].countRunningBills()); err != nil {
+		return 0, err
+	}
 
 	m.bills[customerId].bills[billId] = &storedBillAndItems{
 		bill:      bill,
 		lineItems: make(map[string]*model.BillLineItem),
+		total:     model.NewTotalAmount(bill.CurrencyCode),
 	}
 	fmt.Printf("In Memory Saving: %v\n", bill)
 	return 1, nil
 }
 
-func (m InMemoryBillDatabase) AddLineItem(lineItem model.BillLineItem, _ model.TotalAmount) (uint64, error) {
+func (m InMemoryBillDatabase) AddLineItem(ctx context.Context, lineItem model.BillLineItem, _ model.TotalAmount) (uint64, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -67,23 +80,62 @@ func (m InMemoryBillDatabase) AddLineItem(lineItem model.BillLineItem, _ model.T
 	if !ok {
 		return 0, ErrBillNotFound
 	}
-	if storedBill.bill.Status == model.Closed {
+	if !storedBill.bill.Status.AcceptsLineItems() {
 		return 0, ErrBillClosed
 	}
 	if _, ok := storedBill.lineItems[lineItemId]; ok {
 		return 0, ErrLineItemAlreadyExists
 	}
+	if e := storedBill.total.Add(lineItem.Amount); e != nil {
+		return 0, ErrCurrencyMismatch
+	}
 
 	storedBill.lineItems[lineItemId] = &lineItem
 	storedBill.lineItemCount++
-	if storedBill.totalOk {
-		storedBill.totalAmount, storedBill.totalOk = storedBill.totalAmount.Add(lineItem.Amount)
-	}
 	fmt.Printf("In Memory Saving: %v\n", lineItem)
 	return 1, nil
 }
 
-func (m InMemoryBillDatabase) CloseBill(billId model.BillId) (uint64, error) {
+func (m InMemoryBillDatabase) AddLineItems(ctx context.Context, billId model.BillId, lineItems []model.BillLineItem, _ model.TotalAmount) ([]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	customerBills, ok := m.bills[billId.CustomerId]
+	if !ok {
+		return nil, ErrBillNotFound
+	}
+	storedBill, ok := customerBills.bills[billId.Id]
+	if !ok {
+		return nil, ErrBillNotFound
+	}
+	if !storedBill.bill.Status.AcceptsLineItems() {
+		return nil, ErrBillClosed
+	}
+	for _, lineItem := range lineItems {
+		if lineItem.Id.BillId != billId {
+			return nil, ErrBillMismatch
+		} else if lineItem.Amount.CurrencyCode != storedBill.bill.CurrencyCode {
+			return nil, ErrCurrencyMismatch
+		}
+	}
+
+	added := make([]bool, len(lineItems))
+	for i, lineItem := range lineItems {
+		if _, ok := storedBill.lineItems[lineItem.Id.Id]; ok {
+			continue
+		}
+		if e := storedBill.total.Add(lineItem.Amount); e != nil {
+			return nil, ErrCurrencyMismatch
+		}
+		storedBill.lineItems[lineItem.Id.Id] = &lineItem
+		storedBill.lineItemCount++
+		added[i] = true
+	}
+	fmt.Printf("In Memory Saving: %v line items of %v\n", len(lineItems), billId)
+	return added, nil
+}
+
+func (m InMemoryBillDatabase) CloseBill(ctx context.Context, billId model.BillId, closedAt time.Time) (uint64, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -96,30 +148,199 @@ func (m InMemoryBillDatabase) CloseBill(billId model.BillId) (uint64, error) {
 	if !ok {
 		return 0, ErrBillNotFound
 	}
+	if storedBillAndItems.bill.Status == model.Closed {
+		return 0, nil
+	}
+	if e := storedBillAndItems.bill.Status.CheckTransition(model.Closed); e != nil {
+		return 0, e
+	}
 
 	storedBillAndItems.bill.Status = model.Closed
+	storedBillAndItems.bill.ClosedAt = closedAt
 	customerBills.bills[id] = storedBillAndItems
 	fmt.Printf("In Memory Closing: %v\n", billId)
 	return 1, nil
 }
 
-func (m InMemoryBillDatabase) GetBill(billId model.BillId) (BillInfoAndMetadata, error) {
+func (m InMemoryBillDatabase) SetCloseTime(ctx context.Context, billId model.BillId, closeTime time.Time) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	storedBillAndItems, ok := m.getStoredBill(billId)
+	if !ok {
+		return 0, ErrBillNotFound
+	}
+	if storedBillAndItems.bill.Status != model.Open {
+		return 0, ErrBillClosed
+	} else if storedBillAndItems.bill.CloseTime.Equal(closeTime) {
+		return 0, nil
+	}
+	storedBillAndItems.bill.CloseTime = closeTime
+	fmt.Printf("In Memory Setting close time: %v %v\n", billId, closeTime)
+	return 1, nil
+}
+
+func (m InMemoryBillDatabase) SetSpendingCap(ctx context.Context, billId model.BillId, spendingCap model.SpendingCap) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	storedBillAndItems, ok := m.getStoredBill(billId)
+	if !ok {
+		return 0, ErrBillNotFound
+	}
+	if storedBillAndItems.bill.Status != model.Open {
+		return 0, ErrBillClosed
+	}
+	storedBillAndItems.bill.SpendingCap = spendingCap
+	return 1, nil
+}
+
+func (m InMemoryBillDatabase) SetBillStatus(ctx context.Context, billId model.BillId, status model.BillStatus) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	storedBillAndItems, ok := m.getStoredBill(billId)
+	if !ok {
+		return 0, ErrBillNotFound
+	}
+	if storedBillAndItems.bill.Status == status {
+		return 0, nil
+	}
+	if e := storedBillAndItems.bill.Status.CheckTransition(status); e != nil {
+		return 0, e
+	}
+	storedBillAndItems.bill.Status = status
+	fmt.Printf("In Memory Setting status: %v %v\n", billId, status)
+	return 1, nil
+}
+
+func (m InMemoryBillDatabase) getStoredBill(billId model.BillId) (*storedBillAndItems, bool) {
+	customerBills, ok := m.bills[billId.CustomerId]
+	if !ok {
+		return nil, false
+	}
+	storedBillAndItems, ok := customerBills.bills[billId.Id]
+	return storedBillAndItems, ok
+}
+
+func (stored *storedBillAndItems) toMetadata() BillInfoAndMetadata {
+	return BillInfoAndMetadata{
+		BillInfo:      stored.bill,
+		LineItemCount: stored.lineItemCount,
+		Total:         stored.total,
+	}
+}
+
+func (stored *storedBillAndItems) sortedLineItems() []model.BillLineItem {
+	lineItems := make([]model.BillLineItem, 0, len(stored.lineItems))
+	for _, lineItem := range stored.lineItems {
+		lineItems = append(lineItems, *lineItem)
+	}
+	sort.Slice(lineItems, func(i, j int) bool {
+		if !lineItems[i].CreatedAt.Equal(lineItems[j].CreatedAt) {
+			return lineItems[i].CreatedAt.Before(lineItems[j].CreatedAt)
+		}
+		return lineItems[i].Id.Id < lineItems[j].Id.Id
+	})
+	return lineItems
+}
+
+func (m InMemoryBillDatabase) GetBill(ctx context.Context, billId model.BillId) (BillInfoAndMetadata, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	customerId, id := billId.CustomerId, billId.Id
-	customerBills, ok := m.bills[customerId]
+	storedBillAndItems, ok := m.getStoredBill(billId)
 	if !ok {
 		return BillInfoAndMetadata{}, ErrBillNotFound
 	}
-	storedBillAndItems, ok := customerBills.bills[id]
+	return storedBillAndItems.toMetadata(), nil
+}
+
+func (m InMemoryBillDatabase) GetLineItems(ctx context.Context, billId model.BillId) ([]model.BillLineItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	storedBillAndItems, ok := m.getStoredBill(billId)
 	if !ok {
-		return BillInfoAndMetadata{}, ErrBillNotFound
+		return nil, ErrBillNotFound
 	}
+	return storedBillAndItems.sortedLineItems(), nil
+}
 
-	return BillInfoAndMetadata{
-		BillInfo:      storedBillAndItems.bill,
-		LineItemCount: storedBillAndItems.lineItemCount,
-		TotalAmount:   storedBillAndItems.totalAmount,
-		TotalOk:       storedBillAndItems.totalOk,
-	}, nil
+func (m InMemoryBillDatabase) CountRunningBills(ctx context.Context, customerId model.CustomerId) (uint64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if customer, ok := m.bills[customerId]; ok {
+		return customer.countRunningBills(), nil
+	}
+	return 0, nil
+}
+
+func (c *customerBills) countRunningBills() uint64 {
+	var count uint64
+	for _, stored := range c.bills {
+		if stored.bill.Status.IsRunning() {
+			count++
+		}
+	}
+	return count
+}
+
+func (m InMemoryBillDatabase) ListBillsClosedBefore(ctx context.Context, closedBefore time.Time, limit int) ([]model.BillId, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	closed := []model.BillInfo{}
+	for _, customerBills := range m.bills {
+		for _, stored := range customerBills.bills {
+			if (stored.bill.Status == model.Closed || stored.bill.Status == model.Paid) && stored.bill.ClosedAt.Before(closedBefore) {
+				closed = append(closed, stored.bill)
+			}
+		}
+	}
+	sort.Slice(closed, func(i, j int) bool {
+		return closed[i].ClosedAt.Before(closed[j].ClosedAt)
+	})
+	billIds := make([]model.BillId, 0, min(limit, len(closed)))
+	for i := 0; i < len(closed) && i < limit; i++ {
+		billIds = append(billIds, closed[i].Id)
+	}
+	return billIds, nil
+}
+
+func (m InMemoryBillDatabase) GetBillsToArchive(ctx context.Context, billIds []model.BillId) ([]ArchivedBill, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	bills := make([]ArchivedBill, 0, len(billIds))
+	for _, billId := range billIds {
+		stored, ok := m.getStoredBill(billId)
+		if !ok {
+			continue
+		}
+		bills = append(bills, ArchivedBill{Bill: stored.toMetadata(), LineItems: stored.sortedLineItems()})
+	}
+	return bills, nil
+}
+
+func (m InMemoryBillDatabase) TombstoneBills(ctx context.Context, billIds []model.BillId, segmentKey string, _ time.Time) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var count uint64
+	for _, billId := range billIds {
+		if _, ok := m.getStoredBill(billId); !ok {
+			continue
+		}
+		delete(m.bills[billId.CustomerId].bills, billId.Id)
+		m.tombstones[billId] = segmentKey
+		count++
+	}
+	fmt.Printf("In Memory Tombstoning: %d bills to %s\n", count, segmentKey)
+	return count, nil
+}
+
+func (m InMemoryBillDatabase) GetTombstone(ctx context.Context, billId model.BillId) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	segmentKey, ok := m.tombstones[billId]
+	if !ok {
+		return "", ErrTombstoneNotFound
+	}
+	return segmentKey, nil
 }
@@ -2,8 +2,13 @@ package db
 
 import (
 	"coding-challenge/pkg/model"
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 const SqlDbType = "sql"
@@ -13,6 +18,7 @@ type SqlBillDatabase struct {
 }
 
 var _ BillDatabase = SqlBillDatabase{}
+var _ BillArchiveDatabase = SqlBillDatabase{}
 
 func NewSqlBillDatabase(sql *sql.DB) *SqlBillDatabase {
 	return &SqlBillDatabase{
@@ -20,14 +26,53 @@ func NewSqlBillDatabase(sql *sql.DB) *SqlBillDatabase {
 	}
 }
 
-func (m SqlBillDatabase) CreateBill(bill model.BillInfo) (uint64, error) {
-	res, err := m.sql.Exec(`
-		INSERT INTO Bill (CustomerId, Id, CurrencyCode)
-		VALUES ($1, $2, $3)
+func (m SqlBillDatabase) CreateBill(ctx context.Context, bill model.BillInfo) (uint64, error) {
+	if !bill.Status.IsInitial() {
+		return 0, model.InvalidBillStatusError{Status: bill.Status.String()}
+	}
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	// Serializes the bills opened by the customer until the commit, so that they are counted one after the other
+	_, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1));`, string(bill.Id.CustomerId))
+	if err != nil {
+		return 0, err
+	}
+	var exists bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM Bill WHERE CustomerId = $1 AND Id = $2);
+	`, string(bill.Id.CustomerId), bill.Id.Id).Scan(&exists)
+	if err != nil {
+		return 0, err
+	} else if exists {
+		return 0, nil
+	}
+	var runningBillCount uint64
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM Bill
+		WHERE CustomerId = $1 AND Status IN ($2, $3, $4);
+	`, string(bill.Id.CustomerId), model.Draft, model.Open, model.Closing).Scan(&runningBillCount)
+	if err != nil {
+		return 0, err
+	} else if err = bill.CheckOpenBillCount(runningBillCount); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO Bill (CustomerId, Id, CurrencyCode, CreatedAt, CloseTime, TaxJurisdiction, Status, SpendingCap, AlertThresholds)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (CustomerId, Id) DO NOTHING;
 	`, string(bill.Id.CustomerId),
 		bill.Id.Id,
-		bill.CurrencyCode)
+		bill.CurrencyCode,
+		bill.CreatedAt,
+		bill.CloseTime,
+		string(bill.TaxJurisdiction),
+		bill.Status,
+		bill.SpendingCap.Max.Number,
+		alertThresholdsArray(bill.SpendingCap.AlertThresholds))
 	if err != nil {
 		return 0, err
 	}
@@ -36,16 +81,16 @@ func (m SqlBillDatabase) CreateBill(bill model.BillInfo) (uint64, error) {
 		return 0, err
 	}
 	fmt.Printf("Sql saving bill: %v, rows %d\n", bill, rowsAffected)
-	return uint64(rowsAffected), nil
+	return uint64(rowsAffected), tx.Commit()
 }
 
-func (m SqlBillDatabase) AddLineItem(lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error) {
-	tx, err := m.sql.Begin()
+func (m SqlBillDatabase) AddLineItem(ctx context.Context, lineItem model.BillLineItem, _ model.TotalAmount) (uint64, error) {
+	tx, err := m.sql.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
 	defer tx.Rollback()
-	rows, err := tx.Query(`
+	rows, err := tx.QueryContext(ctx, `
 		SELECT Status, CurrencyCode
 		FROM Bill
 		WHERE CustomerId = $1 AND Id = $2;
@@ -63,25 +108,23 @@ func (m SqlBillDatabase) AddLineItem(lineItem model.BillLineItem, totalBefore mo
 	if err != nil {
 		return 0, err
 	}
-	if status != model.Open {
+	if !status.AcceptsLineItems() {
 		return 0, ErrBillClosed
 	}
 	if lineItem.Amount.CurrencyCode != model.CurrencyCode(currencyCode) {
 		return 0, ErrCurrencyMismatch
 	}
 	rows.Close()
-	totalBefore.Add(lineItem.Amount)
-	res, err := tx.Exec(`
+	// Incremented so that the line items added concurrently all count
+	res, err := tx.ExecContext(ctx, `
 		UPDATE Bill
 		SET
 			LineItemCount = LineItemCount + 1,
-			TotalAmount = $3,
-			TotalOk = $4
+			TotalAmount = TotalAmount + $3
 		WHERE CustomerId = $1 AND Id = $2;
 	`, string(lineItem.Id.BillId.CustomerId),
 		lineItem.Id.BillId.Id,
-		totalBefore.Total.Number,
-		totalBefore.Ok)
+		lineItem.Amount.Number)
 	if err != nil {
 		return 0, err
 	}
@@ -92,15 +135,11 @@ func (m SqlBillDatabase) AddLineItem(lineItem model.BillLineItem, totalBefore mo
 	if rowsAffected == 0 {
 		return 0, ErrBillNotFound
 	}
-	res, err = tx.Exec(`
-		INSERT INTO LineItem (CustomerId, BillId, Id, Description, Amount)
-		VALUES ($1, $2, $3, $4, $5)
+	res, err = tx.ExecContext(ctx, `
+		INSERT INTO LineItem (`+lineItemColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		ON CONFLICT (CustomerId, BillId, Id) DO NOTHING;
-	`, string(lineItem.Id.BillId.CustomerId),
-		lineItem.Id.BillId.Id,
-		lineItem.Id.Id,
-		lineItem.Description,
-		lineItem.Amount.Number)
+	`, lineItemValues(lineItem)...)
 	if err != nil {
 		return 0, err
 	}
@@ -115,13 +154,179 @@ func (m SqlBillDatabase) AddLineItem(lineItem model.BillLineItem, totalBefore mo
 	return uint64(rowsAffected), tx.Commit()
 }
 
-func (m SqlBillDatabase) CloseBill(billId model.BillId) (uint64, error) {
-	res, err := m.sql.Exec(`
+const lineItemColumns = "CustomerId, BillId, Id, Description, Amount, CreatedAt, OriginalAmount, OriginalCurrencyCode, FxRate, FxRateAsOf, TaxCategory, TaxInclusive, Quantity, UnitPrice, Rounding"
+
+// In the order of lineItemColumns.
+func lineItemValues(lineItem model.BillLineItem) []any {
+	var (
+		originalAmount       sql.NullInt64
+		originalCurrencyCode sql.NullString
+		fxRate               sql.NullString
+		fxRateAsOf           sql.NullTime
+	)
+	if conversion := lineItem.Conversion; conversion != nil {
+		originalAmount = sql.NullInt64{Int64: conversion.OriginalAmount.Number, Valid: true}
+		originalCurrencyCode = sql.NullString{String: string(conversion.OriginalAmount.CurrencyCode), Valid: true}
+		fxRate = sql.NullString{String: conversion.Rate, Valid: true}
+		fxRateAsOf = sql.NullTime{Time: conversion.AsOf, Valid: true}
+	}
+	return []any{
+		string(lineItem.Id.BillId.CustomerId),
+		lineItem.Id.BillId.Id,
+		lineItem.Id.Id,
+		lineItem.Description,
+		lineItem.Amount.Number,
+		lineItem.CreatedAt,
+		originalAmount,
+		originalCurrencyCode,
+		fxRate,
+		fxRateAsOf,
+		string(lineItem.TaxCategory),
+		lineItem.TaxInclusive,
+		lineItem.Quantity,
+		lineItem.UnitPrice,
+		string(lineItem.Rounding),
+	}
+}
+
+// The line items are inserted with one statement, and the bill is locked meanwhile so that its total is only
+// updated once.
+func (m SqlBillDatabase) AddLineItems(ctx context.Context, billId model.BillId, lineItems []model.BillLineItem, _ model.TotalAmount) ([]bool, error) {
+	added := make([]bool, len(lineItems))
+	for _, lineItem := range lineItems {
+		if lineItem.Id.BillId != billId {
+			return nil, ErrBillMismatch
+		}
+	}
+	if len(lineItems) == 0 {
+		return added, nil
+	}
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	var (
+		status       model.BillStatus
+		currencyCode string
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT Status, CurrencyCode
+		FROM Bill
+		WHERE CustomerId = $1 AND Id = $2
+		FOR UPDATE;
+	`, string(billId.CustomerId), billId.Id).Scan(&status, &currencyCode)
+	if err == sql.ErrNoRows {
+		return nil, ErrBillNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if !status.AcceptsLineItems() {
+		return nil, ErrBillClosed
+	}
+	columns := strings.Count(lineItemColumns, ",") + 1
+	values := make([]string, 0, len(lineItems))
+	args := make([]any, 0, columns*len(lineItems))
+	for i, lineItem := range lineItems {
+		if lineItem.Amount.CurrencyCode != model.CurrencyCode(currencyCode) {
+			return nil, ErrCurrencyMismatch
+		}
+		placeholders := make([]string, columns)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", i*columns+j+1)
+		}
+		values = append(values, "("+strings.Join(placeholders, ", ")+")")
+		args = append(args, lineItemValues(lineItem)...)
+	}
+	rows, err := tx.QueryContext(ctx, `
+		INSERT INTO LineItem (`+lineItemColumns+`)
+		VALUES `+strings.Join(values, ", ")+`
+		ON CONFLICT (CustomerId, BillId, Id) DO NOTHING
+		RETURNING Id;
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	inserted := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		inserted[id] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	var addedCount uint64
+	addedTotal := model.NewTotalAmount(model.CurrencyCode(currencyCode))
+	for i, lineItem := range lineItems {
+		// A line item given twice is only added once
+		if inserted[lineItem.Id.Id] {
+			delete(inserted, lineItem.Id.Id)
+			added[i] = true
+			addedCount++
+			if err = addedTotal.Add(lineItem.Amount); err != nil {
+				return nil, ErrCurrencyMismatch
+			}
+		}
+	}
+	if addedCount == 0 {
+		return added, nil
+	}
+	_, err = tx.ExecContext(ctx, `
 		UPDATE Bill
-		SET Status = $3
+		SET
+			LineItemCount = LineItemCount + $3,
+			TotalAmount = TotalAmount + $4::NUMERIC
 		WHERE CustomerId = $1 AND Id = $2;
-	`, string(billId.CustomerId), billId.Id, model.Closed)
+	`, string(billId.CustomerId), billId.Id, addedCount, addedTotal.Number)
+	if err != nil {
+		return nil, err
+	}
+	return added, tx.Commit()
+}
+
+func (m SqlBillDatabase) CloseBill(ctx context.Context, billId model.BillId, closedAt time.Time) (uint64, error) {
 	fmt.Printf("Sql Closing: %v\n", billId)
+	return m.transitionBillStatus(ctx, billId, model.Closed, `
+		UPDATE Bill
+		SET Status = $3, ClosedAt = $4
+		WHERE CustomerId = $1 AND Id = $2;
+	`, closedAt)
+}
+
+func (m SqlBillDatabase) SetCloseTime(ctx context.Context, billId model.BillId, closeTime time.Time) (uint64, error) {
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var status model.BillStatus
+	var current sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		SELECT Status, CloseTime
+		FROM Bill
+		WHERE CustomerId = $1 AND Id = $2
+		FOR UPDATE;
+	`, string(billId.CustomerId), billId.Id).Scan(&status, &current)
+	if err == sql.ErrNoRows {
+		return 0, ErrBillNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	if status != model.Open {
+		return 0, ErrBillClosed
+	} else if current.Valid && current.Time.Equal(closeTime) {
+		return 0, nil
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE Bill
+		SET CloseTime = $3
+		WHERE CustomerId = $1 AND Id = $2;
+	`, string(billId.CustomerId), billId.Id, closeTime)
 	if err != nil {
 		return 0, err
 	}
@@ -129,15 +334,136 @@ func (m SqlBillDatabase) CloseBill(billId model.BillId) (uint64, error) {
 	if err != nil {
 		return 0, err
 	}
-	if rowsAffected == 0 {
+	return uint64(rowsAffected), tx.Commit()
+}
+
+func (m SqlBillDatabase) SetSpendingCap(ctx context.Context, billId model.BillId, spendingCap model.SpendingCap) (uint64, error) {
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var status model.BillStatus
+	err = tx.QueryRowContext(ctx, `
+		SELECT Status
+		FROM Bill
+		WHERE CustomerId = $1 AND Id = $2
+		FOR UPDATE;
+	`, string(billId.CustomerId), billId.Id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return 0, ErrBillNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	if status != model.Open {
+		return 0, ErrBillClosed
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE Bill
+		SET SpendingCap = $3, AlertThresholds = $4
+		WHERE CustomerId = $1 AND Id = $2;
+	`, string(billId.CustomerId), billId.Id, spendingCap.Max.Number, alertThresholdsArray(spendingCap.AlertThresholds))
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(rowsAffected), tx.Commit()
+}
+
+// Postgres has no unsigned integers.
+func alertThresholdsArray(thresholds []uint32) interface{} {
+	values := make([]int64, len(thresholds))
+	for i, threshold := range thresholds {
+		values[i] = int64(threshold)
+	}
+	return pq.Array(values)
+}
+
+func (m SqlBillDatabase) SetBillStatus(ctx context.Context, billId model.BillId, status model.BillStatus) (uint64, error) {
+	fmt.Printf("Sql Setting status: %v %v\n", billId, status)
+	return m.transitionBillStatus(ctx, billId, status, `
+		UPDATE Bill
+		SET Status = $3
+		WHERE CustomerId = $1 AND Id = $2;
+	`)
+}
+
+// The update takes the customer id, the id and the status, then the extra args. It is skipped when the bill already
+// has the status.
+func (m SqlBillDatabase) transitionBillStatus(ctx context.Context, billId model.BillId, status model.BillStatus, update string, args ...any) (uint64, error) {
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var current model.BillStatus
+	err = tx.QueryRowContext(ctx, `
+		SELECT Status
+		FROM Bill
+		WHERE CustomerId = $1 AND Id = $2
+		FOR UPDATE;
+	`, string(billId.CustomerId), billId.Id).Scan(&current)
+	if err == sql.ErrNoRows {
 		return 0, ErrBillNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	if current == status {
+		return 0, nil
+	}
+	if err = current.CheckTransition(status); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, update, append([]any{string(billId.CustomerId), billId.Id, status}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(rowsAffected), tx.Commit()
+}
+
+func (m SqlBillDatabase) CountRunningBills(ctx context.Context, customerId model.CustomerId) (uint64, error) {
+	var count uint64
+	err := m.sql.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM Bill
+		WHERE CustomerId = $1 AND Status IN ($2, $3, $4);
+	`, string(customerId), model.Draft, model.Open, model.Closing).Scan(&count)
+	return count, err
+}
+
+// The bills of the customer whose workflow runs, see model.BillStatus.IsRunning, by creation time.
+func (m SqlBillDatabase) GetRunningBillIds(ctx context.Context, customerId model.CustomerId) ([]model.BillId, error) {
+	rows, err := m.sql.QueryContext(ctx, `
+		SELECT Id
+		FROM Bill
+		WHERE CustomerId = $1 AND Status IN ($2, $3, $4)
+		ORDER BY CreatedAt;
+	`, string(customerId), model.Draft, model.Open, model.Closing)
+	if err != nil {
+		return nil, err
 	}
-	return uint64(rowsAffected), nil
+	defer rows.Close()
+	var billIds []model.BillId
+	for rows.Next() {
+		billId := model.BillId{CustomerId: customerId}
+		if err = rows.Scan(&billId.Id); err != nil {
+			return nil, err
+		}
+		billIds = append(billIds, billId)
+	}
+	return billIds, rows.Err()
 }
 
-func (m SqlBillDatabase) GetBill(billId model.BillId) (BillInfoAndMetadata, error) {
-	rows, err := m.sql.Query(`
-		SELECT CustomerId, Id, Status, LineItemCount, TotalAmount, TotalOk, CurrencyCode
+func (m SqlBillDatabase) GetBill(ctx context.Context, billId model.BillId) (BillInfoAndMetadata, error) {
+	rows, err := m.sql.QueryContext(ctx, `
+		SELECT CustomerId, Id, Status, LineItemCount, TotalAmount, CurrencyCode, CreatedAt, CloseTime, ClosedAt, TaxJurisdiction, SpendingCap, AlertThresholds
 		FROM Bill
 		WHERE CustomerId = $1 AND Id = $2;
 	`, string(billId.CustomerId), billId.Id)
@@ -153,25 +479,191 @@ func (m SqlBillDatabase) GetBill(billId model.BillId) (BillInfoAndMetadata, erro
 		id            string
 		status        model.BillStatus
 		lineItemCount uint64
-		totalAmount   int64
-		totalOk       bool
+		totalAmount   string
 		currencyCode  string
+		createdAt     sql.NullTime
+		closeTime     sql.NullTime
+		closedAt      sql.NullTime
+		jurisdiction  string
+		spendingCap   int64
+		thresholds    []int64
 	)
-	err = rows.Scan(&customerId, &id, &status, &lineItemCount, &totalAmount, &totalOk, &currencyCode)
+	err = rows.Scan(&customerId, &id, &status, &lineItemCount, &totalAmount, &currencyCode, &createdAt, &closeTime, &closedAt, &jurisdiction, &spendingCap, pq.Array(&thresholds))
 	if err != nil {
 		return BillInfoAndMetadata{}, err
 	}
+	var alertThresholds []uint32
+	for _, threshold := range thresholds {
+		alertThresholds = append(alertThresholds, uint32(threshold))
+	}
 	return BillInfoAndMetadata{
 		BillInfo: model.BillInfo{
 			Id: model.BillId{
 				CustomerId: model.CustomerId(customerId),
 				Id:         id,
 			},
-			Status:       status,
-			CurrencyCode: model.CurrencyCode(currencyCode),
+			Status:          status,
+			CurrencyCode:    model.CurrencyCode(currencyCode),
+			CreatedAt:       createdAt.Time,
+			CloseTime:       closeTime.Time,
+			ClosedAt:        closedAt.Time,
+			TaxJurisdiction: model.TaxJurisdiction(jurisdiction),
+			SpendingCap:     model.NewSpendingCap(spendingCap, model.CurrencyCode(currencyCode), alertThresholds),
 		},
 		LineItemCount: lineItemCount,
-		TotalAmount:   model.Amount{Number: totalAmount, CurrencyCode: model.CurrencyCode(currencyCode)},
-		TotalOk:       totalOk,
+		Total:         model.TotalAmount{Number: totalAmount, CurrencyCode: model.CurrencyCode(currencyCode)},
 	}, nil
 }
+
+func (m SqlBillDatabase) GetLineItems(ctx context.Context, billId model.BillId) ([]model.BillLineItem, error) {
+	bill, err := m.GetBill(ctx, billId)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := m.sql.QueryContext(ctx, `
+		SELECT Id, Description, Amount, CreatedAt, OriginalAmount, OriginalCurrencyCode, FxRate, FxRateAsOf, TaxCategory, TaxInclusive, Quantity, UnitPrice, Rounding
+		FROM LineItem
+		WHERE CustomerId = $1 AND BillId = $2
+		ORDER BY CreatedAt, Id;
+	`, string(billId.CustomerId), billId.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lineItems := []model.BillLineItem{}
+	for rows.Next() {
+		var (
+			id                   string
+			description          string
+			amount               int64
+			createdAt            sql.NullTime
+			originalAmount       sql.NullInt64
+			originalCurrencyCode sql.NullString
+			fxRate               sql.NullString
+			fxRateAsOf           sql.NullTime
+			taxCategory          string
+			taxInclusive         bool
+			quantity             string
+			unitPrice            string
+			rounding             string
+		)
+		if err = rows.Scan(&id, &description, &amount, &createdAt, &originalAmount, &originalCurrencyCode, &fxRate, &fxRateAsOf, &taxCategory, &taxInclusive, &quantity, &unitPrice, &rounding); err != nil {
+			return nil, err
+		}
+		lineItem := model.BillLineItem{
+			Id:           model.BillLineItemId{BillId: billId, Id: id},
+			Description:  description,
+			Amount:       model.Amount{Number: amount, CurrencyCode: bill.BillInfo.CurrencyCode},
+			CreatedAt:    createdAt.Time,
+			TaxCategory:  model.TaxCategory(taxCategory),
+			TaxInclusive: taxInclusive,
+			Quantity:     quantity,
+			UnitPrice:    unitPrice,
+			Rounding:     model.RoundingMode(rounding),
+		}
+		if originalAmount.Valid {
+			lineItem.Conversion = &model.FxConversion{
+				OriginalAmount: model.Amount{Number: originalAmount.Int64, CurrencyCode: model.CurrencyCode(originalCurrencyCode.String)},
+				Rate:           fxRate.String,
+				AsOf:           fxRateAsOf.Time,
+			}
+		}
+		lineItems = append(lineItems, lineItem)
+	}
+	return lineItems, rows.Err()
+}
+
+func (m SqlBillDatabase) ListBillsClosedBefore(ctx context.Context, closedBefore time.Time, limit int) ([]model.BillId, error) {
+	rows, err := m.sql.QueryContext(ctx, `
+		SELECT CustomerId, Id
+		FROM Bill
+		WHERE Status IN ($1, $2) AND ClosedAt < $3
+		ORDER BY ClosedAt
+		LIMIT $4;
+	`, model.Closed, model.Paid, closedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	billIds := []model.BillId{}
+	for rows.Next() {
+		var customerId, id string
+		if err = rows.Scan(&customerId, &id); err != nil {
+			return nil, err
+		}
+		billIds = append(billIds, model.BillId{CustomerId: model.CustomerId(customerId), Id: id})
+	}
+	return billIds, rows.Err()
+}
+
+func (m SqlBillDatabase) GetBillsToArchive(ctx context.Context, billIds []model.BillId) ([]ArchivedBill, error) {
+	bills := make([]ArchivedBill, 0, len(billIds))
+	for _, billId := range billIds {
+		bill, err := m.GetBill(ctx, billId)
+		if err == ErrBillNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		lineItems, err := m.GetLineItems(ctx, billId)
+		if err != nil {
+			return nil, err
+		}
+		bills = append(bills, ArchivedBill{Bill: bill, LineItems: lineItems})
+	}
+	return bills, nil
+}
+
+func (m SqlBillDatabase) TombstoneBills(ctx context.Context, billIds []model.BillId, segmentKey string, archivedAt time.Time) (uint64, error) {
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var count uint64
+	for _, billId := range billIds {
+		res, err := tx.ExecContext(ctx, `
+			DELETE FROM Bill
+			WHERE CustomerId = $1 AND Id = $2;
+		`, string(billId.CustomerId), billId.Id)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected == 0 {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM LineItem
+			WHERE CustomerId = $1 AND BillId = $2;
+		`, string(billId.CustomerId), billId.Id)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO BillTombstone (CustomerId, Id, SegmentKey, ArchivedAt)
+			VALUES ($1, $2, $3, $4);
+		`, string(billId.CustomerId), billId.Id, segmentKey, archivedAt)
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, tx.Commit()
+}
+
+func (m SqlBillDatabase) GetTombstone(ctx context.Context, billId model.BillId) (string, error) {
+	var segmentKey string
+	err := m.sql.QueryRowContext(ctx, `
+		SELECT SegmentKey
+		FROM BillTombstone
+		WHERE CustomerId = $1 AND Id = $2;
+	`, string(billId.CustomerId), billId.Id).Scan(&segmentKey)
+	if err == sql.ErrNoRows {
+		return "", ErrTombstoneNotFound
+	}
+	return segmentKey, err
+}
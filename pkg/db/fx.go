@@ -0,0 +1,16 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+	"time"
+)
+
+type FxRateProvider interface {
+	// Returns the latest rate that is not after at.
+	GetRate(ctx context.Context, from model.CurrencyCode, to model.CurrencyCode, at time.Time) (model.FxRate, error)
+}
+
+// ErrFxRateNotFound is returned when there is no rate for the currency pair at the given time.
+var ErrFxRateNotFound = errors.New("fx rate not found")
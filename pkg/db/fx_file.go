@@ -0,0 +1,50 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Loads a CSV rate table with the columns from,to,rate,as_of where as_of is RFC 3339, header included.
+func LoadFileFxRateProvider(path string) (*InMemoryFxRateProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadFxRates(file)
+}
+
+func ReadFxRates(r io.Reader) (*InMemoryFxRateProvider, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	provider := NewInMemoryFxRateProvider()
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		asOf, err := time.Parse(time.RFC3339, record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		rate := model.FxRate{
+			From: model.CurrencyCode(record[0]),
+			To:   model.CurrencyCode(record[1]),
+			Rate: record[2],
+			AsOf: asOf,
+		}
+		if _, err := rate.Convert(model.Amount{Number: 1, CurrencyCode: rate.From}); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		provider.AddRate(rate)
+	}
+	return provider, nil
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type fxPair struct {
+	from model.CurrencyCode
+	to   model.CurrencyCode
+}
+
+type InMemoryFxRateProvider struct {
+	rates map[fxPair][]model.FxRate
+	mu    *sync.RWMutex
+}
+
+var _ FxRateProvider = InMemoryFxRateProvider{}
+
+func NewInMemoryFxRateProvider() *InMemoryFxRateProvider {
+	return &InMemoryFxRateProvider{
+		rates: make(map[fxPair][]model.FxRate),
+		mu:    &sync.RWMutex{},
+	}
+}
+
+func (m InMemoryFxRateProvider) AddRate(rate model.FxRate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pair := fxPair{rate.From, rate.To}
+	rates := append(m.rates[pair], rate)
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].AsOf.Before(rates[j].AsOf)
+	})
+	m.rates[pair] = rates
+}
+
+func (m InMemoryFxRateProvider) GetRate(ctx context.Context, from model.CurrencyCode, to model.CurrencyCode, at time.Time) (model.FxRate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rates := m.rates[fxPair{from, to}]
+	for i := len(rates) - 1; 0 <= i; i-- {
+		if !rates[i].AsOf.After(at) {
+			fmt.Printf("In Memory fx rate: %v\n", rates[i])
+			return rates[i], nil
+		}
+	}
+	return model.FxRate{}, ErrFxRateNotFound
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type SqlFxRateProvider struct {
+	sql *sql.DB
+}
+
+var _ FxRateProvider = SqlFxRateProvider{}
+
+func NewSqlFxRateProvider(sql *sql.DB) *SqlFxRateProvider {
+	return &SqlFxRateProvider{
+		sql: sql,
+	}
+}
+
+func (m SqlFxRateProvider) GetRate(ctx context.Context, from model.CurrencyCode, to model.CurrencyCode, at time.Time) (model.FxRate, error) {
+	rate := model.FxRate{From: from, To: to}
+	err := m.sql.QueryRowContext(ctx, `
+		SELECT Rate, AsOf
+		FROM FxRate
+		WHERE FromCurrencyCode = $1 AND ToCurrencyCode = $2 AND AsOf <= $3
+		ORDER BY AsOf DESC
+		LIMIT 1;
+	`, string(from), string(to), at).Scan(&rate.Rate, &rate.AsOf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.FxRate{}, ErrFxRateNotFound
+	} else if err != nil {
+		return model.FxRate{}, err
+	}
+	fmt.Printf("Sql fx rate: %v\n", rate)
+	return rate, nil
+}
@@ -0,0 +1,18 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+)
+
+// Postings are never updated nor deleted. Corrections are made with new transactions.
+type LedgerDatabase interface {
+	// Returns 0 when a transaction with the same id was already posted.
+	PostTransaction(ctx context.Context, transaction model.LedgerTransaction) (uint64, error)
+	// The balance is the sum of the postings of the account, which may not fit in an Amount.
+	GetBalance(ctx context.Context, account model.LedgerAccount) (model.TotalAmount, error)
+}
+
+// ErrLedgerTransactionEmpty is returned when a ledger transaction has no postings.
+var ErrLedgerTransactionEmpty = errors.New("ledger transaction has no postings")
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+type InMemoryLedgerDatabase struct {
+	transactions map[string]model.LedgerTransaction
+	balances     map[model.LedgerAccount]*big.Int
+	mu           *sync.RWMutex
+}
+
+var _ LedgerDatabase = InMemoryLedgerDatabase{}
+
+func NewInMemoryLedgerDatabase() *InMemoryLedgerDatabase {
+	return &InMemoryLedgerDatabase{
+		transactions: make(map[string]model.LedgerTransaction),
+		balances:     make(map[model.LedgerAccount]*big.Int),
+		mu:           &sync.RWMutex{},
+	}
+}
+
+func (m InMemoryLedgerDatabase) PostTransaction(ctx context.Context, transaction model.LedgerTransaction) (uint64, error) {
+	if len(transaction.Postings) == 0 {
+		return 0, ErrLedgerTransactionEmpty
+	}
+	if e := transaction.CheckBalanced(); e != nil {
+		return 0, e
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.transactions[transaction.Id]; ok {
+		return 0, nil
+	}
+	for _, posting := range transaction.Postings {
+		balance := m.getBalance(posting.Account)
+		m.balances[posting.Account] = balance.Add(balance, big.NewInt(posting.Amount.Number))
+	}
+	m.transactions[transaction.Id] = transaction
+	fmt.Printf("In Memory Posting: %v\n", transaction)
+	return 1, nil
+}
+
+// A copy, which the caller may add to.
+func (m InMemoryLedgerDatabase) getBalance(account model.LedgerAccount) *big.Int {
+	if balance, ok := m.balances[account]; ok {
+		return new(big.Int).Set(balance)
+	}
+	return new(big.Int)
+}
+
+func (m InMemoryLedgerDatabase) GetBalance(ctx context.Context, account model.LedgerAccount) (model.TotalAmount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return model.NewTotalAmountFromBigInt(m.getBalance(account), account.CurrencyCode), nil
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type SqlLedgerDatabase struct {
+	sql *sql.DB
+}
+
+var _ LedgerDatabase = SqlLedgerDatabase{}
+
+func NewSqlLedgerDatabase(sql *sql.DB) *SqlLedgerDatabase {
+	return &SqlLedgerDatabase{
+		sql: sql,
+	}
+}
+
+func (m SqlLedgerDatabase) PostTransaction(ctx context.Context, transaction model.LedgerTransaction) (uint64, error) {
+	if len(transaction.Postings) == 0 {
+		return 0, ErrLedgerTransactionEmpty
+	}
+	if e := transaction.CheckBalanced(); e != nil {
+		return 0, e
+	}
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO LedgerTransaction (Id, CustomerId, BillId, Description)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (Id) DO NOTHING;
+	`, transaction.Id,
+		string(transaction.BillId.CustomerId),
+		transaction.BillId.Id,
+		transaction.Description)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected == 0 {
+		fmt.Printf("Sql ledger transaction already posted: %v\n", transaction.Id)
+		return 0, nil
+	}
+	for i, posting := range transaction.Postings {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO LedgerPosting (TransactionId, Seq, CustomerId, Account, CurrencyCode, Amount)
+			VALUES ($1, $2, $3, $4, $5, $6);
+		`, transaction.Id,
+			i,
+			string(posting.Account.CustomerId),
+			string(posting.Account.Type),
+			string(posting.Account.CurrencyCode),
+			posting.Amount.Number)
+		if err != nil {
+			return 0, err
+		}
+	}
+	fmt.Printf("Sql posting ledger transaction: %v\n", transaction)
+	return uint64(rowsAffected), tx.Commit()
+}
+
+// The sum of BIGINT is a NUMERIC, read as text since it may not fit in an int64.
+func (m SqlLedgerDatabase) GetBalance(ctx context.Context, account model.LedgerAccount) (model.TotalAmount, error) {
+	var balance string
+	err := m.sql.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(Amount), 0)::TEXT
+		FROM LedgerPosting
+		WHERE CustomerId = $1 AND Account = $2 AND CurrencyCode = $3;
+	`, string(account.CustomerId), string(account.Type), string(account.CurrencyCode)).Scan(&balance)
+	if err != nil {
+		return model.TotalAmount{}, err
+	}
+	return model.TotalAmount{Number: balance, CurrencyCode: account.CurrencyCode}, nil
+}
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+)
+
+type OrganizationDatabase interface {
+	GetMembership(ctx context.Context, orgId model.CustomerId, userId model.UserId) (model.Membership, error)
+	// In the order the users joined.
+	ListMemberships(ctx context.Context, orgId model.CustomerId) ([]model.Membership, error)
+	// Adds the user to the organization, or changes its role, unless the organization would be left without an owner.
+	SaveMembership(ctx context.Context, membership model.Membership) error
+	DeleteMembership(ctx context.Context, orgId model.CustomerId, userId model.UserId) error
+}
+
+// ErrMembershipNotFound is returned when the user is not a member of the organization.
+var ErrMembershipNotFound = errors.New("membership not found")
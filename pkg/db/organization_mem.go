@@ -0,0 +1,74 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"slices"
+	"sync"
+)
+
+type InMemoryOrganizationDatabase struct {
+	memberships map[model.CustomerId][]model.Membership
+	mu          *sync.RWMutex
+}
+
+var _ OrganizationDatabase = InMemoryOrganizationDatabase{}
+
+func NewInMemoryOrganizationDatabase(memberships ...model.Membership) *InMemoryOrganizationDatabase {
+	m := &InMemoryOrganizationDatabase{
+		memberships: make(map[model.CustomerId][]model.Membership),
+		mu:          &sync.RWMutex{},
+	}
+	for _, membership := range memberships {
+		m.memberships[membership.OrgId] = append(m.memberships[membership.OrgId], membership)
+	}
+	return m
+}
+
+func (m InMemoryOrganizationDatabase) GetMembership(ctx context.Context, orgId model.CustomerId, userId model.UserId) (model.Membership, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, membership := range m.memberships[orgId] {
+		if membership.UserId == userId {
+			return membership, nil
+		}
+	}
+	return model.Membership{}, ErrMembershipNotFound
+}
+
+func (m InMemoryOrganizationDatabase) ListMemberships(ctx context.Context, orgId model.CustomerId) ([]model.Membership, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]model.Membership{}, m.memberships[orgId]...), nil
+}
+
+func (m InMemoryOrganizationDatabase) SaveMembership(ctx context.Context, membership model.Membership) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	memberships := m.memberships[membership.OrgId]
+	if err := model.CheckKeepsOwner(membership.OrgId, memberships, membership.UserId, membership.Role); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(memberships, func(other model.Membership) bool { return other.UserId == membership.UserId })
+	if i < 0 {
+		m.memberships[membership.OrgId] = append(memberships, membership)
+		return nil
+	}
+	memberships[i].Role = membership.Role
+	return nil
+}
+
+func (m InMemoryOrganizationDatabase) DeleteMembership(ctx context.Context, orgId model.CustomerId, userId model.UserId) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	memberships := m.memberships[orgId]
+	i := slices.IndexFunc(memberships, func(other model.Membership) bool { return other.UserId == userId })
+	if i < 0 {
+		return ErrMembershipNotFound
+	}
+	if err := model.CheckKeepsOwner(orgId, memberships, userId, ""); err != nil {
+		return err
+	}
+	m.memberships[orgId] = slices.Delete(memberships, i, i+1)
+	return nil
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type SqlOrganizationDatabase struct {
+	sql *sql.DB
+}
+
+var _ OrganizationDatabase = SqlOrganizationDatabase{}
+
+func NewSqlOrganizationDatabase(sql *sql.DB) *SqlOrganizationDatabase {
+	return &SqlOrganizationDatabase{
+		sql: sql,
+	}
+}
+
+func (m SqlOrganizationDatabase) GetMembership(ctx context.Context, orgId model.CustomerId, userId model.UserId) (model.Membership, error) {
+	membership := model.Membership{OrgId: orgId, UserId: userId}
+	var role string
+	err := m.sql.QueryRowContext(ctx, `
+		SELECT Role, CreatedAt
+		FROM Membership
+		WHERE OrgId = $1 AND UserId = $2;
+	`, string(orgId), string(userId)).Scan(&role, &membership.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Membership{}, ErrMembershipNotFound
+	} else if err != nil {
+		return model.Membership{}, err
+	}
+	membership.Role = model.Role(role)
+	return membership, nil
+}
+
+func (m SqlOrganizationDatabase) ListMemberships(ctx context.Context, orgId model.CustomerId) ([]model.Membership, error) {
+	return listMemberships(ctx, m.sql, orgId, "")
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// With FOR UPDATE, the memberships are locked so that concurrent changes cannot remove the last owner.
+func listMemberships(ctx context.Context, q querier, orgId model.CustomerId, lock string) ([]model.Membership, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT UserId, Role, CreatedAt
+		FROM Membership
+		WHERE OrgId = $1
+		ORDER BY CreatedAt, UserId
+	`+lock+`;`, string(orgId))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	memberships := []model.Membership{}
+	for rows.Next() {
+		var (
+			membership   model.Membership
+			userId, role string
+		)
+		if err = rows.Scan(&userId, &role, &membership.CreatedAt); err != nil {
+			return nil, err
+		}
+		membership.OrgId = orgId
+		membership.UserId = model.UserId(userId)
+		membership.Role = model.Role(role)
+		memberships = append(memberships, membership)
+	}
+	return memberships, rows.Err()
+}
+
+func (m SqlOrganizationDatabase) SaveMembership(ctx context.Context, membership model.Membership) error {
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	memberships, err := listMemberships(ctx, tx, membership.OrgId, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if err := model.CheckKeepsOwner(membership.OrgId, memberships, membership.UserId, membership.Role); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO Membership (OrgId, UserId, Role, CreatedAt)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (OrgId, UserId) DO UPDATE SET Role = EXCLUDED.Role;
+	`, string(membership.OrgId), string(membership.UserId), string(membership.Role), membership.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m SqlOrganizationDatabase) DeleteMembership(ctx context.Context, orgId model.CustomerId, userId model.UserId) error {
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	memberships, err := listMemberships(ctx, tx, orgId, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if err := model.CheckKeepsOwner(orgId, memberships, userId, ""); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM Membership
+		WHERE OrgId = $1 AND UserId = $2;
+	`, string(orgId), string(userId))
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrMembershipNotFound
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,18 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+)
+
+type PaymentDatabase interface {
+	// Returns 0 when the attempt was already recorded, the first one is kept unless it errored: an errored attempt is
+	// retried with its number.
+	RecordPaymentAttempt(ctx context.Context, attempt model.PaymentAttempt) (uint64, error)
+	// Sorted by number.
+	GetPaymentAttempts(ctx context.Context, billId model.BillId) ([]model.PaymentAttempt, error)
+	// Returns 0 when the notification was already recorded, the first one is kept.
+	RecordDunningNotification(ctx context.Context, notification model.DunningNotification) (uint64, error)
+	// Sorted by step.
+	GetDunningNotifications(ctx context.Context, billId model.BillId) ([]model.DunningNotification, error)
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+type InMemoryPaymentDatabase struct {
+	attempts      map[model.BillId][]model.PaymentAttempt
+	notifications map[model.BillId][]model.DunningNotification
+	mu            *sync.RWMutex
+}
+
+var _ PaymentDatabase = InMemoryPaymentDatabase{}
+
+func NewInMemoryPaymentDatabase() *InMemoryPaymentDatabase {
+	return &InMemoryPaymentDatabase{
+		attempts:      make(map[model.BillId][]model.PaymentAttempt),
+		notifications: make(map[model.BillId][]model.DunningNotification),
+		mu:            &sync.RWMutex{},
+	}
+}
+
+func (m InMemoryPaymentDatabase) RecordPaymentAttempt(ctx context.Context, attempt model.PaymentAttempt) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	attempts := m.attempts[attempt.BillId]
+	i := slices.IndexFunc(attempts, func(recorded model.PaymentAttempt) bool {
+		return recorded.Number == attempt.Number
+	})
+	if 0 <= i && attempts[i].Status != model.PaymentErrored {
+		return 0, nil
+	} else if 0 <= i {
+		attempts[i] = attempt
+	} else {
+		attempts = append(attempts, attempt)
+		sort.Slice(attempts, func(i, j int) bool {
+			return attempts[i].Number < attempts[j].Number
+		})
+	}
+	m.attempts[attempt.BillId] = attempts
+	fmt.Printf("In Memory Recording payment attempt: %v\n", attempt)
+	return 1, nil
+}
+
+func (m InMemoryPaymentDatabase) GetPaymentAttempts(ctx context.Context, billId model.BillId) ([]model.PaymentAttempt, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]model.PaymentAttempt{}, m.attempts[billId]...), nil
+}
+
+func (m InMemoryPaymentDatabase) RecordDunningNotification(ctx context.Context, notification model.DunningNotification) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	notifications := m.notifications[notification.BillId]
+	for _, recorded := range notifications {
+		if recorded.Step == notification.Step {
+			return 0, nil
+		}
+	}
+	notifications = append(notifications, notification)
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].Step < notifications[j].Step
+	})
+	m.notifications[notification.BillId] = notifications
+	fmt.Printf("In Memory Recording dunning notification: %v\n", notification)
+	return 1, nil
+}
+
+func (m InMemoryPaymentDatabase) GetDunningNotifications(ctx context.Context, billId model.BillId) ([]model.DunningNotification, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]model.DunningNotification{}, m.notifications[billId]...), nil
+}
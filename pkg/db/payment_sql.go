@@ -0,0 +1,134 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"database/sql"
+)
+
+type SqlPaymentDatabase struct {
+	sql *sql.DB
+}
+
+var _ PaymentDatabase = SqlPaymentDatabase{}
+
+func NewSqlPaymentDatabase(sql *sql.DB) *SqlPaymentDatabase {
+	return &SqlPaymentDatabase{
+		sql: sql,
+	}
+}
+
+func (m SqlPaymentDatabase) RecordPaymentAttempt(ctx context.Context, attempt model.PaymentAttempt) (uint64, error) {
+	res, err := m.sql.ExecContext(ctx, `
+		INSERT INTO PaymentAttempt (CustomerId, BillId, Number, Amount, CurrencyCode, Status, Reference, FailureReason, AttemptedAt)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (CustomerId, BillId, Number) DO UPDATE
+		SET Amount = EXCLUDED.Amount, CurrencyCode = EXCLUDED.CurrencyCode, Status = EXCLUDED.Status,
+			Reference = EXCLUDED.Reference, FailureReason = EXCLUDED.FailureReason, AttemptedAt = EXCLUDED.AttemptedAt
+		WHERE PaymentAttempt.Status = $10;
+	`, string(attempt.BillId.CustomerId),
+		attempt.BillId.Id,
+		attempt.Number,
+		attempt.Amount.Number,
+		string(attempt.Amount.CurrencyCode),
+		string(attempt.Status),
+		attempt.Reference,
+		attempt.FailureReason,
+		attempt.AttemptedAt,
+		string(model.PaymentErrored))
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(rowsAffected), nil
+}
+
+func (m SqlPaymentDatabase) GetPaymentAttempts(ctx context.Context, billId model.BillId) ([]model.PaymentAttempt, error) {
+	rows, err := m.sql.QueryContext(ctx, `
+		SELECT Number, Amount, CurrencyCode, Status, Reference, FailureReason, AttemptedAt
+		FROM PaymentAttempt
+		WHERE CustomerId = $1 AND BillId = $2
+		ORDER BY Number;
+	`, string(billId.CustomerId), billId.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	attempts := []model.PaymentAttempt{}
+	for rows.Next() {
+		var (
+			attempt      = model.PaymentAttempt{BillId: billId}
+			currencyCode string
+			status       string
+		)
+		err = rows.Scan(
+			&attempt.Number,
+			&attempt.Amount.Number,
+			&currencyCode,
+			&status,
+			&attempt.Reference,
+			&attempt.FailureReason,
+			&attempt.AttemptedAt)
+		if err != nil {
+			return nil, err
+		}
+		attempt.Amount.CurrencyCode = model.CurrencyCode(currencyCode)
+		attempt.Status = model.PaymentStatus(status)
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
+
+func (m SqlPaymentDatabase) RecordDunningNotification(ctx context.Context, notification model.DunningNotification) (uint64, error) {
+	res, err := m.sql.ExecContext(ctx, `
+		INSERT INTO DunningNotification (CustomerId, BillId, Step, Kind, AmountDue, CurrencyCode, SentAt)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (CustomerId, BillId, Step) DO NOTHING;
+	`, string(notification.BillId.CustomerId),
+		notification.BillId.Id,
+		notification.Step,
+		string(notification.Kind),
+		notification.AmountDue.Number,
+		string(notification.AmountDue.CurrencyCode),
+		notification.SentAt)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(rowsAffected), nil
+}
+
+func (m SqlPaymentDatabase) GetDunningNotifications(ctx context.Context, billId model.BillId) ([]model.DunningNotification, error) {
+	rows, err := m.sql.QueryContext(ctx, `
+		SELECT Step, Kind, AmountDue, CurrencyCode, SentAt
+		FROM DunningNotification
+		WHERE CustomerId = $1 AND BillId = $2
+		ORDER BY Step;
+	`, string(billId.CustomerId), billId.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	notifications := []model.DunningNotification{}
+	for rows.Next() {
+		var (
+			notification = model.DunningNotification{BillId: billId}
+			kind         string
+			currencyCode string
+		)
+		err = rows.Scan(&notification.Step, &kind, &notification.AmountDue.Number, &currencyCode, &notification.SentAt)
+		if err != nil {
+			return nil, err
+		}
+		notification.Kind = model.DunningNotificationKind(kind)
+		notification.AmountDue.CurrencyCode = model.CurrencyCode(currencyCode)
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
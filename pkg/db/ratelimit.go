@@ -0,0 +1,13 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"time"
+)
+
+// The token buckets of the rate limits, by key such as a customer and an endpoint.
+type RateLimitDatabase interface {
+	// Refills the bucket of the key and takes a token from it, a bucket not used yet is full.
+	TakeRateLimitToken(ctx context.Context, key string, limit model.RateLimit, now time.Time) (model.RateLimitDecision, error)
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"sync"
+	"time"
+)
+
+// Limits the requests of a single process, the buckets are shared by the processes with SqlRateLimitDatabase.
+type InMemoryRateLimitDatabase struct {
+	buckets map[string]model.RateLimitBucket
+	mu      *sync.Mutex
+}
+
+var _ RateLimitDatabase = InMemoryRateLimitDatabase{}
+
+func NewInMemoryRateLimitDatabase() *InMemoryRateLimitDatabase {
+	return &InMemoryRateLimitDatabase{
+		buckets: make(map[string]model.RateLimitBucket),
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (m InMemoryRateLimitDatabase) TakeRateLimitToken(ctx context.Context, key string, limit model.RateLimit, now time.Time) (model.RateLimitDecision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bucket, ok := m.buckets[key]
+	if !ok {
+		bucket = model.NewRateLimitBucket(limit, now)
+	}
+	bucket, decision := bucket.Take(limit, now)
+	m.buckets[key] = bucket
+	return decision, nil
+}
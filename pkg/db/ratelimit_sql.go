@@ -0,0 +1,58 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type SqlRateLimitDatabase struct {
+	sql *sql.DB
+}
+
+var _ RateLimitDatabase = SqlRateLimitDatabase{}
+
+func NewSqlRateLimitDatabase(sql *sql.DB) *SqlRateLimitDatabase {
+	return &SqlRateLimitDatabase{
+		sql: sql,
+	}
+}
+
+// The bucket is locked while the token is taken, so that concurrent requests take a token each.
+func (m SqlRateLimitDatabase) TakeRateLimitToken(ctx context.Context, key string, limit model.RateLimit, now time.Time) (model.RateLimitDecision, error) {
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return model.RateLimitDecision{}, err
+	}
+	defer tx.Rollback()
+	full := model.NewRateLimitBucket(limit, now)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO RateLimitBucket (Key, Tokens, UpdatedAt)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (Key) DO NOTHING;
+	`, key, full.Tokens, full.UpdatedAt)
+	if err != nil {
+		return model.RateLimitDecision{}, err
+	}
+	var bucket model.RateLimitBucket
+	err = tx.QueryRowContext(ctx, `
+		SELECT Tokens, UpdatedAt
+		FROM RateLimitBucket
+		WHERE Key = $1
+		FOR UPDATE;
+	`, key).Scan(&bucket.Tokens, &bucket.UpdatedAt)
+	if err != nil {
+		return model.RateLimitDecision{}, err
+	}
+	bucket, decision := bucket.Take(limit, now)
+	_, err = tx.ExecContext(ctx, `
+		UPDATE RateLimitBucket
+		SET Tokens = $2, UpdatedAt = $3
+		WHERE Key = $1;
+	`, key, bucket.Tokens, bucket.UpdatedAt)
+	if err != nil {
+		return model.RateLimitDecision{}, err
+	}
+	return decision, tx.Commit()
+}
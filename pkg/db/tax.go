@@ -0,0 +1,18 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+)
+
+type TaxDatabase interface {
+	GetTaxRate(ctx context.Context, jurisdiction model.TaxJurisdiction, category model.TaxCategory) (model.TaxRate, error)
+	// Returns 0 when the tax of the bill was already saved, the first one is kept.
+	SaveBillTax(ctx context.Context, billId model.BillId, tax model.BillTax) (uint64, error)
+	// Returns a BillTax that is not computed when none was saved.
+	GetBillTax(ctx context.Context, billId model.BillId) (model.BillTax, error)
+}
+
+// ErrTaxRateNotFound is returned when the jurisdiction has no rate for the category.
+var ErrTaxRateNotFound = errors.New("tax rate not found")
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"fmt"
+	"sync"
+)
+
+type taxRateKey struct {
+	jurisdiction model.TaxJurisdiction
+	category     model.TaxCategory
+}
+
+type InMemoryTaxDatabase struct {
+	rates     map[taxRateKey]model.TaxRate
+	billTaxes map[model.BillId]model.BillTax
+	mu        *sync.RWMutex
+}
+
+var _ TaxDatabase = InMemoryTaxDatabase{}
+
+func NewInMemoryTaxDatabase() *InMemoryTaxDatabase {
+	return &InMemoryTaxDatabase{
+		rates:     make(map[taxRateKey]model.TaxRate),
+		billTaxes: make(map[model.BillId]model.BillTax),
+		mu:        &sync.RWMutex{},
+	}
+}
+
+func (m InMemoryTaxDatabase) AddTaxRate(rate model.TaxRate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rates[taxRateKey{rate.Jurisdiction, rate.Category}] = rate
+}
+
+func (m InMemoryTaxDatabase) GetTaxRate(ctx context.Context, jurisdiction model.TaxJurisdiction, category model.TaxCategory) (model.TaxRate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rate, ok := m.rates[taxRateKey{jurisdiction, category}]
+	if !ok {
+		return model.TaxRate{}, ErrTaxRateNotFound
+	}
+	return rate, nil
+}
+
+func (m InMemoryTaxDatabase) SaveBillTax(ctx context.Context, billId model.BillId, tax model.BillTax) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.billTaxes[billId]; ok {
+		return 0, nil
+	}
+	m.billTaxes[billId] = tax
+	fmt.Printf("In Memory Saving tax: %v %v\n", billId, tax)
+	return 1, nil
+}
+
+func (m InMemoryTaxDatabase) GetBillTax(ctx context.Context, billId model.BillId) (model.BillTax, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.billTaxes[billId], nil
+}
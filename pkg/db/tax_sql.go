@@ -0,0 +1,131 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type SqlTaxDatabase struct {
+	sql *sql.DB
+}
+
+var _ TaxDatabase = SqlTaxDatabase{}
+
+func NewSqlTaxDatabase(sql *sql.DB) *SqlTaxDatabase {
+	return &SqlTaxDatabase{
+		sql: sql,
+	}
+}
+
+func (m SqlTaxDatabase) GetTaxRate(ctx context.Context, jurisdiction model.TaxJurisdiction, category model.TaxCategory) (model.TaxRate, error) {
+	rate := model.TaxRate{Jurisdiction: jurisdiction, Category: category}
+	err := m.sql.QueryRowContext(ctx, `
+		SELECT Rate
+		FROM TaxRate
+		WHERE Jurisdiction = $1 AND Category = $2;
+	`, string(jurisdiction), string(category)).Scan(&rate.Rate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.TaxRate{}, ErrTaxRateNotFound
+	} else if err != nil {
+		return model.TaxRate{}, err
+	}
+	return rate, nil
+}
+
+func (m SqlTaxDatabase) SaveBillTax(ctx context.Context, billId model.BillId, tax model.BillTax) (uint64, error) {
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO BillTax (CustomerId, BillId, CurrencyCode, Subtotal, TaxTotal, GrandTotal)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (CustomerId, BillId) DO NOTHING;
+	`, string(billId.CustomerId),
+		billId.Id,
+		string(tax.GrandTotal.CurrencyCode),
+		tax.Subtotal.Number,
+		tax.TaxTotal.Number,
+		tax.GrandTotal.Number)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected == 0 {
+		return 0, nil
+	}
+	for _, line := range tax.Lines {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO BillTaxLine (CustomerId, BillId, Category, Inclusive, Rate, Taxable, Tax)
+			VALUES ($1, $2, $3, $4, $5, $6, $7);
+		`, string(billId.CustomerId),
+			billId.Id,
+			string(line.Category),
+			line.Inclusive,
+			line.Rate,
+			line.Taxable.Number,
+			line.Tax.Number)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return uint64(rowsAffected), tx.Commit()
+}
+
+func (m SqlTaxDatabase) GetBillTax(ctx context.Context, billId model.BillId) (model.BillTax, error) {
+	var (
+		currencyCode string
+		subtotal     string
+		taxTotal     string
+		grandTotal   string
+	)
+	err := m.sql.QueryRowContext(ctx, `
+		SELECT CurrencyCode, Subtotal, TaxTotal, GrandTotal
+		FROM BillTax
+		WHERE CustomerId = $1 AND BillId = $2;
+	`, string(billId.CustomerId), billId.Id).Scan(&currencyCode, &subtotal, &taxTotal, &grandTotal)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.BillTax{}, nil
+	} else if err != nil {
+		return model.BillTax{}, err
+	}
+	rows, err := m.sql.QueryContext(ctx, `
+		SELECT Category, Inclusive, Rate, Taxable, Tax
+		FROM BillTaxLine
+		WHERE CustomerId = $1 AND BillId = $2
+		ORDER BY Category, Inclusive;
+	`, string(billId.CustomerId), billId.Id)
+	if err != nil {
+		return model.BillTax{}, err
+	}
+	defer rows.Close()
+	code := model.CurrencyCode(currencyCode)
+	tax := model.BillTax{
+		Lines:      []model.TaxLine{},
+		Subtotal:   model.TotalAmount{Number: subtotal, CurrencyCode: code},
+		TaxTotal:   model.TotalAmount{Number: taxTotal, CurrencyCode: code},
+		GrandTotal: model.TotalAmount{Number: grandTotal, CurrencyCode: code},
+	}
+	for rows.Next() {
+		var (
+			category string
+			line     model.TaxLine
+			taxable  string
+			taxValue string
+		)
+		if err = rows.Scan(&category, &line.Inclusive, &line.Rate, &taxable, &taxValue); err != nil {
+			return model.BillTax{}, err
+		}
+		line.Category = model.TaxCategory(category)
+		line.Taxable = model.TotalAmount{Number: taxable, CurrencyCode: code}
+		line.Tax = model.TotalAmount{Number: taxValue, CurrencyCode: code}
+		tax.Lines = append(tax.Lines, line)
+	}
+	return tax, rows.Err()
+}
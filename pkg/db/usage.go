@@ -0,0 +1,14 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+)
+
+type UsageDatabase interface {
+	// The events must be of the bill, which must be open. Returns the number of events recorded, those already
+	// recorded are ignored.
+	RecordUsageEvents(ctx context.Context, billId model.BillId, events []model.UsageEvent) (uint64, error)
+	// Sorted by meter then unit price.
+	AggregateUsage(ctx context.Context, billId model.BillId) ([]model.UsageAggregate, error)
+}
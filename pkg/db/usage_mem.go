@@ -0,0 +1,66 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"fmt"
+	"sync"
+)
+
+type InMemoryUsageDatabase struct {
+	bills BillDatabase
+	// By customer then event id.
+	ids    map[model.CustomerId]map[string]struct{}
+	events map[model.BillId][]model.UsageEvent
+	mu     *sync.RWMutex
+}
+
+var _ UsageDatabase = InMemoryUsageDatabase{}
+
+// The bills are only read to check that they are open.
+func NewInMemoryUsageDatabase(bills BillDatabase) *InMemoryUsageDatabase {
+	return &InMemoryUsageDatabase{
+		bills:  bills,
+		ids:    make(map[model.CustomerId]map[string]struct{}),
+		events: make(map[model.BillId][]model.UsageEvent),
+		mu:     &sync.RWMutex{},
+	}
+}
+
+func (m InMemoryUsageDatabase) RecordUsageEvents(ctx context.Context, billId model.BillId, events []model.UsageEvent) (uint64, error) {
+	for _, event := range events {
+		if event.BillId != billId {
+			return 0, ErrBillMismatch
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bill, err := m.bills.GetBill(ctx, billId)
+	if err != nil {
+		return 0, err
+	} else if bill.BillInfo.Status != model.Open {
+		return 0, ErrBillClosed
+	}
+	ids, ok := m.ids[billId.CustomerId]
+	if !ok {
+		ids = make(map[string]struct{})
+		m.ids[billId.CustomerId] = ids
+	}
+	var recorded uint64
+	for _, event := range events {
+		if _, ok := ids[event.Id]; ok {
+			continue
+		}
+		ids[event.Id] = struct{}{}
+		m.events[billId] = append(m.events[billId], event)
+		recorded++
+	}
+	fmt.Printf("In Memory Recording usage events: %v %d of %d\n", billId, recorded, len(events))
+	return recorded, nil
+}
+
+func (m InMemoryUsageDatabase) AggregateUsage(ctx context.Context, billId model.BillId) ([]model.UsageAggregate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return model.AggregateUsage(m.events[billId])
+}
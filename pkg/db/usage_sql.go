@@ -0,0 +1,117 @@
+package db
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type SqlUsageDatabase struct {
+	sql *sql.DB
+}
+
+var _ UsageDatabase = SqlUsageDatabase{}
+
+func NewSqlUsageDatabase(sql *sql.DB) *SqlUsageDatabase {
+	return &SqlUsageDatabase{
+		sql: sql,
+	}
+}
+
+// The bill is locked while the events are recorded, so that they are either recorded before it starts closing,
+// and then aggregated, or refused.
+func (m SqlUsageDatabase) RecordUsageEvents(ctx context.Context, billId model.BillId, events []model.UsageEvent) (uint64, error) {
+	for _, event := range events {
+		if event.BillId != billId {
+			return 0, ErrBillMismatch
+		}
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	tx, err := m.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var status model.BillStatus
+	err = tx.QueryRowContext(ctx, `
+		SELECT Status
+		FROM Bill
+		WHERE CustomerId = $1 AND Id = $2
+		FOR SHARE;
+	`, string(billId.CustomerId), billId.Id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return 0, ErrBillNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	if status != model.Open {
+		return 0, ErrBillClosed
+	}
+	const columns = 7
+	values := make([]string, 0, len(events))
+	args := make([]any, 0, columns*len(events))
+	for i, event := range events {
+		placeholders := make([]string, columns)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", i*columns+j+1)
+		}
+		values = append(values, "("+strings.Join(placeholders, ", ")+")")
+		args = append(args,
+			string(billId.CustomerId),
+			event.Id,
+			billId.Id,
+			event.Meter,
+			event.Quantity,
+			event.UnitPrice,
+			event.RecordedAt)
+	}
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO UsageEvent (CustomerId, Id, BillId, Meter, Quantity, UnitPrice, RecordedAt)
+		VALUES `+strings.Join(values, ", ")+`
+		ON CONFLICT (CustomerId, Id) DO NOTHING;
+	`, args...)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(rowsAffected), tx.Commit()
+}
+
+func (m SqlUsageDatabase) AggregateUsage(ctx context.Context, billId model.BillId) ([]model.UsageAggregate, error) {
+	rows, err := m.sql.QueryContext(ctx, `
+		SELECT Meter, SUM(Quantity)::TEXT, UnitPrice::TEXT, COUNT(*)
+		FROM UsageEvent
+		WHERE CustomerId = $1 AND BillId = $2
+		GROUP BY Meter, UnitPrice;
+	`, string(billId.CustomerId), billId.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	aggregates := []model.UsageAggregate{}
+	for rows.Next() {
+		var (
+			meter      string
+			quantity   string
+			unitPrice  string
+			eventCount uint64
+		)
+		if err = rows.Scan(&meter, &quantity, &unitPrice, &eventCount); err != nil {
+			return nil, err
+		}
+		aggregate, err := model.NewUsageAggregate(meter, quantity, unitPrice, eventCount)
+		if err != nil {
+			return nil, err
+		}
+		aggregates = append(aggregates, aggregate)
+	}
+	model.SortUsageAggregates(aggregates)
+	return aggregates, rows.Err()
+}
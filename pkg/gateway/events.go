@@ -0,0 +1,119 @@
+package gateway
+
+import (
+	"coding-challenge/pkg/model"
+	"sync"
+	"time"
+)
+
+type BillEventPublisher interface {
+	// May receive the same event more than once, or after a later event.
+	Publish(event model.BillEvent) error
+}
+
+// The number of latest events of a bill kept for the subscribers that resume.
+const BillEventHistorySize = 64
+
+// The events of a bill are forgotten once it had no subscriber nor event for that long.
+const BillEventRetention = time.Hour
+
+// The events each subscriber has not received yet, beyond which it is unsubscribed.
+const billEventSubscriberBuffer = 16
+
+type billEventTopic struct {
+	history     []model.BillEvent
+	subscribers map[chan model.BillEvent]struct{}
+	activeAt    time.Time
+}
+
+// Publishes the events to the subscribers of this process only.
+type InMemoryBillEventBroker struct {
+	mutex    *sync.Mutex
+	topics   map[model.BillId]*billEventTopic
+	prunedAt time.Time
+	now      func() time.Time
+}
+
+var _ BillEventPublisher = &InMemoryBillEventBroker{}
+
+func NewInMemoryBillEventBroker() *InMemoryBillEventBroker {
+	return &InMemoryBillEventBroker{
+		mutex:  &sync.Mutex{},
+		topics: make(map[model.BillId]*billEventTopic),
+		now:    time.Now,
+	}
+}
+
+func (b *InMemoryBillEventBroker) topic(billId model.BillId) *billEventTopic {
+	topic, ok := b.topics[billId]
+	if !ok {
+		topic = &billEventTopic{subscribers: make(map[chan model.BillEvent]struct{})}
+		b.topics[billId] = topic
+	}
+	topic.activeAt = b.now()
+	return topic
+}
+
+// At most once per minute, since every topic is visited.
+func (b *InMemoryBillEventBroker) prune() {
+	now := b.now()
+	if now.Sub(b.prunedAt) < time.Minute {
+		return
+	}
+	b.prunedAt = now
+	for billId, topic := range b.topics {
+		if len(topic.subscribers) == 0 && BillEventRetention < now.Sub(topic.activeAt) {
+			delete(b.topics, billId)
+		}
+	}
+}
+
+// Events that are not newer than the latest event of the bill are dropped, since each event has the whole state.
+// A subscriber too slow to receive the event is unsubscribed and its channel closed.
+func (b *InMemoryBillEventBroker) Publish(event model.BillEvent) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.prune()
+	topic := b.topic(event.BillId)
+	if n := len(topic.history); n != 0 && event.Sequence <= topic.history[n-1].Sequence {
+		return nil
+	}
+	topic.history = append(topic.history, event)
+	if BillEventHistorySize < len(topic.history) {
+		topic.history = topic.history[len(topic.history)-BillEventHistorySize:]
+	}
+	for subscriber := range topic.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			delete(topic.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+	return nil
+}
+
+// Returns the kept events after the given sequence, then the channel of the next events.
+// The channel is closed once unsubscribed.
+func (b *InMemoryBillEventBroker) Subscribe(billId model.BillId, afterSequence uint64) (missed []model.BillEvent, events <-chan model.BillEvent, unsubscribe func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	topic := b.topic(billId)
+	for _, event := range topic.history {
+		if afterSequence < event.Sequence {
+			missed = append(missed, event)
+		}
+	}
+	subscriber := make(chan model.BillEvent, billEventSubscriberBuffer)
+	topic.subscribers[subscriber] = struct{}{}
+	unsubscribe = func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		if _, ok := topic.subscribers[subscriber]; ok {
+			delete(topic.subscribers, subscriber)
+			close(subscriber)
+		}
+		topic.activeAt = b.now()
+	}
+	return missed, subscriber, unsubscribe
+}
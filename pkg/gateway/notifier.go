@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"coding-challenge/pkg/model"
+	"fmt"
+)
+
+type Notifier interface {
+	// May deliver the same notification more than once.
+	Notify(notification model.DunningNotification) error
+	// May deliver the same alert more than once.
+	NotifySpendingAlert(alert model.SpendingAlert) error
+}
+
+// Prints the notifications instead of delivering them.
+type LogNotifier struct {
+}
+
+var _ Notifier = LogNotifier{}
+
+func (n LogNotifier) Notify(notification model.DunningNotification) error {
+	fmt.Printf("Notifying customer %v of bill %v: %v %v\n", notification.BillId.CustomerId, notification.BillId.Id, notification.Kind, notification.AmountDue)
+	return nil
+}
+
+func (n LogNotifier) NotifySpendingAlert(alert model.SpendingAlert) error {
+	fmt.Printf("Alerting customer %v of bill %v: %d%% of %v reached, total %v\n", alert.BillId.CustomerId, alert.BillId.Id, alert.Threshold, alert.Cap, alert.Total)
+	return nil
+}
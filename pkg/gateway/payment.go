@@ -0,0 +1,27 @@
+package gateway
+
+import (
+	"coding-challenge/pkg/model"
+	"fmt"
+)
+
+type ChargeRequest struct {
+	// The gateway charges at most once per key.
+	IdempotencyKey string
+	CustomerId     model.CustomerId
+	Amount         model.TotalAmount
+}
+
+type PaymentGateway interface {
+	// Returns the gateway reference of the charge. A DeclinedError is final, other errors may be retried with the same
+	// idempotency key.
+	Charge(request ChargeRequest) (reference string, err error)
+}
+
+type DeclinedError struct {
+	Reason string
+}
+
+func (e DeclinedError) Error() string {
+	return fmt.Sprintf("payment declined: %s", e.Reason)
+}
@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type FakeOutcome string
+
+const (
+	FakeSucceed FakeOutcome = "succeed"
+	FakeDecline FakeOutcome = "decline"
+	// As when the gateway cannot be reached.
+	FakeFail FakeOutcome = "fail"
+)
+
+type InvalidFakeOutcomeError struct {
+	Outcome string
+}
+
+func (e InvalidFakeOutcomeError) Error() string {
+	return fmt.Sprintf("invalid fake payment outcome %q", e.Outcome)
+}
+
+// ErrFakeUnavailable is returned for a scripted failure.
+var ErrFakeUnavailable = errors.New("fake payment gateway is unavailable")
+
+// Plays the scripted outcomes in order, one per charge, then succeeds. A key that was charged before gets the same
+// reference back without playing an outcome, as a real gateway would.
+type FakePaymentGateway struct {
+	outcomes []FakeOutcome
+	charged  map[string]string
+	requests []ChargeRequest
+	mu       *sync.Mutex
+}
+
+var _ PaymentGateway = &FakePaymentGateway{}
+
+func NewFakePaymentGateway(outcomes ...FakeOutcome) *FakePaymentGateway {
+	return &FakePaymentGateway{
+		outcomes: outcomes,
+		charged:  make(map[string]string),
+		mu:       &sync.Mutex{},
+	}
+}
+
+// Parses a comma separated list of outcomes, such as "fail,decline,succeed".
+func ParseFakeOutcomes(script string) ([]FakeOutcome, error) {
+	outcomes := []FakeOutcome{}
+	if script == "" {
+		return outcomes, nil
+	}
+	for _, outcome := range strings.Split(script, ",") {
+		switch FakeOutcome(strings.TrimSpace(outcome)) {
+		case FakeSucceed, FakeDecline, FakeFail:
+			outcomes = append(outcomes, FakeOutcome(strings.TrimSpace(outcome)))
+		default:
+			return nil, InvalidFakeOutcomeError{outcome}
+		}
+	}
+	return outcomes, nil
+}
+
+func (g *FakePaymentGateway) Charge(request ChargeRequest) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.requests = append(g.requests, request)
+	if reference, ok := g.charged[request.IdempotencyKey]; ok {
+		return reference, nil
+	}
+	outcome := FakeSucceed
+	if len(g.outcomes) != 0 {
+		outcome, g.outcomes = g.outcomes[0], g.outcomes[1:]
+	}
+	switch outcome {
+	case FakeDecline:
+		return "", DeclinedError{Reason: "scripted decline"}
+	case FakeFail:
+		return "", ErrFakeUnavailable
+	}
+	reference := fmt.Sprintf("fake-charge-%d", len(g.charged)+1)
+	g.charged[request.IdempotencyKey] = reference
+	return reference, nil
+}
+
+// Every charge request received, in order, including the repeated ones.
+func (g *FakePaymentGateway) Requests() []ChargeRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]ChargeRequest{}, g.requests...)
+}
@@ -1,7 +1,11 @@
 package model
 
 import (
+	"cmp"
 	"fmt"
+	"math"
+	"math/big"
+	"slices"
 
 	"github.com/JohnCGriffin/overflow"
 )
@@ -46,3 +50,97 @@ func (a Amount) Add(b Amount) (Amount, bool) {
 	}
 	return Amount{Number: sum, CurrencyCode: a.CurrencyCode}, ok
 }
+
+func (a Amount) Subtract(b Amount) (Amount, bool) {
+	if a.CurrencyCode != b.CurrencyCode {
+		return Amount{}, false
+	}
+	difference, ok := overflow.Sub64(a.Number, b.Number)
+	if !ok {
+		return Amount{}, false
+	}
+	return Amount{Number: difference, CurrencyCode: a.CurrencyCode}, ok
+}
+
+func (a Amount) Negate() (Amount, bool) {
+	if a.Number == math.MinInt64 {
+		return Amount{}, false
+	}
+	return Amount{Number: -a.Number, CurrencyCode: a.CurrencyCode}, true
+}
+
+func (a Amount) Multiply(n int64) (Amount, bool) {
+	product, ok := overflow.Mul64(a.Number, n)
+	if !ok {
+		return Amount{}, false
+	}
+	return Amount{Number: product, CurrencyCode: a.CurrencyCode}, ok
+}
+
+// Returns -1, 0 or 1 like cmp.Compare, and false when the currency codes differ.
+func (a Amount) Compare(b Amount) (int, bool) {
+	if a.CurrencyCode != b.CurrencyCode {
+		return 0, false
+	}
+	return cmp.Compare(a.Number, b.Number), true
+}
+
+// Returns false when the currency codes differ, rather than the amounts being merely unequal.
+func (a Amount) Equal(b Amount) (equal bool, ok bool) {
+	c, ok := a.Compare(b)
+	return ok && c == 0, ok
+}
+
+// Splits the amount in proportion to ratios, so that the parts add up to the amount exactly. The minor units left
+// over by rounding down go one each to the parts with the largest remainders, the first ones on a tie. Returns false
+// without ratios or when they are all zero.
+func (a Amount) Allocate(ratios ...uint64) ([]Amount, bool) {
+	sum := new(big.Int)
+	for _, ratio := range ratios {
+		sum.Add(sum, new(big.Int).SetUint64(ratio))
+	}
+	if sum.Sign() == 0 {
+		return nil, false
+	}
+	// Computed on the absolute value so that rounding down is towards zero
+	number := new(big.Int).Abs(big.NewInt(a.Number))
+	parts := make([]*big.Int, len(ratios))
+	remainders := make([]*big.Int, len(ratios))
+	left := new(big.Int).Set(number)
+	for i, ratio := range ratios {
+		parts[i], remainders[i] = new(big.Int).QuoRem(new(big.Int).Mul(number, new(big.Int).SetUint64(ratio)), sum, new(big.Int))
+		left.Sub(left, parts[i])
+	}
+	order := make([]int, len(ratios))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		return remainders[j].Cmp(remainders[i])
+	})
+	// Fewer minor units are left than there are parts
+	for _, i := range order[:left.Int64()] {
+		parts[i].Add(parts[i], big.NewInt(1))
+	}
+	allocated := make([]Amount, len(parts))
+	for i, part := range parts {
+		if a.Number < 0 {
+			part.Neg(part)
+		}
+		// A part is at most the amount, it fits
+		allocated[i] = Amount{Number: part.Int64(), CurrencyCode: a.CurrencyCode}
+	}
+	return allocated, true
+}
+
+// Splits the amount in n parts that differ by at most one minor unit.
+func (a Amount) Split(n int) ([]Amount, bool) {
+	if n <= 0 {
+		return nil, false
+	}
+	ratios := make([]uint64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return a.Allocate(ratios...)
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmountArithmetic(t *testing.T) {
+	// Arrange
+	hundred := Amount{Number: 100, CurrencyCode: "USD"}
+	thirty := Amount{Number: 30, CurrencyCode: "USD"}
+
+	// Act
+	difference, ok1 := hundred.Subtract(thirty)
+	negated, ok2 := thirty.Negate()
+	product, ok3 := thirty.Multiply(-3)
+	comparison, ok4 := thirty.Compare(hundred)
+	equal, ok5 := hundred.Equal(Amount{Number: 100, CurrencyCode: "USD"})
+
+	// Assert
+	assert.True(t, ok1 && ok2 && ok3 && ok4 && ok5)
+	assert.Equal(t, Amount{Number: 70, CurrencyCode: "USD"}, difference)
+	assert.Equal(t, Amount{Number: -30, CurrencyCode: "USD"}, negated)
+	assert.Equal(t, Amount{Number: -90, CurrencyCode: "USD"}, product)
+	assert.Equal(t, -1, comparison)
+	assert.True(t, equal)
+}
+
+func TestAmountArithmeticFailures(t *testing.T) {
+	// Arrange
+	max := Amount{Number: math.MaxInt64, CurrencyCode: "USD"}
+	min := Amount{Number: math.MinInt64, CurrencyCode: "USD"}
+	gel := Amount{Number: 100, CurrencyCode: "GEL"}
+
+	// Act
+	_, okSubtract := min.Subtract(Amount{Number: 1, CurrencyCode: "USD"})
+	_, okNegate := min.Negate()
+	_, okMultiply := max.Multiply(2)
+	_, okCompare := max.Compare(gel)
+	equal, okEqual := Amount{Number: 100, CurrencyCode: "USD"}.Equal(gel)
+
+	// Assert
+	assert.False(t, okSubtract)
+	assert.False(t, okNegate)
+	assert.False(t, okMultiply)
+	assert.False(t, okCompare)
+	assert.False(t, okEqual)
+	assert.False(t, equal)
+}
+
+func TestAmountAllocate(t *testing.T) {
+	// Arrange
+	amount := Amount{Number: 100, CurrencyCode: "USD"}
+
+	// Act
+	parts, ok := amount.Allocate(1, 1, 1)
+	uneven, okUneven := Amount{Number: 5, CurrencyCode: "USD"}.Allocate(3, 7)
+	withZero, okWithZero := amount.Allocate(0, 1)
+
+	// Assert
+	assert.True(t, ok)
+	assert.Equal(t, []Amount{{34, "USD"}, {33, "USD"}, {33, "USD"}}, parts)
+	assert.True(t, okUneven)
+	// 1.5 and 3.5, the tie goes to the first
+	assert.Equal(t, []Amount{{2, "USD"}, {3, "USD"}}, uneven)
+	assert.True(t, okWithZero)
+	assert.Equal(t, []Amount{{0, "USD"}, {100, "USD"}}, withZero)
+}
+
+func TestAmountAllocateLargestRemainder(t *testing.T) {
+	// Arrange
+	amount := Amount{Number: 1, CurrencyCode: "USD"}
+
+	// Act
+	parts, ok := amount.Allocate(1, 99)
+
+	// Assert
+	assert.True(t, ok)
+	assert.Equal(t, []Amount{{0, "USD"}, {1, "USD"}}, parts)
+}
+
+func TestAmountAllocateNegativeAndExtreme(t *testing.T) {
+	// Arrange
+	min := Amount{Number: math.MinInt64, CurrencyCode: "USD"}
+
+	// Act
+	negative, ok1 := Amount{Number: -100, CurrencyCode: "USD"}.Split(3)
+	whole, ok2 := min.Allocate(math.MaxUint64)
+	halves, ok3 := min.Split(2)
+
+	// Assert
+	assert.True(t, ok1 && ok2 && ok3)
+	assert.Equal(t, []Amount{{-34, "USD"}, {-33, "USD"}, {-33, "USD"}}, negative)
+	assert.Equal(t, []Amount{min}, whole)
+	assert.Equal(t, []Amount{{math.MinInt64 / 2, "USD"}, {math.MinInt64 / 2, "USD"}}, halves)
+}
+
+func TestAmountAllocateFailures(t *testing.T) {
+	// Arrange
+	amount := Amount{Number: 100, CurrencyCode: "USD"}
+
+	// Act
+	_, okNoRatio := amount.Allocate()
+	_, okZeroRatios := amount.Allocate(0, 0)
+	_, okZeroParts := amount.Split(0)
+
+	// Assert
+	assert.False(t, okNoRatio)
+	assert.False(t, okZeroRatios)
+	assert.False(t, okZeroParts)
+}
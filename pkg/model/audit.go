@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+type ActorType string
+
+const (
+	ActorCustomer ActorType = "customer"
+	// A member of the organization of the bill.
+	ActorUser        ActorType = "user"
+	ActorApiKey      ActorType = "api_key"
+	ActorSystemTimer ActorType = "system_timer"
+	// The workflow itself, such as when it sends an alert.
+	ActorSystem ActorType = "system"
+	// A person of the operations team, such as with billctl.
+	ActorOperator ActorType = "operator"
+)
+
+// Id is the customer id, the user id, the API key id or the operator name. It is empty for the system timer.
+type Actor struct {
+	Type ActorType
+	Id   string
+}
+
+func NewCustomerActor(customerId CustomerId) Actor {
+	return Actor{Type: ActorCustomer, Id: string(customerId)}
+}
+
+func NewUserActor(userId UserId) Actor {
+	return Actor{Type: ActorUser, Id: string(userId)}
+}
+
+func NewApiKeyActor(apiKeyId string) Actor {
+	return Actor{Type: ActorApiKey, Id: apiKeyId}
+}
+
+func NewOperatorActor(name string) Actor {
+	return Actor{Type: ActorOperator, Id: name}
+}
+
+func NewSystemTimerActor() Actor {
+	return Actor{Type: ActorSystemTimer}
+}
+
+func NewSystemActor() Actor {
+	return Actor{Type: ActorSystem}
+}
+
+type AuditAction string
+
+const (
+	AuditCreate      AuditAction = "create"
+	AuditAddLineItem AuditAction = "add_line_item"
+	// One entry for the line items added by a batch.
+	AuditAddLineItems AuditAction = "add_line_items"
+	AuditClose        AuditAction = "close"
+	AuditExtend       AuditAction = "extend"
+	// A draft opened to line items.
+	AuditPublish AuditAction = "publish"
+	AuditVoid    AuditAction = "void"
+	// The request id is the coupon code.
+	AuditAttachCoupon   AuditAction = "attach_coupon"
+	AuditSetSpendingCap AuditAction = "set_spending_cap"
+	// The request id is the threshold and the cap, see SpendingAlert.RequestId.
+	AuditSpendingAlert AuditAction = "spending_alert"
+)
+
+// An entry is unique by BillId, Action and RequestId, so recording it again is a no-op.
+type AuditEntry struct {
+	BillId       BillId
+	Action       AuditAction
+	Actor        Actor
+	RequestId    string
+	TotalBefore  TotalAmount
+	TotalAfter   TotalAmount
+	WorkflowTime time.Time
+}
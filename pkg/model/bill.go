@@ -1,27 +1,66 @@
 package model
 
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"time"
+)
+
 type BillId struct {
 	CustomerId CustomerId
 	Id         string
 }
 
-type BillStatus uint8
-
-const (
-	Open BillStatus = iota
-	Closed
-)
-
+// The times come from the workflow clock.
 type BillInfo struct {
 	Id           BillId
 	CurrencyCode CurrencyCode
 	Status       BillStatus
+	CreatedAt    time.Time
+	// When the bill is scheduled to close at maturity.
+	CloseTime time.Time
+	// Zero while the bill is open.
+	ClosedAt time.Time
+	// Empty when the bill is not taxed.
+	TaxJurisdiction TaxJurisdiction
+	// Only enforced by the workflow while the bill is open, it is saved with the bill though.
+	SpendingCap SpendingCap
+	// Of the line items added while the bill is open, zero is no maximum. Not saved either.
+	MaxLineItems uint64
+	// Of the running bills of the customer, checked when the open bill is saved, zero is no maximum. Not saved either.
+	MaxOpenBills uint64
+}
+
+// The count excludes the bill being saved.
+func (b *BillInfo) CheckOpenBillCount(runningBillCount uint64) error {
+	return Quotas{MaxOpenBills: b.MaxOpenBills}.CheckOpenBills(runningBillCount)
+}
+
+// The count includes the line items being added.
+func (b *BillInfo) CheckLineItemCount(lineItemCount uint64) error {
+	if b.MaxLineItems != 0 && b.MaxLineItems < lineItemCount {
+		return LineItemQuotaExceededError{b.MaxLineItems}
+	}
+	return nil
 }
 
 func (b *BillInfo) CheckLineItemCompatible(lineItem BillLineItem) error {
 	return CheckCurrencyCodeCompatible(b.CurrencyCode, lineItem.Amount.CurrencyCode)
 }
 
+// A line item in another valid currency can be converted into the bill currency.
+func (b *BillInfo) CheckLineItemConvertible(lineItem BillLineItem) error {
+	if lineItem.Amount.CurrencyCode == b.CurrencyCode {
+		return nil
+	}
+	if _, ok := GetDigits(lineItem.Amount.CurrencyCode); !ok {
+		return InvalidCurrencyCodeError{lineItem.Amount.CurrencyCode}
+	}
+	return nil
+}
+
 type Bill struct {
 	Info      BillInfo
 	LineItems []BillLineItem
@@ -56,10 +95,23 @@ type BillLineItemId struct {
 	Id     string
 }
 
+// Amount is in the bill currency. Conversion is nil when the line item was not converted.
 type BillLineItem struct {
 	Id          BillLineItemId
 	Description string
 	Amount      Amount
+	CreatedAt   time.Time
+	Conversion  *FxConversion
+	// Empty means TaxCategoryStandard.
+	TaxCategory TaxCategory
+	// Whether Amount already includes the tax.
+	TaxInclusive bool
+	// Decimal strings. When set, Amount is computed from them, see WithComputedAmount. UnitPrice is in
+	// major units of the currency the line item was given in, before any conversion.
+	Quantity  string
+	UnitPrice string
+	// Empty means RoundHalfEven.
+	Rounding RoundingMode
 }
 
 func (b *Bill) AddLineItem(lineItem BillLineItem) error {
@@ -70,13 +122,74 @@ func (b *Bill) AddLineItem(lineItem BillLineItem) error {
 	return nil
 }
 
+// Number is in minor units like Amount.Number, but as a decimal integer string of any size, so that adding to a
+// total never overflows.
 type TotalAmount struct {
-	Total Amount
-	Ok    bool
+	Number       string
+	CurrencyCode CurrencyCode
+}
+
+func NewTotalAmount(currencyCode CurrencyCode) TotalAmount {
+	return TotalAmount{Number: "0", CurrencyCode: currencyCode}
+}
+
+func NewTotalAmountFromAmount(amount Amount) TotalAmount {
+	return TotalAmount{Number: strconv.FormatInt(amount.Number, 10), CurrencyCode: amount.CurrencyCode}
+}
+
+func NewTotalAmountFromBigInt(n *big.Int, currencyCode CurrencyCode) TotalAmount {
+	return TotalAmount{Number: n.String(), CurrencyCode: currencyCode}
+}
+
+// An empty Number is zero.
+func (total TotalAmount) BigInt() *big.Int {
+	n, ok := new(big.Int).SetString(total.Number, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return n
+}
+
+func (total *TotalAmount) Add(amount Amount) error {
+	if e := CheckCurrencyCodeCompatible(total.CurrencyCode, amount.CurrencyCode); e != nil {
+		return e
+	}
+	sum := total.BigInt()
+	sum.Add(sum, big.NewInt(amount.Number))
+	total.Number = sum.String()
+	return nil
+}
+
+// Also accepts the number of an Amount, which the tax, discounts and payments used to be serialized with.
+func (total *TotalAmount) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Number       json.RawMessage
+		CurrencyCode CurrencyCode
+	}
+	if e := json.Unmarshal(data, &fields); e != nil {
+		return e
+	}
+	*total = TotalAmount{CurrencyCode: fields.CurrencyCode}
+	if len(fields.Number) == 0 || bytes.Equal(fields.Number, []byte("null")) {
+		return nil
+	} else if bytes.HasPrefix(fields.Number, []byte(`"`)) {
+		return json.Unmarshal(fields.Number, &total.Number)
+	}
+	n, ok := new(big.Int).SetString(string(fields.Number), 10)
+	if !ok {
+		return InvalidNumberError{string(fields.Number)}
+	}
+	total.Number = n.String()
+	return nil
 }
 
-func (total *TotalAmount) Add(amount Amount) {
-	if total.Ok {
-		total.Total, total.Ok = total.Total.Add(amount)
+// Line items must all be in currencyCode.
+func SumLineItems(currencyCode CurrencyCode, lineItems []BillLineItem) (TotalAmount, error) {
+	total := NewTotalAmount(currencyCode)
+	for _, lineItem := range lineItems {
+		if e := total.Add(lineItem.Amount); e != nil {
+			return TotalAmount{}, e
+		}
 	}
+	return total, nil
 }
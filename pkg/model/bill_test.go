@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -89,3 +91,39 @@ func TestAddLineItemWithDifferentCurrencyCode(t *testing.T) {
 	assert.Len(t, bill.LineItems, 1)
 	assert.EqualValues(t, matchboxItem1, bill.LineItems[0])
 }
+
+func TestTotalAmountAddBeyondInt64(t *testing.T) {
+	// Arrange
+	total := NewTotalAmount("USD")
+
+	// Act
+	e1 := total.Add(Amount{Number: math.MaxInt64, CurrencyCode: "USD"})
+	e2 := total.Add(Amount{Number: math.MaxInt64, CurrencyCode: "USD"})
+	eCurrency := total.Add(Amount{Number: 1, CurrencyCode: "GEL"})
+
+	// Assert
+	assert.NoError(t, e1)
+	assert.NoError(t, e2)
+	assert.Equal(t, TotalAmount{Number: "18446744073709551614", CurrencyCode: "USD"}, total)
+	assert.ErrorIs(t, eCurrency, IncompatibleCurrencyCodesError{"USD", "GEL"})
+}
+
+func TestTotalAmountUnmarshalsAmountNumber(t *testing.T) {
+	// Arrange
+	var fromTotal, fromAmount, fromZero, invalid TotalAmount
+
+	// Act
+	e1 := json.Unmarshal([]byte(`{"Number":"18446744073709551614","CurrencyCode":"USD"}`), &fromTotal)
+	e2 := json.Unmarshal([]byte(`{"Number":-250,"CurrencyCode":"GEL"}`), &fromAmount)
+	e3 := json.Unmarshal([]byte(`{"Number":"","CurrencyCode":""}`), &fromZero)
+	eInvalid := json.Unmarshal([]byte(`{"Number":1.5,"CurrencyCode":"GEL"}`), &invalid)
+
+	// Assert
+	assert.NoError(t, e1)
+	assert.NoError(t, e2)
+	assert.NoError(t, e3)
+	assert.Equal(t, TotalAmount{Number: "18446744073709551614", CurrencyCode: "USD"}, fromTotal)
+	assert.Equal(t, TotalAmount{Number: "-250", CurrencyCode: "GEL"}, fromAmount)
+	assert.Equal(t, TotalAmount{}, fromZero)
+	assert.ErrorIs(t, eInvalid, InvalidNumberError{"1.5"})
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+type InvalidCouponValueError struct {
+	Code  string
+	Value string
+}
+
+func (e InvalidCouponValueError) Error() string {
+	return fmt.Sprintf("coupon %q has invalid value %q", e.Code, e.Value)
+}
+
+type CouponNotValidError struct {
+	Code string
+	At   time.Time
+}
+
+func (e CouponNotValidError) Error() string {
+	return fmt.Sprintf("coupon %q is not valid at %s", e.Code, e.At.Format(time.RFC3339))
+}
+
+type CouponType string
+
+const (
+	PercentageCoupon  CouponType = "percentage"
+	FixedAmountCoupon CouponType = "fixed_amount"
+)
+
+type Coupon struct {
+	Code string
+	Type CouponType
+	// A percentage such as "15" for 15% off, or an amount in major units such as "5.00".
+	Value string
+	// Only for a fixed amount coupon.
+	CurrencyCode CurrencyCode
+	ValidFrom    time.Time
+	// Zero when the coupon does not expire.
+	ValidUntil time.Time
+	// 0 when the coupon can be redeemed without limit.
+	MaxRedemptions uint64
+}
+
+func (c *Coupon) CheckRedeemable(at time.Time, currencyCode CurrencyCode) error {
+	if at.Before(c.ValidFrom) || (!c.ValidUntil.IsZero() && !at.Before(c.ValidUntil)) {
+		return CouponNotValidError{c.Code, at}
+	}
+	if c.Type == FixedAmountCoupon {
+		if e := CheckCurrencyCodeCompatible(currencyCode, c.CurrencyCode); e != nil {
+			return e
+		}
+	}
+	_, e := c.discount(NewTotalAmount(currencyCode))
+	return e
+}
+
+// The discount before it is capped, in minor units.
+func (c *Coupon) discount(amountDue TotalAmount) (*big.Int, error) {
+	value, ok := parseRate(c.Value)
+	if !ok || value.Sign() < 0 {
+		return nil, InvalidCouponValueError{c.Code, c.Value}
+	}
+	switch c.Type {
+	case PercentageCoupon:
+		if value.Cmp(big.NewRat(100, 1)) > 0 {
+			return nil, InvalidCouponValueError{c.Code, c.Value}
+		}
+		value.Mul(value, new(big.Rat).SetInt(amountDue.BigInt()))
+		value.Quo(value, big.NewRat(100, 1))
+		return roundHalfEven(value), nil
+	case FixedAmountCoupon:
+		digits, ok := GetDigits(c.CurrencyCode)
+		if !ok {
+			return nil, InvalidCurrencyCodeError{c.CurrencyCode}
+		}
+		value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)))
+		// A fixed amount cannot have more digits than the currency
+		if !value.IsInt() {
+			return nil, InvalidCouponValueError{c.Code, c.Value}
+		}
+		return new(big.Int).Set(value.Num()), nil
+	default:
+		return nil, InvalidCouponValueError{c.Code, c.Value}
+	}
+}
+
+// Amount is positive and is subtracted from what is due.
+type DiscountLine struct {
+	CouponCode string
+	Amount     TotalAmount
+}
+
+// Percentages apply to the amount due before any discount. Coupons are applied in order and each
+// one is capped so that the amount due never goes below zero.
+func ComputeDiscounts(amountDue TotalAmount, coupons []Coupon) ([]DiscountLine, error) {
+	remaining := amountDue.BigInt()
+	if remaining.Sign() < 0 {
+		remaining.SetInt64(0)
+	}
+	lines := make([]DiscountLine, 0, len(coupons))
+	for _, coupon := range coupons {
+		discount, e := coupon.discount(amountDue)
+		if e != nil {
+			return nil, e
+		}
+		if discount.Sign() < 0 {
+			discount.SetInt64(0)
+		} else if discount.Cmp(remaining) > 0 {
+			discount.Set(remaining)
+		}
+		remaining.Sub(remaining, discount)
+		lines = append(lines, DiscountLine{
+			CouponCode: coupon.Code,
+			Amount:     NewTotalAmountFromBigInt(discount, amountDue.CurrencyCode),
+		})
+	}
+	return lines, nil
+}
+
+func SumDiscounts(currencyCode CurrencyCode, lines []DiscountLine) TotalAmount {
+	sum := new(big.Int)
+	for _, line := range lines {
+		sum.Add(sum, line.Amount.BigInt())
+	}
+	return NewTotalAmountFromBigInt(sum, currencyCode)
+}
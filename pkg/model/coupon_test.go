@@ -0,0 +1,78 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeDiscountsRoundsAndNeverGoesBelowZero(t *testing.T) {
+	// Arrange
+	amountDue := TotalAmount{Number: "1005", CurrencyCode: "USD"}
+	coupons := []Coupon{
+		{Code: "QUARTER", Type: PercentageCoupon, Value: "25"},
+		{Code: "FIVE", Type: FixedAmountCoupon, Value: "5", CurrencyCode: "USD"},
+		{Code: "TEN", Type: FixedAmountCoupon, Value: "10.00", CurrencyCode: "USD"},
+	}
+
+	// Act
+	lines, e := ComputeDiscounts(amountDue, coupons)
+
+	// Assert
+	assert.NoError(t, e)
+	assert.Equal(t, []DiscountLine{
+		{CouponCode: "QUARTER", Amount: TotalAmount{Number: "251", CurrencyCode: "USD"}}, // 251.25 rounded
+		{CouponCode: "FIVE", Amount: TotalAmount{Number: "500", CurrencyCode: "USD"}},
+		{CouponCode: "TEN", Amount: TotalAmount{Number: "254", CurrencyCode: "USD"}}, // What is left
+	}, lines)
+	assert.Equal(t, amountDue, SumDiscounts("USD", lines))
+}
+
+func TestComputeDiscountsBeyondInt64(t *testing.T) {
+	// Arrange
+	amountDue := TotalAmount{Number: "20000000000000000000", CurrencyCode: "USD"}
+	coupons := []Coupon{{Code: "HALF", Type: PercentageCoupon, Value: "50"}}
+
+	// Act
+	lines, e := ComputeDiscounts(amountDue, coupons)
+
+	// Assert
+	assert.NoError(t, e)
+	assert.Equal(t, []DiscountLine{{CouponCode: "HALF", Amount: TotalAmount{Number: "10000000000000000000", CurrencyCode: "USD"}}}, lines)
+	assert.Equal(t, TotalAmount{Number: "10000000000000000000", CurrencyCode: "USD"}, AmountToCollect(amountDue, lines))
+}
+
+func TestComputeDiscountsRejectsInvalidValues(t *testing.T) {
+	// Arrange
+	amountDue := TotalAmount{Number: "1000", CurrencyCode: "USD"}
+	tooPrecise := Coupon{Code: "CENT", Type: FixedAmountCoupon, Value: "0.005", CurrencyCode: "USD"}
+	tooMuch := Coupon{Code: "ALL", Type: PercentageCoupon, Value: "101"}
+
+	// Act
+	_, e1 := ComputeDiscounts(amountDue, []Coupon{tooPrecise})
+	_, e2 := ComputeDiscounts(amountDue, []Coupon{tooMuch})
+
+	// Assert
+	assert.ErrorIs(t, e1, InvalidCouponValueError{"CENT", "0.005"})
+	assert.ErrorIs(t, e2, InvalidCouponValueError{"ALL", "101"})
+}
+
+func TestCouponCheckRedeemable(t *testing.T) {
+	// Arrange
+	validFrom := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	validUntil := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	coupon := Coupon{Code: "FIVE", Type: FixedAmountCoupon, Value: "5", CurrencyCode: "USD", ValidFrom: validFrom, ValidUntil: validUntil}
+
+	// Act
+	eValid := coupon.CheckRedeemable(validFrom, "USD")
+	eBefore := coupon.CheckRedeemable(validFrom.Add(-time.Second), "USD")
+	eAfter := coupon.CheckRedeemable(validUntil, "USD")
+	eCurrency := coupon.CheckRedeemable(validFrom, "GEL")
+
+	// Assert
+	assert.NoError(t, eValid)
+	assert.ErrorIs(t, eBefore, CouponNotValidError{"FIVE", validFrom.Add(-time.Second)})
+	assert.ErrorIs(t, eAfter, CouponNotValidError{"FIVE", validUntil})
+	assert.ErrorIs(t, eCurrency, IncompatibleCurrencyCodesError{"GEL", "USD"})
+}
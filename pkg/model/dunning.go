@@ -0,0 +1,81 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CustomerTier string
+
+const TierStandard CustomerTier = "standard"
+
+type InvalidDunningScheduleError struct {
+	Schedule string
+}
+
+func (e InvalidDunningScheduleError) Error() string {
+	return fmt.Sprintf("invalid dunning schedule %q", e.Schedule)
+}
+
+// At each step the payment is retried, and the customer is reminded when it fails again. The bill is uncollectible
+// once the last step failed.
+type DunningSchedule struct {
+	Tier CustomerTier
+	// Since the payment failed, in increasing order.
+	Steps []time.Duration
+}
+
+func DefaultDunningSchedules() map[CustomerTier]DunningSchedule {
+	return map[CustomerTier]DunningSchedule{
+		TierStandard: {Tier: TierStandard, Steps: []time.Duration{24 * time.Hour, 3 * 24 * time.Hour, 7 * 24 * time.Hour, 14 * 24 * time.Hour}},
+	}
+}
+
+// Parses schedules such as "standard=1,3,7,14;premium=3,7,14,30", in days since the payment failed, on top of the
+// default ones.
+func ParseDunningSchedules(text string) (map[CustomerTier]DunningSchedule, error) {
+	schedules := DefaultDunningSchedules()
+	if text == "" {
+		return schedules, nil
+	}
+	for _, tierText := range strings.Split(text, ";") {
+		tier, daysText, ok := strings.Cut(tierText, "=")
+		if !ok || tier == "" || daysText == "" {
+			return nil, InvalidDunningScheduleError{tierText}
+		}
+		schedule := DunningSchedule{Tier: CustomerTier(tier)}
+		for _, dayText := range strings.Split(daysText, ",") {
+			days, err := strconv.Atoi(strings.TrimSpace(dayText))
+			if err != nil || days <= 0 {
+				return nil, InvalidDunningScheduleError{tierText}
+			}
+			step := time.Duration(days) * 24 * time.Hour
+			if len(schedule.Steps) != 0 && step <= schedule.Steps[len(schedule.Steps)-1] {
+				return nil, InvalidDunningScheduleError{tierText}
+			}
+			schedule.Steps = append(schedule.Steps, step)
+		}
+		schedules[schedule.Tier] = schedule
+	}
+	return schedules, nil
+}
+
+type DunningNotificationKind string
+
+const (
+	DunningReminder DunningNotificationKind = "reminder"
+	// The bill is uncollectible.
+	DunningEscalation DunningNotificationKind = "escalation"
+)
+
+// A notification is unique by BillId and Step, so recording it again is a no-op.
+type DunningNotification struct {
+	BillId BillId
+	// From 1, the escalation comes after the last step.
+	Step      uint32
+	Kind      DunningNotificationKind
+	AmountDue TotalAmount
+	SentAt    time.Time
+}
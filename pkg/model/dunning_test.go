@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDunningSchedules(t *testing.T) {
+	// Arrange
+	day := 24 * time.Hour
+
+	// Act
+	schedules, err := ParseDunningSchedules("premium=3,7,14,30;standard=1,2")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, map[CustomerTier]DunningSchedule{
+		TierStandard: {Tier: TierStandard, Steps: []time.Duration{day, 2 * day}},
+		"premium":    {Tier: "premium", Steps: []time.Duration{3 * day, 7 * day, 14 * day, 30 * day}},
+	}, schedules)
+}
+
+func TestParseDunningSchedulesDefaults(t *testing.T) {
+	// Act
+	schedules, err := ParseDunningSchedules("")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultDunningSchedules(), schedules)
+	assert.Equal(t, []time.Duration{24 * time.Hour, 72 * time.Hour, 168 * time.Hour, 336 * time.Hour}, schedules[TierStandard].Steps)
+}
+
+func TestParseDunningSchedulesRejectsInvalid(t *testing.T) {
+	for _, text := range []string{"standard", "=1", "standard=", "standard=1,x", "standard=0", "standard=3,3", "standard=7,3"} {
+		// Act
+		_, err := ParseDunningSchedules(text)
+
+		// Assert
+		assert.ErrorAs(t, err, &InvalidDunningScheduleError{}, text)
+	}
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+type BillEventKind string
+
+const (
+	BillLineItemAdded BillEventKind = "line_item_added"
+	BillStatusChanged BillEventKind = "status_changed"
+	BillClosed        BillEventKind = "closed"
+)
+
+// A change of the state of a bill, sent to the live subscribers of the bill.
+type BillEvent struct {
+	BillId BillId
+	// Increases by one with each event of the bill, from 1.
+	Sequence uint64
+	Kind     BillEventKind
+	// Empty unless a line item was added.
+	LineItemId    string
+	Status        BillStatus
+	LineItemCount uint64
+	Total         TotalAmount
+	At            time.Time
+}
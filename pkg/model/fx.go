@@ -0,0 +1,89 @@
+package model
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+type InvalidFxRateError struct {
+	Rate string
+}
+
+func (e InvalidFxRateError) Error() string {
+	return fmt.Sprintf("invalid fx rate %q", e.Rate)
+}
+
+type FxConversionOverflowError struct {
+	Amount Amount
+	Rate   string
+}
+
+func (e FxConversionOverflowError) Error() string {
+	return fmt.Sprintf("converting %v at rate %q overflows", e.Amount, e.Rate)
+}
+
+// Rate is a decimal string, so that it is stored and replayed exactly. 1 From is worth Rate To.
+type FxRate struct {
+	From CurrencyCode
+	To   CurrencyCode
+	Rate string
+	AsOf time.Time
+}
+
+func (r FxRate) parse() (*big.Rat, error) {
+	rate, ok := parseRate(r.Rate)
+	if !ok || rate.Sign() <= 0 {
+		return nil, InvalidFxRateError{r.Rate}
+	}
+	return rate, nil
+}
+
+func parseRate(rate string) (*big.Rat, bool) {
+	return new(big.Rat).SetString(rate)
+}
+
+// The result is rounded half to even, in the minor units of To.
+func (r FxRate) Convert(amount Amount) (Amount, error) {
+	if e := CheckCurrencyCodeCompatible(r.From, amount.CurrencyCode); e != nil {
+		return Amount{}, e
+	}
+	fromDigits, ok := GetDigits(r.From)
+	if !ok {
+		return Amount{}, InvalidCurrencyCodeError{r.From}
+	}
+	toDigits, ok := GetDigits(r.To)
+	if !ok {
+		return Amount{}, InvalidCurrencyCodeError{r.To}
+	}
+	rate, e := r.parse()
+	if e != nil {
+		return Amount{}, e
+	}
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(amount.Number), rate)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(absDiff(toDigits, fromDigits))), nil))
+	if fromDigits < toDigits {
+		converted.Mul(converted, scale)
+	} else {
+		converted.Quo(converted, scale)
+	}
+	rounded := roundHalfEven(converted)
+	if !rounded.IsInt64() {
+		return Amount{}, FxConversionOverflowError{amount, r.Rate}
+	}
+	return Amount{Number: rounded.Int64(), CurrencyCode: r.To}, nil
+}
+
+func absDiff(a uint8, b uint8) uint8 {
+	if a < b {
+		return b - a
+	}
+	return a - b
+}
+
+// Kept on a line item that was converted into the bill currency.
+type FxConversion struct {
+	OriginalAmount Amount
+	Rate           string
+	AsOf           time.Time
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFxConvertSameDigits(t *testing.T) {
+	// Arrange
+	rate := FxRate{From: "GEL", To: "USD", Rate: "0.3712"}
+
+	// Act
+	converted, e := rate.Convert(Amount{Number: 1000, CurrencyCode: "GEL"})
+
+	// Assert
+	assert.NoError(t, e)
+	assert.Equal(t, Amount{Number: 371, CurrencyCode: "USD"}, converted)
+}
+
+func TestFxConvertRoundsHalfToEven(t *testing.T) {
+	// Arrange
+	rate := FxRate{From: "GEL", To: "USD", Rate: "0.5"}
+
+	// Act
+	convertedOdd, e1 := rate.Convert(Amount{Number: 5, CurrencyCode: "GEL"})
+	convertedEven, e2 := rate.Convert(Amount{Number: 7, CurrencyCode: "GEL"})
+	convertedNegative, e3 := rate.Convert(Amount{Number: -5, CurrencyCode: "GEL"})
+
+	// Assert
+	assert.NoError(t, e1)
+	assert.NoError(t, e2)
+	assert.NoError(t, e3)
+	assert.Equal(t, int64(2), convertedOdd.Number)
+	assert.Equal(t, int64(4), convertedEven.Number)
+	assert.Equal(t, int64(-2), convertedNegative.Number)
+}
+
+func TestFxConvertErrors(t *testing.T) {
+	// Arrange
+	rate := FxRate{From: "GEL", To: "USD", Rate: "1e30"}
+
+	// Act
+	_, eCurrency := rate.Convert(Amount{Number: 1, CurrencyCode: "EUR"})
+	_, eOverflow := rate.Convert(Amount{Number: 1, CurrencyCode: "GEL"})
+	_, eRate := FxRate{From: "GEL", To: "USD", Rate: "-1"}.Convert(Amount{Number: 1, CurrencyCode: "GEL"})
+
+	// Assert
+	assert.ErrorIs(t, eCurrency, IncompatibleCurrencyCodesError{"GEL", "EUR"})
+	assert.ErrorIs(t, eOverflow, FxConversionOverflowError{Amount{Number: 1, CurrencyCode: "GEL"}, "1e30"})
+	assert.ErrorIs(t, eRate, InvalidFxRateError{"-1"})
+}
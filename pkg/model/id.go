@@ -13,3 +13,8 @@ var _ BillIdGenerator = &UuidBillIdGenerator{}
 func (*UuidBillIdGenerator) New() string {
 	return uuid.New().String()
 }
+
+// The same key of the same customer gives the same id, so that a request sent again finds what the first one created.
+func NewIdempotentId(customerId CustomerId, key string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(string(customerId)+"/"+key)).String()
+}
@@ -0,0 +1,225 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+type UnbalancedLedgerTransactionError struct {
+	TransactionId string
+	CurrencyCode  CurrencyCode
+	// A decimal integer string, like TotalAmount.Number.
+	Imbalance string
+}
+
+func (e UnbalancedLedgerTransactionError) Error() string {
+	return fmt.Sprintf("ledger transaction %q is unbalanced by %s in %q", e.TransactionId, e.Imbalance, e.CurrencyCode)
+}
+
+type LedgerAccountType string
+
+const (
+	// What the customer owes on bills that are still open.
+	AccruedReceivable LedgerAccountType = "accrued_receivable"
+	// What the customer owes on closed bills.
+	Receivable LedgerAccountType = "receivable"
+	Revenue    LedgerAccountType = "revenue"
+	// What is owed to the tax authorities.
+	TaxPayable LedgerAccountType = "tax_payable"
+	// What was collected from the customer.
+	Cash LedgerAccountType = "cash"
+)
+
+type LedgerAccount struct {
+	CustomerId   CustomerId
+	Type         LedgerAccountType
+	CurrencyCode CurrencyCode
+}
+
+// A positive Amount.Number is a debit, a negative one is a credit.
+type LedgerPosting struct {
+	Account LedgerAccount
+	Amount  Amount
+}
+
+type LedgerTransaction struct {
+	// Deterministic so that posting the same transaction twice is a no-op.
+	Id          string
+	BillId      BillId
+	Description string
+	Postings    []LedgerPosting
+}
+
+func (t *LedgerTransaction) CheckBalanced() error {
+	sums := make(map[CurrencyCode]*big.Int)
+	for _, posting := range t.Postings {
+		if posting.Account.CurrencyCode != posting.Amount.CurrencyCode {
+			return IncompatibleCurrencyCodesError{posting.Account.CurrencyCode, posting.Amount.CurrencyCode}
+		}
+		sum, ok := sums[posting.Amount.CurrencyCode]
+		if !ok {
+			sum = new(big.Int)
+			sums[posting.Amount.CurrencyCode] = sum
+		}
+		sum.Add(sum, big.NewInt(posting.Amount.Number))
+	}
+	for currencyCode, sum := range sums {
+		if sum.Sign() != 0 {
+			return UnbalancedLedgerTransactionError{t.Id, currencyCode, sum.String()}
+		}
+	}
+	return nil
+}
+
+func newTransferPostings(debit LedgerAccount, credit LedgerAccount, amount Amount) []LedgerPosting {
+	return []LedgerPosting{
+		{Account: debit, Amount: amount},
+		{Account: credit, Amount: Amount{Number: -amount.Number, CurrencyCode: amount.CurrencyCode}},
+	}
+}
+
+// A total that does not fit in an Amount is transferred in several chunks.
+func newTotalTransferPostings(debit LedgerAccount, credit LedgerAccount, total TotalAmount) []LedgerPosting {
+	remaining := total.BigInt()
+	chunk := big.NewInt(math.MaxInt64)
+	if remaining.Sign() < 0 {
+		chunk.Neg(chunk)
+	}
+	postings := []LedgerPosting{}
+	for remaining.CmpAbs(chunk) > 0 {
+		postings = append(postings, newTransferPostings(debit, credit, Amount{Number: chunk.Int64(), CurrencyCode: total.CurrencyCode})...)
+		remaining.Sub(remaining, chunk)
+	}
+	return append(postings, newTransferPostings(debit, credit, Amount{Number: remaining.Int64(), CurrencyCode: total.CurrencyCode})...)
+}
+
+func LineItemLedgerTransactionId(lineItemId BillLineItemId) string {
+	return fmt.Sprintf("line-item-%s-%s-%s", lineItemId.BillId.CustomerId, lineItemId.BillId.Id, lineItemId.Id)
+}
+
+// The customer owes the line item amount, to be confirmed when the bill closes.
+func NewLineItemLedgerTransaction(lineItem BillLineItem) LedgerTransaction {
+	customerId, currencyCode := lineItem.Id.BillId.CustomerId, lineItem.Amount.CurrencyCode
+	return LedgerTransaction{
+		Id:          LineItemLedgerTransactionId(lineItem.Id),
+		BillId:      lineItem.Id.BillId,
+		Description: lineItem.Description,
+		Postings: newTransferPostings(
+			LedgerAccount{CustomerId: customerId, Type: AccruedReceivable, CurrencyCode: currencyCode},
+			LedgerAccount{CustomerId: customerId, Type: Revenue, CurrencyCode: currencyCode},
+			lineItem.Amount),
+	}
+}
+
+func CloseBillLedgerTransactionId(billId BillId) string {
+	return fmt.Sprintf("close-bill-%s-%s", billId.CustomerId, billId.Id)
+}
+
+// Moves the bill total from the accrued receivable to the receivable of the customer.
+func NewCloseBillLedgerTransaction(billId BillId, total TotalAmount) LedgerTransaction {
+	customerId, currencyCode := billId.CustomerId, total.CurrencyCode
+	return LedgerTransaction{
+		Id:          CloseBillLedgerTransactionId(billId),
+		BillId:      billId,
+		Description: "Bill closed",
+		Postings: newTotalTransferPostings(
+			LedgerAccount{CustomerId: customerId, Type: Receivable, CurrencyCode: currencyCode},
+			LedgerAccount{CustomerId: customerId, Type: AccruedReceivable, CurrencyCode: currencyCode},
+			total),
+	}
+}
+
+func VoidBillLedgerTransactionId(billId BillId) string {
+	return fmt.Sprintf("void-bill-%s-%s", billId.CustomerId, billId.Id)
+}
+
+// Reverses the line items of the bill, which the customer no longer owes.
+func NewVoidBillLedgerTransaction(billId BillId, total TotalAmount) LedgerTransaction {
+	customerId, currencyCode := billId.CustomerId, total.CurrencyCode
+	return LedgerTransaction{
+		Id:          VoidBillLedgerTransactionId(billId),
+		BillId:      billId,
+		Description: "Bill voided",
+		Postings: newTotalTransferPostings(
+			LedgerAccount{CustomerId: customerId, Type: Revenue, CurrencyCode: currencyCode},
+			LedgerAccount{CustomerId: customerId, Type: AccruedReceivable, CurrencyCode: currencyCode},
+			total),
+	}
+}
+
+func BillTaxLedgerTransactionId(billId BillId) string {
+	return fmt.Sprintf("tax-bill-%s-%s", billId.CustomerId, billId.Id)
+}
+
+// Exclusive tax is added to what the customer owes, inclusive tax is taken out of the revenue.
+// Has no postings when there is no tax.
+func NewBillTaxLedgerTransaction(billId BillId, tax BillTax) LedgerTransaction {
+	customerId := billId.CustomerId
+	postings := []LedgerPosting{}
+	for _, line := range tax.Lines {
+		if line.Tax.BigInt().Sign() == 0 {
+			continue
+		}
+		currencyCode := line.Tax.CurrencyCode
+		debit := LedgerAccount{CustomerId: customerId, Type: Receivable, CurrencyCode: currencyCode}
+		if line.Inclusive {
+			debit.Type = Revenue
+		}
+		postings = append(postings, newTotalTransferPostings(
+			debit,
+			LedgerAccount{CustomerId: customerId, Type: TaxPayable, CurrencyCode: currencyCode},
+			line.Tax)...)
+	}
+	return LedgerTransaction{
+		Id:          BillTaxLedgerTransactionId(billId),
+		BillId:      billId,
+		Description: "Bill tax",
+		Postings:    postings,
+	}
+}
+
+func BillDiscountLedgerTransactionId(billId BillId) string {
+	return fmt.Sprintf("discount-bill-%s-%s", billId.CustomerId, billId.Id)
+}
+
+// Discounts reduce both the revenue and what the customer owes.
+// Has no postings when there is no discount.
+func NewBillDiscountLedgerTransaction(billId BillId, discounts []DiscountLine) LedgerTransaction {
+	customerId := billId.CustomerId
+	postings := []LedgerPosting{}
+	for _, discount := range discounts {
+		if discount.Amount.BigInt().Sign() == 0 {
+			continue
+		}
+		currencyCode := discount.Amount.CurrencyCode
+		postings = append(postings, newTotalTransferPostings(
+			LedgerAccount{CustomerId: customerId, Type: Revenue, CurrencyCode: currencyCode},
+			LedgerAccount{CustomerId: customerId, Type: Receivable, CurrencyCode: currencyCode},
+			discount.Amount)...)
+	}
+	return LedgerTransaction{
+		Id:          BillDiscountLedgerTransactionId(billId),
+		BillId:      billId,
+		Description: "Bill discount",
+		Postings:    postings,
+	}
+}
+
+func PaymentLedgerTransactionId(attempt PaymentAttempt) string {
+	return fmt.Sprintf("payment-bill-%s-%s-%d", attempt.BillId.CustomerId, attempt.BillId.Id, attempt.Number)
+}
+
+// A successful payment settles what the customer owes.
+func NewPaymentLedgerTransaction(attempt PaymentAttempt) LedgerTransaction {
+	customerId, currencyCode := attempt.BillId.CustomerId, attempt.Amount.CurrencyCode
+	return LedgerTransaction{
+		Id:          PaymentLedgerTransactionId(attempt),
+		BillId:      attempt.BillId,
+		Description: "Bill paid",
+		Postings: newTotalTransferPostings(
+			LedgerAccount{CustomerId: customerId, Type: Cash, CurrencyCode: currencyCode},
+			LedgerAccount{CustomerId: customerId, Type: Receivable, CurrencyCode: currencyCode},
+			attempt.Amount),
+	}
+}
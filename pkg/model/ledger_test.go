@@ -0,0 +1,149 @@
+package model
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineItemLedgerTransactionIsBalanced(t *testing.T) {
+	// Arrange
+	billId := BillId{CustomerId: "alice", Id: "6c5bb10f-6fd2-49be-a75a-806ad1c4cfcf"}
+	one, e := NewAmountFromInt64(100, "USD")
+	assert.NoError(t, e)
+	matchboxItem := BillLineItem{
+		Id:          BillLineItemId{BillId: billId, Id: "dfddc86a-6e07-4476-9b53-4ae78b4bce1c"},
+		Description: "Matchbox",
+		Amount:      one,
+	}
+
+	// Act
+	transaction := NewLineItemLedgerTransaction(matchboxItem)
+
+	// Assert
+	assert.NoError(t, transaction.CheckBalanced())
+	assert.Equal(t, "line-item-alice-6c5bb10f-6fd2-49be-a75a-806ad1c4cfcf-dfddc86a-6e07-4476-9b53-4ae78b4bce1c", transaction.Id)
+	assert.Equal(t, []LedgerPosting{
+		{Account: LedgerAccount{CustomerId: "alice", Type: AccruedReceivable, CurrencyCode: "USD"}, Amount: Amount{100, "USD"}},
+		{Account: LedgerAccount{CustomerId: "alice", Type: Revenue, CurrencyCode: "USD"}, Amount: Amount{-100, "USD"}},
+	}, transaction.Postings)
+}
+
+func TestCloseBillLedgerTransactionIsBalanced(t *testing.T) {
+	// Arrange
+	billId := BillId{CustomerId: "bob", Id: "91c05476-2ae1-4fcf-a25c-f1851847aafe"}
+
+	// Act
+	transaction := NewCloseBillLedgerTransaction(billId, TotalAmount{"300", "GEL"})
+
+	// Assert
+	assert.NoError(t, transaction.CheckBalanced())
+	assert.Equal(t, []LedgerPosting{
+		{Account: LedgerAccount{CustomerId: "bob", Type: Receivable, CurrencyCode: "GEL"}, Amount: Amount{300, "GEL"}},
+		{Account: LedgerAccount{CustomerId: "bob", Type: AccruedReceivable, CurrencyCode: "GEL"}, Amount: Amount{-300, "GEL"}},
+	}, transaction.Postings)
+}
+
+func TestVoidBillLedgerTransactionReversesLineItems(t *testing.T) {
+	// Arrange
+	billId := BillId{CustomerId: "bob", Id: "91c05476-2ae1-4fcf-a25c-f1851847aafe"}
+
+	// Act
+	transaction := NewVoidBillLedgerTransaction(billId, TotalAmount{"300", "GEL"})
+
+	// Assert
+	assert.NoError(t, transaction.CheckBalanced())
+	assert.Equal(t, []LedgerPosting{
+		{Account: LedgerAccount{CustomerId: "bob", Type: Revenue, CurrencyCode: "GEL"}, Amount: Amount{300, "GEL"}},
+		{Account: LedgerAccount{CustomerId: "bob", Type: AccruedReceivable, CurrencyCode: "GEL"}, Amount: Amount{-300, "GEL"}},
+	}, transaction.Postings)
+}
+
+func TestCloseBillLedgerTransactionBeyondInt64(t *testing.T) {
+	// Arrange
+	billId := BillId{CustomerId: "bob", Id: "91c05476-2ae1-4fcf-a25c-f1851847aafe"}
+	receivable := LedgerAccount{CustomerId: "bob", Type: Receivable, CurrencyCode: "GEL"}
+	accruedReceivable := LedgerAccount{CustomerId: "bob", Type: AccruedReceivable, CurrencyCode: "GEL"}
+
+	// Act
+	// 2 * MaxInt64 + 1
+	transaction := NewCloseBillLedgerTransaction(billId, TotalAmount{"18446744073709551615", "GEL"})
+
+	// Assert
+	assert.NoError(t, transaction.CheckBalanced())
+	assert.Equal(t, []LedgerPosting{
+		{Account: receivable, Amount: Amount{math.MaxInt64, "GEL"}},
+		{Account: accruedReceivable, Amount: Amount{-math.MaxInt64, "GEL"}},
+		{Account: receivable, Amount: Amount{math.MaxInt64, "GEL"}},
+		{Account: accruedReceivable, Amount: Amount{-math.MaxInt64, "GEL"}},
+		{Account: receivable, Amount: Amount{1, "GEL"}},
+		{Account: accruedReceivable, Amount: Amount{-1, "GEL"}},
+	}, transaction.Postings)
+}
+
+func TestPaymentLedgerTransactionSettlesReceivable(t *testing.T) {
+	// Arrange
+	attempt := PaymentAttempt{
+		BillId: BillId{CustomerId: "bob", Id: "91c05476-2ae1-4fcf-a25c-f1851847aafe"},
+		Number: 2,
+		Amount: TotalAmount{"300", "GEL"},
+		Status: PaymentSucceeded,
+	}
+
+	// Act
+	transaction := NewPaymentLedgerTransaction(attempt)
+
+	// Assert
+	assert.NoError(t, transaction.CheckBalanced())
+	assert.Equal(t, "payment-bill-bob-91c05476-2ae1-4fcf-a25c-f1851847aafe-2", transaction.Id)
+	assert.Equal(t, []LedgerPosting{
+		{Account: LedgerAccount{CustomerId: "bob", Type: Cash, CurrencyCode: "GEL"}, Amount: Amount{300, "GEL"}},
+		{Account: LedgerAccount{CustomerId: "bob", Type: Receivable, CurrencyCode: "GEL"}, Amount: Amount{-300, "GEL"}},
+	}, transaction.Postings)
+}
+
+func TestUnbalancedLedgerTransaction(t *testing.T) {
+	// Arrange
+	account := LedgerAccount{CustomerId: "carol", Type: Revenue, CurrencyCode: "USD"}
+	transaction := LedgerTransaction{Id: "tx", Postings: []LedgerPosting{
+		{Account: account, Amount: Amount{100, "USD"}},
+		{Account: account, Amount: Amount{-99, "USD"}},
+	}}
+
+	// Act
+	e := transaction.CheckBalanced()
+
+	// Assert
+	assert.ErrorIs(t, e, UnbalancedLedgerTransactionError{"tx", "USD", "1"})
+}
+
+func TestLedgerTransactionBalancedPerCurrency(t *testing.T) {
+	// Arrange
+	transaction := LedgerTransaction{Id: "tx", Postings: []LedgerPosting{
+		{Account: LedgerAccount{CustomerId: "carol", Type: Receivable, CurrencyCode: "USD"}, Amount: Amount{100, "USD"}},
+		{Account: LedgerAccount{CustomerId: "carol", Type: Revenue, CurrencyCode: "GEL"}, Amount: Amount{-100, "GEL"}},
+	}}
+
+	// Act
+	e := transaction.CheckBalanced()
+
+	// Assert
+	assert.Error(t, e)
+}
+
+func TestLedgerTransactionBalancedBeyondInt64(t *testing.T) {
+	// Arrange
+	account := LedgerAccount{CustomerId: "carol", Type: Revenue, CurrencyCode: "USD"}
+	transaction := LedgerTransaction{Id: "tx", Postings: []LedgerPosting{
+		{Account: account, Amount: Amount{math.MaxInt64, "USD"}},
+		{Account: account, Amount: Amount{1, "USD"}},
+		{Account: account, Amount: Amount{math.MinInt64, "USD"}},
+	}}
+
+	// Act
+	e := transaction.CheckBalanced()
+
+	// Assert
+	assert.NoError(t, e)
+}
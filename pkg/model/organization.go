@@ -0,0 +1,97 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// string is a stand-in for however a user is represented by the identity provider.
+type UserId string
+
+type InvalidRoleError struct {
+	Role string
+}
+
+func (e InvalidRoleError) Error() string {
+	return fmt.Sprintf("invalid role %q", e.Role)
+}
+
+type PermissionDeniedError struct {
+	Role       Role
+	Permission Permission
+}
+
+func (e PermissionDeniedError) Error() string {
+	return fmt.Sprintf("the %s role cannot %s", e.Role, e.Permission)
+}
+
+// What a member may do in the organization.
+type Role string
+
+const (
+	RoleOwner Role = "owner"
+	// Such as a finance admin, or an automated service adding line items.
+	RoleBillingAdmin Role = "billing-admin"
+	// Such as a read-only auditor.
+	RoleViewer Role = "viewer"
+)
+
+type Permission string
+
+const (
+	PermissionViewBills     Permission = "view bills"
+	PermissionManageBills   Permission = "manage bills"
+	PermissionManageMembers Permission = "manage members"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner:        {PermissionViewBills, PermissionManageBills, PermissionManageMembers},
+	RoleBillingAdmin: {PermissionViewBills, PermissionManageBills},
+	RoleViewer:       {PermissionViewBills},
+}
+
+func ParseRole(text string) (Role, error) {
+	role := Role(text)
+	if _, ok := rolePermissions[role]; !ok {
+		return "", InvalidRoleError{text}
+	}
+	return role, nil
+}
+
+// An unknown role, such as the empty one, has no permission.
+func (r Role) Check(permission Permission) error {
+	if !slices.Contains(rolePermissions[r], permission) {
+		return PermissionDeniedError{r, permission}
+	}
+	return nil
+}
+
+// A user of an organization. The organization is the customer its bills are keyed by, so its members share them.
+type Membership struct {
+	OrgId     CustomerId
+	UserId    UserId
+	Role      Role
+	CreatedAt time.Time
+}
+
+type LastOwnerError struct {
+	OrgId CustomerId
+}
+
+func (e LastOwnerError) Error() string {
+	return fmt.Sprintf("the organization %s needs an owner", e.OrgId)
+}
+
+// Whether the organization still has an owner once the user got the role, or left it with an empty role.
+func CheckKeepsOwner(orgId CustomerId, memberships []Membership, userId UserId, role Role) error {
+	if role == RoleOwner {
+		return nil
+	}
+	for _, membership := range memberships {
+		if membership.Role == RoleOwner && membership.UserId != userId {
+			return nil
+		}
+	}
+	return LastOwnerError{orgId}
+}
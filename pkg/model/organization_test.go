@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleCheck(t *testing.T) {
+	// Arrange
+	var none Role
+
+	// Act nil
+
+	// Assert
+	assert.NoError(t, RoleOwner.Check(PermissionManageMembers))
+	assert.NoError(t, RoleBillingAdmin.Check(PermissionManageBills))
+	assert.Equal(t, PermissionDeniedError{RoleBillingAdmin, PermissionManageMembers}, RoleBillingAdmin.Check(PermissionManageMembers))
+	assert.NoError(t, RoleViewer.Check(PermissionViewBills))
+	assert.Error(t, RoleViewer.Check(PermissionManageBills))
+	assert.Error(t, none.Check(PermissionViewBills))
+}
+
+func TestParseRole(t *testing.T) {
+	// Act
+	role, err := ParseRole("billing-admin")
+	_, invalidErr := ParseRole("admin")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, RoleBillingAdmin, role)
+	assert.Equal(t, InvalidRoleError{"admin"}, invalidErr)
+}
+
+func TestCheckKeepsOwner(t *testing.T) {
+	// Arrange
+	memberships := []Membership{
+		{OrgId: "org", UserId: "alice", Role: RoleOwner},
+		{OrgId: "org", UserId: "carol", Role: RoleViewer},
+	}
+
+	// Act nil
+
+	// Assert
+	assert.NoError(t, CheckKeepsOwner("org", memberships, "carol", RoleBillingAdmin))
+	assert.NoError(t, CheckKeepsOwner("org", memberships, "carol", ""))
+	assert.NoError(t, CheckKeepsOwner("org", memberships, "alice", RoleOwner))
+	assert.Equal(t, LastOwnerError{"org"}, CheckKeepsOwner("org", memberships, "alice", RoleViewer))
+	assert.Equal(t, LastOwnerError{"org"}, CheckKeepsOwner("org", memberships, "alice", ""))
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentSucceeded PaymentStatus = "succeeded"
+	// The gateway refused the charge, retrying the same charge would not help.
+	PaymentDeclined PaymentStatus = "declined"
+	// The gateway could not be reached, whether the customer was charged is unknown to the gateway caller.
+	PaymentErrored PaymentStatus = "errored"
+)
+
+// An attempt is unique by BillId and Number, so recording it again is a no-op.
+type PaymentAttempt struct {
+	BillId BillId
+	// From 1.
+	Number uint32
+	Amount TotalAmount
+	Status PaymentStatus
+	// The gateway id of the charge, empty unless it succeeded.
+	Reference string
+	// Empty when it succeeded.
+	FailureReason string
+	AttemptedAt   time.Time
+}
+
+// The gateway charges at most once per key, so a retried attempt is not charged twice.
+func PaymentIdempotencyKey(billId BillId, number uint32) string {
+	return fmt.Sprintf("payment-%s-%s-%d", billId.CustomerId, billId.Id, number)
+}
+
+func (p *PaymentAttempt) IdempotencyKey() string {
+	return PaymentIdempotencyKey(p.BillId, p.Number)
+}
+
+// What is left to collect once the discounts are taken off the amount due. The discounts are capped to the amount due,
+// so it is never negative.
+func AmountToCollect(amountDue TotalAmount, discounts []DiscountLine) TotalAmount {
+	toCollect := amountDue.BigInt()
+	toCollect.Sub(toCollect, SumDiscounts(amountDue.CurrencyCode, discounts).BigInt())
+	return NewTotalAmountFromBigInt(toCollect, amountDue.CurrencyCode)
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Quantities and unit prices are limited to this many decimal places, so that a typo cannot
+// silently turn into a rounding.
+const MaxPricingDecimals = 9
+
+type InvalidQuantityError struct {
+	Quantity string
+}
+
+func (e InvalidQuantityError) Error() string {
+	return fmt.Sprintf("invalid quantity %q", e.Quantity)
+}
+
+type InvalidUnitPriceError struct {
+	UnitPrice string
+}
+
+func (e InvalidUnitPriceError) Error() string {
+	return fmt.Sprintf("invalid unit price %q", e.UnitPrice)
+}
+
+type PrecisionError struct {
+	Number string
+}
+
+func (e PrecisionError) Error() string {
+	return fmt.Sprintf("%q has more than %d decimal places", e.Number, MaxPricingDecimals)
+}
+
+type LineAmountOverflowError struct {
+	Quantity  string
+	UnitPrice string
+}
+
+func (e LineAmountOverflowError) Error() string {
+	return fmt.Sprintf("quantity %q at unit price %q overflows", e.Quantity, e.UnitPrice)
+}
+
+// A plain decimal such as "1.5" or "-2"; no exponents or fractions.
+func parseDecimal(s string) (*big.Rat, bool, error) {
+	trimmed := strings.TrimPrefix(s, "-")
+	if trimmed == "" || strings.Trim(trimmed, "0123456789.") != "" || strings.Count(trimmed, ".") > 1 ||
+		strings.HasPrefix(trimmed, ".") || strings.HasSuffix(trimmed, ".") {
+		return nil, false, nil
+	}
+	if i := strings.IndexByte(trimmed, '.'); i >= 0 && len(trimmed)-i-1 > MaxPricingDecimals {
+		return nil, true, PrecisionError{s}
+	}
+	r, ok := new(big.Rat).SetString(s)
+	return r, ok, nil
+}
+
+func (l BillLineItem) IsPriced() bool {
+	return l.Quantity != "" || l.UnitPrice != ""
+}
+
+// Returns the line item with Amount.Number set to Quantity times UnitPrice, in minor units of
+// Amount.CurrencyCode and rounded with Rounding. A line item without a quantity or unit price is
+// returned as is.
+func (l BillLineItem) WithComputedAmount() (BillLineItem, error) {
+	if !l.IsPriced() {
+		return l, nil
+	}
+	rounded, e := l.computeAmount()
+	if e != nil {
+		return BillLineItem{}, e
+	}
+	if !rounded.IsInt64() {
+		return BillLineItem{}, LineAmountOverflowError{l.Quantity, l.UnitPrice}
+	}
+	l.Amount.Number = rounded.Int64()
+	return l, nil
+}
+
+// Quantity times UnitPrice in minor units of Amount.CurrencyCode, whatever its size.
+func (l BillLineItem) computeAmount() (*big.Int, error) {
+	if e := l.Rounding.Check(); e != nil {
+		return nil, e
+	}
+	digits, ok := GetDigits(l.Amount.CurrencyCode)
+	if !ok {
+		return nil, InvalidCurrencyCodeError{l.Amount.CurrencyCode}
+	}
+	quantity, ok, e := parseDecimal(l.Quantity)
+	if e != nil {
+		return nil, e
+	}
+	if !ok || quantity.Sign() < 0 {
+		return nil, InvalidQuantityError{l.Quantity}
+	}
+	unitPrice, ok, e := parseDecimal(l.UnitPrice)
+	if e != nil {
+		return nil, e
+	}
+	if !ok {
+		return nil, InvalidUnitPriceError{l.UnitPrice}
+	}
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil))
+	amount := new(big.Rat).Mul(quantity, unitPrice)
+	amount.Mul(amount, scale)
+	return l.Rounding.Round(amount)
+}
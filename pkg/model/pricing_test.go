@@ -0,0 +1,105 @@
+package model
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundingModes(t *testing.T) {
+	// Arrange
+	values := []*big.Rat{big.NewRat(5, 2), big.NewRat(7, 2), big.NewRat(-5, 2), big.NewRat(27, 10)}
+
+	// Act
+	round := func(mode RoundingMode) []int64 {
+		rounded := make([]int64, len(values))
+		for i, value := range values {
+			r, e := mode.Round(value)
+			assert.NoError(t, e)
+			rounded[i] = r.Int64()
+		}
+		return rounded
+	}
+
+	// Assert
+	assert.Equal(t, []int64{2, 4, -2, 3}, round(""))
+	assert.Equal(t, []int64{2, 4, -2, 3}, round(RoundHalfEven))
+	assert.Equal(t, []int64{3, 4, -3, 3}, round(RoundHalfUp))
+	assert.Equal(t, []int64{2, 3, -2, 2}, round(RoundDown))
+	_, e := RoundingMode("up").Round(big.NewRat(1, 2))
+	assert.ErrorIs(t, e, InvalidRoundingModeError{"up"})
+}
+
+func TestWithComputedAmount(t *testing.T) {
+	// Arrange
+	lineItem := BillLineItem{Amount: Amount{CurrencyCode: "USD"}, Quantity: "12.5", UnitPrice: "0.085"}
+
+	// Act
+	halfEven, e1 := lineItem.WithComputedAmount()
+	lineItem.Rounding = RoundHalfUp
+	halfUp, e2 := lineItem.WithComputedAmount()
+	lineItem.Rounding = RoundDown
+	down, e3 := lineItem.WithComputedAmount()
+
+	// Assert
+	assert.NoError(t, e1)
+	assert.NoError(t, e2)
+	assert.NoError(t, e3)
+	// 12.5 * 0.085 = 1.0625 USD
+	assert.Equal(t, Amount{Number: 106, CurrencyCode: "USD"}, halfEven.Amount)
+	assert.Equal(t, Amount{Number: 106, CurrencyCode: "USD"}, halfUp.Amount)
+	assert.Equal(t, Amount{Number: 106, CurrencyCode: "USD"}, down.Amount)
+}
+
+func TestWithComputedAmountTie(t *testing.T) {
+	// Arrange
+	lineItem := BillLineItem{Amount: Amount{CurrencyCode: "USD"}, Quantity: "0.5", UnitPrice: "0.05"}
+
+	// Act
+	halfEven, e1 := lineItem.WithComputedAmount()
+	lineItem.Rounding = RoundHalfUp
+	halfUp, e2 := lineItem.WithComputedAmount()
+
+	// Assert
+	assert.NoError(t, e1)
+	assert.NoError(t, e2)
+	// 0.5 * 0.05 = 0.025 USD
+	assert.Equal(t, int64(2), halfEven.Amount.Number)
+	assert.Equal(t, int64(3), halfUp.Amount.Number)
+}
+
+func TestWithComputedAmountWithoutQuantity(t *testing.T) {
+	// Arrange
+	lineItem := BillLineItem{Amount: Amount{Number: 42, CurrencyCode: "USD"}}
+
+	// Act
+	computed, e := lineItem.WithComputedAmount()
+
+	// Assert
+	assert.NoError(t, e)
+	assert.Equal(t, lineItem, computed)
+}
+
+func TestWithComputedAmountErrors(t *testing.T) {
+	// Arrange
+	priced := func(quantity string, unitPrice string) BillLineItem {
+		return BillLineItem{Amount: Amount{CurrencyCode: "USD"}, Quantity: quantity, UnitPrice: unitPrice}
+	}
+
+	// Act
+	_, eQuantity := priced("1e3", "1").WithComputedAmount()
+	_, eNegative := priced("-1", "1").WithComputedAmount()
+	_, eUnitPrice := priced("1", "").WithComputedAmount()
+	_, ePrecision := priced("0.0000000001", "1").WithComputedAmount()
+	_, eOverflow := priced("100000000000", "100000000000").WithComputedAmount()
+	_, eCurrency := BillLineItem{Amount: Amount{CurrencyCode: "EUR"}, Quantity: "1", UnitPrice: "1"}.WithComputedAmount()
+
+	// Assert
+	assert.ErrorIs(t, eQuantity, InvalidQuantityError{"1e3"})
+	assert.ErrorIs(t, eNegative, InvalidQuantityError{"-1"})
+	assert.ErrorIs(t, eUnitPrice, InvalidUnitPriceError{""})
+	assert.ErrorIs(t, ePrecision, PrecisionError{"0.0000000001"})
+	assert.ErrorIs(t, eOverflow, LineAmountOverflowError{"100000000000", "100000000000"})
+	assert.ErrorIs(t, eCurrency, InvalidCurrencyCodeError{"EUR"})
+}
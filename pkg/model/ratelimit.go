@@ -0,0 +1,157 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The rate limit of the endpoints without one of their own.
+const DefaultRateLimitEndpoint = "*"
+
+type InvalidRateLimitError struct {
+	RateLimit string
+}
+
+func (e InvalidRateLimitError) Error() string {
+	return fmt.Sprintf("invalid rate limit %q", e.RateLimit)
+}
+
+// A token bucket: up to Burst requests at once, then Rate requests per second.
+type RateLimit struct {
+	// Tokens added per second.
+	Rate  float64
+	Burst uint32
+}
+
+func DefaultRateLimits() map[string]RateLimit {
+	return map[string]RateLimit{
+		DefaultRateLimitEndpoint: {Rate: 20, Burst: 40},
+		// Each bill starts a workflow
+		"OpenNewBill": {Rate: 1, Burst: 10},
+	}
+}
+
+// Parses limits such as "*=20/s:40;OpenNewBill=30/m:10", the rate per second, minute or hour and the burst, by
+// endpoint on top of the default ones.
+func ParseRateLimits(text string) (map[string]RateLimit, error) {
+	limits := DefaultRateLimits()
+	if text == "" {
+		return limits, nil
+	}
+	for _, limitText := range strings.Split(text, ";") {
+		endpoint, rest, ok := strings.Cut(limitText, "=")
+		rateText, burstText, ok2 := strings.Cut(rest, ":")
+		countText, unit, ok3 := strings.Cut(rateText, "/")
+		if !ok || !ok2 || !ok3 || endpoint == "" {
+			return nil, InvalidRateLimitError{limitText}
+		}
+		count, err := strconv.ParseFloat(strings.TrimSpace(countText), 64)
+		if err != nil || count <= 0 || math.IsInf(count, 0) {
+			return nil, InvalidRateLimitError{limitText}
+		}
+		burst, err := strconv.ParseUint(strings.TrimSpace(burstText), 10, 32)
+		if err != nil || burst == 0 {
+			return nil, InvalidRateLimitError{limitText}
+		}
+		var per time.Duration
+		switch unit {
+		case "s":
+			per = time.Second
+		case "m":
+			per = time.Minute
+		case "h":
+			per = time.Hour
+		default:
+			return nil, InvalidRateLimitError{limitText}
+		}
+		limits[endpoint] = RateLimit{Rate: count / per.Seconds(), Burst: uint32(burst)}
+	}
+	return limits, nil
+}
+
+// The limit of the endpoint, or the default one.
+func FindRateLimit(limits map[string]RateLimit, endpoint string) RateLimit {
+	if limit, ok := limits[endpoint]; ok {
+		return limit
+	}
+	return limits[DefaultRateLimitEndpoint]
+}
+
+type RateLimitBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// A bucket not used yet is full.
+func NewRateLimitBucket(limit RateLimit, now time.Time) RateLimitBucket {
+	return RateLimitBucket{Tokens: float64(limit.Burst), UpdatedAt: now}
+}
+
+// Whether a request was allowed, with what the RateLimit-* headers tell the caller.
+type RateLimitDecision struct {
+	Allowed   bool
+	Limit     uint32
+	Remaining uint32
+	// Until the bucket is full again.
+	Reset time.Duration
+	// Until the next request can be allowed, zero when this one was.
+	RetryAfter time.Duration
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+// Refills the bucket for the time since it was last updated, then takes a token from it when there is one.
+func (b RateLimitBucket) Take(limit RateLimit, now time.Time) (RateLimitBucket, RateLimitDecision) {
+	elapsed := max(now.Sub(b.UpdatedAt).Seconds(), 0)
+	tokens := min(b.Tokens+elapsed*limit.Rate, float64(limit.Burst))
+	decision := RateLimitDecision{Limit: limit.Burst}
+	if 1 <= tokens {
+		tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+	decision.Remaining = uint32(tokens)
+	decision.Reset = secondsToDuration((float64(limit.Burst) - tokens) / limit.Rate)
+	return RateLimitBucket{Tokens: tokens, UpdatedAt: now}, decision
+}
+
+type OpenBillQuotaExceededError struct {
+	Max uint64
+}
+
+func (e OpenBillQuotaExceededError) Error() string {
+	return fmt.Sprintf("the customer already has the maximum of %d open bills", e.Max)
+}
+
+type LineItemQuotaExceededError struct {
+	Max uint64
+}
+
+func (e LineItemQuotaExceededError) Error() string {
+	return fmt.Sprintf("the bill already has the maximum of %d line items", e.Max)
+}
+
+// Zero is no quota.
+type Quotas struct {
+	// Drafts, open or closing, by customer.
+	MaxOpenBills uint64
+	// Added by the customer, the usage billed at close is not counted.
+	MaxLineItemsPerBill uint64
+}
+
+func DefaultQuotas() Quotas {
+	return Quotas{MaxOpenBills: 1000, MaxLineItemsPerBill: 10000}
+}
+
+func (q Quotas) CheckOpenBills(openBillCount uint64) error {
+	if q.MaxOpenBills != 0 && q.MaxOpenBills <= openBillCount {
+		return OpenBillQuotaExceededError{q.MaxOpenBills}
+	}
+	return nil
+}
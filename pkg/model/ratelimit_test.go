@@ -0,0 +1,69 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	// Act
+	limits, err := ParseRateLimits("*=10/s:20;OpenNewBill=30/m:5")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimit{Rate: 10, Burst: 20}, FindRateLimit(limits, "GetBill"))
+	assert.Equal(t, RateLimit{Rate: 0.5, Burst: 5}, FindRateLimit(limits, "OpenNewBill"))
+}
+
+func TestParseRateLimitsDefaults(t *testing.T) {
+	// Act
+	limits, err := ParseRateLimits("")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultRateLimits(), limits)
+}
+
+func TestParseRateLimitsInvalid(t *testing.T) {
+	for _, text := range []string{"*=10/s", "*=10:20", "=10/s:20", "*=10/d:20", "*=0/s:20", "*=10/s:0", "*=ten/s:20"} {
+		// Act
+		_, err := ParseRateLimits(text)
+
+		// Assert
+		assert.Equal(t, InvalidRateLimitError{text}, err, text)
+	}
+}
+
+func TestRateLimitBucketTake(t *testing.T) {
+	// Arrange
+	limit := RateLimit{Rate: 2, Burst: 2}
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	bucket := NewRateLimitBucket(limit, now)
+
+	// Act
+	bucket, first := bucket.Take(limit, now)
+	bucket, second := bucket.Take(limit, now)
+	bucket, refused := bucket.Take(limit, now)
+	_, refilled := bucket.Take(limit, now.Add(time.Second))
+
+	// Assert
+	assert.Equal(t, RateLimitDecision{Allowed: true, Limit: 2, Remaining: 1, Reset: 500 * time.Millisecond}, first)
+	assert.Equal(t, RateLimitDecision{Allowed: true, Limit: 2, Remaining: 0, Reset: time.Second}, second)
+	assert.Equal(t, RateLimitDecision{Limit: 2, Remaining: 0, Reset: time.Second, RetryAfter: 500 * time.Millisecond}, refused)
+	assert.Equal(t, RateLimitDecision{Allowed: true, Limit: 2, Remaining: 1, Reset: 500 * time.Millisecond}, refilled)
+}
+
+func TestQuotasCheckOpenBills(t *testing.T) {
+	// Arrange
+	quotas := Quotas{MaxOpenBills: 2}
+	none := Quotas{}
+
+	// Act nil
+
+	// Assert
+	assert.NoError(t, quotas.CheckOpenBills(1))
+	assert.Equal(t, OpenBillQuotaExceededError{2}, quotas.CheckOpenBills(2))
+	assert.NoError(t, none.CheckOpenBills(1000000))
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"fmt"
+	"math/big"
+)
+
+type InvalidRoundingModeError struct {
+	Mode RoundingMode
+}
+
+func (e InvalidRoundingModeError) Error() string {
+	return fmt.Sprintf("invalid rounding mode %q", e.Mode)
+}
+
+type RoundingMode string
+
+const (
+	// Ties go to the even neighbour. Also the mode when none is given.
+	RoundHalfEven RoundingMode = "half_even"
+	// Ties go away from zero.
+	RoundHalfUp RoundingMode = "half_up"
+	// Towards zero.
+	RoundDown RoundingMode = "down"
+)
+
+func (m RoundingMode) OrHalfEven() RoundingMode {
+	if m == "" {
+		return RoundHalfEven
+	}
+	return m
+}
+
+func (m RoundingMode) Check() error {
+	switch m.OrHalfEven() {
+	case RoundHalfEven, RoundHalfUp, RoundDown:
+		return nil
+	default:
+		return InvalidRoundingModeError{m}
+	}
+}
+
+func (m RoundingMode) Round(r *big.Rat) (*big.Int, error) {
+	if e := m.Check(); e != nil {
+		return nil, e
+	}
+	quotient, remainder := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if remainder.Sign() == 0 || m.OrHalfEven() == RoundDown {
+		return quotient, nil
+	}
+	// Compare 2*|remainder| with the denominator
+	twice := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2))
+	cmp := twice.Cmp(r.Denom())
+	awayFromZero := cmp > 0 || (cmp == 0 && (m.OrHalfEven() == RoundHalfUp || quotient.Bit(0) == 1))
+	if !awayFromZero {
+		return quotient, nil
+	}
+	if r.Sign() < 0 {
+		return quotient.Sub(quotient, big.NewInt(1)), nil
+	}
+	return quotient.Add(quotient, big.NewInt(1)), nil
+}
+
+func roundHalfEven(r *big.Rat) *big.Int {
+	rounded, _ := RoundHalfEven.Round(r)
+	return rounded
+}
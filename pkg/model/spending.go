@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+type InvalidSpendingCapError struct {
+	Reason string
+}
+
+func (e InvalidSpendingCapError) Error() string {
+	return fmt.Sprintf("invalid spending cap: %s", e.Reason)
+}
+
+type SpendingCapExceededError struct {
+	Cap        Amount
+	TotalAfter TotalAmount
+}
+
+func (e SpendingCapExceededError) Error() string {
+	return fmt.Sprintf("total %s %s would exceed the spending cap of %d", e.TotalAfter.Number, e.TotalAfter.CurrencyCode, e.Cap.Number)
+}
+
+// There is no cap while Max is zero.
+type SpendingCap struct {
+	// In the bill currency.
+	Max Amount
+	// In percents of Max, increasing. An alert is sent when the total reaches each of them.
+	AlertThresholds []uint32
+}
+
+// A zero max is no cap.
+func NewSpendingCap(max int64, currencyCode CurrencyCode, alertThresholds []uint32) SpendingCap {
+	if max == 0 {
+		return SpendingCap{AlertThresholds: alertThresholds}
+	}
+	return SpendingCap{Max: Amount{Number: max, CurrencyCode: currencyCode}, AlertThresholds: alertThresholds}
+}
+
+func (c SpendingCap) IsSet() bool {
+	return c.Max.Number != 0
+}
+
+func (c SpendingCap) Check(currencyCode CurrencyCode) error {
+	if c.Max.Number < 0 {
+		return InvalidSpendingCapError{"the maximum is negative"}
+	}
+	if !c.IsSet() {
+		if len(c.AlertThresholds) != 0 {
+			return InvalidSpendingCapError{"alert thresholds need a maximum"}
+		}
+		return nil
+	}
+	if e := CheckCurrencyCodeCompatible(currencyCode, c.Max.CurrencyCode); e != nil {
+		return e
+	}
+	for i, threshold := range c.AlertThresholds {
+		if threshold == 0 || 100 < threshold {
+			return InvalidSpendingCapError{fmt.Sprintf("alert threshold %d%% is not within 1%% and 100%%", threshold)}
+		}
+		if 0 < i && threshold <= c.AlertThresholds[i-1] {
+			return InvalidSpendingCapError{"alert thresholds are not increasing"}
+		}
+	}
+	return nil
+}
+
+// Total must be in the currency of the cap.
+func (c SpendingCap) CheckAllows(total TotalAmount) error {
+	if c.IsSet() && total.BigInt().Cmp(big.NewInt(c.Max.Number)) > 0 {
+		return SpendingCapExceededError{Cap: c.Max, TotalAfter: total}
+	}
+	return nil
+}
+
+// The thresholds that the total reached when it went from before to after.
+func (c SpendingCap) CrossedThresholds(before TotalAmount, after TotalAmount) []uint32 {
+	if !c.IsSet() {
+		return nil
+	}
+	var crossed []uint32
+	before100 := new(big.Int).Mul(before.BigInt(), big.NewInt(100))
+	after100 := new(big.Int).Mul(after.BigInt(), big.NewInt(100))
+	for _, threshold := range c.AlertThresholds {
+		reached := new(big.Int).Mul(big.NewInt(c.Max.Number), big.NewInt(int64(threshold)))
+		if before100.Cmp(reached) < 0 && after100.Cmp(reached) >= 0 {
+			crossed = append(crossed, threshold)
+		}
+	}
+	return crossed
+}
+
+type SpendingAlert struct {
+	BillId BillId
+	// In percents of the cap.
+	Threshold uint32
+	Cap       Amount
+	Total     TotalAmount
+	SentAt    time.Time
+}
+
+// An alert is sent once per threshold of a given cap.
+func (a SpendingAlert) RequestId() string {
+	return fmt.Sprintf("%d%%-of-%d", a.Threshold, a.Cap.Number)
+}
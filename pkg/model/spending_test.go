@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpendingCapCheck(t *testing.T) {
+	// Arrange
+	valid := SpendingCap{Max: Amount{Number: 1000, CurrencyCode: "USD"}, AlertThresholds: []uint32{50, 80, 100}}
+	none := SpendingCap{}
+	negative := SpendingCap{Max: Amount{Number: -1, CurrencyCode: "USD"}}
+	foreign := SpendingCap{Max: Amount{Number: 1000, CurrencyCode: "GEL"}}
+	unordered := SpendingCap{Max: Amount{Number: 1000, CurrencyCode: "USD"}, AlertThresholds: []uint32{80, 50}}
+	tooHigh := SpendingCap{Max: Amount{Number: 1000, CurrencyCode: "USD"}, AlertThresholds: []uint32{120}}
+	thresholdsOnly := SpendingCap{AlertThresholds: []uint32{50}}
+
+	// Act nil
+
+	// Assert
+	assert.NoError(t, valid.Check("USD"))
+	assert.NoError(t, none.Check("USD"))
+	assert.Error(t, negative.Check("USD"))
+	assert.Equal(t, IncompatibleCurrencyCodesError{"USD", "GEL"}, foreign.Check("USD"))
+	assert.Error(t, unordered.Check("USD"))
+	assert.Error(t, tooHigh.Check("USD"))
+	assert.Error(t, thresholdsOnly.Check("USD"))
+}
+
+func TestSpendingCapCheckAllows(t *testing.T) {
+	// Arrange
+	spendingCap := SpendingCap{Max: Amount{Number: 1000, CurrencyCode: "USD"}}
+	atCap := TotalAmount{Number: "1000", CurrencyCode: "USD"}
+	overCap := TotalAmount{Number: "1001", CurrencyCode: "USD"}
+
+	// Act nil
+
+	// Assert
+	assert.NoError(t, spendingCap.CheckAllows(atCap))
+	assert.Equal(t, SpendingCapExceededError{Cap: spendingCap.Max, TotalAfter: overCap}, spendingCap.CheckAllows(overCap))
+	assert.NoError(t, SpendingCap{}.CheckAllows(overCap))
+}
+
+func TestSpendingCapCrossedThresholds(t *testing.T) {
+	// Arrange
+	spendingCap := SpendingCap{Max: Amount{Number: 1000, CurrencyCode: "USD"}, AlertThresholds: []uint32{50, 80, 100}}
+	total := func(number string) TotalAmount { return TotalAmount{Number: number, CurrencyCode: "USD"} }
+
+	// Act nil
+
+	// Assert
+	assert.Empty(t, spendingCap.CrossedThresholds(total("0"), total("499")))
+	assert.Equal(t, []uint32{50}, spendingCap.CrossedThresholds(total("499"), total("500")))
+	assert.Empty(t, spendingCap.CrossedThresholds(total("500"), total("700")))
+	assert.Equal(t, []uint32{80, 100}, spendingCap.CrossedThresholds(total("700"), total("1000")))
+	assert.Empty(t, SpendingCap{}.CrossedThresholds(total("0"), total("1000")))
+}
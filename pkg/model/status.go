@@ -0,0 +1,154 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+type InvalidBillStatusError struct {
+	Status string
+}
+
+func (e InvalidBillStatusError) Error() string {
+	return fmt.Sprintf("invalid bill status %q", e.Status)
+}
+
+type InvalidBillStatusTransitionError struct {
+	From BillStatus
+	To   BillStatus
+}
+
+func (e InvalidBillStatusTransitionError) Error() string {
+	return fmt.Sprintf("bill cannot go from %s to %s", e.From, e.To)
+}
+
+// The numbers are stored, new statuses are appended.
+type BillStatus uint8
+
+const (
+	Open BillStatus = iota
+	Closed
+	// The amount due was collected, or there was nothing to collect.
+	Paid
+	// The amount due could not be collected, it is being dunned.
+	PaymentFailed
+	// Dunning gave up collecting the amount due.
+	Uncollectible
+	// Not open yet to line items.
+	Draft
+	// Refuses line items while the tax and discounts are computed.
+	Closing
+	// Cancelled, nothing is due.
+	Void
+)
+
+var billStatusNames = map[BillStatus]string{
+	Draft:         "draft",
+	Open:          "open",
+	Closing:       "closing",
+	Closed:        "closed",
+	Paid:          "paid",
+	PaymentFailed: "payment_failed",
+	Uncollectible: "uncollectible",
+	Void:          "void",
+}
+
+// Setting a status again is not a transition, it is always allowed. Only the bills whose workflow runs are voided,
+// since their line items are still accrued, see NewVoidBillLedgerTransaction.
+var billStatusTransitions = map[BillStatus][]BillStatus{
+	Draft:         {Open, Void},
+	Open:          {Closing, Void},
+	Closing:       {Closed},
+	Closed:        {Paid, PaymentFailed},
+	PaymentFailed: {Paid, Uncollectible},
+	Paid:          {},
+	Uncollectible: {},
+	Void:          {},
+}
+
+func (s BillStatus) String() string {
+	if name, ok := billStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("BillStatus(%d)", uint8(s))
+}
+
+func ParseBillStatus(text string) (BillStatus, error) {
+	for status, name := range billStatusNames {
+		if name == text {
+			return status, nil
+		}
+	}
+	return 0, InvalidBillStatusError{text}
+}
+
+// A bill is created either as a draft or open.
+func (s BillStatus) IsInitial() bool {
+	return s == Draft || s == Open
+}
+
+// The workflow of the bill runs, until the bill is closed or voided.
+func (s BillStatus) IsRunning() bool {
+	return s == Draft || s == Open || s == Closing
+}
+
+// A closing bill only accepts the line items that its workflow adds while closing, such as the metered usage.
+func (s BillStatus) AcceptsLineItems() bool {
+	return s == Open || s == Closing
+}
+
+func (s BillStatus) CheckTransition(to BillStatus) error {
+	if s == to {
+		return nil
+	}
+	if !slices.Contains(billStatusTransitions[s], to) {
+		return InvalidBillStatusTransitionError{From: s, To: to}
+	}
+	return nil
+}
+
+// The statuses from which a bill can go to this one, including itself.
+func (s BillStatus) Predecessors() []BillStatus {
+	predecessors := []BillStatus{s}
+	for from, tos := range billStatusTransitions {
+		if slices.Contains(tos, s) {
+			predecessors = append(predecessors, from)
+		}
+	}
+	slices.Sort(predecessors)
+	return predecessors
+}
+
+func (s BillStatus) MarshalJSON() ([]byte, error) {
+	if _, ok := billStatusNames[s]; !ok {
+		return nil, InvalidBillStatusError{s.String()}
+	}
+	return json.Marshal(s.String())
+}
+
+// Also accepts the number the status used to be serialized as.
+func (s *BillStatus) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var text string
+		if e := json.Unmarshal(data, &text); e != nil {
+			return e
+		}
+		status, e := ParseBillStatus(text)
+		if e != nil {
+			return e
+		}
+		*s = status
+		return nil
+	}
+	var number uint8
+	if e := json.Unmarshal(data, &number); e != nil {
+		return InvalidBillStatusError{string(data)}
+	}
+	if _, ok := billStatusNames[BillStatus(number)]; !ok {
+		return InvalidBillStatusError{string(data)}
+	}
+	*s = BillStatus(number)
+	return nil
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBillStatusTransitions(t *testing.T) {
+	// Arrange
+	allowed := [][2]BillStatus{
+		{Draft, Open},
+		{Open, Closing},
+		{Closing, Closed},
+		{Closed, Paid},
+		{Closed, PaymentFailed},
+		{PaymentFailed, Paid},
+		{PaymentFailed, Uncollectible},
+		{Draft, Void},
+		{Open, Void},
+		{Paid, Paid},
+	}
+	refused := [][2]BillStatus{
+		{Open, Closed},
+		{Open, Paid},
+		{Closing, Open},
+		{Closed, Open},
+		{Paid, PaymentFailed},
+		{Uncollectible, Paid},
+		{Void, Open},
+		{Draft, Closing},
+		{Closed, Void},
+		{PaymentFailed, Void},
+	}
+
+	// Act nil
+
+	// Assert
+	for _, transition := range allowed {
+		assert.NoError(t, transition[0].CheckTransition(transition[1]), "%v", transition)
+	}
+	for _, transition := range refused {
+		assert.Equal(t,
+			InvalidBillStatusTransitionError{From: transition[0], To: transition[1]},
+			transition[0].CheckTransition(transition[1]))
+	}
+}
+
+func TestBillStatusPredecessors(t *testing.T) {
+	// Arrange nil
+
+	// Act
+	predecessors := Paid.Predecessors()
+
+	// Assert
+	assert.Equal(t, []BillStatus{Closed, Paid, PaymentFailed}, predecessors)
+}
+
+func TestBillStatusJsonIsString(t *testing.T) {
+	// Arrange
+	value := struct {
+		Status BillStatus `json:"status"`
+	}{Status: PaymentFailed}
+
+	// Act
+	data, err := json.Marshal(value)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, `{"status":"payment_failed"}`, string(data))
+}
+
+func TestBillStatusJsonAcceptsStringAndNumber(t *testing.T) {
+	// Arrange
+	var fromString, fromNumber, invalid BillStatus
+
+	// Act
+	errString := json.Unmarshal([]byte(`"closed"`), &fromString)
+	errNumber := json.Unmarshal([]byte(`1`), &fromNumber)
+	errInvalid := json.Unmarshal([]byte(`"settled"`), &invalid)
+
+	// Assert
+	assert.NoError(t, errString)
+	assert.Equal(t, Closed, fromString)
+	assert.NoError(t, errNumber)
+	assert.Equal(t, Closed, fromNumber)
+	assert.Equal(t, InvalidBillStatusError{"settled"}, errInvalid)
+}
+
+func TestParseBillStatusRoundTrips(t *testing.T) {
+	for status := range billStatusNames {
+		// Act
+		parsed, err := ParseBillStatus(status.String())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, status, parsed)
+	}
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+type InvalidTaxRateError struct {
+	Rate string
+}
+
+func (e InvalidTaxRateError) Error() string {
+	return fmt.Sprintf("invalid tax rate %q", e.Rate)
+}
+
+type TaxJurisdiction string
+
+type TaxCategory string
+
+const (
+	TaxCategoryStandard TaxCategory = "standard"
+	// Never looked up in the rate table.
+	TaxCategoryExempt TaxCategory = "exempt"
+)
+
+func (c TaxCategory) OrStandard() TaxCategory {
+	if c == "" {
+		return TaxCategoryStandard
+	}
+	return c
+}
+
+// Rate is a decimal string, for instance "0.18" for 18%.
+type TaxRate struct {
+	Jurisdiction TaxJurisdiction
+	Category     TaxCategory
+	Rate         string
+}
+
+// The line items of a bill that share a category and pricing, and their tax.
+type TaxLine struct {
+	Category  TaxCategory
+	Inclusive bool
+	Rate      string
+	// Net of tax.
+	Taxable TotalAmount
+	Tax     TotalAmount
+}
+
+// Computed on totals like the bill total, so that it never overflows.
+type BillTax struct {
+	Lines []TaxLine
+	// Net of tax.
+	Subtotal   TotalAmount
+	TaxTotal   TotalAmount
+	GrandTotal TotalAmount
+}
+
+// The tax of an open or untaxed bill is not computed.
+func (t *BillTax) IsComputed() bool {
+	return t.GrandTotal.CurrencyCode != ""
+}
+
+type taxGroup struct {
+	category  TaxCategory
+	inclusive bool
+}
+
+// Tax is computed per category and pricing, not per line item, and rounded half to even in minor units.
+func ComputeBillTax(currencyCode CurrencyCode, lineItems []BillLineItem, getRate func(category TaxCategory) (TaxRate, error)) (BillTax, error) {
+	sums := make(map[taxGroup]*big.Int)
+	for _, lineItem := range lineItems {
+		if e := CheckCurrencyCodeCompatible(currencyCode, lineItem.Amount.CurrencyCode); e != nil {
+			return BillTax{}, e
+		}
+		group := taxGroup{lineItem.TaxCategory.OrStandard(), lineItem.TaxInclusive}
+		if sums[group] == nil {
+			sums[group] = new(big.Int)
+		}
+		sums[group].Add(sums[group], big.NewInt(lineItem.Amount.Number))
+	}
+	groups := make([]taxGroup, 0, len(sums))
+	for group := range sums {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].category != groups[j].category {
+			return groups[i].category < groups[j].category
+		}
+		return !groups[i].inclusive && groups[j].inclusive
+	})
+
+	tax := BillTax{Lines: make([]TaxLine, 0, len(groups))}
+	subtotal, taxTotal := new(big.Int), new(big.Int)
+	for _, group := range groups {
+		line, e := computeTaxLine(currencyCode, group, sums[group], getRate)
+		if e != nil {
+			return BillTax{}, e
+		}
+		subtotal.Add(subtotal, line.Taxable.BigInt())
+		taxTotal.Add(taxTotal, line.Tax.BigInt())
+		tax.Lines = append(tax.Lines, line)
+	}
+	tax.Subtotal = NewTotalAmountFromBigInt(subtotal, currencyCode)
+	tax.TaxTotal = NewTotalAmountFromBigInt(taxTotal, currencyCode)
+	tax.GrandTotal = NewTotalAmountFromBigInt(new(big.Int).Add(subtotal, taxTotal), currencyCode)
+	return tax, nil
+}
+
+func computeTaxLine(currencyCode CurrencyCode, group taxGroup, sum *big.Int, getRate func(category TaxCategory) (TaxRate, error)) (TaxLine, error) {
+	line := TaxLine{Category: group.category, Inclusive: group.inclusive, Rate: "0"}
+	if group.category != TaxCategoryExempt {
+		taxRate, e := getRate(group.category)
+		if e != nil {
+			return TaxLine{}, e
+		}
+		line.Rate = taxRate.Rate
+	}
+	rate, ok := parseRate(line.Rate)
+	if !ok || rate.Sign() < 0 {
+		return TaxLine{}, InvalidTaxRateError{line.Rate}
+	}
+	if group.inclusive {
+		// The tax is the part of the sum that is rate / (1 + rate)
+		rate.Quo(rate, new(big.Rat).Add(big.NewRat(1, 1), rate))
+	}
+	taxNumber := roundHalfEven(new(big.Rat).Mul(new(big.Rat).SetInt(sum), rate))
+	taxable := new(big.Int).Set(sum)
+	if group.inclusive {
+		taxable.Sub(taxable, taxNumber)
+	}
+	line.Taxable = NewTotalAmountFromBigInt(taxable, currencyCode)
+	line.Tax = NewTotalAmountFromBigInt(taxNumber, currencyCode)
+	return line, nil
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func taxRatesForTest(category TaxCategory) (TaxRate, error) {
+	rates := map[TaxCategory]string{TaxCategoryStandard: "0.18", "reduced": "0.05"}
+	return TaxRate{Jurisdiction: "GE", Category: category, Rate: rates[category]}, nil
+}
+
+func TestComputeBillTaxExclusiveAndInclusive(t *testing.T) {
+	// Arrange
+	lineItems := []BillLineItem{
+		{Description: "Matchbox", Amount: Amount{Number: 100, CurrencyCode: "USD"}},
+		{Description: "Candle", Amount: Amount{Number: 250, CurrencyCode: "USD"}, TaxCategory: TaxCategoryStandard},
+		{Description: "Book", Amount: Amount{Number: 105, CurrencyCode: "USD"}, TaxCategory: "reduced", TaxInclusive: true},
+		{Description: "Bread", Amount: Amount{Number: 300, CurrencyCode: "USD"}, TaxCategory: TaxCategoryExempt},
+	}
+
+	// Act
+	tax, e := ComputeBillTax("USD", lineItems, taxRatesForTest)
+
+	// Assert
+	assert.NoError(t, e)
+	assert.Equal(t, BillTax{
+		Lines: []TaxLine{
+			{Category: TaxCategoryExempt, Rate: "0", Taxable: TotalAmount{Number: "300", CurrencyCode: "USD"}, Tax: TotalAmount{Number: "0", CurrencyCode: "USD"}},
+			{Category: "reduced", Inclusive: true, Rate: "0.05", Taxable: TotalAmount{Number: "100", CurrencyCode: "USD"}, Tax: TotalAmount{Number: "5", CurrencyCode: "USD"}},
+			{Category: TaxCategoryStandard, Rate: "0.18", Taxable: TotalAmount{Number: "350", CurrencyCode: "USD"}, Tax: TotalAmount{Number: "63", CurrencyCode: "USD"}},
+		},
+		Subtotal:   TotalAmount{Number: "750", CurrencyCode: "USD"},
+		TaxTotal:   TotalAmount{Number: "68", CurrencyCode: "USD"},
+		GrandTotal: TotalAmount{Number: "818", CurrencyCode: "USD"},
+	}, tax)
+}
+
+func TestComputeBillTaxRoundsHalfToEven(t *testing.T) {
+	// Arrange
+	getRate := func(category TaxCategory) (TaxRate, error) {
+		return TaxRate{Category: category, Rate: "0.1"}, nil
+	}
+
+	// Act
+	taxDown, e1 := ComputeBillTax("USD", []BillLineItem{{Amount: Amount{Number: 25, CurrencyCode: "USD"}}}, getRate)
+	taxUp, e2 := ComputeBillTax("USD", []BillLineItem{{Amount: Amount{Number: 35, CurrencyCode: "USD"}}}, getRate)
+
+	// Assert
+	assert.NoError(t, e1)
+	assert.NoError(t, e2)
+	assert.Equal(t, "2", taxDown.TaxTotal.Number)
+	assert.Equal(t, "4", taxUp.TaxTotal.Number)
+}
+
+func TestComputeBillTaxBeyondInt64(t *testing.T) {
+	// Arrange
+	lineItems := []BillLineItem{
+		{Amount: Amount{Number: math.MaxInt64, CurrencyCode: "USD"}},
+		{Amount: Amount{Number: math.MaxInt64, CurrencyCode: "USD"}},
+	}
+
+	// Act
+	tax, e := ComputeBillTax("USD", lineItems, taxRatesForTest)
+
+	// Assert
+	assert.NoError(t, e)
+	assert.Equal(t, TotalAmount{Number: "18446744073709551614", CurrencyCode: "USD"}, tax.Subtotal)
+	assert.Equal(t, TotalAmount{Number: "3320413933267719291", CurrencyCode: "USD"}, tax.TaxTotal)
+	assert.Equal(t, TotalAmount{Number: "21767158006977270905", CurrencyCode: "USD"}, tax.GrandTotal)
+}
+
+func TestComputeBillTaxErrors(t *testing.T) {
+	// Arrange
+	usd := []BillLineItem{{Amount: Amount{Number: 100, CurrencyCode: "USD"}}}
+	gel := []BillLineItem{{Amount: Amount{Number: 100, CurrencyCode: "GEL"}}}
+	badRate := func(category TaxCategory) (TaxRate, error) {
+		return TaxRate{Category: category, Rate: "-0.1"}, nil
+	}
+
+	// Act
+	_, eCurrency := ComputeBillTax("USD", gel, taxRatesForTest)
+	_, eRate := ComputeBillTax("USD", usd, badRate)
+
+	// Assert
+	assert.ErrorIs(t, eCurrency, IncompatibleCurrencyCodesError{"USD", "GEL"})
+	assert.ErrorIs(t, eRate, InvalidTaxRateError{"-0.1"})
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+type InvalidUsageEventError struct {
+	Id     string
+	Reason string
+}
+
+func (e InvalidUsageEventError) Error() string {
+	return fmt.Sprintf("invalid usage event %q: %s", e.Id, e.Reason)
+}
+
+// Emitted by our services, such as a number of API calls or of stored bytes.
+type UsageEvent struct {
+	// Given by the emitter and unique per customer, an event sent again is ignored.
+	Id     string
+	BillId BillId
+	Meter  string
+	// Decimal strings like those of a priced line item. UnitPrice is in major units of the bill currency.
+	Quantity   string
+	UnitPrice  string
+	RecordedAt time.Time
+}
+
+func (e UsageEvent) Check() error {
+	if e.Id == "" {
+		return InvalidUsageEventError{e.Id, "the id is empty"}
+	} else if e.Meter == "" {
+		return InvalidUsageEventError{e.Id, "the meter is empty"}
+	}
+	quantity, ok, err := parseDecimal(e.Quantity)
+	if err != nil {
+		return err
+	} else if !ok || quantity.Sign() < 0 {
+		return InvalidQuantityError{e.Quantity}
+	}
+	if _, ok, err = parseDecimal(e.UnitPrice); err != nil {
+		return err
+	} else if !ok {
+		return InvalidUnitPriceError{e.UnitPrice}
+	}
+	return nil
+}
+
+// The usage of a bill for one meter at one unit price.
+type UsageAggregate struct {
+	Meter string
+	// Decimal strings without trailing zeros, so that equal prices are aggregated together.
+	Quantity   string
+	UnitPrice  string
+	EventCount uint64
+}
+
+func NewUsageAggregate(meter string, quantity string, unitPrice string, eventCount uint64) (UsageAggregate, error) {
+	normalizedQuantity, err := normalizeDecimal(quantity)
+	if err != nil {
+		return UsageAggregate{}, InvalidQuantityError{quantity}
+	}
+	normalizedUnitPrice, err := normalizeDecimal(unitPrice)
+	if err != nil {
+		return UsageAggregate{}, InvalidUnitPriceError{unitPrice}
+	}
+	return UsageAggregate{Meter: meter, Quantity: normalizedQuantity, UnitPrice: normalizedUnitPrice, EventCount: eventCount}, nil
+}
+
+// Such as "1.50" into "1.5". Sums of decimals never have more places than their terms.
+func normalizeDecimal(s string) (string, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return "", InvalidNumberError{s}
+	}
+	normalized := strings.TrimRight(r.FloatString(MaxPricingDecimals), "0")
+	return strings.TrimSuffix(normalized, "."), nil
+}
+
+// Sums the events per meter and unit price, sorted by meter then unit price. The events must be valid.
+func AggregateUsage(events []UsageEvent) ([]UsageAggregate, error) {
+	type key struct {
+		meter     string
+		unitPrice string
+	}
+	quantities := make(map[key]*big.Rat)
+	counts := make(map[key]uint64)
+	for _, event := range events {
+		unitPrice, err := normalizeDecimal(event.UnitPrice)
+		if err != nil {
+			return nil, InvalidUnitPriceError{event.UnitPrice}
+		}
+		quantity, ok := new(big.Rat).SetString(event.Quantity)
+		if !ok {
+			return nil, InvalidQuantityError{event.Quantity}
+		}
+		k := key{event.Meter, unitPrice}
+		if _, ok := quantities[k]; !ok {
+			quantities[k] = new(big.Rat)
+		}
+		quantities[k].Add(quantities[k], quantity)
+		counts[k]++
+	}
+	aggregates := make([]UsageAggregate, 0, len(quantities))
+	for k, quantity := range quantities {
+		aggregate, err := NewUsageAggregate(k.meter, quantity.FloatString(MaxPricingDecimals), k.unitPrice, counts[k])
+		if err != nil {
+			return nil, err
+		}
+		aggregates = append(aggregates, aggregate)
+	}
+	SortUsageAggregates(aggregates)
+	return aggregates, nil
+}
+
+// By meter then unit price, compared as decimals.
+func SortUsageAggregates(aggregates []UsageAggregate) {
+	sort.Slice(aggregates, func(i, j int) bool {
+		if aggregates[i].Meter != aggregates[j].Meter {
+			return aggregates[i].Meter < aggregates[j].Meter
+		}
+		unitPriceI, _ := new(big.Rat).SetString(aggregates[i].UnitPrice)
+		unitPriceJ, _ := new(big.Rat).SetString(aggregates[j].UnitPrice)
+		return unitPriceI.Cmp(unitPriceJ) < 0
+	})
+}
+
+// The ids only depend on the meter and unit price, so that the line items are added once. A usage priced beyond an
+// Amount is split into line items of the amount only, like the ledger splits a total.
+func (a UsageAggregate) LineItems(billInfo BillInfo) ([]BillLineItem, error) {
+	lineItem := BillLineItem{
+		Id:          BillLineItemId{BillId: billInfo.Id, Id: fmt.Sprintf("usage-%s-%s", a.Meter, a.UnitPrice)},
+		Description: fmt.Sprintf("%s usage, %d events", a.Meter, a.EventCount),
+		Amount:      Amount{CurrencyCode: billInfo.CurrencyCode},
+		Quantity:    a.Quantity,
+		UnitPrice:   a.UnitPrice,
+	}
+	remaining, e := lineItem.computeAmount()
+	if e != nil {
+		return nil, e
+	}
+	if remaining.IsInt64() {
+		lineItem.Amount.Number = remaining.Int64()
+		return []BillLineItem{lineItem}, nil
+	}
+	chunk := big.NewInt(math.MaxInt64)
+	if remaining.Sign() < 0 {
+		chunk.Neg(chunk)
+	}
+	var amounts []int64
+	for remaining.CmpAbs(chunk) > 0 {
+		amounts = append(amounts, chunk.Int64())
+		remaining.Sub(remaining, chunk)
+	}
+	amounts = append(amounts, remaining.Int64())
+	lineItems := make([]BillLineItem, 0, len(amounts))
+	for i, amount := range amounts {
+		lineItems = append(lineItems, BillLineItem{
+			Id:          BillLineItemId{BillId: billInfo.Id, Id: fmt.Sprintf("%s-%d", lineItem.Id.Id, i+1)},
+			Description: fmt.Sprintf("%s, part %d of %d: %s at %s", lineItem.Description, i+1, len(amounts), a.Quantity, a.UnitPrice),
+			Amount:      Amount{Number: amount, CurrencyCode: billInfo.CurrencyCode},
+		})
+	}
+	return lineItems, nil
+}
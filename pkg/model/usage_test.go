@@ -0,0 +1,92 @@
+package model
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageEventCheck(t *testing.T) {
+	// Arrange
+	valid := UsageEvent{Id: "e1", Meter: "api_calls", Quantity: "3", UnitPrice: "0.001"}
+	noId := UsageEvent{Meter: "api_calls", Quantity: "3", UnitPrice: "0.001"}
+	noMeter := UsageEvent{Id: "e1", Quantity: "3", UnitPrice: "0.001"}
+	negative := UsageEvent{Id: "e1", Meter: "api_calls", Quantity: "-3", UnitPrice: "0.001"}
+	tooPrecise := UsageEvent{Id: "e1", Meter: "api_calls", Quantity: "3", UnitPrice: "0.0000000001"}
+
+	// Act nil
+
+	// Assert
+	assert.NoError(t, valid.Check())
+	assert.Error(t, noId.Check())
+	assert.Error(t, noMeter.Check())
+	assert.Equal(t, InvalidQuantityError{"-3"}, negative.Check())
+	assert.Equal(t, PrecisionError{"0.0000000001"}, tooPrecise.Check())
+}
+
+func TestAggregateUsage(t *testing.T) {
+	// Arrange
+	events := []UsageEvent{
+		{Id: "e1", Meter: "storage_bytes", Quantity: "1000", UnitPrice: "0.00001"},
+		{Id: "e2", Meter: "api_calls", Quantity: "2", UnitPrice: "0.010"},
+		{Id: "e3", Meter: "api_calls", Quantity: "1.5", UnitPrice: "0.01"},
+		{Id: "e4", Meter: "api_calls", Quantity: "4", UnitPrice: "0.002"},
+	}
+
+	// Act
+	aggregates, err := AggregateUsage(events)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []UsageAggregate{
+		{Meter: "api_calls", Quantity: "4", UnitPrice: "0.002", EventCount: 1},
+		{Meter: "api_calls", Quantity: "3.5", UnitPrice: "0.01", EventCount: 2},
+		{Meter: "storage_bytes", Quantity: "1000", UnitPrice: "0.00001", EventCount: 1},
+	}, aggregates)
+}
+
+func TestUsageAggregateLineItem(t *testing.T) {
+	// Arrange
+	billInfo := BillInfo{Id: BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}, CurrencyCode: "USD"}
+	aggregate, err := NewUsageAggregate("api_calls", "1234.000", "0.010", 7)
+	assert.NoError(t, err)
+
+	// Act
+	lineItems, err := aggregate.LineItems(billInfo)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []BillLineItem{{
+		Id:          BillLineItemId{BillId: billInfo.Id, Id: "usage-api_calls-0.01"},
+		Description: "api_calls usage, 7 events",
+		Amount:      Amount{Number: 1234, CurrencyCode: "USD"},
+		Quantity:    "1234",
+		UnitPrice:   "0.01",
+	}}, lineItems)
+}
+
+func TestUsageAggregateLineItemsBeyondInt64(t *testing.T) {
+	// Arrange
+	billInfo := BillInfo{Id: BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"}, CurrencyCode: "USD"}
+	aggregate, err := NewUsageAggregate("api_calls", "100000000", "1000000000", 2)
+	assert.NoError(t, err)
+
+	// Act
+	lineItems, err := aggregate.LineItems(billInfo)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []BillLineItem{
+		{
+			Id:          BillLineItemId{BillId: billInfo.Id, Id: "usage-api_calls-1000000000-1"},
+			Description: "api_calls usage, 2 events, part 1 of 2: 100000000 at 1000000000",
+			Amount:      Amount{Number: math.MaxInt64, CurrencyCode: "USD"},
+		},
+		{
+			Id:          BillLineItemId{BillId: billInfo.Id, Id: "usage-api_calls-1000000000-2"},
+			Description: "api_calls usage, 2 events, part 2 of 2: 100000000 at 1000000000",
+			Amount:      Amount{Number: 776627963145224193, CurrencyCode: "USD"},
+		},
+	}, lineItems)
+}
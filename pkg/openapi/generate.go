@@ -0,0 +1,577 @@
+package openapi
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const modulePath = "coding-challenge"
+
+// The package of the endpoints, relative to the module.
+const servicePackage = "pkg/rest"
+
+const bearerAuth = "bearerAuth"
+
+type UnsupportedTypeError struct {
+	Type string
+}
+
+func (e UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("type %s cannot be described", e.Type)
+}
+
+type InvalidEndpointError struct {
+	Name   string
+	Reason string
+}
+
+func (e InvalidEndpointError) Error() string {
+	return fmt.Sprintf("endpoint %s: %s", e.Name, e.Reason)
+}
+
+type typeDecl struct {
+	spec *ast.TypeSpec
+	// Of the file declaring the type, by import name.
+	imports map[string]string
+}
+
+type parsedPackage struct {
+	path  string
+	files []*ast.File
+	types map[string]typeDecl
+	// The types with their own JSON encoding, which are all strings.
+	marshalers map[string]bool
+}
+
+type generator struct {
+	moduleDir string
+	fset      *token.FileSet
+	packages  map[string]*parsedPackage
+	schemas   map[string]*Schema
+	// The package of each schema, since their names must be unique.
+	schemaPackages map[string]string
+}
+
+// Describes the endpoints of the billing service from its Go sources, and the types they use from their packages.
+func Generate(moduleDir string) (*Document, error) {
+	g := &generator{
+		moduleDir:      moduleDir,
+		fset:           token.NewFileSet(),
+		packages:       make(map[string]*parsedPackage),
+		schemas:        map[string]*Schema{"Error": errorSchema()},
+		schemaPackages: map[string]string{"Error": ""},
+	}
+	service, err := g.load(modulePath + "/" + servicePackage)
+	if err != nil {
+		return nil, err
+	}
+	document := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: "Billing API", Version: Version},
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas:         g.schemas,
+			SecuritySchemes: map[string]SecurityScheme{bearerAuth: {Type: "http", Scheme: "bearer"}},
+		},
+	}
+	for _, file := range service.files {
+		imports := fileImports(file)
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Doc == nil {
+				continue
+			}
+			directive, ok := findDirective(funcDecl.Doc)
+			if !ok || directive.access == "private" {
+				continue
+			}
+			operation, err := g.operation(service, imports, funcDecl, directive)
+			if err != nil {
+				return nil, err
+			}
+			path := openApiPath(directive.path)
+			if document.Paths[path] == nil {
+				document.Paths[path] = make(PathItem)
+			}
+			document.Paths[path][strings.ToLower(directive.method)] = operation
+		}
+	}
+	return document, nil
+}
+
+// The error body of every endpoint.
+func errorSchema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":    {Type: "string", Description: "Such as not_found or invalid_argument."},
+			"message": {Type: "string"},
+			"details": {Type: "object"},
+		},
+	}
+}
+
+// Parses the package once, without its tests.
+func (g *generator) load(importPath string) (*parsedPackage, error) {
+	if pkg, ok := g.packages[importPath]; ok {
+		return pkg, nil
+	}
+	dir := filepath.Join(g.moduleDir, filepath.FromSlash(strings.TrimPrefix(importPath, modulePath+"/")))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &parsedPackage{path: importPath, types: make(map[string]typeDecl), marshalers: make(map[string]bool)}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(g.fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg.files = append(pkg.files, file)
+		imports := fileImports(file)
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+						pkg.types[typeSpec.Name.Name] = typeDecl{spec: typeSpec, imports: imports}
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv != nil && (decl.Name.Name == "MarshalJSON" || decl.Name.Name == "MarshalText") {
+					pkg.marshalers[receiverName(decl.Recv.List[0].Type)] = true
+				}
+			}
+		}
+	}
+	g.packages[importPath] = pkg
+	return pkg, nil
+}
+
+func receiverName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = path
+	}
+	return imports
+}
+
+type directive struct {
+	access string // public, auth or private
+	raw    bool
+	method string
+	path   string
+}
+
+func findDirective(doc *ast.CommentGroup) (directive, bool) {
+	for _, comment := range doc.List {
+		fields := strings.Fields(strings.TrimPrefix(comment.Text, "//"))
+		if len(fields) == 0 || fields[0] != "encore:api" {
+			continue
+		}
+		d := directive{access: "private", method: "POST"}
+		for _, field := range fields[1:] {
+			switch {
+			case field == "public" || field == "auth" || field == "private":
+				d.access = field
+			case field == "raw":
+				d.raw = true
+			case strings.HasPrefix(field, "method="):
+				d.method = strings.TrimPrefix(field, "method=")
+			case strings.HasPrefix(field, "path="):
+				d.path = strings.TrimPrefix(field, "path=")
+			}
+		}
+		return d, true
+	}
+	return directive{}, false
+}
+
+// The doc comment without the directive.
+func description(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	var lines []string
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "encore:") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+func pathParameterNames(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
+
+// From /bill/:id to /bill/{id}.
+func openApiPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (g *generator) operation(pkg *parsedPackage, imports map[string]string, funcDecl *ast.FuncDecl, d directive) (*Operation, error) {
+	name := funcDecl.Name.Name
+	operation := &Operation{
+		OperationId: name,
+		Description: description(funcDecl.Doc),
+		Responses:   map[string]Response{"default": {Description: "An error", Content: jsonContent(refSchema("Error"))}},
+	}
+	if d.access == "auth" {
+		operation.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+	if d.raw {
+		for _, parameterName := range pathParameterNames(d.path) {
+			operation.Parameters = append(operation.Parameters, Parameter{Name: parameterName, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+		operation.Responses["200"] = Response{Description: "The raw response, see the description"}
+		return operation, nil
+	}
+
+	var params []*ast.Field
+	for _, field := range funcDecl.Type.Params.List {
+		for range max(1, len(field.Names)) {
+			params = append(params, field)
+		}
+	}
+	pathNames := pathParameterNames(d.path)
+	if len(params) < 1+len(pathNames) || 2+len(pathNames) < len(params) {
+		return nil, InvalidEndpointError{name, "the parameters do not match the path"}
+	}
+	for i, parameterName := range pathNames {
+		schema, err := g.schema(pkg, imports, params[1+i].Type)
+		if err != nil {
+			return nil, err
+		}
+		operation.Parameters = append(operation.Parameters, Parameter{Name: parameterName, In: "path", Required: true, Schema: schema})
+	}
+	if len(params) == 2+len(pathNames) {
+		if err := g.request(pkg, imports, params[len(params)-1].Type, d.method, operation); err != nil {
+			return nil, InvalidEndpointError{name, err.Error()}
+		}
+	}
+
+	results := funcDecl.Type.Results
+	if results == nil || len(results.List) == 0 {
+		return nil, InvalidEndpointError{name, "no error is returned"}
+	}
+	response := Response{Description: "OK"}
+	if len(results.List) == 2 {
+		schema, err := g.schema(pkg, imports, results.List[0].Type)
+		if err != nil {
+			return nil, err
+		}
+		response.Content = jsonContent(schema)
+		if response.Headers, err = g.responseHeaders(pkg, results.List[0].Type); err != nil {
+			return nil, InvalidEndpointError{name, err.Error()}
+		}
+	}
+	operation.Responses["200"] = response
+	return operation, nil
+}
+
+// The header fields of the response, which are left out of its body.
+func (g *generator) responseHeaders(pkg *parsedPackage, expr ast.Expr) (map[string]Header, error) {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return nil, fmt.Errorf("the response is not a pointer")
+	}
+	ident, ok := star.X.(*ast.Ident)
+	if !ok {
+		return nil, nil
+	}
+	decl, ok := pkg.types[ident.Name]
+	if !ok {
+		return nil, fmt.Errorf("the response %s is not declared", ident.Name)
+	}
+	structType, ok := decl.spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, nil
+	}
+	fields, err := structFields(pkg, ident.Name, decl, structType)
+	if err != nil {
+		return nil, err
+	}
+	var headers map[string]Header
+	for _, field := range fields {
+		header, ok := fieldTag(field.field).Lookup("header")
+		if !ok {
+			continue
+		}
+		schema, err := g.schema(pkg, field.decl.imports, field.field.Type)
+		if err != nil {
+			return nil, err
+		}
+		if headers == nil {
+			headers = make(map[string]Header)
+		}
+		headers[header] = Header{Description: description(fieldDoc(field.field)), Schema: schema}
+	}
+	return headers, nil
+}
+
+// The header and query fields are parameters, the other fields are the body, or the query of the methods without body.
+func (g *generator) request(pkg *parsedPackage, imports map[string]string, expr ast.Expr, method string, operation *Operation) error {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return fmt.Errorf("the request is not a pointer")
+	}
+	ident, ok := star.X.(*ast.Ident)
+	if !ok {
+		return fmt.Errorf("the request is not declared next to the endpoint")
+	}
+	decl, ok := pkg.types[ident.Name]
+	if !ok {
+		return fmt.Errorf("the request %s is not declared", ident.Name)
+	}
+	structType, ok := decl.spec.Type.(*ast.StructType)
+	if !ok {
+		return fmt.Errorf("the request %s is not a struct", ident.Name)
+	}
+	withBody := method != "GET" && method != "HEAD" && method != "DELETE"
+	hasBody := false
+	for _, field := range structType.Fields.List {
+		tag := fieldTag(field)
+		for _, fieldName := range field.Names {
+			if !fieldName.IsExported() {
+				continue
+			}
+			in, parameterName := "", ""
+			if header, ok := tag.Lookup("header"); ok {
+				in, parameterName = "header", header
+			} else if query, ok := tag.Lookup("query"); ok {
+				in, parameterName = "query", query
+			} else if !withBody {
+				in, parameterName = "query", jsonName(fieldName.Name, tag)
+			} else {
+				hasBody = true
+				continue
+			}
+			schema, err := g.schema(pkg, decl.imports, field.Type)
+			if err != nil {
+				return err
+			}
+			operation.Parameters = append(operation.Parameters, Parameter{Name: parameterName, In: in, Description: description(fieldDoc(field)), Schema: schema})
+		}
+	}
+	if hasBody {
+		schema, err := g.schema(pkg, imports, star.X)
+		if err != nil {
+			return err
+		}
+		operation.RequestBody = &RequestBody{Required: true, Content: jsonContent(schema)}
+	}
+	return nil
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// A field of a struct, with the declaration of the struct it belongs to, an embedded one for its promoted fields.
+type structField struct {
+	field *ast.Field
+	decl  typeDecl
+}
+
+// The fields of the struct, with the fields of its embedded structs in place of them, as encoding/json does. Only the
+// structs of the same package, without tag, can be embedded.
+func structFields(pkg *parsedPackage, name string, decl typeDecl, structType *ast.StructType) ([]structField, error) {
+	var fields []structField
+	for _, field := range structType.Fields.List {
+		if len(field.Names) != 0 {
+			fields = append(fields, structField{field: field, decl: decl})
+			continue
+		}
+		unsupported := UnsupportedTypeError{fmt.Sprintf("%s.%s with an embedded field", pkg.path, name)}
+		ident, ok := field.Type.(*ast.Ident)
+		if !ok || field.Tag != nil {
+			return nil, unsupported
+		}
+		embeddedDecl, ok := pkg.types[ident.Name]
+		if !ok {
+			return nil, unsupported
+		}
+		embeddedStruct, ok := embeddedDecl.spec.Type.(*ast.StructType)
+		if !ok {
+			return nil, unsupported
+		}
+		embedded, err := structFields(pkg, ident.Name, embeddedDecl, embeddedStruct)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, embedded...)
+	}
+	return fields, nil
+}
+
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, _ := strconv.Unquote(field.Tag.Value)
+	return reflect.StructTag(tag)
+}
+
+func fieldDoc(field *ast.Field) *ast.CommentGroup {
+	if field.Doc != nil {
+		return field.Doc
+	}
+	return field.Comment
+}
+
+func jsonName(fieldName string, tag reflect.StructTag) string {
+	if name, _, _ := strings.Cut(tag.Get("json"), ","); name != "" {
+		return name
+	}
+	return fieldName
+}
+
+var basicSchemas = map[string]Schema{
+	"string":  {Type: "string"},
+	"bool":    {Type: "boolean"},
+	"int":     {Type: "integer", Format: "int64"},
+	"int8":    {Type: "integer", Format: "int32"},
+	"int16":   {Type: "integer", Format: "int32"},
+	"int32":   {Type: "integer", Format: "int32"},
+	"int64":   {Type: "integer", Format: "int64"},
+	"uint":    {Type: "integer", Format: "int64"},
+	"uint8":   {Type: "integer", Format: "int32"},
+	"uint16":  {Type: "integer", Format: "int32"},
+	"uint32":  {Type: "integer", Format: "int32"},
+	"uint64":  {Type: "integer", Format: "int64"},
+	"float32": {Type: "number", Format: "float"},
+	"float64": {Type: "number", Format: "double"},
+}
+
+func (g *generator) schema(pkg *parsedPackage, imports map[string]string, expr ast.Expr) (*Schema, error) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if basic, ok := basicSchemas[expr.Name]; ok {
+			return &basic, nil
+		}
+		return g.namedSchema(pkg, expr.Name)
+	case *ast.SelectorExpr:
+		packageName, ok := expr.X.(*ast.Ident)
+		if !ok {
+			return nil, UnsupportedTypeError{fmt.Sprintf("%T", expr.X)}
+		}
+		importPath := imports[packageName.Name]
+		switch {
+		case importPath == "time" && expr.Sel.Name == "Time":
+			return &Schema{Type: "string", Format: "date-time"}, nil
+		case importPath == "time" && expr.Sel.Name == "Duration":
+			return &Schema{Type: "integer", Format: "int64", Description: "In nanoseconds."}, nil
+		case strings.HasPrefix(importPath, modulePath+"/"):
+			imported, err := g.load(importPath)
+			if err != nil {
+				return nil, err
+			}
+			return g.namedSchema(imported, expr.Sel.Name)
+		}
+		return nil, UnsupportedTypeError{importPath + "." + expr.Sel.Name}
+	case *ast.StarExpr:
+		return g.schema(pkg, imports, expr.X)
+	case *ast.ArrayType:
+		if ident, ok := expr.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := g.schema(pkg, imports, expr.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	}
+	return nil, UnsupportedTypeError{fmt.Sprintf("%T", expr)}
+}
+
+// Structs are components named after their type, the other types are described in place.
+func (g *generator) namedSchema(pkg *parsedPackage, name string) (*Schema, error) {
+	if pkg.marshalers[name] {
+		return &Schema{Type: "string"}, nil
+	}
+	decl, ok := pkg.types[name]
+	if !ok {
+		return nil, UnsupportedTypeError{pkg.path + "." + name}
+	}
+	structType, ok := decl.spec.Type.(*ast.StructType)
+	if !ok {
+		return g.schema(pkg, decl.imports, decl.spec.Type)
+	}
+	if schemaPackage, ok := g.schemaPackages[name]; ok {
+		if schemaPackage != pkg.path {
+			return nil, UnsupportedTypeError{fmt.Sprintf("%s.%s, named like a type of %s", pkg.path, name, schemaPackage)}
+		}
+		return refSchema(name), nil
+	}
+	schema := &Schema{Type: "object", Description: description(decl.spec.Doc), Properties: make(map[string]*Schema)}
+	// Registered before its fields, which may refer to it
+	g.schemas[name] = schema
+	g.schemaPackages[name] = pkg.path
+	fields, err := structFields(pkg, name, decl, structType)
+	if err != nil {
+		return nil, err
+	}
+	for _, embedded := range fields {
+		field := embedded.field
+		tag := fieldTag(field)
+		if _, ok := tag.Lookup("header"); ok {
+			continue
+		} else if _, ok := tag.Lookup("query"); ok {
+			continue
+		}
+		for _, fieldName := range field.Names {
+			propertyName := jsonName(fieldName.Name, tag)
+			if !fieldName.IsExported() || propertyName == "-" {
+				continue
+			}
+			property, err := g.schema(pkg, embedded.decl.imports, field.Type)
+			if err != nil {
+				return nil, err
+			}
+			if property.Ref == "" {
+				property.Description = strings.TrimSpace(strings.Join([]string{property.Description, description(fieldDoc(field))}, " "))
+			}
+			schema.Properties[propertyName] = property
+		}
+	}
+	return refSchema(name), nil
+}
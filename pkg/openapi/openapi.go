@@ -0,0 +1,131 @@
+package openapi
+
+import "encoding/json"
+
+//go:generate go run ../../cmd/openapi -module ../.. -out ../../openapi.json
+
+// Of the billing API, to increase with each change of the endpoints along with client.Version.
+const Version = "1.7.0"
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// The subset of OpenAPI 3 that the endpoints need.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// By lower case HTTP method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationId string                `json:"operationId"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path, query or header
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+}
+
+func refSchema(name string) *Schema {
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+// The name of the component the schema refers to, empty unless it is a reference.
+func (s *Schema) RefName() string {
+	if len(s.Ref) <= len(schemaRefPrefix) {
+		return ""
+	}
+	return s.Ref[len(schemaRefPrefix):]
+}
+
+// The operation of the id with its method and path, nil when there is none.
+func (d *Document) FindOperation(operationId string) (method string, path string, operation *Operation) {
+	for path, pathItem := range d.Paths {
+		for method, operation := range pathItem {
+			if operation.OperationId == operationId {
+				return method, path, operation
+			}
+		}
+	}
+	return "", "", nil
+}
+
+// The component the schema refers to, or the schema itself.
+func (d *Document) Resolve(schema *Schema) *Schema {
+	if name := schema.RefName(); name != "" {
+		return d.Components.Schemas[name]
+	}
+	return schema
+}
+
+// Indented, with the keys sorted so that the document only changes with the endpoints.
+func (d *Document) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func Unmarshal(data []byte) (*Document, error) {
+	var document Document
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
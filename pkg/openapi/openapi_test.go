@@ -0,0 +1,86 @@
+package openapi_test
+
+import (
+	"bytes"
+	"coding-challenge/pkg/openapi"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const moduleDir = "../.."
+
+func TestDocumentMatchesEndpoints(t *testing.T) {
+	// Arrange
+	committed, err := os.ReadFile(moduleDir + "/openapi.json")
+	require.NoError(t, err)
+
+	// Act
+	document, err := openapi.Generate(moduleDir)
+	require.NoError(t, err)
+	generated, err := document.Marshal()
+	require.NoError(t, err)
+
+	// Assert
+	assert.True(t, bytes.Equal(committed, generated), "openapi.json is stale, run go generate ./pkg/openapi")
+}
+
+func TestDocumentDescribesEndpoint(t *testing.T) {
+	// Arrange
+	document, err := openapi.Generate(moduleDir)
+	require.NoError(t, err)
+
+	// Act
+	method, path, operation := document.FindOperation("AddBillLineItem")
+
+	// Assert
+	require.NotNil(t, operation)
+	assert.Equal(t, "post", method)
+	assert.Equal(t, "/bill/{id}/line-items", path)
+	assert.Equal(t, []openapi.Parameter{
+		{Name: "id", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}},
+		{Name: "Idempotency-Key", In: "header", Description: "Optional, a request sent again with the same key adds the line item once.", Schema: &openapi.Schema{Type: "string"}},
+	}, operation.Parameters)
+	assert.Equal(t, []map[string][]string{{"bearerAuth": {}}}, operation.Security)
+	request := document.Resolve(operation.RequestBody.Content["application/json"].Schema)
+	assert.Contains(t, request.Properties, "currency_code")
+	assert.NotContains(t, request.Properties, "IdempotencyKey")
+	response := document.Resolve(operation.Responses["200"].Content["application/json"].Schema)
+	assert.Equal(t, &openapi.Schema{Type: "integer", Format: "int64"}, response.Properties["line_item_count"])
+	assert.NotContains(t, response.Properties, "RateLimitRemaining")
+	assert.Contains(t, operation.Responses["200"].Headers, "RateLimit-Remaining")
+}
+
+var snakeCase = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// Clients write the requests by hand, where a misspelled property is silently ignored.
+func TestRequestPropertiesAreSnakeCase(t *testing.T) {
+	// Arrange
+	document, err := openapi.Generate(moduleDir)
+	require.NoError(t, err)
+
+	// Act
+	var check func(operationId string, schema *openapi.Schema)
+	check = func(operationId string, schema *openapi.Schema) {
+		schema = document.Resolve(schema)
+		if schema.Items != nil {
+			check(operationId, schema.Items)
+		}
+		for name, property := range schema.Properties {
+			assert.Regexp(t, snakeCase, name, "in the request of %s", operationId)
+			check(operationId, property)
+		}
+	}
+
+	// Assert
+	for _, pathItem := range document.Paths {
+		for _, operation := range pathItem {
+			if operation.RequestBody != nil {
+				check(operation.OperationId, operation.RequestBody.Content["application/json"].Schema)
+			}
+		}
+	}
+}
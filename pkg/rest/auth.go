@@ -1,27 +1,40 @@
 package rest
 
 import (
+	"coding-challenge/pkg/db"
 	"coding-challenge/pkg/model"
 	"context"
+	"errors"
 
 	"encore.dev/beta/auth"
 	"encore.dev/beta/errs"
 	"encore.dev/rlog"
 )
 
-type AuthData struct{}
+// The auth.UID is the user id.
+type AuthData struct {
+	OrgId string
+	Role  model.Role
+	// Empty when the token is not an API key
+	ApiKeyId string
+}
 
 //encore:authhandler
 func (s *BillingService) AuthHandler(ctx context.Context, token string) (auth.UID, *AuthData, error) {
-	sessionInfo, err := s.tokenDb.VerifyToken(ctx, token)
+	sessionInfo, err := s.verifySession(ctx, token)
 	if err != nil {
-		return "", nil, errs.WrapCode(err, errs.Unauthenticated, "invalid token")
+		return "", nil, err
 	}
-	return auth.UID(sessionInfo.CustomerId), &AuthData{}, nil
+	return auth.UID(sessionInfo.UserId), &AuthData{OrgId: sessionInfo.OrgId, Role: sessionInfo.Role, ApiKeyId: sessionInfo.ApiKeyId}, nil
 }
 
 type SessionInfo struct {
-	CustomerId string
+	UserId string
+	// The organization the user signed in to, the customer its bills are keyed by.
+	OrgId string
+	// Of the membership of the user in the organization, not set by the TokenDb.
+	Role     model.Role
+	ApiKeyId string
 }
 
 type TokenDb interface {
@@ -33,18 +46,82 @@ func TokenDbFactory(_ string) (TokenDb, error) {
 	return CreateFakeDummyTokenDb(), nil
 }
 
-func getAuthenticatedCustomerId() (*model.CustomerId, error) {
-	// // Use this hack while encore does not return UID when unit testing auth end points.
-	// customerId := model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633")
-	// return &customerId, nil
+// The role is read on each request, so that a changed role applies to the tokens already given.
+func (s *BillingService) verifySession(ctx context.Context, token string) (SessionInfo, error) {
+	sessionInfo, err := s.tokenDb.VerifyToken(ctx, token)
+	if err != nil {
+		return SessionInfo{}, errs.WrapCode(err, errs.Unauthenticated, "invalid token")
+	}
+	membership, err := s.orgDb.GetMembership(ctx, model.CustomerId(sessionInfo.OrgId), model.UserId(sessionInfo.UserId))
+	if errors.Is(err, db.ErrMembershipNotFound) {
+		return SessionInfo{}, errs.WrapCode(err, errs.PermissionDenied, "not a member of the organization")
+	} else if err != nil {
+		rlog.Error("failed to get membership", "orgId", sessionInfo.OrgId, "userId", sessionInfo.UserId, "err", err)
+		return SessionInfo{}, errs.WrapCode(err, errs.Internal, "failed to get membership")
+	}
+	sessionInfo.Role = membership.Role
+	return sessionInfo, nil
+}
+
+// The session of a caller authenticated outside of encore, such as a gRPC caller.
+type sessionKey struct{}
+
+// Authenticates the token as the auth handler does, for the callers that do not go through encore.
+func (s *BillingService) authenticate(ctx context.Context, token string) (context.Context, error) {
+	sessionInfo, err := s.verifySession(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, sessionKey{}, sessionInfo), nil
+}
+
+func getAuthenticatedSession(ctx context.Context) (SessionInfo, error) {
+	if sessionInfo, ok := ctx.Value(sessionKey{}).(SessionInfo); ok {
+		return sessionInfo, nil
+	}
 	authId, ok := auth.UserID()
-	if !ok {
+	authData, dataOk := auth.Data().(*AuthData)
+	if !ok || !dataOk {
 		rlog.Error("failed to get user id", ok)
-		return nil, &errs.Error{
+		return SessionInfo{}, &errs.Error{
 			Code:    errs.Unauthenticated,
 			Message: "failed to get user id",
 		}
 	}
-	customerId := model.CustomerId(authId)
+	return SessionInfo{UserId: string(authId), OrgId: authData.OrgId, Role: authData.Role, ApiKeyId: authData.ApiKeyId}, nil
+}
+
+// The organization of the caller, whatever its role.
+func getAuthenticatedCustomerId(ctx context.Context) (*model.CustomerId, error) {
+	sessionInfo, err := getAuthenticatedSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	customerId := model.CustomerId(sessionInfo.OrgId)
+	return &customerId, nil
+}
+
+// The organization of the caller, when its role has the permission.
+func authorize(ctx context.Context, permission model.Permission) (*model.CustomerId, error) {
+	sessionInfo, err := getAuthenticatedSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := sessionInfo.Role.Check(permission); err != nil {
+		rlog.Warn("permission denied", "userId", sessionInfo.UserId, "orgId", sessionInfo.OrgId, "err", err)
+		return nil, errs.WrapCode(err, errs.PermissionDenied, "permission denied")
+	}
+	customerId := model.CustomerId(sessionInfo.OrgId)
 	return &customerId, nil
 }
+
+func getAuthenticatedActor(ctx context.Context, customerId model.CustomerId) model.Actor {
+	sessionInfo, err := getAuthenticatedSession(ctx)
+	if err != nil {
+		return model.NewCustomerActor(customerId)
+	}
+	if sessionInfo.ApiKeyId != "" {
+		return model.NewApiKeyActor(sessionInfo.ApiKeyId)
+	}
+	return model.NewUserActor(model.UserId(sessionInfo.UserId))
+}
@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"coding-challenge/pkg/model"
 	"context"
 
 	"encore.dev/beta/errs"
@@ -21,9 +22,28 @@ func (d *DummyTokenDb) VerifyToken(ctx context.Context, token string) (SessionIn
 
 func (d *DummyTokenDb) Close(ctx context.Context) {}
 
+const (
+	dummyAliceOrgId = "aec31fe6-04b5-4dbf-a024-b5f45db6f633"
+	dummyBobOrgId   = "b59c18af-50be-4f4d-91ad-b25c9c9d0581"
+)
+
+// Alice owns her organization, Dave adds its line items, also with an API key, and Carol audits it.
 func CreateFakeDummyTokenDb() *DummyTokenDb {
 	return &DummyTokenDb{Tokens: map[string]SessionInfo{
-		"token-alice": {CustomerId: "aec31fe6-04b5-4dbf-a024-b5f45db6f633"},
-		"token-bob":   {CustomerId: "b59c18af-50be-4f4d-91ad-b25c9c9d0581"},
+		"token-alice": {UserId: "0b6f3c1e-5a2d-4e8f-9c7b-1d3e5f7a9b2c", OrgId: dummyAliceOrgId},
+		"token-dave":  {UserId: "7e4d2a9c-3b1f-4c6e-8a5d-9f2b4c6e8a1d", OrgId: dummyAliceOrgId},
+		"key-dave":    {UserId: "7e4d2a9c-3b1f-4c6e-8a5d-9f2b4c6e8a1d", OrgId: dummyAliceOrgId, ApiKeyId: "5b2e8d4a-6c1f-4e3b-9a7d-2f4c6e8a1b3d"},
+		"token-carol": {UserId: "c2a8e6f4-1d3b-4f5a-b7c9-e1f3a5b7c9d2", OrgId: dummyAliceOrgId},
+		"token-bob":   {UserId: "4f1a7c3e-9d2b-4a6f-8e5c-3b7d9f1a5c8e", OrgId: dummyBobOrgId},
 	}}
 }
+
+// The memberships of the users of the dummy tokens, as saved by the migrations.
+func CreateFakeDummyMemberships() []model.Membership {
+	return []model.Membership{
+		{OrgId: dummyAliceOrgId, UserId: "0b6f3c1e-5a2d-4e8f-9c7b-1d3e5f7a9b2c", Role: model.RoleOwner},
+		{OrgId: dummyAliceOrgId, UserId: "7e4d2a9c-3b1f-4c6e-8a5d-9f2b4c6e8a1d", Role: model.RoleBillingAdmin},
+		{OrgId: dummyAliceOrgId, UserId: "c2a8e6f4-1d3b-4f5a-b7c9-e1f3a5b7c9d2", Role: model.RoleViewer},
+		{OrgId: dummyBobOrgId, UserId: "4f1a7c3e-9d2b-4a6f-8e5c-3b7d9f1a5c8e", Role: model.RoleOwner},
+	}
+}
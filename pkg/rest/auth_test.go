@@ -1,25 +1,56 @@
 package rest
 
 import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
 	"context"
 	"testing"
 
 	"encore.dev/beta/auth"
+	"encore.dev/beta/errs"
 	"github.com/stretchr/testify/assert"
 )
 
+func newDummyAuthService() BillingService {
+	return BillingService{tokenDb: CreateFakeDummyTokenDb(), orgDb: db.NewInMemoryOrganizationDatabase(CreateFakeDummyMemberships()...)}
+}
+
 func TestDummyAuthHandler_Alice(t *testing.T) {
-	s := BillingService{tokenDb: CreateFakeDummyTokenDb()}
+	s := newDummyAuthService()
 	uid, data, err := s.AuthHandler(context.Background(), "token-alice")
-	assert.Equal(t, auth.UID("aec31fe6-04b5-4dbf-a024-b5f45db6f633"), uid)
-	assert.Equal(t, &AuthData{}, data)
+	assert.Equal(t, auth.UID("0b6f3c1e-5a2d-4e8f-9c7b-1d3e5f7a9b2c"), uid)
+	assert.Equal(t, &AuthData{OrgId: "aec31fe6-04b5-4dbf-a024-b5f45db6f633", Role: model.RoleOwner}, data)
+	assert.NoError(t, err)
+}
+
+func TestDummyAuthHandler_Carol(t *testing.T) {
+	s := newDummyAuthService()
+	uid, data, err := s.AuthHandler(context.Background(), "token-carol")
+	assert.Equal(t, auth.UID("c2a8e6f4-1d3b-4f5a-b7c9-e1f3a5b7c9d2"), uid)
+	assert.Equal(t, &AuthData{OrgId: "aec31fe6-04b5-4dbf-a024-b5f45db6f633", Role: model.RoleViewer}, data)
+	assert.NoError(t, err)
+}
+
+func TestDummyAuthHandler_DaveApiKey(t *testing.T) {
+	s := newDummyAuthService()
+	uid, data, err := s.AuthHandler(context.Background(), "key-dave")
+	assert.Equal(t, auth.UID("7e4d2a9c-3b1f-4c6e-8a5d-9f2b4c6e8a1d"), uid)
+	assert.Equal(t, &AuthData{OrgId: "aec31fe6-04b5-4dbf-a024-b5f45db6f633", Role: model.RoleBillingAdmin, ApiKeyId: "5b2e8d4a-6c1f-4e3b-9a7d-2f4c6e8a1b3d"}, data)
 	assert.NoError(t, err)
 }
 
 func TestDummyAuthHandler_Fail(t *testing.T) {
-	s := BillingService{tokenDb: CreateFakeDummyTokenDb()}
+	s := newDummyAuthService()
 	uid, data, err := s.AuthHandler(context.Background(), "token-will")
 	assert.Equal(t, auth.UID(""), uid)
 	assert.Nil(t, data)
 	assert.Error(t, err)
 }
+
+func TestDummyAuthHandler_RemovedMember(t *testing.T) {
+	s := newDummyAuthService()
+	assert.NoError(t, s.orgDb.DeleteMembership(context.Background(), "aec31fe6-04b5-4dbf-a024-b5f45db6f633", "c2a8e6f4-1d3b-4f5a-b7c9-e1f3a5b7c9d2"))
+	_, data, err := s.AuthHandler(context.Background(), "token-carol")
+	assert.Nil(t, data)
+	assert.Equal(t, errs.PermissionDenied, errs.Code(err))
+}
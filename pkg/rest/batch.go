@@ -0,0 +1,131 @@
+package rest
+
+import (
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"context"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+	"go.temporal.io/sdk/client"
+)
+
+// Like AddBillLineItemRequest, with an optional id.
+type BatchLineItemRequest struct {
+	// A line item whose id was already added to the bill is not added again, so that a batch can be sent again.
+	// Generated when empty.
+	Id           string             `json:"id"`
+	Description  string             `json:"description"`
+	Amount       int64              `json:"amount"`
+	CurrencyCode model.CurrencyCode `json:"currency_code"`
+	TaxCategory  model.TaxCategory  `json:"tax_category"`
+	TaxInclusive bool               `json:"tax_inclusive"`
+	Quantity     string             `json:"quantity"`
+	UnitPrice    string             `json:"unit_price"`
+	Rounding     model.RoundingMode `json:"rounding"`
+}
+
+type AddBillLineItemsRequest struct {
+	// At most workflow.MaxBillLineItemsPerBatch.
+	LineItems []BatchLineItemRequest `json:"line_items"`
+	// When set, no line item is added unless all of them can be. Otherwise those that can are added.
+	AllOrNothing bool `json:"all_or_nothing"`
+}
+
+type BatchLineItemResult struct {
+	Id     string                       `json:"id"`
+	Status workflow.BillLineItemOutcome `json:"status"` // added, already_added or refused
+	Error  string                       `json:"error,omitempty"`
+}
+
+type AddBillLineItemsResponse struct {
+	Id            string             `json:"id"`
+	CurrencyCode  model.CurrencyCode `json:"currency_code"`
+	LineItemCount uint64             `json:"line_item_count"`
+	Total         string             `json:"total"` // Decimal string in minor units
+	// In the order of the line items.
+	Results []BatchLineItemResult `json:"results"`
+	RateLimitHeaders
+}
+
+// Adds the line items with one workflow update and one database transaction. The path is line-items/batch rather
+// than the custom method line-items:batch on purpose: a colon starts a path parameter in Encore's path syntax, so a
+// segment cannot hold one.
+//
+//encore:api auth method=POST path=/bill/:id/line-items/batch
+func (s *BillingService) AddBillLineItems(ctx context.Context, id string, addBillLineItemsRequest *AddBillLineItemsRequest) (*AddBillLineItemsResponse, error) {
+	customerId, err := authorize(ctx, model.PermissionManageBills)
+	if err != nil {
+		return nil, err
+	}
+	updateId := s.billIdGenerator.New()
+	billId := model.BillId{CustomerId: *customerId, Id: id}
+	lineItems := make([]model.BillLineItem, 0, len(addBillLineItemsRequest.LineItems))
+	for _, lineItemRequest := range addBillLineItemsRequest.LineItems {
+		lineItemId := lineItemRequest.Id
+		if lineItemId == "" {
+			lineItemId = s.billIdGenerator.New()
+		}
+		lineItems = append(lineItems, model.BillLineItem{
+			Id:          model.BillLineItemId{BillId: billId, Id: lineItemId},
+			Description: lineItemRequest.Description,
+			Amount: model.Amount{
+				CurrencyCode: lineItemRequest.CurrencyCode,
+				Number:       lineItemRequest.Amount,
+			},
+			TaxCategory:  lineItemRequest.TaxCategory,
+			TaxInclusive: lineItemRequest.TaxInclusive,
+			Quantity:     lineItemRequest.Quantity,
+			UnitPrice:    lineItemRequest.UnitPrice,
+			Rounding:     lineItemRequest.Rounding,
+		})
+	}
+	if err := s.checkLineItemTaxRates(ctx, billId, lineItems); err != nil {
+		return nil, err
+	}
+	options := client.UpdateWorkflowOptions{
+		UpdateID:   updateId,
+		WorkflowID: CreateWorkflowId(id),
+		UpdateName: workflow.AddBillLineItemsUpdate,
+		Args: []interface{}{
+			workflow.AddBillLineItemsArgs{
+				LineItems:    lineItems,
+				AllOrNothing: addBillLineItemsRequest.AllOrNothing,
+				Actor:        getAuthenticatedActor(ctx, *customerId),
+				RequestId:    updateId,
+			},
+		},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	}
+	updateHandle, err := s.client.UpdateWorkflow(ctx, options)
+	if err != nil {
+		rlog.Error("failed to add line items", "billId", id, "err", err)
+		if isBillNotOpenError(err) || isSpendingCapExceededError(err) {
+			return nil, errs.WrapCode(err, errs.FailedPrecondition, "failed to add line items")
+		} else if isLineItemQuotaExceededError(err) {
+			return nil, errs.WrapCode(err, errs.ResourceExhausted, "too many line items")
+		}
+		return nil, errs.WrapCode(err, errs.InvalidArgument, "invalid line items")
+	}
+	var result workflow.AddBillLineItemsResult
+	err = updateHandle.Get(ctx, &result)
+	if err != nil {
+		rlog.Error("failed to get updated workflow state", "billId", id, "err", err)
+		if isApplicationErrorOfType(err, "BatchLineItemError") {
+			return nil, errs.WrapCode(err, errs.InvalidArgument, "invalid line items")
+		}
+		return nil, errs.WrapCode(err, errs.Internal, "failed to get updated workflow state")
+	}
+	rlog.Info("added line items to workflow", "id", id, "count", len(lineItems))
+	results := make([]BatchLineItemResult, 0, len(result.Results))
+	for _, lineItemResult := range result.Results {
+		results = append(results, BatchLineItemResult{Id: lineItemResult.Id, Status: lineItemResult.Outcome, Error: lineItemResult.Error})
+	}
+	return &AddBillLineItemsResponse{
+		Id:            id,
+		CurrencyCode:  result.State.BillInfo.CurrencyCode,
+		LineItemCount: result.State.BillLineItemCount,
+		Total:         result.State.Total.Number,
+		Results:       results,
+	}, nil
+}
@@ -1,20 +1,31 @@
 package rest
 
 import (
+	"coding-challenge/pkg/activity"
 	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/gateway"
 	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/telemetry"
 	"coding-challenge/pkg/workflow"
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"os"
+	"slices"
 	"time"
 
 	"encore.dev"
 	"encore.dev/beta/errs"
 	"encore.dev/rlog"
 	"encore.dev/storage/sqldb"
+	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/worker"
+	"google.golang.org/grpc"
 )
 
 // Use an environment-specific task queue so we can use the same
@@ -24,8 +35,17 @@ var (
 	greetingTaskQueue = envName + "-billing"
 	tokenDbType       = envName + "-token-db"
 	BillDbType        = envName + "-bill-db"
+	archiveDir        = envOrDefault("BILL_ARCHIVE_DIR", db.LocalArchiveDirDefault)
+	grpcAddress       = envOrDefault("BILLING_GRPC_ADDRESS", ":50051")
 )
 
+func envOrDefault(key string, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
 // This handles the creation and start of Postgresql
 var sqlDb = sqldb.NewDatabase("rest", sqldb.DatabaseConfig{
 	Migrations: "./migrations",
@@ -37,10 +57,33 @@ type BillingService struct {
 	tokenDb         TokenDb
 	billIdGenerator model.BillIdGenerator
 	billDb          db.BillDatabase
+	ledgerDb        db.LedgerDatabase
+	auditDb         db.AuditDatabase
+	taxDb           db.TaxDatabase
+	couponDb        db.CouponDatabase
+	paymentDb       db.PaymentDatabase
+	usageDb         db.UsageDatabase
+	billEvents      *gateway.InMemoryBillEventBroker
+	// Publishes the bill events of the workflows to the subscribers of this process, nil in tests.
+	eventWorker worker.Worker
+	// Serves the gRPC callers, nil in tests.
+	grpcServer *grpc.Server
+	// The token buckets of the customers by endpoint, in memory unless set otherwise.
+	rateLimitDb db.RateLimitDatabase
+	rateLimits  map[string]model.RateLimit
+	quotas      model.Quotas
+	// The roles of the users in their organizations, in memory unless set otherwise.
+	orgDb db.OrganizationDatabase
+	// Flushes the traces and metrics, nil in tests.
+	shutdownTelemetry func(context.Context) error
 }
 
 func initBillingService() (*BillingService, error) {
-	client, err := client.Dial(client.Options{})
+	shutdownTelemetry, err := telemetry.Setup(context.Background(), "billing-api", telemetryExporter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up telemetry: %v", err)
+	}
+	client, err := client.Dial(client.Options{Interceptors: telemetry.TemporalInterceptors()})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temporal client: %v", err)
 	}
@@ -49,35 +92,153 @@ func initBillingService() (*BillingService, error) {
 		return nil, fmt.Errorf("failed to create token db: %v", err)
 	}
 	billIdGenerator := model.UuidBillIdGenerator{}
-	billDb := db.NewSqlBillDatabase(sqlDb.Stdlib())
-	return NewBillingService(client, tokenDb, &billIdGenerator, *billDb), nil
+	sqlBillDb := db.NewSqlBillDatabase(sqlDb.Stdlib())
+	billDb := db.NewArchivedBillDatabase(sqlBillDb, sqlBillDb, db.NewLocalArchiveStore(archiveDir))
+	ledgerDb := db.NewSqlLedgerDatabase(sqlDb.Stdlib())
+	auditDb := db.NewSqlAuditDatabase(sqlDb.Stdlib())
+	taxDb := db.NewSqlTaxDatabase(sqlDb.Stdlib())
+	couponDb := db.NewSqlCouponDatabase(sqlDb.Stdlib())
+	paymentDb := db.NewSqlPaymentDatabase(sqlDb.Stdlib())
+	usageDb := db.NewSqlUsageDatabase(sqlDb.Stdlib())
+	s := NewBillingService(client, tokenDb, &billIdGenerator, *billDb, *ledgerDb, *auditDb, *taxDb, *couponDb, *paymentDb, *usageDb)
+	rateLimitDb, rateLimits, quotas, err := rateLimitsFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure rate limits: %v", err)
+	}
+	s.SetRateLimits(rateLimitDb, rateLimits)
+	s.SetQuotas(quotas)
+	s.SetOrganizationDb(db.NewSqlOrganizationDatabase(sqlDb.Stdlib()))
+	s.shutdownTelemetry = shutdownTelemetry
+	eventWorker := worker.New(client, workflow.BillEventsTaskQueue(greetingTaskQueue), worker.Options{})
+	eventWorker.RegisterActivity(activity.NewBillEventRelay(s.billEvents).PublishBillEventActivity)
+	if err := eventWorker.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start bill event worker: %v", err)
+	}
+	s.eventWorker = eventWorker
+	listener, err := net.Listen("tcp", grpcAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for grpc: %v", err)
+	}
+	s.grpcServer = NewGrpcServer(s)
+	go func() {
+		if err := s.grpcServer.Serve(listener); err != nil {
+			rlog.Error("grpc server stopped", "err", err)
+		}
+	}()
+	return s, nil
 }
 
-func NewBillingService(client client.Client, tokenDb TokenDb, billIdGenerator model.BillIdGenerator, billDb db.BillDatabase) *BillingService {
-	return &BillingService{client, tokenDb, billIdGenerator, billDb}
+func NewBillingService(
+	client client.Client,
+	tokenDb TokenDb,
+	billIdGenerator model.BillIdGenerator,
+	billDb db.BillDatabase,
+	ledgerDb db.LedgerDatabase,
+	auditDb db.AuditDatabase,
+	taxDb db.TaxDatabase,
+	couponDb db.CouponDatabase,
+	paymentDb db.PaymentDatabase,
+	usageDb db.UsageDatabase,
+) *BillingService {
+	return &BillingService{client, tokenDb, billIdGenerator, billDb, ledgerDb, auditDb, taxDb, couponDb, paymentDb, usageDb, gateway.NewInMemoryBillEventBroker(), nil, nil,
+		db.NewInMemoryRateLimitDatabase(), model.DefaultRateLimits(), model.DefaultQuotas(), db.NewInMemoryOrganizationDatabase(), nil}
 }
 
 func (s *BillingService) Shutdown(force context.Context) {
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+	if s.eventWorker != nil {
+		s.eventWorker.Stop()
+	}
 	s.client.Close()
 	s.tokenDb.Close(force)
+	if s.shutdownTelemetry != nil {
+		if err := s.shutdownTelemetry(force); err != nil {
+			rlog.Error("failed to flush telemetry", "err", err)
+		}
+	}
 }
 
 type OpenNewBillRequest struct {
 	CurrencyCode model.CurrencyCode `json:"currency_code"`
 	CloseTime    time.Time          `json:"close_time"`
+	// Leave empty for an untaxed bill.
+	TaxJurisdiction model.TaxJurisdiction `json:"tax_jurisdiction"`
+	// In minor units of the bill currency, leave zero for an uncapped bill.
+	SpendingCap int64 `json:"spending_cap"`
+	// In percents of the spending cap, such as [50, 80, 100].
+	AlertThresholds []uint32 `json:"alert_thresholds"`
+	// Optional, the bill is created as a draft, which refuses line items until it is published.
+	Draft bool `json:"draft"`
+	// Optional, a request sent again with the same key opens no other bill and returns the same id.
+	IdempotencyKey string `header:"Idempotency-Key"`
 }
 
 type OpenNewBillResponse struct {
 	Id string `json:"id"`
+	RateLimitHeaders
+}
+
+// The tax is computed once the bill is closing, so a category without a rate in the jurisdiction is refused before.
+func (s *BillingService) checkTaxRate(ctx context.Context, jurisdiction model.TaxJurisdiction, category model.TaxCategory) error {
+	if jurisdiction == "" || category == model.TaxCategoryExempt {
+		return nil
+	}
+	_, err := s.taxDb.GetTaxRate(ctx, jurisdiction, category.OrStandard())
+	if errors.Is(err, db.ErrTaxRateNotFound) {
+		return errs.WrapCode(err, errs.InvalidArgument, "no tax rate for the category in the jurisdiction")
+	} else if err != nil {
+		rlog.Error("failed to get tax rate", "jurisdiction", jurisdiction, "category", category, "err", err)
+		return errs.WrapCode(err, errs.Internal, "failed to get tax rate")
+	}
+	return nil
+}
+
+// The standard category was checked when the bill was opened, the bill is only read for the other ones.
+func (s *BillingService) checkLineItemTaxRates(ctx context.Context, billId model.BillId, lineItems []model.BillLineItem) error {
+	var bill *db.BillInfoAndMetadata
+	for _, lineItem := range lineItems {
+		if category := lineItem.TaxCategory.OrStandard(); category == model.TaxCategoryStandard || category == model.TaxCategoryExempt {
+			continue
+		}
+		if bill == nil {
+			found, err := s.billDb.GetBill(ctx, billId)
+			if errors.Is(err, db.ErrBillNotFound) {
+				return nil // Refused by the workflow
+			} else if err != nil {
+				rlog.Error("failed to get bill", "billId", billId.Id, "err", err)
+				return errs.WrapCode(err, errs.Internal, "failed to get bill")
+			}
+			bill = &found
+		}
+		if err := s.checkTaxRate(ctx, bill.BillInfo.TaxJurisdiction, lineItem.TaxCategory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The error of a workflow that ended before answering queries, such as refused by the open bill quota. Nil while it
+// runs, or when it cannot be described.
+func (s *BillingService) failedWorkflowError(ctx context.Context, wr client.WorkflowRun) error {
+	description, err := s.client.DescribeWorkflowExecution(ctx, wr.GetID(), wr.GetRunID())
+	if err != nil {
+		rlog.Error("failed to describe workflow", "id", wr.GetID(), "err", err)
+		return nil
+	} else if description.GetWorkflowExecutionInfo().GetStatus() == enums.WORKFLOW_EXECUTION_STATUS_RUNNING {
+		return nil
+	}
+	return wr.Get(ctx, nil)
 }
 
 func CreateWorkflowId(billId string) string {
-	return fmt.Sprintf("create-bill-%v", billId)
+	return workflow.BillingWorkflowId(billId)
 }
 
 //encore:api auth method=POST path=/bills
 func (s *BillingService) OpenNewBill(ctx context.Context, openNewBillRequest *OpenNewBillRequest) (*OpenNewBillResponse, error) {
-	customerId, err := getAuthenticatedCustomerId()
+	customerId, err := authorize(ctx, model.PermissionManageBills)
 	if err != nil {
 		return nil, err
 	}
@@ -86,17 +247,49 @@ func (s *BillingService) OpenNewBill(ctx context.Context, openNewBillRequest *Op
 		ID:        CreateWorkflowId(billId),
 		TaskQueue: greetingTaskQueue,
 	}
+	if openNewBillRequest.IdempotencyKey != "" {
+		// The workflow of the first request is returned while it runs, and refused once it completed
+		billId = model.NewIdempotentId(*customerId, openNewBillRequest.IdempotencyKey)
+		options.ID = CreateWorkflowId(billId)
+		options.WorkflowIDReusePolicy = enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE
+	}
 	billInfo := model.BillInfo{
 		Id: model.BillId{
 			CustomerId: *customerId,
 			Id:         billId,
 		},
-		CurrencyCode: openNewBillRequest.CurrencyCode,
-		Status:       model.Open,
+		CurrencyCode:    openNewBillRequest.CurrencyCode,
+		Status:          model.Open,
+		TaxJurisdiction: openNewBillRequest.TaxJurisdiction,
+		SpendingCap:     model.NewSpendingCap(openNewBillRequest.SpendingCap, openNewBillRequest.CurrencyCode, openNewBillRequest.AlertThresholds),
+		MaxLineItems:    s.quotas.MaxLineItemsPerBill,
+		MaxOpenBills:    s.quotas.MaxOpenBills,
 	}
-	duration := time.Until(openNewBillRequest.CloseTime)
-	wr, err := s.client.ExecuteWorkflow(ctx, options, workflow.BillingWorkflow, billInfo, duration)
+	if openNewBillRequest.Draft {
+		billInfo.Status = model.Draft
+	}
+	if err := billInfo.SpendingCap.Check(billInfo.CurrencyCode); err != nil {
+		return nil, errs.WrapCode(err, errs.InvalidArgument, "invalid spending cap")
+	}
+	if err := s.checkTaxRate(ctx, billInfo.TaxJurisdiction, model.TaxCategoryStandard); err != nil {
+		return nil, err
+	}
+	// Refused before starting the workflow, the quota is enforced when the workflow saves the bill
+	runningBillCount, err := s.billDb.CountRunningBills(ctx, *customerId)
 	if err != nil {
+		rlog.Error("failed to count open bills", "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to count open bills")
+	} else if err := s.quotas.CheckOpenBills(runningBillCount); err != nil {
+		return nil, errs.WrapCode(err, errs.ResourceExhausted, "too many open bills")
+	}
+	duration := time.Until(openNewBillRequest.CloseTime)
+	opener := getAuthenticatedActor(ctx, *customerId)
+	wr, err := s.client.ExecuteWorkflow(ctx, options, workflow.BillingWorkflow, billInfo, duration, opener)
+	var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+	if openNewBillRequest.IdempotencyKey != "" && errors.As(err, &alreadyStarted) {
+		rlog.Info("bill already opened with the idempotency key", "id", billId)
+		return &OpenNewBillResponse{Id: billId}, nil
+	} else if err != nil {
 		rlog.Error("failed to execute workflow", "err", err)
 		return nil, errs.WrapCode(err, errs.Internal, "workflow failed to execute")
 	}
@@ -111,6 +304,12 @@ func (s *BillingService) OpenNewBill(ctx context.Context, openNewBillRequest *Op
 			break
 		}
 		rlog.Error("failed to query workflow", "attempt", i, "err", err)
+		if failed := s.failedWorkflowError(ctx, wr); isOpenBillQuotaExceededError(failed) {
+			return nil, errs.WrapCode(failed, errs.ResourceExhausted, "too many open bills")
+		} else if failed != nil {
+			rlog.Error("workflow failed to open bill", "id", billId, "err", failed)
+			return nil, errs.WrapCode(failed, errs.Internal, "workflow failed to open bill")
+		}
 		time.Sleep(500 * time.Millisecond)
 	}
 	if err != nil {
@@ -134,50 +333,162 @@ type GetBillRequest struct {
 type GetBillResponse struct {
 	Id            string             `json:"id"`
 	CurrencyCode  model.CurrencyCode `json:"currency_code"`
-	Status        model.BillStatus   `json:"status"` // open(0)/closed(1)
+	Status        model.BillStatus   `json:"status"` // Such as "open", see model.BillStatus
 	LineItemCount uint64             `json:"line_item_count"`
-	TotalOk       string             `json:"total_ok"` // y/n instead of true/false
-	Total         int64              `json:"total"`
+	// Totals are decimal strings in minor units, exact whatever their size.
+	Total     string     `json:"total"`
+	CreatedAt time.Time  `json:"created_at"`
+	CloseTime time.Time  `json:"close_time"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"` // Absent while open
+	// Net of tax. The tax and discounts are only known once the bill is closed.
+	Subtotal      string `json:"subtotal"`
+	TaxTotal      string `json:"tax_total"`
+	GrandTotal    string `json:"grand_total"`
+	DiscountTotal string `json:"discount_total"`
+	AmountDue     string `json:"amount_due"` // Grand total minus discounts
+	// The payment on close, then the retries of the dunning and any payment received out of band.
+	Payments      []model.PaymentAttempt      `json:"payments"`
+	Notifications []model.DunningNotification `json:"notifications"`
+	// Zero when uncapped.
+	SpendingCap     int64    `json:"spending_cap"`
+	AlertThresholds []uint32 `json:"alert_thresholds"`
+	// Only known while the bill workflow runs.
+	SpendingAlerts []model.SpendingAlert `json:"spending_alerts"`
+	RateLimitHeaders
 }
 
-func createGetBillResponse(bill db.BillInfoAndMetadata) *GetBillResponse {
+func createGetBillResponse(
+	bill db.BillInfoAndMetadata,
+	tax model.BillTax,
+	discounts []model.DiscountLine,
+	payments []model.PaymentAttempt,
+	notifications []model.DunningNotification,
+) *GetBillResponse {
+	totals := formatBillTotals(bill.Total, tax, discounts)
 	return &GetBillResponse{
-		Id:            bill.BillInfo.Id.Id,
-		CurrencyCode:  bill.BillInfo.CurrencyCode,
-		Status:        bill.BillInfo.Status,
-		LineItemCount: bill.LineItemCount,
-		TotalOk:       formatTotalOk(bill.TotalOk),
-		Total:         bill.TotalAmount.Number,
+		Id:              bill.BillInfo.Id.Id,
+		CurrencyCode:    bill.BillInfo.CurrencyCode,
+		Status:          bill.BillInfo.Status,
+		LineItemCount:   bill.LineItemCount,
+		Total:           bill.Total.Number,
+		CreatedAt:       bill.BillInfo.CreatedAt,
+		CloseTime:       bill.BillInfo.CloseTime,
+		ClosedAt:        formatClosedAt(bill.BillInfo.ClosedAt),
+		Subtotal:        totals.subtotal,
+		TaxTotal:        totals.taxTotal,
+		GrandTotal:      totals.grandTotal,
+		DiscountTotal:   totals.discountTotal,
+		AmountDue:       totals.amountDue,
+		Payments:        payments,
+		Notifications:   notifications,
+		SpendingCap:     bill.BillInfo.SpendingCap.Max.Number,
+		AlertThresholds: bill.BillInfo.SpendingCap.AlertThresholds,
 	}
 }
 
-const TotalOkYes = "y"
-const TotalOkNo = "n"
+type billTotals struct {
+	subtotal      string
+	taxTotal      string
+	grandTotal    string
+	discountTotal string
+	amountDue     string
+}
+
+// An untaxed bill has no tax, its subtotal and grand total are its total.
+func formatBillTotals(total model.TotalAmount, tax model.BillTax, discounts []model.DiscountLine) billTotals {
+	subtotal, taxTotal, grandTotal := total, model.NewTotalAmount(total.CurrencyCode), total
+	if tax.IsComputed() {
+		subtotal, taxTotal, grandTotal = tax.Subtotal, tax.TaxTotal, tax.GrandTotal
+	}
+	return billTotals{
+		subtotal:      subtotal.Number,
+		taxTotal:      taxTotal.Number,
+		grandTotal:    grandTotal.Number,
+		discountTotal: model.SumDiscounts(total.CurrencyCode, discounts).Number,
+		amountDue:     model.AmountToCollect(grandTotal, discounts).Number,
+	}
+}
 
-func formatTotalOk(isOk bool) string {
-	if isOk {
-		return TotalOkYes
-	} else {
-		return TotalOkNo
+func formatClosedAt(closedAt time.Time) *time.Time {
+	if closedAt.IsZero() {
+		return nil
 	}
+	return &closedAt
+}
+
+// The workflow reports Go errors by their type name.
+var lineItemValidationErrorTypes = []string{
+	"InvalidQuantityError",
+	"InvalidUnitPriceError",
+	"InvalidRoundingModeError",
+	"PrecisionError",
+	"LineAmountOverflowError",
+	"FxNotAvailableError",
+}
+
+func isLineItemValidationError(err error) bool {
+	var applicationError *temporal.ApplicationError
+	return errors.As(err, &applicationError) && slices.Contains(lineItemValidationErrorTypes, applicationError.Type())
+}
+
+func isApplicationErrorOfType(err error, errorType string) bool {
+	var applicationError *temporal.ApplicationError
+	return errors.As(err, &applicationError) && applicationError.Type() == errorType
+}
+
+func isSpendingCapExceededError(err error) bool {
+	return isApplicationErrorOfType(err, "SpendingCapExceededError")
+}
+
+func isOpenBillQuotaExceededError(err error) bool {
+	return isApplicationErrorOfType(err, activity.OpenBillQuotaExceededErrorType)
+}
+
+// A closing bill, a draft or a void bill.
+func isBillNotOpenError(err error) bool {
+	return isApplicationErrorOfType(err, "BillClosingError") || isApplicationErrorOfType(err, "BillNotOpenError")
+}
+
+func isLineItemQuotaExceededError(err error) bool {
+	return isApplicationErrorOfType(err, "LineItemQuotaExceededError")
 }
 
 //encore:api auth method=GET path=/bill/:id
 func (s *BillingService) GetBill(ctx context.Context, id string, getBillRequest *GetBillRequest) (*GetBillResponse, error) {
-	customerId, err := getAuthenticatedCustomerId()
+	customerId, err := authorize(ctx, model.PermissionViewBills)
 	if err != nil {
 		return nil, err
 	}
 	encodedResult, err := s.client.QueryWorkflow(ctx, CreateWorkflowId(id), "", workflow.GetPendingBillStateQuery)
 	if err != nil {
 		if _, ok := err.(*serviceerror.NotFound); ok {
-			bill, err := s.billDb.GetBill(model.BillId{CustomerId: *customerId, Id: id})
+			bill, err := s.billDb.GetBill(ctx, model.BillId{CustomerId: *customerId, Id: id})
 			if err != nil {
 				rlog.Error("failed to get  fill from workflow or db", "err", err)
 				return nil, errs.WrapCode(err, errs.NotFound, "failed to get bill from workflow or db")
 			}
 			rlog.Info("got bill from db", "bill", bill)
-			return createGetBillResponse(bill), nil
+			tax, err := s.taxDb.GetBillTax(ctx, bill.BillInfo.Id)
+			if err != nil {
+				rlog.Error("failed to get bill tax from db", "err", err)
+				return nil, errs.WrapCode(err, errs.Internal, "failed to get bill tax from db")
+			}
+			discounts, err := s.couponDb.GetBillDiscounts(ctx, bill.BillInfo.Id)
+			if err != nil {
+				rlog.Error("failed to get bill discounts from db", "err", err)
+				return nil, errs.WrapCode(err, errs.Internal, "failed to get bill discounts from db")
+			}
+			payments, err := s.paymentDb.GetPaymentAttempts(ctx, bill.BillInfo.Id)
+			if err != nil {
+				rlog.Error("failed to get bill payments from db", "err", err)
+				return nil, errs.WrapCode(err, errs.Internal, "failed to get bill payments from db")
+			}
+			notifications, err := s.paymentDb.GetDunningNotifications(ctx, bill.BillInfo.Id)
+			if err != nil {
+				rlog.Error("failed to get bill notifications from db", "err", err)
+				return nil, errs.WrapCode(err, errs.Internal, "failed to get bill notifications from db")
+			}
+			return createGetBillResponse(bill, tax, discounts, payments, notifications), nil
 		}
 		rlog.Error("failed to query workflow", "err", err)
 		return nil, errs.WrapCode(err, errs.NotFound, "failed to query workflow")
@@ -195,14 +506,38 @@ func (s *BillingService) GetBill(ctx context.Context, id string, getBillRequest
 		rlog.Error("failed to query workflow of correct customer", "customerId", customerId, "state customer id", currentState.BillInfo.Id.CustomerId)
 		return nil, errs.WrapCode(err, errs.Internal, "failed to query correct workflow")
 	}
+	status, payments, notifications := currentState.BillInfo.Status, currentState.Payments, []model.DunningNotification(nil)
+	if status == model.PaymentFailed {
+		// The bill workflow is over, the dunning knows what became of the payment
+		dunning, err := s.getDunningState(ctx, currentState.BillInfo.Id)
+		if err != nil {
+			return nil, err
+		}
+		status = dunning.Status
+		payments = append(payments, dunning.Payments...)
+		notifications = dunning.Notifications
+	}
 
+	totals := formatBillTotals(currentState.Total, currentState.Tax, currentState.Discounts)
 	return &GetBillResponse{
-		Id:            id,
-		CurrencyCode:  currentState.BillInfo.CurrencyCode,
-		Status:        currentState.BillInfo.Status,
-		LineItemCount: currentState.BillLineItemCount,
-		TotalOk:       formatTotalOk(currentState.Total.Ok),
-		Total:         currentState.Total.Total.Number,
+		Id:              id,
+		CurrencyCode:    currentState.BillInfo.CurrencyCode,
+		Status:          status,
+		LineItemCount:   currentState.BillLineItemCount,
+		Total:           currentState.Total.Number,
+		CreatedAt:       currentState.BillInfo.CreatedAt,
+		CloseTime:       currentState.BillInfo.CloseTime,
+		ClosedAt:        formatClosedAt(currentState.BillInfo.ClosedAt),
+		Subtotal:        totals.subtotal,
+		TaxTotal:        totals.taxTotal,
+		GrandTotal:      totals.grandTotal,
+		DiscountTotal:   totals.discountTotal,
+		AmountDue:       totals.amountDue,
+		Payments:        payments,
+		Notifications:   notifications,
+		SpendingCap:     currentState.BillInfo.SpendingCap.Max.Number,
+		AlertThresholds: currentState.BillInfo.SpendingCap.AlertThresholds,
+		SpendingAlerts:  currentState.SpendingAlerts,
 	}, nil
 }
 
@@ -212,17 +547,43 @@ type CloseBillRequest struct {
 type CloseBillResponse struct {
 	CurrencyCode  model.CurrencyCode `json:"currency_code"`
 	LineItemCount uint64             `json:"line_item_count"`
-	TotalOk       string             `json:"total_ok"` // y/n instead of true/false
-	Total         int64              `json:"total"`
+	// Totals are decimal strings in minor units, exact whatever their size.
+	Total     string     `json:"total"`
+	CreatedAt time.Time  `json:"created_at"`
+	CloseTime time.Time  `json:"close_time"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	// Net of tax.
+	Subtotal      string `json:"subtotal"`
+	TaxTotal      string `json:"tax_total"`
+	GrandTotal    string `json:"grand_total"`
+	DiscountTotal string `json:"discount_total"`
+	AmountDue     string `json:"amount_due"` // Grand total minus discounts
+	RateLimitHeaders
 }
 
 //encore:api auth method=PATCH path=/bill/:id/close
 func (s *BillingService) CloseBill(ctx context.Context, id string, closeBillRequest *CloseBillRequest) (*CloseBillResponse, error) {
-	_, err := getAuthenticatedCustomerId()
+	customerId, err := authorize(ctx, model.PermissionManageBills)
 	if err != nil {
 		return nil, err
 	}
-	err = s.client.SignalWorkflow(ctx, CreateWorkflowId(id), "", workflow.CloseBillEarlySignal, "API initiated")
+	// The update id is the request id of the audit entry, as for the other updates
+	updateId := s.billIdGenerator.New()
+	updateHandle, err := s.client.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		UpdateID:   updateId,
+		WorkflowID: CreateWorkflowId(id),
+		UpdateName: workflow.CloseBillUpdate,
+		Args: []interface{}{
+			workflow.CloseBillEarlyArgs{
+				Actor:     getAuthenticatedActor(ctx, *customerId),
+				RequestId: updateId,
+			},
+		},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err == nil {
+		err = updateHandle.Get(ctx, nil)
+	}
 	if err != nil {
 		rlog.Error("failed to close workflow", "err", err)
 		return nil, errs.WrapCode(err, errs.Internal, "workflow failed to close")
@@ -235,51 +596,106 @@ func (s *BillingService) CloseBill(ctx context.Context, id string, closeBillRequ
 		rlog.Error("failed to get workflow final state", "err", err)
 		return nil, errs.WrapCode(err, errs.Internal, "failed to get workflow final state")
 	}
+	totals := formatBillTotals(finalState.Total, finalState.Tax, finalState.Discounts)
 	return &CloseBillResponse{
 		CurrencyCode:  finalState.BillInfo.CurrencyCode,
 		LineItemCount: finalState.BillLineItemCount,
-		TotalOk:       formatTotalOk(finalState.Total.Ok),
-		Total:         finalState.Total.Total.Number,
+		Total:         finalState.Total.Number,
+		CreatedAt:     finalState.BillInfo.CreatedAt,
+		CloseTime:     finalState.BillInfo.CloseTime,
+		ClosedAt:      formatClosedAt(finalState.BillInfo.ClosedAt),
+		Subtotal:      totals.subtotal,
+		TaxTotal:      totals.taxTotal,
+		GrandTotal:    totals.grandTotal,
+		DiscountTotal: totals.discountTotal,
+		AmountDue:     totals.amountDue,
 	}, nil
 }
 
 type AddBillLineItemRequest struct {
 	Description  string             `json:"description"`
 	Amount       int64              `json:"amount"`
-	CurrencyCode model.CurrencyCode `json:"currency-code"`
+	CurrencyCode model.CurrencyCode `json:"currency_code"`
+	// Empty means the standard category.
+	TaxCategory  model.TaxCategory `json:"tax_category"`
+	TaxInclusive bool              `json:"tax_inclusive"`
+	// When set, amount is ignored and computed as quantity times unit_price. Decimal strings, unit_price in
+	// major units of currency_code.
+	Quantity  string `json:"quantity"`
+	UnitPrice string `json:"unit_price"`
+	// half_even (the default), half_up or down.
+	Rounding model.RoundingMode `json:"rounding"`
+	// Optional, a request sent again with the same key adds the line item once.
+	IdempotencyKey string `header:"Idempotency-Key"`
 }
 
 type AddBillLineItemResponse struct {
 	Id            string             `json:"id"`
 	CurrencyCode  model.CurrencyCode `json:"currency_code"`
 	LineItemCount uint64             `json:"line_item_count"`
-	TotalOk       string             `json:"total_ok"` // y/n instead of true/false
-	Total         int64              `json:"total"`
+	Total         string             `json:"total"` // Decimal string in minor units
+	RateLimitHeaders
 }
 
 //encore:api auth method=POST path=/bill/:id/line-items
 func (s *BillingService) AddBillLineItem(ctx context.Context, id string, addBillLineItemRequest *AddBillLineItemRequest) (*AddBillLineItemResponse, error) {
-	customerId, err := getAuthenticatedCustomerId()
+	customerId, err := authorize(ctx, model.PermissionManageBills)
 	if err != nil {
 		return nil, err
 	}
-	updateId := s.billIdGenerator.New()
-	lineItemId := s.billIdGenerator.New()
+	var updateId, lineItemId string
+	if addBillLineItemRequest.IdempotencyKey != "" {
+		// The update and the line item are deduplicated by their id
+		updateId = model.NewIdempotentId(*customerId, addBillLineItemRequest.IdempotencyKey)
+		lineItemId = updateId
+	} else {
+		updateId = s.billIdGenerator.New()
+		lineItemId = s.billIdGenerator.New()
+	}
+	lineItem := model.BillLineItem{
+		Id: model.BillLineItemId{
+			BillId: model.BillId{CustomerId: *customerId, Id: id},
+			Id:     lineItemId,
+		},
+		Description: addBillLineItemRequest.Description,
+		Amount: model.Amount{
+			CurrencyCode: addBillLineItemRequest.CurrencyCode,
+			Number:       addBillLineItemRequest.Amount,
+		},
+		TaxCategory:  addBillLineItemRequest.TaxCategory,
+		TaxInclusive: addBillLineItemRequest.TaxInclusive,
+		Quantity:     addBillLineItemRequest.Quantity,
+		UnitPrice:    addBillLineItemRequest.UnitPrice,
+		Rounding:     addBillLineItemRequest.Rounding,
+	}
+	updatedState, err := s.addLineItem(ctx, updateId, lineItem, getAuthenticatedActor(ctx, *customerId))
+	if err != nil {
+		return nil, err
+	}
+	rlog.Info("added line item to workflow", "id", id)
+	return &AddBillLineItemResponse{
+		Id:            lineItemId,
+		CurrencyCode:  updatedState.BillInfo.CurrencyCode,
+		LineItemCount: updatedState.BillLineItemCount,
+		Total:         updatedState.Total.Number,
+	}, nil
+}
+
+// Waits until the bill workflow has added the line item, or refused it.
+func (s *BillingService) addLineItem(ctx context.Context, updateId string, lineItem model.BillLineItem, actor model.Actor) (workflow.BillingState, error) {
+	id := lineItem.Id.BillId.Id
+	if err := s.checkLineItemTaxRates(ctx, lineItem.Id.BillId, []model.BillLineItem{lineItem}); err != nil {
+		return workflow.BillingState{}, err
+	}
 	options := client.UpdateWorkflowOptions{
 		UpdateID:   updateId,
 		WorkflowID: CreateWorkflowId(id),
 		UpdateName: workflow.AddBillLineItemUpdate,
 		Args: []interface{}{
-			model.BillLineItem{
-				Id: model.BillLineItemId{
-					BillId: model.BillId{CustomerId: *customerId, Id: id},
-					Id:     lineItemId,
-				},
-				Description: addBillLineItemRequest.Description,
-				Amount: model.Amount{
-					CurrencyCode: addBillLineItemRequest.CurrencyCode,
-					Number:       addBillLineItemRequest.Amount,
-				},
+			workflow.AddBillLineItemArgs{
+				LineItem:  lineItem,
+				Actor:     actor,
+				RequestId: updateId,
 			},
 		},
 		WaitForStage: client.WorkflowUpdateStageCompleted,
@@ -288,20 +704,107 @@ func (s *BillingService) AddBillLineItem(ctx context.Context, id string, addBill
 	updateHandle, err := s.client.UpdateWorkflow(ctx, options)
 	if err != nil {
 		rlog.Error("failed to add line item", "billId", id, "err", err)
-		return nil, errs.WrapCode(err, errs.Internal, "failed to add line item")
+		if isLineItemValidationError(err) {
+			return workflow.BillingState{}, errs.WrapCode(err, errs.InvalidArgument, "invalid line item")
+		} else if isSpendingCapExceededError(err) {
+			return workflow.BillingState{}, errs.WrapCode(err, errs.FailedPrecondition, "line item would exceed the spending cap")
+		} else if isLineItemQuotaExceededError(err) {
+			return workflow.BillingState{}, errs.WrapCode(err, errs.ResourceExhausted, "too many line items")
+		} else if isBillNotOpenError(err) {
+			return workflow.BillingState{}, errs.WrapCode(err, errs.FailedPrecondition, "bill is not open")
+		}
+		return workflow.BillingState{}, errs.WrapCode(err, errs.Internal, "failed to add line item")
 	}
 	var updatedState workflow.BillingState
 	err = updateHandle.Get(ctx, &updatedState)
 	if err != nil {
 		rlog.Error("failed to get updated workflow state", "billId", id, "err", err)
-		return nil, errs.WrapCode(err, errs.Internal, "failed to get updated workflow state")
+		if isLineItemValidationError(err) {
+			return workflow.BillingState{}, errs.WrapCode(err, errs.InvalidArgument, "invalid line item")
+		} else if isSpendingCapExceededError(err) {
+			return workflow.BillingState{}, errs.WrapCode(err, errs.FailedPrecondition, "line item would exceed the spending cap")
+		} else if isLineItemQuotaExceededError(err) {
+			return workflow.BillingState{}, errs.WrapCode(err, errs.ResourceExhausted, "too many line items")
+		}
+		return workflow.BillingState{}, errs.WrapCode(err, errs.Internal, "failed to get updated workflow state")
 	}
-	rlog.Info("added line item to workflow", "id", id)
-	return &AddBillLineItemResponse{
-		Id:            lineItemId,
-		CurrencyCode:  updatedState.BillInfo.CurrencyCode,
-		LineItemCount: updatedState.BillLineItemCount,
-		TotalOk:       formatTotalOk(updatedState.Total.Ok),
-		Total:         updatedState.Total.Total.Number,
-	}, nil
+	return updatedState, nil
+}
+
+type ListBillLineItemsRequest struct {
+}
+
+type BillLineItemResponse struct {
+	Id           string             `json:"id"`
+	Description  string             `json:"description"`
+	Amount       int64              `json:"amount"`
+	CurrencyCode model.CurrencyCode `json:"currency_code"`
+	CreatedAt    time.Time          `json:"created_at"`
+	// Only present when the line item was converted into the bill currency.
+	Conversion   *FxConversionResponse `json:"conversion,omitempty"`
+	TaxCategory  model.TaxCategory     `json:"tax_category,omitempty"`
+	TaxInclusive bool                  `json:"tax_inclusive,omitempty"`
+	// Only present when the amount was computed from them.
+	Quantity  string             `json:"quantity,omitempty"`
+	UnitPrice string             `json:"unit_price,omitempty"`
+	Rounding  model.RoundingMode `json:"rounding,omitempty"`
+}
+
+type FxConversionResponse struct {
+	OriginalAmount       int64              `json:"original_amount"`
+	OriginalCurrencyCode model.CurrencyCode `json:"original_currency_code"`
+	Rate                 string             `json:"rate"`
+	RateAsOf             time.Time          `json:"rate_as_of"`
+}
+
+func formatConversion(conversion *model.FxConversion) *FxConversionResponse {
+	if conversion == nil {
+		return nil
+	}
+	return &FxConversionResponse{
+		OriginalAmount:       conversion.OriginalAmount.Number,
+		OriginalCurrencyCode: conversion.OriginalAmount.CurrencyCode,
+		Rate:                 conversion.Rate,
+		RateAsOf:             conversion.AsOf,
+	}
+}
+
+type ListBillLineItemsResponse struct {
+	Id        string                 `json:"id"`
+	LineItems []BillLineItemResponse `json:"line_items"`
+	RateLimitHeaders
+}
+
+// Archived bills are read from their archive segment.
+//
+//encore:api auth method=GET path=/bill/:id/line-items
+func (s *BillingService) ListBillLineItems(ctx context.Context, id string, listBillLineItemsRequest *ListBillLineItemsRequest) (*ListBillLineItemsResponse, error) {
+	customerId, err := authorize(ctx, model.PermissionViewBills)
+	if err != nil {
+		return nil, err
+	}
+	lineItems, err := s.billDb.GetLineItems(ctx, model.BillId{CustomerId: *customerId, Id: id})
+	if err == db.ErrBillNotFound {
+		return nil, errs.WrapCode(err, errs.NotFound, "bill not found")
+	} else if err != nil {
+		rlog.Error("failed to list line items", "billId", id, "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to list line items")
+	}
+	response := &ListBillLineItemsResponse{Id: id, LineItems: make([]BillLineItemResponse, 0, len(lineItems))}
+	for _, lineItem := range lineItems {
+		response.LineItems = append(response.LineItems, BillLineItemResponse{
+			Id:           lineItem.Id.Id,
+			Description:  lineItem.Description,
+			Amount:       lineItem.Amount.Number,
+			CurrencyCode: lineItem.Amount.CurrencyCode,
+			CreatedAt:    lineItem.CreatedAt,
+			Conversion:   formatConversion(lineItem.Conversion),
+			TaxCategory:  lineItem.TaxCategory,
+			TaxInclusive: lineItem.TaxInclusive,
+			Quantity:     lineItem.Quantity,
+			UnitPrice:    lineItem.UnitPrice,
+			Rounding:     lineItem.Rounding,
+		})
+	}
+	return response, nil
 }
@@ -7,15 +7,34 @@ import (
 	"coding-challenge/pkg/rest/mocks"
 	"coding-challenge/pkg/workflow"
 	"context"
+	"net/http"
+	"reflect"
 	"testing"
 	"time"
 
+	"encore.dev"
 	"encore.dev/beta/auth"
+	"encore.dev/beta/errs"
+	"encore.dev/middleware"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	sdkclient "go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
 )
 
+// The owner of the organization of the bills.
+const aliceUserId = model.UserId("0b6f3c1e-5a2d-4e8f-9c7b-1d3e5f7a9b2c")
+
+func withAuth(customerId model.CustomerId, role model.Role) context.Context {
+	return auth.WithContext(context.Background(), auth.UID(aliceUserId), &rest.AuthData{OrgId: string(customerId), Role: role})
+}
+
 func encodeMockedState(ctrl *gomock.Controller, state workflow.BillingState) *mocks.MockEncodedValue {
 	encodedBillingstate := mocks.NewMockEncodedValue(ctrl)
 	encodedBillingstate.EXPECT().
@@ -31,16 +50,27 @@ func createBasicMocks(ctrl *gomock.Controller, billInfo model.BillInfo) (
 	*mocks.MockTokenDb,
 	*mocks.MockBillIdGenerator,
 	*mocks.MockBillDatabase,
+	*mocks.MockLedgerDatabase,
+	*mocks.MockAuditDatabase,
+	*mocks.MockTaxDatabase,
+	*mocks.MockCouponDatabase,
+	*mocks.MockPaymentDatabase,
+	*mocks.MockUsageDatabase,
 ) {
 	worflowRun := mocks.NewMockWorkflowRun(ctrl)
 	worflowRun.EXPECT().GetID().Return("mock-wr-id")
 	worflowRun.EXPECT().GetRunID().Return("mock-run-id")
+	// The bill is opened with the default quotas
+	openedBillInfo := billInfo
+	openedBillInfo.MaxLineItems = model.DefaultQuotas().MaxLineItemsPerBill
+	openedBillInfo.MaxOpenBills = model.DefaultQuotas().MaxOpenBills
 	client := mocks.NewMockClient(ctrl)
 	client.EXPECT().
 		ExecuteWorkflow(
 			gomock.Any(), gomock.Any(), gomock.Any(),
-			billInfo,
-			gomock.Any()).
+			openedBillInfo,
+			gomock.Any(),
+			model.NewUserActor(aliceUserId)).
 		Return(worflowRun, nil)
 	tokenDb := mocks.NewMockTokenDb(ctrl)
 	// tokenDb.EXPECT(). // For some reason, unit testing the auth end point does not work as expected.
@@ -51,7 +81,16 @@ func createBasicMocks(ctrl *gomock.Controller, billInfo model.BillInfo) (
 		New().
 		Return(billInfo.Id.Id)
 	billDatabase := mocks.NewMockBillDatabase(ctrl)
-	return worflowRun, client, tokenDb, billIdGenerator, billDatabase
+	billDatabase.EXPECT().
+		CountRunningBills(gomock.Any(), billInfo.Id.CustomerId).
+		Return(uint64(0), nil)
+	ledgerDatabase := mocks.NewMockLedgerDatabase(ctrl)
+	auditDatabase := mocks.NewMockAuditDatabase(ctrl)
+	taxDatabase := mocks.NewMockTaxDatabase(ctrl)
+	couponDatabase := mocks.NewMockCouponDatabase(ctrl)
+	paymentDatabase := mocks.NewMockPaymentDatabase(ctrl)
+	usageDatabase := mocks.NewMockUsageDatabase(ctrl)
+	return worflowRun, client, tokenDb, billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase
 }
 
 func addGetExpectations(ctrl *gomock.Controller, client *mocks.MockClient, billingStates ...workflow.BillingState) {
@@ -66,8 +105,26 @@ func addGetExpectations(ctrl *gomock.Controller, client *mocks.MockClient, billi
 	}
 }
 
-func addCloseExpectations(ctrl *gomock.Controller, client *mocks.MockClient, finalState workflow.BillingState) *mocks.MockWorkflowRun {
-	client.EXPECT().SignalWorkflow(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+func addCloseExpectations(
+	ctrl *gomock.Controller,
+	client *mocks.MockClient,
+	billIdGenerator *mocks.MockBillIdGenerator,
+	requestId string,
+	finalState workflow.BillingState,
+) *mocks.MockWorkflowRun {
+	billIdGenerator.EXPECT().New().Return(requestId)
+	updateHandle := mocks.NewMockWorkflowUpdateHandle(ctrl)
+	updateHandle.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, options sdkclient.UpdateWorkflowOptions) (sdkclient.WorkflowUpdateHandle, error) {
+			assert.Equal(ctrl.T, requestId, options.UpdateID)
+			assert.Equal(ctrl.T, workflow.CloseBillUpdate, options.UpdateName)
+			assert.Equal(ctrl.T, workflow.CloseBillEarlyArgs{
+				Actor:     model.NewUserActor(aliceUserId),
+				RequestId: requestId,
+			}, options.Args[0])
+			return updateHandle, nil
+		})
 	workflowRun := mocks.NewMockWorkflowRun(ctrl)
 	workflowRun.EXPECT().
 		Get(gomock.Any(), gomock.Any()).
@@ -102,20 +159,17 @@ func TestOpenNewBill(t *testing.T) {
 		},
 		CurrencyCode: "USD",
 		Status:       model.Open}
-	authedContext := auth.WithContext(context.Background(), auth.UID(newBill.Id.CustomerId), &rest.AuthData{})
+	authedContext := withAuth(newBill.Id.CustomerId, model.RoleOwner)
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	_, client, tokenDb, billIdGenerator, billDatabase := createBasicMocks(ctrl, newBill)
+	_, client, tokenDb, billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase := createBasicMocks(ctrl, newBill)
 	initialBillingState := workflow.BillingState{
 		BillInfo:          newBill,
 		BillLineItemCount: 0,
-		Total: model.TotalAmount{
-			Total: model.Amount{Number: 0, CurrencyCode: newBill.CurrencyCode},
-			Ok:    true,
-		},
+		Total:             model.TotalAmount{Number: "0", CurrencyCode: newBill.CurrencyCode},
 	}
 	addGetExpectations(ctrl, client, initialBillingState)
-	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase)
+	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase)
 
 	// Act
 	resp, err := s.OpenNewBill(authedContext, &rest.OpenNewBillRequest{
@@ -139,20 +193,17 @@ func TestGetOpenBill(t *testing.T) {
 		},
 		CurrencyCode: "USD",
 		Status:       model.Open}
-	authedContext := auth.WithContext(context.Background(), auth.UID(newBill.Id.CustomerId), &rest.AuthData{})
+	authedContext := withAuth(newBill.Id.CustomerId, model.RoleOwner)
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	_, client, tokenDb, billIdGenerator, billDatabase := createBasicMocks(ctrl, newBill)
+	_, client, tokenDb, billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase := createBasicMocks(ctrl, newBill)
 	initialBillingState := workflow.BillingState{
 		BillInfo:          newBill,
 		BillLineItemCount: 0,
-		Total: model.TotalAmount{
-			Total: model.Amount{Number: 0, CurrencyCode: newBill.CurrencyCode},
-			Ok:    true,
-		},
+		Total:             model.TotalAmount{Number: "0", CurrencyCode: newBill.CurrencyCode},
 	}
 	addGetExpectations(ctrl, client, initialBillingState, initialBillingState)
-	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase)
+	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase)
 	_, err := s.OpenNewBill(authedContext, &rest.OpenNewBillRequest{
 		CurrencyCode: "USD",
 		CloseTime:    time.Now().Add(time.Minute),
@@ -170,8 +221,12 @@ func TestGetOpenBill(t *testing.T) {
 			CurrencyCode:  newBill.CurrencyCode,
 			Status:        model.Open,
 			LineItemCount: 0,
-			TotalOk:       "y",
-			Total:         0,
+			Total:         "0",
+			Subtotal:      "0",
+			TaxTotal:      "0",
+			GrandTotal:    "0",
+			DiscountTotal: "0",
+			AmountDue:     "0",
 		},
 		resp)
 }
@@ -185,29 +240,28 @@ func TestCloseBill(t *testing.T) {
 		},
 		CurrencyCode: "USD",
 		Status:       model.Open}
-	authedContext := auth.WithContext(context.Background(), auth.UID(newBill.Id.CustomerId), &rest.AuthData{})
+	authedContext := withAuth(newBill.Id.CustomerId, model.RoleOwner)
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	_, client, tokenDb, billIdGenerator, billDatabase := createBasicMocks(ctrl, newBill)
+	_, client, tokenDb, billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase := createBasicMocks(ctrl, newBill)
 	initialBillingState := workflow.BillingState{
 		BillInfo:          newBill,
 		BillLineItemCount: 0,
-		Total: model.TotalAmount{
-			Total: model.Amount{Number: 0, CurrencyCode: newBill.CurrencyCode},
-			Ok:    true,
-		},
+		Total:             model.TotalAmount{Number: "0", CurrencyCode: newBill.CurrencyCode},
 	}
 	addGetExpectations(ctrl, client, initialBillingState)
+	closedBill := newBill
+	closedBill.Status = model.Closed
+	closedBill.CreatedAt = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	closedBill.CloseTime = time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC)
+	closedBill.ClosedAt = time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
 	finalBillingState := workflow.BillingState{
-		BillInfo:          newBill,
+		BillInfo:          closedBill,
 		BillLineItemCount: 0,
-		Total: model.TotalAmount{
-			Total: model.Amount{Number: 0, CurrencyCode: newBill.CurrencyCode},
-			Ok:    true,
-		},
+		Total:             model.TotalAmount{Number: "0", CurrencyCode: newBill.CurrencyCode},
 	}
-	_ = addCloseExpectations(ctrl, client, finalBillingState)
-	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase)
+	_ = addCloseExpectations(ctrl, client, billIdGenerator, "0b8c4f6e-3f0e-4d7e-9d64-3c1d3a8f0e11", finalBillingState)
+	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase)
 	_, err := s.OpenNewBill(authedContext, &rest.OpenNewBillRequest{
 		CurrencyCode: "USD",
 		CloseTime:    time.Now().Add(time.Minute),
@@ -223,8 +277,87 @@ func TestCloseBill(t *testing.T) {
 		&rest.CloseBillResponse{
 			CurrencyCode:  "USD",
 			LineItemCount: 0,
-			TotalOk:       "y",
-			Total:         0,
+			Total:         "0",
+			CreatedAt:     closedBill.CreatedAt,
+			CloseTime:     closedBill.CloseTime,
+			ClosedAt:      &closedBill.ClosedAt,
+			Subtotal:      "0",
+			TaxTotal:      "0",
+			GrandTotal:    "0",
+			DiscountTotal: "0",
+			AmountDue:     "0",
+		},
+		resp)
+}
+
+func TestCloseTaxedBill(t *testing.T) {
+	// Arrange
+	newBill := model.BillInfo{
+		Id: model.BillId{
+			CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+			Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+		},
+		CurrencyCode:    "USD",
+		Status:          model.Open,
+		TaxJurisdiction: "GE"}
+	authedContext := withAuth(newBill.Id.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, client, tokenDb, billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase := createBasicMocks(ctrl, newBill)
+	initialBillingState := workflow.BillingState{
+		BillInfo:          newBill,
+		BillLineItemCount: 0,
+		Total:             model.TotalAmount{Number: "0", CurrencyCode: newBill.CurrencyCode},
+	}
+	addGetExpectations(ctrl, client, initialBillingState)
+	closedBill := newBill
+	closedBill.Status = model.Closed
+	closedBill.CreatedAt = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	closedBill.CloseTime = time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC)
+	closedBill.ClosedAt = time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	finalBillingState := workflow.BillingState{
+		BillInfo:          closedBill,
+		BillLineItemCount: 1,
+		Total:             model.TotalAmount{Number: "100", CurrencyCode: newBill.CurrencyCode},
+		Tax: model.BillTax{
+			Lines: []model.TaxLine{{
+				Category: model.TaxCategoryStandard,
+				Rate:     "0.18",
+				Taxable:  model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+				Tax:      model.TotalAmount{Number: "18", CurrencyCode: "USD"},
+			}},
+			Subtotal:   model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+			TaxTotal:   model.TotalAmount{Number: "18", CurrencyCode: "USD"},
+			GrandTotal: model.TotalAmount{Number: "118", CurrencyCode: "USD"},
+		},
+	}
+	_ = addCloseExpectations(ctrl, client, billIdGenerator, "0b8c4f6e-3f0e-4d7e-9d64-3c1d3a8f0e11", finalBillingState)
+	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase)
+	_, err := s.OpenNewBill(authedContext, &rest.OpenNewBillRequest{
+		CurrencyCode:    "USD",
+		CloseTime:       time.Now().Add(time.Minute),
+		TaxJurisdiction: "GE",
+	})
+	assert.NoError(t, err)
+
+	// Act
+	resp, err := s.CloseBill(authedContext, newBill.Id.Id, &rest.CloseBillRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&rest.CloseBillResponse{
+			CurrencyCode:  "USD",
+			LineItemCount: 1,
+			Total:         "100",
+			CreatedAt:     closedBill.CreatedAt,
+			CloseTime:     closedBill.CloseTime,
+			ClosedAt:      &closedBill.ClosedAt,
+			Subtotal:      "100",
+			TaxTotal:      "18",
+			GrandTotal:    "118",
+			DiscountTotal: "0",
+			AmountDue:     "118",
 		},
 		resp)
 }
@@ -238,17 +371,14 @@ func TestAddLineItem(t *testing.T) {
 		},
 		CurrencyCode: "USD",
 		Status:       model.Open}
-	authedContext := auth.WithContext(context.Background(), auth.UID(newBill.Id.CustomerId), &rest.AuthData{})
+	authedContext := withAuth(newBill.Id.CustomerId, model.RoleOwner)
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	_, client, tokenDb, billIdGenerator, billDatabase := createBasicMocks(ctrl, newBill)
+	_, client, tokenDb, billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase := createBasicMocks(ctrl, newBill)
 	initialBillingState := workflow.BillingState{
 		BillInfo:          newBill,
 		BillLineItemCount: 0,
-		Total: model.TotalAmount{
-			Total: model.Amount{Number: 0, CurrencyCode: newBill.CurrencyCode},
-			Ok:    true,
-		},
+		Total:             model.TotalAmount{Number: "0", CurrencyCode: newBill.CurrencyCode},
 	}
 	addGetExpectations(ctrl, client, initialBillingState)
 	lineItem := model.BillLineItem{
@@ -259,12 +389,9 @@ func TestAddLineItem(t *testing.T) {
 	updatedBillingState := workflow.BillingState{
 		BillInfo:          newBill,
 		BillLineItemCount: 1,
-		Total: model.TotalAmount{
-			Total: model.Amount{Number: 100, CurrencyCode: newBill.CurrencyCode},
-			Ok:    true,
-		},
+		Total:             model.TotalAmount{Number: "100", CurrencyCode: newBill.CurrencyCode},
 	}
-	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase)
+	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase)
 	_, err := s.OpenNewBill(authedContext, &rest.OpenNewBillRequest{
 		CurrencyCode: "USD",
 		CloseTime:    time.Now().Add(time.Minute),
@@ -286,12 +413,61 @@ func TestAddLineItem(t *testing.T) {
 			Id:            lineItem.Id.Id,
 			CurrencyCode:  "USD",
 			LineItemCount: 1,
-			TotalOk:       "y",
-			Total:         100,
+			Total:         "100",
 		},
 		resp)
 }
 
+func TestAddInvalidPricedLineItem(t *testing.T) {
+	// Arrange
+	newBill := model.BillInfo{
+		Id: model.BillId{
+			CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+			Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+		},
+		CurrencyCode: "USD",
+		Status:       model.Open}
+	authedContext := withAuth(newBill.Id.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, client, tokenDb, billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase := createBasicMocks(ctrl, newBill)
+	addGetExpectations(ctrl, client, workflow.BillingState{
+		BillInfo: newBill,
+		Total:    model.TotalAmount{Number: "0", CurrencyCode: newBill.CurrencyCode},
+	})
+	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase)
+	_, err := s.OpenNewBill(authedContext, &rest.OpenNewBillRequest{
+		CurrencyCode: "USD",
+		CloseTime:    time.Now().Add(time.Minute),
+	})
+	assert.NoError(t, err)
+	billIdGenerator.EXPECT().New().Return("a8f2784e-a7e6-45b6-ad09-8186422a9261")
+	billIdGenerator.EXPECT().New().Return("a579a2e5-9c31-473e-94ed-577c7cd14acd")
+	updateHandle := mocks.NewMockWorkflowUpdateHandle(ctrl)
+	updateHandle.EXPECT().Get(gomock.Any(), gomock.Any()).Return(
+		temporal.NewApplicationError(`"0.0000000001" has more than 9 decimal places`, "PrecisionError"))
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, options sdkclient.UpdateWorkflowOptions) (sdkclient.WorkflowUpdateHandle, error) {
+			lineItem := options.Args[0].(workflow.AddBillLineItemArgs).LineItem
+			assert.Equal(t, "0.0000000001", lineItem.Quantity)
+			assert.Equal(t, "1", lineItem.UnitPrice)
+			assert.Equal(t, model.RoundDown, lineItem.Rounding)
+			return updateHandle, nil
+		})
+
+	// Act
+	_, err = s.AddBillLineItem(authedContext, newBill.Id.Id, &rest.AddBillLineItemRequest{
+		Description:  "Storage",
+		CurrencyCode: "USD",
+		Quantity:     "0.0000000001",
+		UnitPrice:    "1",
+		Rounding:     model.RoundDown,
+	})
+
+	// Assert
+	assert.Equal(t, errs.InvalidArgument, errs.Code(err))
+}
+
 func TestGetClosedBill(t *testing.T) {
 	// Arrange
 	newBill := model.BillInfo{
@@ -300,26 +476,61 @@ func TestGetClosedBill(t *testing.T) {
 			Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
 		},
 		CurrencyCode: "USD",
-		Status:       model.Closed}
-	authedContext := auth.WithContext(context.Background(), auth.UID(newBill.Id.CustomerId), &rest.AuthData{})
+		Status:       model.Paid,
+		CreatedAt:    time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		CloseTime:    time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC),
+		ClosedAt:     time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC),
+		SpendingCap:  model.NewSpendingCap(1000, "USD", []uint32{80}),
+	}
+	authedContext := withAuth(newBill.Id.CustomerId, model.RoleOwner)
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	client := mocks.NewMockClient(ctrl)
 	tokenDb := mocks.NewMockTokenDb(ctrl)
 	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
 	billDatabase := mocks.NewMockBillDatabase(ctrl)
+	ledgerDatabase := mocks.NewMockLedgerDatabase(ctrl)
+	auditDatabase := mocks.NewMockAuditDatabase(ctrl)
+	taxDatabase := mocks.NewMockTaxDatabase(ctrl)
 	// Bill is in database
 	billDatabase.EXPECT().
-		GetBill(gomock.Eq(newBill.Id)).
+		GetBill(gomock.Any(), gomock.Eq(newBill.Id)).
 		Return(
 			db.BillInfoAndMetadata{
 				BillInfo:      newBill,
 				LineItemCount: 1,
-				TotalAmount:   model.Amount{Number: 100, CurrencyCode: newBill.CurrencyCode},
-				TotalOk:       true,
+				Total:         model.TotalAmount{Number: "100", CurrencyCode: newBill.CurrencyCode},
 			},
 			nil).
 		Times(1)
+	// Bill is untaxed
+	taxDatabase.EXPECT().
+		GetBillTax(gomock.Any(), gomock.Eq(newBill.Id)).
+		Return(model.BillTax{}, nil).
+		Times(1)
+	couponDatabase := mocks.NewMockCouponDatabase(ctrl)
+	couponDatabase.EXPECT().
+		GetBillDiscounts(gomock.Any(), gomock.Eq(newBill.Id)).
+		Return([]model.DiscountLine{}, nil).
+		Times(1)
+	// Bill was paid on close
+	paid := model.PaymentAttempt{
+		BillId:      newBill.Id,
+		Number:      1,
+		Amount:      model.TotalAmount{Number: "100", CurrencyCode: newBill.CurrencyCode},
+		Status:      model.PaymentSucceeded,
+		Reference:   "fake-charge-1",
+		AttemptedAt: newBill.ClosedAt,
+	}
+	paymentDatabase := mocks.NewMockPaymentDatabase(ctrl)
+	paymentDatabase.EXPECT().
+		GetPaymentAttempts(gomock.Any(), gomock.Eq(newBill.Id)).
+		Return([]model.PaymentAttempt{paid}, nil).
+		Times(1)
+	paymentDatabase.EXPECT().
+		GetDunningNotifications(gomock.Any(), gomock.Eq(newBill.Id)).
+		Return([]model.DunningNotification{}, nil).
+		Times(1)
 	// Bill has been removed from workflows
 	client.EXPECT().
 		QueryWorkflow(
@@ -328,7 +539,7 @@ func TestGetClosedBill(t *testing.T) {
 			workflow.GetPendingBillStateQuery).
 		Return(nil, &serviceerror.NotFound{}).
 		Times(1)
-	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase)
+	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, mocks.NewMockUsageDatabase(ctrl))
 
 	// Act
 	resp, err := s.GetBill(authedContext, newBill.Id.Id, &rest.GetBillRequest{})
@@ -338,12 +549,1525 @@ func TestGetClosedBill(t *testing.T) {
 	assert.NotNil(t, resp)
 	assert.Equal(t,
 		&rest.GetBillResponse{
-			Id:            newBill.Id.Id,
-			CurrencyCode:  newBill.CurrencyCode,
-			Status:        model.Closed,
-			LineItemCount: 1,
-			TotalOk:       "y",
-			Total:         100,
+			Id:              newBill.Id.Id,
+			CurrencyCode:    newBill.CurrencyCode,
+			Status:          model.Paid,
+			LineItemCount:   1,
+			Total:           "100",
+			CreatedAt:       newBill.CreatedAt,
+			CloseTime:       newBill.CloseTime,
+			ClosedAt:        &newBill.ClosedAt,
+			Subtotal:        "100",
+			TaxTotal:        "0",
+			GrandTotal:      "100",
+			DiscountTotal:   "0",
+			AmountDue:       "100",
+			Payments:        []model.PaymentAttempt{paid},
+			Notifications:   []model.DunningNotification{},
+			SpendingCap:     1000,
+			AlertThresholds: []uint32{80},
+		},
+		resp)
+}
+
+func TestGetBalance(t *testing.T) {
+	// Arrange
+	customerId := model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633")
+	authedContext := withAuth(customerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ledgerDatabase := mocks.NewMockLedgerDatabase(ctrl)
+	ledgerDatabase.EXPECT().
+		GetBalance(gomock.Any(), gomock.Eq(model.LedgerAccount{CustomerId: customerId, Type: model.Receivable, CurrencyCode: "USD"})).
+		Return(model.TotalAmount{Number: "300", CurrencyCode: "USD"}, nil)
+	ledgerDatabase.EXPECT().
+		GetBalance(gomock.Any(), gomock.Eq(model.LedgerAccount{CustomerId: customerId, Type: model.AccruedReceivable, CurrencyCode: "USD"})).
+		Return(model.TotalAmount{Number: "100", CurrencyCode: "USD"}, nil)
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		ledgerDatabase,
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.GetBalance(authedContext, "USD", &rest.GetBalanceRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&rest.GetBalanceResponse{
+			CurrencyCode:      "USD",
+			Receivable:        "300",
+			AccruedReceivable: "100",
+		},
+		resp)
+}
+
+func TestGetBillHistory(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	closedAt := time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC)
+	auditDatabase := mocks.NewMockAuditDatabase(ctrl)
+	auditDatabase.EXPECT().
+		GetEntries(gomock.Any(), gomock.Eq(billId)).
+		Return([]model.AuditEntry{
+			{
+				BillId:       billId,
+				Action:       model.AuditClose,
+				Actor:        model.NewSystemTimerActor(),
+				RequestId:    workflow.CloseAtMaturityRequestId,
+				TotalBefore:  model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+				TotalAfter:   model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+				WorkflowTime: closedAt,
+			},
+		}, nil)
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		auditDatabase,
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.GetBillHistory(authedContext, billId.Id, &rest.GetBillHistoryRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&rest.GetBillHistoryResponse{
+			Id: billId.Id,
+			Entries: []rest.BillHistoryEntry{
+				{
+					Action:       model.AuditClose,
+					ActorType:    model.ActorSystemTimer,
+					ActorId:      "",
+					RequestId:    "maturity",
+					TotalBefore:  "100",
+					TotalAfter:   "100",
+					WorkflowTime: closedAt,
+				},
+			},
+		},
+		resp)
+}
+
+func TestSearchBills(t *testing.T) {
+	// Arrange
+	customerId := model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633")
+	authedContext := withAuth(customerId, model.RoleViewer)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	closeTime := time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC)
+	dataConverter := converter.GetDefaultDataConverter()
+	searchAttributes := make(map[string]*common.Payload)
+	for name, value := range map[string]any{
+		workflow.CustomerIdSearchAttribute.GetName():   string(customerId),
+		workflow.CurrencyCodeSearchAttribute.GetName(): "USD",
+		workflow.StatusSearchAttribute.GetName():       "open",
+		workflow.CloseTimeSearchAttribute.GetName():    closeTime,
+		workflow.TotalSearchAttribute.GetName():        int64(100),
+	} {
+		payload, err := dataConverter.ToPayload(value)
+		assert.NoError(t, err)
+		searchAttributes[name] = payload
+	}
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().
+		ListWorkflow(gomock.Any(), gomock.Eq(&workflowservice.ListWorkflowExecutionsRequest{
+			PageSize: 20,
+			Query: "WorkflowType = 'BillingWorkflow' AND BillCustomerId = 'aec31fe6-04b5-4dbf-a024-b5f45db6f633' AND " +
+				"BillStatus = 'open' AND BillCurrencyCode = 'USD' AND BillCloseTime < '2025-04-01T00:00:00Z'",
+		})).
+		Return(&workflowservice.ListWorkflowExecutionsResponse{
+			Executions: []*workflowpb.WorkflowExecutionInfo{
+				{
+					Execution:        &common.WorkflowExecution{WorkflowId: "create-bill-fc03932f-2b53-4d07-ad55-24fc7d85e277"},
+					SearchAttributes: &common.SearchAttributes{IndexedFields: searchAttributes},
+				},
+			},
+			NextPageToken: []byte("next"),
+		}, nil)
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.SearchBills(authedContext, &rest.SearchBillsRequest{
+		CurrencyCode: "USD",
+		CloseBefore:  time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&rest.SearchBillsResponse{
+			Bills: []rest.BillSearchResult{
+				{
+					Id:           "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+					CurrencyCode: "USD",
+					Status:       model.Open,
+					CloseTime:    closeTime,
+					Total:        "100",
+				},
+			},
+			NextPageToken: "bmV4dA",
+		},
+		resp)
+}
+
+func TestSearchBillsWithInvalidStatus(t *testing.T) {
+	// Arrange
+	authedContext := withAuth(model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"), model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	_, err := s.SearchBills(authedContext, &rest.SearchBillsRequest{Status: "open' OR BillCustomerId != '"})
+
+	// Assert
+	assert.Equal(t, errs.InvalidArgument, errs.Code(err))
+}
+
+func TestSearchBillsSettledByDunning(t *testing.T) {
+	// Arrange
+	authedContext := withAuth(model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"), model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	for _, status := range []string{"paid", "payment_failed", "uncollectible", "closed"} {
+		// Act
+		_, err := s.SearchBills(authedContext, &rest.SearchBillsRequest{Status: status})
+
+		// Assert
+		assert.Equal(t, errs.InvalidArgument, errs.Code(err), status)
+	}
+}
+
+func TestListArchivedBillLineItems(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	billDatabase := mocks.NewMockBillDatabase(ctrl)
+	// The archived bill database is transparent
+	billDatabase.EXPECT().
+		GetLineItems(gomock.Any(), gomock.Eq(billId)).
+		Return([]model.BillLineItem{
+			{
+				Id:          model.BillLineItemId{BillId: billId, Id: "a579a2e5-9c31-473e-94ed-577c7cd14acd"},
+				Description: "Matchbox",
+				Amount:      model.Amount{Number: 100, CurrencyCode: "USD"},
+				CreatedAt:   createdAt,
+			},
+		}, nil)
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		billDatabase,
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.ListBillLineItems(authedContext, billId.Id, &rest.ListBillLineItemsRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&rest.ListBillLineItemsResponse{
+			Id: billId.Id,
+			LineItems: []rest.BillLineItemResponse{
+				{
+					Id:           "a579a2e5-9c31-473e-94ed-577c7cd14acd",
+					Description:  "Matchbox",
+					Amount:       100,
+					CurrencyCode: "USD",
+					CreatedAt:    createdAt,
+				},
+			},
+		},
+		resp)
+}
+
+func TestListConvertedBillLineItems(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rateAsOf := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	billDatabase := mocks.NewMockBillDatabase(ctrl)
+	billDatabase.EXPECT().
+		GetLineItems(gomock.Any(), gomock.Eq(billId)).
+		Return([]model.BillLineItem{
+			{
+				Id:          model.BillLineItemId{BillId: billId, Id: "a579a2e5-9c31-473e-94ed-577c7cd14acd"},
+				Description: "Matchbox",
+				Amount:      model.Amount{Number: 100, CurrencyCode: "USD"},
+				CreatedAt:   createdAt,
+				Conversion: &model.FxConversion{
+					OriginalAmount: model.Amount{Number: 270, CurrencyCode: "GEL"},
+					Rate:           "0.37",
+					AsOf:           rateAsOf,
+				},
+			},
+		}, nil)
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		billDatabase,
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.ListBillLineItems(authedContext, billId.Id, &rest.ListBillLineItemsRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&rest.ListBillLineItemsResponse{
+			Id: billId.Id,
+			LineItems: []rest.BillLineItemResponse{
+				{
+					Id:           "a579a2e5-9c31-473e-94ed-577c7cd14acd",
+					Description:  "Matchbox",
+					Amount:       100,
+					CurrencyCode: "USD",
+					CreatedAt:    createdAt,
+					Conversion: &rest.FxConversionResponse{
+						OriginalAmount:       270,
+						OriginalCurrencyCode: "GEL",
+						Rate:                 "0.37",
+						RateAsOf:             rateAsOf,
+					},
+				},
+			},
+		},
+		resp)
+}
+
+func TestAttachCoupon(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	updatedState := workflow.BillingState{
+		BillInfo: model.BillInfo{Id: billId, CurrencyCode: "USD", Status: model.Open},
+		Coupons: []model.Coupon{
+			{Code: "TEN", Type: model.PercentageCoupon, Value: "10"},
+			{Code: "FIVE", Type: model.FixedAmountCoupon, Value: "5.00", CurrencyCode: "USD"},
+		},
+	}
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("6a1c3e0b-2d4f-4b8a-9e7c-5f3d2b1a0c9e")
+	updateHandle := mocks.NewMockWorkflowUpdateHandle(ctrl)
+	updateHandle.EXPECT().Get(gomock.Any(), gomock.Any()).SetArg(1, updatedState).Return(nil)
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).Return(updateHandle, nil)
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.AttachCoupon(authedContext, billId.Id, &rest.AttachCouponRequest{Code: "FIVE"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&rest.AttachCouponResponse{Id: billId.Id, Coupons: []string{"TEN", "FIVE"}},
+		resp)
+}
+
+func TestAttachExhaustedCoupon(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("6a1c3e0b-2d4f-4b8a-9e7c-5f3d2b1a0c9e")
+	updateHandle := mocks.NewMockWorkflowUpdateHandle(ctrl)
+	updateHandle.EXPECT().Get(gomock.Any(), gomock.Any()).Return(
+		temporal.NewNonRetryableApplicationError("coupon has no redemptions left", "CouponNotRedeemable", nil))
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).Return(updateHandle, nil)
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	_, err := s.AttachCoupon(authedContext, billId.Id, &rest.AttachCouponRequest{Code: "SPRING10"})
+
+	// Assert
+	assert.Equal(t, errs.FailedPrecondition, errs.Code(err))
+}
+
+func TestSplitCharge(t *testing.T) {
+	// Arrange
+	customerId := model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633")
+	billIds := []string{"fc03932f-2b53-4d07-ad55-24fc7d85e277", "0b6f1c1e-8d0e-4f57-9a2b-3c4d5e6f7a8b"}
+	authedContext := withAuth(customerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mocks.NewMockClient(ctrl)
+	for _, id := range billIds {
+		client.EXPECT().
+			QueryWorkflow(gomock.Any(), rest.CreateWorkflowId(id), gomock.Any(), workflow.GetPendingBillStateQuery).
+			Return(encodeMockedState(ctrl, workflow.BillingState{
+				BillInfo: model.BillInfo{Id: model.BillId{CustomerId: customerId, Id: id}, CurrencyCode: "USD", Status: model.Open},
+			}), nil)
+	}
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	gomock.InOrder(
+		billIdGenerator.EXPECT().New().Return("split-1"),
+		billIdGenerator.EXPECT().New().Return("line-item-1"),
+		billIdGenerator.EXPECT().New().Return("line-item-2"),
+	)
+	workflowRun := mocks.NewMockWorkflowRun(ctrl)
+	workflowRun.EXPECT().Get(gomock.Any(), nil).Return(nil)
+	var request workflow.SplitChargeRequest
+	client.EXPECT().
+		ExecuteWorkflow(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, options sdkclient.StartWorkflowOptions, _ interface{}, args ...interface{}) (sdkclient.WorkflowRun, error) {
+			assert.Equal(t, workflow.SplitChargeWorkflowId("split-1"), options.ID)
+			request = args[0].(workflow.SplitChargeRequest)
+			return workflowRun, nil
+		})
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.SplitCharge(authedContext, &rest.SplitChargeRequest{
+		Description:  "Dinner",
+		Amount:       1001,
+		CurrencyCode: "USD",
+		BillIds:      billIds,
+		Ratios:       []uint64{1, 2},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&rest.SplitChargeResponse{
+			Id:           "split-1",
+			CurrencyCode: "USD",
+			LineItems: []rest.SplitChargeLineItem{
+				{BillId: billIds[0], LineItemId: "line-item-1", Amount: 334},
+				{BillId: billIds[1], LineItemId: "line-item-2", Amount: 667},
+			},
 		},
 		resp)
+	assert.Equal(t, "split-1", request.SplitId)
+	assert.Equal(t, "Dinner (1/2)", request.LineItems[0].Description)
+	assert.Equal(t, "Dinner (2/2)", request.LineItems[1].Description)
+}
+
+func TestSplitChargeLeavesOutZeroParts(t *testing.T) {
+	// Arrange
+	customerId := model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633")
+	billIds := []string{"fc03932f-2b53-4d07-ad55-24fc7d85e277", "0b6f1c1e-8d0e-4f57-9a2b-3c4d5e6f7a8b"}
+	authedContext := withAuth(customerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mocks.NewMockClient(ctrl)
+	for _, id := range billIds {
+		client.EXPECT().
+			QueryWorkflow(gomock.Any(), rest.CreateWorkflowId(id), gomock.Any(), workflow.GetPendingBillStateQuery).
+			Return(encodeMockedState(ctrl, workflow.BillingState{
+				BillInfo: model.BillInfo{Id: model.BillId{CustomerId: customerId, Id: id}, CurrencyCode: "USD", Status: model.Open},
+			}), nil)
+	}
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	gomock.InOrder(
+		billIdGenerator.EXPECT().New().Return("split-1"),
+		billIdGenerator.EXPECT().New().Return("line-item-1"),
+	)
+	workflowRun := mocks.NewMockWorkflowRun(ctrl)
+	workflowRun.EXPECT().Get(gomock.Any(), nil).Return(nil)
+	var request workflow.SplitChargeRequest
+	client.EXPECT().
+		ExecuteWorkflow(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ sdkclient.StartWorkflowOptions, _ interface{}, args ...interface{}) (sdkclient.WorkflowRun, error) {
+			request = args[0].(workflow.SplitChargeRequest)
+			return workflowRun, nil
+		})
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.SplitCharge(authedContext, &rest.SplitChargeRequest{
+		Description:  "Dinner",
+		Amount:       1,
+		CurrencyCode: "USD",
+		BillIds:      billIds,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []rest.SplitChargeLineItem{{BillId: billIds[0], LineItemId: "line-item-1", Amount: 1}}, resp.LineItems)
+	assert.Equal(t,
+		[]model.BillLineItem{{
+			Id:          model.BillLineItemId{BillId: model.BillId{CustomerId: customerId, Id: billIds[0]}, Id: "line-item-1"},
+			Description: "Dinner (1/1)",
+			Amount:      model.Amount{Number: 1, CurrencyCode: "USD"},
+		}},
+		request.LineItems)
+}
+
+func TestGetBillBeingDunned(t *testing.T) {
+	// Arrange
+	billInfo := model.BillInfo{
+		Id: model.BillId{
+			CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+			Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+		},
+		CurrencyCode: "USD",
+		Status:       model.PaymentFailed,
+		ClosedAt:     time.Date(2025, 3, 31, 23, 59, 59, 0, time.UTC),
+	}
+	authedContext := withAuth(billInfo.Id.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	declined := model.PaymentAttempt{
+		BillId:        billInfo.Id,
+		Number:        1,
+		Amount:        model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Status:        model.PaymentDeclined,
+		FailureReason: "insufficient funds",
+		AttemptedAt:   billInfo.ClosedAt,
+	}
+	retried := declined
+	retried.Number = 2
+	retried.AttemptedAt = billInfo.ClosedAt.Add(24 * time.Hour)
+	reminder := model.DunningNotification{
+		BillId:    billInfo.Id,
+		Step:      1,
+		Kind:      model.DunningReminder,
+		AmountDue: declined.Amount,
+		SentAt:    retried.AttemptedAt,
+	}
+	client := mocks.NewMockClient(ctrl)
+	addGetExpectations(ctrl, client, workflow.BillingState{
+		BillInfo:          billInfo,
+		BillLineItemCount: 1,
+		Total:             model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Payments:          []model.PaymentAttempt{declined},
+	})
+	encodedDunningState := mocks.NewMockEncodedValue(ctrl)
+	encodedDunningState.EXPECT().
+		Get(gomock.Any()).
+		SetArg(0, workflow.DunningState{
+			BillId:        billInfo.Id,
+			Amount:        declined.Amount,
+			Status:        model.PaymentFailed,
+			Payments:      []model.PaymentAttempt{retried},
+			Notifications: []model.DunningNotification{reminder},
+		}).
+		Return(nil)
+	client.EXPECT().
+		QueryWorkflow(gomock.Any(), workflow.DunningWorkflowId(billInfo.Id), gomock.Any(), workflow.GetDunningStateQuery).
+		Return(encodedDunningState, nil)
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.GetBill(authedContext, billInfo.Id.Id, &rest.GetBillRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, model.PaymentFailed, resp.Status)
+	assert.Equal(t, []model.PaymentAttempt{declined, retried}, resp.Payments)
+	assert.Equal(t, []model.DunningNotification{reminder}, resp.Notifications)
+}
+
+func TestRecordPayment(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	dunning := workflow.DunningState{
+		BillId: billId,
+		Amount: model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Status: model.PaymentFailed,
+	}
+	received := model.PaymentAttempt{
+		BillId:    billId,
+		Number:    2,
+		Amount:    dunning.Amount,
+		Status:    model.PaymentSucceeded,
+		Reference: "wire-42",
+	}
+	client := mocks.NewMockClient(ctrl)
+	encodedDunningState := mocks.NewMockEncodedValue(ctrl)
+	encodedDunningState.EXPECT().Get(gomock.Any()).SetArg(0, dunning).Return(nil)
+	client.EXPECT().
+		QueryWorkflow(gomock.Any(), workflow.DunningWorkflowId(billId), gomock.Any(), workflow.GetDunningStateQuery).
+		Return(encodedDunningState, nil)
+	paid := dunning
+	paid.Status = model.Paid
+	paid.Payments = []model.PaymentAttempt{received}
+	updateHandle := mocks.NewMockWorkflowUpdateHandle(ctrl)
+	updateHandle.EXPECT().Get(gomock.Any(), gomock.Any()).SetArg(1, paid).Return(nil)
+	client.EXPECT().
+		UpdateWorkflow(gomock.Any(), sdkclient.UpdateWorkflowOptions{
+			UpdateID:     "wire-42",
+			WorkflowID:   workflow.DunningWorkflowId(billId),
+			UpdateName:   workflow.RecordPaymentUpdate,
+			Args:         []interface{}{workflow.RecordPaymentArgs{Reference: "wire-42"}},
+			WaitForStage: sdkclient.WorkflowUpdateStageCompleted,
+		}).
+		Return(updateHandle, nil)
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.RecordPayment(authedContext, billId.Id, &rest.RecordPaymentRequest{Reference: "wire-42"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&rest.RecordPaymentResponse{Id: billId.Id, Status: model.Paid, Payments: []model.PaymentAttempt{received}},
+		resp)
+}
+
+func TestOpenNewCappedBill(t *testing.T) {
+	// Arrange
+	newBill := model.BillInfo{
+		Id: model.BillId{
+			CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+			Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+		},
+		CurrencyCode: "USD",
+		Status:       model.Open,
+		SpendingCap:  model.NewSpendingCap(1000, "USD", []uint32{50, 80, 100})}
+	authedContext := withAuth(newBill.Id.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, client, tokenDb, billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase := createBasicMocks(ctrl, newBill)
+	addGetExpectations(ctrl, client, workflow.BillingState{
+		BillInfo: newBill,
+		Total:    model.TotalAmount{Number: "0", CurrencyCode: newBill.CurrencyCode},
+	})
+	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase)
+
+	// Act
+	resp, err := s.OpenNewBill(authedContext, &rest.OpenNewBillRequest{
+		CurrencyCode:    "USD",
+		CloseTime:       time.Now().Add(time.Minute),
+		SpendingCap:     1000,
+		AlertThresholds: []uint32{50, 80, 100},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, &rest.OpenNewBillResponse{Id: newBill.Id.Id}, resp)
+}
+
+func TestOpenNewBillWithInvalidSpendingCap(t *testing.T) {
+	// Arrange
+	authedContext := withAuth("aec31fe6-04b5-4dbf-a024-b5f45db6f633", model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("fc03932f-2b53-4d07-ad55-24fc7d85e277")
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	_, err := s.OpenNewBill(authedContext, &rest.OpenNewBillRequest{
+		CurrencyCode:    "USD",
+		CloseTime:       time.Now().Add(time.Minute),
+		SpendingCap:     1000,
+		AlertThresholds: []uint32{80, 50},
+	})
+
+	// Assert
+	assert.Equal(t, errs.InvalidArgument, errs.Code(err))
+}
+
+func TestOpenNewBillWithUnknownTaxJurisdiction(t *testing.T) {
+	// Arrange
+	authedContext := withAuth("aec31fe6-04b5-4dbf-a024-b5f45db6f633", model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("fc03932f-2b53-4d07-ad55-24fc7d85e277")
+	taxDatabase := mocks.NewMockTaxDatabase(ctrl)
+	taxDatabase.EXPECT().
+		GetTaxRate(gomock.Any(), model.TaxJurisdiction("XX"), model.TaxCategoryStandard).
+		Return(model.TaxRate{}, db.ErrTaxRateNotFound)
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		taxDatabase,
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	_, err := s.OpenNewBill(authedContext, &rest.OpenNewBillRequest{
+		CurrencyCode:    "USD",
+		CloseTime:       time.Now().Add(time.Minute),
+		TaxJurisdiction: "XX",
+	})
+
+	// Assert
+	assert.Equal(t, errs.InvalidArgument, errs.Code(err))
+}
+
+func TestAddLineItemOverSpendingCap(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("a8f2784e-a7e6-45b6-ad09-8186422a9261")
+	billIdGenerator.EXPECT().New().Return("a579a2e5-9c31-473e-94ed-577c7cd14acd")
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).Return(nil,
+		temporal.NewApplicationError("total 1100 USD would exceed the spending cap of 1000", "SpendingCapExceededError"))
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	_, err := s.AddBillLineItem(authedContext, billId.Id, &rest.AddBillLineItemRequest{
+		Description:  "Candle",
+		CurrencyCode: "USD",
+		Amount:       200,
+	})
+
+	// Assert
+	assert.Equal(t, errs.FailedPrecondition, errs.Code(err))
+}
+
+func TestOpenNewBillOverOpenBillQuota(t *testing.T) {
+	// Arrange
+	customerId := model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633")
+	authedContext := withAuth(customerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("fc03932f-2b53-4d07-ad55-24fc7d85e277")
+	billDatabase := mocks.NewMockBillDatabase(ctrl)
+	billDatabase.EXPECT().CountRunningBills(gomock.Any(), customerId).Return(uint64(2), nil)
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		billDatabase,
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+	s.SetQuotas(model.Quotas{MaxOpenBills: 2})
+
+	// Act
+	_, err := s.OpenNewBill(authedContext, &rest.OpenNewBillRequest{
+		CurrencyCode: "USD",
+		CloseTime:    time.Now().Add(time.Minute),
+	})
+
+	// Assert
+	assert.Equal(t, errs.ResourceExhausted, errs.Code(err))
+}
+
+func TestOpenNewBillRefusedByOpenBillQuotaWhenSaved(t *testing.T) {
+	// Arrange
+	newBill := model.BillInfo{
+		Id: model.BillId{
+			CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+			Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+		},
+		CurrencyCode: "USD",
+		Status:       model.Open}
+	authedContext := withAuth(newBill.Id.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	workflowRun, client, tokenDb, billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase := createBasicMocks(ctrl, newBill)
+	// A concurrent request opened a bill since the count
+	workflowRun.EXPECT().GetID().Return("mock-wr-id").AnyTimes()
+	workflowRun.EXPECT().GetRunID().Return("mock-run-id").AnyTimes()
+	workflowRun.EXPECT().Get(gomock.Any(), gomock.Any()).
+		Return(temporal.NewNonRetryableApplicationError("the customer already has the maximum of 1000 open bills", "OpenBillQuotaExceededError", nil))
+	client.EXPECT().
+		QueryWorkflow(gomock.Any(), gomock.Any(), gomock.Any(), workflow.GetPendingBillStateQuery).
+		Return(nil, serviceerror.NewQueryFailed("unknown queryType GetPendingBillState"))
+	client.EXPECT().
+		DescribeWorkflowExecution(gomock.Any(), "mock-wr-id", "mock-run-id").
+		Return(&workflowservice.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{Status: enums.WORKFLOW_EXECUTION_STATUS_FAILED},
+		}, nil)
+	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase)
+
+	// Act
+	_, err := s.OpenNewBill(authedContext, &rest.OpenNewBillRequest{
+		CurrencyCode: "USD",
+		CloseTime:    time.Now().Add(time.Minute),
+	})
+
+	// Assert
+	assert.Equal(t, errs.ResourceExhausted, errs.Code(err))
+}
+
+func TestAddLineItemOverLineItemQuota(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("a8f2784e-a7e6-45b6-ad09-8186422a9261")
+	billIdGenerator.EXPECT().New().Return("a579a2e5-9c31-473e-94ed-577c7cd14acd")
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).Return(nil,
+		temporal.NewApplicationError("the bill already has the maximum of 10000 line items", "LineItemQuotaExceededError"))
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	_, err := s.AddBillLineItem(authedContext, billId.Id, &rest.AddBillLineItemRequest{
+		Description:  "Candle",
+		CurrencyCode: "USD",
+		Amount:       200,
+	})
+
+	// Assert
+	assert.Equal(t, errs.ResourceExhausted, errs.Code(err))
+}
+
+func TestViewerCannotAddLineItem(t *testing.T) {
+	// Arrange
+	authedContext := withAuth("aec31fe6-04b5-4dbf-a024-b5f45db6f633", model.RoleViewer)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	_, err := s.AddBillLineItem(authedContext, "fc03932f-2b53-4d07-ad55-24fc7d85e277", &rest.AddBillLineItemRequest{
+		Description:  "Candle",
+		CurrencyCode: "USD",
+		Amount:       200,
+	})
+	_, membersErr := s.ListMembers(withAuth("aec31fe6-04b5-4dbf-a024-b5f45db6f633", model.RoleBillingAdmin))
+
+	// Assert
+	assert.Equal(t, errs.PermissionDenied, errs.Code(err))
+	assert.Equal(t, errs.PermissionDenied, errs.Code(membersErr))
+}
+
+func TestSetMemberKeepsAnOwner(t *testing.T) {
+	// Arrange
+	customerId := model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633")
+	authedContext := withAuth(customerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+	s.SetOrganizationDb(db.NewInMemoryOrganizationDatabase(model.Membership{OrgId: customerId, UserId: aliceUserId, Role: model.RoleOwner}))
+
+	// Act
+	setResp, setErr := s.SetMember(authedContext, "c2a8e6f4-1d3b-4f5a-b7c9-e1f3a5b7c9d2", &rest.SetMemberRequest{Role: "viewer"})
+	_, demoteErr := s.SetMember(authedContext, string(aliceUserId), &rest.SetMemberRequest{Role: "billing-admin"})
+	_, invalidErr := s.SetMember(authedContext, "c2a8e6f4-1d3b-4f5a-b7c9-e1f3a5b7c9d2", &rest.SetMemberRequest{Role: "auditor"})
+	_, removeErr := s.RemoveMember(authedContext, string(aliceUserId))
+	listResp, listErr := s.ListMembers(authedContext)
+
+	// Assert
+	assert.NoError(t, setErr)
+	assert.Equal(t, "viewer", setResp.Member.Role)
+	assert.Equal(t, errs.FailedPrecondition, errs.Code(demoteErr))
+	assert.Equal(t, errs.InvalidArgument, errs.Code(invalidErr))
+	assert.Equal(t, errs.FailedPrecondition, errs.Code(removeErr))
+	assert.NoError(t, listErr)
+	assert.Len(t, listResp.Members, 2)
+	assert.Equal(t, string(aliceUserId), listResp.Members[0].UserId)
+	assert.Equal(t, "owner", listResp.Members[0].Role)
+}
+
+func TestSetSpendingCap(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	spendingCap := model.NewSpendingCap(1000, "USD", []uint32{50, 80})
+	alert := model.SpendingAlert{
+		BillId:    billId,
+		Threshold: 50,
+		Cap:       spendingCap.Max,
+		Total:     model.TotalAmount{Number: "600", CurrencyCode: "USD"},
+		SentAt:    time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+	}
+	updatedState := workflow.BillingState{
+		BillInfo:       model.BillInfo{Id: billId, CurrencyCode: "USD", Status: model.Open, SpendingCap: spendingCap},
+		Total:          model.TotalAmount{Number: "600", CurrencyCode: "USD"},
+		SpendingAlerts: []model.SpendingAlert{alert},
+	}
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("6a1c3e0b-2d4f-4b8a-9e7c-5f3d2b1a0c9e")
+	updateHandle := mocks.NewMockWorkflowUpdateHandle(ctrl)
+	updateHandle.EXPECT().Get(gomock.Any(), gomock.Any()).SetArg(1, updatedState).Return(nil)
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, options sdkclient.UpdateWorkflowOptions) (sdkclient.WorkflowUpdateHandle, error) {
+			assert.Equal(t, workflow.SetSpendingCapUpdate, options.UpdateName)
+			assert.Equal(t, workflow.SetSpendingCapArgs{
+				Max:             1000,
+				AlertThresholds: []uint32{50, 80},
+				Actor:           model.NewUserActor(aliceUserId),
+				RequestId:       "6a1c3e0b-2d4f-4b8a-9e7c-5f3d2b1a0c9e",
+			}, options.Args[0])
+			return updateHandle, nil
+		})
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.SetSpendingCap(authedContext, billId.Id, &rest.SetSpendingCapRequest{SpendingCap: 1000, AlertThresholds: []uint32{50, 80}})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&rest.SetSpendingCapResponse{
+			Id:              billId.Id,
+			SpendingCap:     1000,
+			AlertThresholds: []uint32{50, 80},
+			Total:           "600",
+			SpendingAlerts:  []model.SpendingAlert{alert},
+		},
+		resp)
+}
+
+func TestRecordUsage(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	recordedAt := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	usageDatabase := mocks.NewMockUsageDatabase(ctrl)
+	usageDatabase.EXPECT().
+		RecordUsageEvents(gomock.Any(), billId, []model.UsageEvent{
+			{Id: "e1", BillId: billId, Meter: "api_calls", Quantity: "600", UnitPrice: "0.01", RecordedAt: recordedAt},
+			{Id: "e2", BillId: billId, Meter: "api_calls", Quantity: "634", UnitPrice: "0.01", RecordedAt: recordedAt},
+		}).
+		Return(uint64(1), nil)
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		usageDatabase)
+
+	// Act
+	resp, err := s.RecordUsage(authedContext, billId.Id, &rest.RecordUsageRequest{Events: []rest.UsageEventRequest{
+		{Id: "e1", Meter: "api_calls", Quantity: "600", UnitPrice: "0.01", RecordedAt: recordedAt},
+		{Id: "e2", Meter: "api_calls", Quantity: "634", UnitPrice: "0.01", RecordedAt: recordedAt},
+	}})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, &rest.RecordUsageResponse{Id: billId.Id, Received: 2, Recorded: 1}, resp)
+}
+
+func TestRecordUsageOfClosedBill(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	usageDatabase := mocks.NewMockUsageDatabase(ctrl)
+	usageDatabase.EXPECT().RecordUsageEvents(gomock.Any(), billId, gomock.Any()).Return(uint64(0), db.ErrBillClosed)
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		usageDatabase)
+
+	// Act
+	_, err := s.RecordUsage(authedContext, billId.Id, &rest.RecordUsageRequest{Events: []rest.UsageEventRequest{
+		{Id: "e1", Meter: "api_calls", Quantity: "600", UnitPrice: "0.01"},
+	}})
+	_, errInvalid := s.RecordUsage(authedContext, billId.Id, &rest.RecordUsageRequest{Events: []rest.UsageEventRequest{
+		{Id: "e2", Meter: "api_calls", Quantity: "-1", UnitPrice: "0.01"},
+	}})
+
+	// Assert
+	assert.Equal(t, errs.FailedPrecondition, errs.Code(err))
+	assert.Equal(t, errs.InvalidArgument, errs.Code(errInvalid))
+}
+
+func TestAddLineItemsInBatch(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("a8f2784e-a7e6-45b6-ad09-8186422a9261")
+	billIdGenerator.EXPECT().New().Return("a579a2e5-9c31-473e-94ed-577c7cd14acd")
+	updateHandle := mocks.NewMockWorkflowUpdateHandle(ctrl)
+	updateHandle.EXPECT().Get(gomock.Any(), gomock.Any()).SetArg(1, workflow.AddBillLineItemsResult{
+		State: workflow.BillingState{
+			BillInfo:          model.BillInfo{Id: billId, CurrencyCode: "USD", Status: model.Open},
+			BillLineItemCount: 2,
+			Total:             model.TotalAmount{Number: "300", CurrencyCode: "USD"},
+		},
+		Results: []workflow.BillLineItemResult{
+			{Id: "candle-1", Outcome: workflow.BillLineItemAlreadyAdded},
+			{Id: "a579a2e5-9c31-473e-94ed-577c7cd14acd", Outcome: workflow.BillLineItemAdded},
+			{Id: "c3b0a7a2-1c1e-4cbe-9d3b-55c2d1bc2f7e", Outcome: workflow.BillLineItemRefused, Error: "currency code GEL is not USD"},
+		},
+	}).Return(nil)
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, options sdkclient.UpdateWorkflowOptions) (sdkclient.WorkflowUpdateHandle, error) {
+			args := options.Args[0].(workflow.AddBillLineItemsArgs)
+			assert.Equal(t, workflow.AddBillLineItemsUpdate, options.UpdateName)
+			assert.Equal(t, "a8f2784e-a7e6-45b6-ad09-8186422a9261", args.RequestId)
+			assert.False(t, args.AllOrNothing)
+			assert.Len(t, args.LineItems, 3)
+			assert.Equal(t, model.BillLineItemId{BillId: billId, Id: "candle-1"}, args.LineItems[0].Id)
+			assert.Equal(t, model.BillLineItemId{BillId: billId, Id: "a579a2e5-9c31-473e-94ed-577c7cd14acd"}, args.LineItems[1].Id)
+			assert.Equal(t, "2", args.LineItems[1].Quantity)
+			return updateHandle, nil
+		})
+	billIdGenerator.EXPECT().New().Return("c3b0a7a2-1c1e-4cbe-9d3b-55c2d1bc2f7e")
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.AddBillLineItems(authedContext, billId.Id, &rest.AddBillLineItemsRequest{
+		LineItems: []rest.BatchLineItemRequest{
+			{Id: "candle-1", Description: "Candle", CurrencyCode: "USD", Amount: 100},
+			{Description: "Matchbox", CurrencyCode: "USD", Quantity: "2", UnitPrice: "100"},
+			{Description: "Lamp", CurrencyCode: "GEL", Amount: 500},
+		},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&rest.AddBillLineItemsResponse{
+			Id:            billId.Id,
+			CurrencyCode:  "USD",
+			LineItemCount: 2,
+			Total:         "300",
+			Results: []rest.BatchLineItemResult{
+				{Id: "candle-1", Status: workflow.BillLineItemAlreadyAdded},
+				{Id: "a579a2e5-9c31-473e-94ed-577c7cd14acd", Status: workflow.BillLineItemAdded},
+				{Id: "c3b0a7a2-1c1e-4cbe-9d3b-55c2d1bc2f7e", Status: workflow.BillLineItemRefused, Error: "currency code GEL is not USD"},
+			},
+		},
+		resp)
+}
+
+func TestAddLineItemsInBatchToClosingBill(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("a8f2784e-a7e6-45b6-ad09-8186422a9261")
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).Return(nil,
+		temporal.NewApplicationError("bill is closing", "BillClosingError"))
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	_, err := s.AddBillLineItems(authedContext, billId.Id, &rest.AddBillLineItemsRequest{
+		LineItems:    []rest.BatchLineItemRequest{{Id: "candle-1", Description: "Candle", CurrencyCode: "USD", Amount: 100}},
+		AllOrNothing: true,
+	})
+
+	// Assert
+	assert.Equal(t, errs.FailedPrecondition, errs.Code(err))
+}
+
+func TestAddLineItemWithIdempotencyKey(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	idempotentId := model.NewIdempotentId(billId.CustomerId, "retry-me")
+	updateHandle := mocks.NewMockWorkflowUpdateHandle(ctrl)
+	updateHandle.EXPECT().Get(gomock.Any(), gomock.Any()).SetArg(1, workflow.BillingState{
+		BillInfo:          model.BillInfo{Id: billId, CurrencyCode: "USD", Status: model.Open},
+		BillLineItemCount: 1,
+		Total:             model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+	}).Return(nil)
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, options sdkclient.UpdateWorkflowOptions) (sdkclient.WorkflowUpdateHandle, error) {
+			assert.Equal(t, idempotentId, options.UpdateID)
+			assert.Equal(t, idempotentId, options.Args[0].(workflow.AddBillLineItemArgs).LineItem.Id.Id)
+			return updateHandle, nil
+		})
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.AddBillLineItem(authedContext, billId.Id, &rest.AddBillLineItemRequest{
+		Description:    "Matchbox",
+		Amount:         100,
+		CurrencyCode:   "USD",
+		IdempotencyKey: "retry-me",
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, idempotentId, resp.Id)
+}
+
+func TestExtendBill(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	closeTime := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	updatedState := workflow.BillingState{
+		BillInfo: model.BillInfo{Id: billId, CurrencyCode: "USD", Status: model.Open, CloseTime: closeTime},
+		Total:    model.TotalAmount{Number: "0", CurrencyCode: "USD"},
+	}
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("3f5c2a1e-7b8d-4e6f-9a0b-1c2d3e4f5a6b")
+	updateHandle := mocks.NewMockWorkflowUpdateHandle(ctrl)
+	updateHandle.EXPECT().Get(gomock.Any(), gomock.Any()).SetArg(1, updatedState).Return(nil)
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, options sdkclient.UpdateWorkflowOptions) (sdkclient.WorkflowUpdateHandle, error) {
+			assert.Equal(t, workflow.ExtendBillUpdate, options.UpdateName)
+			assert.Equal(t, workflow.ExtendBillArgs{
+				CloseTime: closeTime,
+				Actor:     model.NewUserActor(aliceUserId),
+				RequestId: "3f5c2a1e-7b8d-4e6f-9a0b-1c2d3e4f5a6b",
+			}, options.Args[0])
+			return updateHandle, nil
+		})
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.ExtendBill(authedContext, billId.Id, &rest.ExtendBillRequest{CloseTime: closeTime})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, &rest.ExtendBillResponse{Id: billId.Id, CloseTime: closeTime}, resp)
+}
+
+func TestExtendBillToEarlierCloseTime(t *testing.T) {
+	// Arrange
+	authedContext := withAuth("aec31fe6-04b5-4dbf-a024-b5f45db6f633", model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("3f5c2a1e-7b8d-4e6f-9a0b-1c2d3e4f5a6b")
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).Return(nil,
+		temporal.NewApplicationError("close time is not later than 2025-04-01 00:00:00 +0000 UTC", "CloseTimeNotLaterError"))
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	_, err := s.ExtendBill(authedContext, "fc03932f-2b53-4d07-ad55-24fc7d85e277", &rest.ExtendBillRequest{CloseTime: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)})
+
+	// Assert
+	assert.Equal(t, errs.InvalidArgument, errs.Code(err))
+}
+
+func TestPublishBill(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	authedContext := withAuth(billId.CustomerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	updatedState := workflow.BillingState{
+		BillInfo: model.BillInfo{Id: billId, CurrencyCode: "USD", Status: model.Open},
+		Total:    model.TotalAmount{Number: "0", CurrencyCode: "USD"},
+	}
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("3f5c2a1e-7b8d-4e6f-9a0b-1c2d3e4f5a6b")
+	updateHandle := mocks.NewMockWorkflowUpdateHandle(ctrl)
+	updateHandle.EXPECT().Get(gomock.Any(), gomock.Any()).SetArg(1, updatedState).Return(nil)
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, options sdkclient.UpdateWorkflowOptions) (sdkclient.WorkflowUpdateHandle, error) {
+			assert.Equal(t, workflow.PublishBillUpdate, options.UpdateName)
+			assert.Equal(t, workflow.PublishBillArgs{
+				Actor:     model.NewUserActor(aliceUserId),
+				RequestId: "3f5c2a1e-7b8d-4e6f-9a0b-1c2d3e4f5a6b",
+			}, options.Args[0])
+			return updateHandle, nil
+		})
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	resp, err := s.PublishBill(authedContext, billId.Id, &rest.PublishBillRequest{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, &rest.PublishBillResponse{Id: billId.Id, Status: model.Open}, resp)
+}
+
+func TestVoidClosingBill(t *testing.T) {
+	// Arrange
+	authedContext := withAuth("aec31fe6-04b5-4dbf-a024-b5f45db6f633", model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("3f5c2a1e-7b8d-4e6f-9a0b-1c2d3e4f5a6b")
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).Return(nil,
+		temporal.NewApplicationError("bill is closing", "BillClosingError"))
+	s := rest.NewBillingService(
+		client,
+		mocks.NewMockTokenDb(ctrl),
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+
+	// Act
+	_, err := s.VoidBill(authedContext, "fc03932f-2b53-4d07-ad55-24fc7d85e277", &rest.VoidBillRequest{})
+
+	// Assert
+	assert.Equal(t, errs.FailedPrecondition, errs.Code(err))
+}
+
+func TestRefusesOverRateLimitWithHeaders(t *testing.T) {
+	// Arrange
+	customerId := model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633")
+	authedContext := withAuth(customerId, model.RoleOwner)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		mocks.NewMockTokenDb(ctrl),
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+	limit := model.RateLimit{Rate: 0.1, Burst: 1}
+	rateLimitDb := db.NewInMemoryRateLimitDatabase()
+	_, err := rateLimitDb.TakeRateLimitToken(context.Background(), string(customerId)+"/RemoveMember", limit, time.Now())
+	assert.NoError(t, err)
+	s.SetRateLimits(rateLimitDb, map[string]model.RateLimit{model.DefaultRateLimitEndpoint: limit})
+	req := middleware.NewRequest(authedContext, &encore.Request{
+		Endpoint: "RemoveMember",
+		API:      &encore.APIDesc{ResponseType: reflect.TypeOf(&rest.RemoveMemberResponse{})},
+	})
+
+	// Act
+	resp := s.RateLimitMiddleware(req, func(middleware.Request) middleware.Response {
+		t.Fatal("the refused request reached the endpoint")
+		return middleware.Response{}
+	})
+
+	// Assert
+	assert.NoError(t, resp.Err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.HTTPStatus)
+	assert.Equal(t, &rest.RemoveMemberResponse{RateLimitHeaders: rest.RateLimitHeaders{
+		RateLimitLimit:     1,
+		RateLimitRemaining: 0,
+		RateLimitReset:     10,
+		RetryAfter:         "10",
+	}}, resp.Payload)
 }
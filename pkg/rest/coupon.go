@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"context"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+	"go.temporal.io/sdk/client"
+)
+
+type AttachCouponRequest struct {
+	Code string `json:"code"`
+}
+
+type AttachCouponResponse struct {
+	Id string `json:"id"`
+	// In the order they were attached.
+	Coupons []string `json:"coupons"`
+	RateLimitHeaders
+}
+
+// A coupon is refused when its code is empty or attached already, or when it cannot be redeemed for the bill.
+func attachCouponError(err error, message string) error {
+	if isApplicationErrorOfType(err, "EmptyCouponCodeError") {
+		return errs.WrapCode(err, errs.InvalidArgument, "coupon code is empty")
+	} else if isApplicationErrorOfType(err, "CouponAlreadyAttachedError") {
+		return errs.WrapCode(err, errs.AlreadyExists, "coupon is already attached")
+	} else if isApplicationErrorOfType(err, activity.CouponNotRedeemableErrorType) {
+		return errs.WrapCode(err, errs.FailedPrecondition, "coupon cannot be redeemed")
+	} else if isBillNotOpenError(err) {
+		return errs.WrapCode(err, errs.FailedPrecondition, "bill is not open")
+	}
+	return errs.WrapCode(err, errs.Internal, message)
+}
+
+// The discount is only computed when the bill closes.
+//
+//encore:api auth method=POST path=/bill/:id/coupons
+func (s *BillingService) AttachCoupon(ctx context.Context, id string, attachCouponRequest *AttachCouponRequest) (*AttachCouponResponse, error) {
+	customerId, err := authorize(ctx, model.PermissionManageBills)
+	if err != nil {
+		return nil, err
+	}
+	options := client.UpdateWorkflowOptions{
+		UpdateID:   s.billIdGenerator.New(),
+		WorkflowID: CreateWorkflowId(id),
+		UpdateName: workflow.AttachCouponUpdate,
+		Args: []interface{}{
+			workflow.AttachCouponArgs{
+				Code:  attachCouponRequest.Code,
+				Actor: getAuthenticatedActor(ctx, *customerId),
+			},
+		},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	}
+	updateHandle, err := s.client.UpdateWorkflow(ctx, options)
+	if err != nil {
+		rlog.Error("failed to attach coupon", "billId", id, "err", err)
+		return nil, attachCouponError(err, "failed to attach coupon")
+	}
+	var updatedState workflow.BillingState
+	err = updateHandle.Get(ctx, &updatedState)
+	if err != nil {
+		rlog.Error("failed to get updated workflow state", "billId", id, "err", err)
+		return nil, attachCouponError(err, "failed to get updated workflow state")
+	}
+	rlog.Info("attached coupon to workflow", "id", id, "code", attachCouponRequest.Code)
+	return &AttachCouponResponse{Id: id, Coupons: couponCodes(updatedState.Coupons)}, nil
+}
+
+func couponCodes(coupons []model.Coupon) []string {
+	codes := make([]string, 0, len(coupons))
+	for _, coupon := range coupons {
+		codes = append(codes, coupon.Code)
+	}
+	return codes
+}
@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"context"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+	"go.temporal.io/sdk/client"
+)
+
+type PublishBillRequest struct {
+}
+
+type PublishBillResponse struct {
+	Id     string           `json:"id"`
+	Status model.BillStatus `json:"status"` // Such as "open", see model.BillStatus
+	RateLimitHeaders
+}
+
+type VoidBillRequest struct {
+}
+
+type VoidBillResponse struct {
+	Id     string           `json:"id"`
+	Status model.BillStatus `json:"status"`
+	RateLimitHeaders
+}
+
+// A draft is published once, and only a draft or an open bill is voided.
+func billStatusError(err error, message string) error {
+	if isBillNotOpenError(err) || isApplicationErrorOfType(err, "InvalidBillStatusTransitionError") {
+		return errs.WrapCode(err, errs.FailedPrecondition, "bill status does not allow it")
+	}
+	return errs.WrapCode(err, errs.Internal, message)
+}
+
+// Sends the update of the bill status and waits until the workflow saved it.
+func (s *BillingService) updateBillStatus(ctx context.Context, id string, updateName string, args func(actor model.Actor, requestId string) any) (workflow.BillingState, error) {
+	customerId, err := authorize(ctx, model.PermissionManageBills)
+	if err != nil {
+		return workflow.BillingState{}, err
+	}
+	updateId := s.billIdGenerator.New()
+	options := client.UpdateWorkflowOptions{
+		UpdateID:     updateId,
+		WorkflowID:   CreateWorkflowId(id),
+		UpdateName:   updateName,
+		Args:         []interface{}{args(getAuthenticatedActor(ctx, *customerId), updateId)},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	}
+	updateHandle, err := s.client.UpdateWorkflow(ctx, options)
+	if err != nil {
+		rlog.Error("failed to update bill status", "billId", id, "update", updateName, "err", err)
+		return workflow.BillingState{}, billStatusError(err, "failed to update bill status")
+	}
+	var updatedState workflow.BillingState
+	err = updateHandle.Get(ctx, &updatedState)
+	if err != nil {
+		rlog.Error("failed to get updated workflow state", "billId", id, "update", updateName, "err", err)
+		return workflow.BillingState{}, billStatusError(err, "failed to get updated workflow state")
+	}
+	rlog.Info("updated bill status", "id", id, "status", updatedState.BillInfo.Status)
+	return updatedState, nil
+}
+
+// Opens a draft to line items.
+//
+//encore:api auth method=POST path=/bill/:id/publish
+func (s *BillingService) PublishBill(ctx context.Context, id string, publishBillRequest *PublishBillRequest) (*PublishBillResponse, error) {
+	updatedState, err := s.updateBillStatus(ctx, id, workflow.PublishBillUpdate, func(actor model.Actor, requestId string) any {
+		return workflow.PublishBillArgs{Actor: actor, RequestId: requestId}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PublishBillResponse{Id: id, Status: updatedState.BillInfo.Status}, nil
+}
+
+// Cancels a draft or an open bill, nothing is due and its line items are reversed in the ledger.
+//
+//encore:api auth method=POST path=/bill/:id/void
+func (s *BillingService) VoidBill(ctx context.Context, id string, voidBillRequest *VoidBillRequest) (*VoidBillResponse, error) {
+	updatedState, err := s.updateBillStatus(ctx, id, workflow.VoidBillUpdate, func(actor model.Actor, requestId string) any {
+		return workflow.VoidBillArgs{Actor: actor, RequestId: requestId}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &VoidBillResponse{Id: id, Status: updatedState.BillInfo.Status}, nil
+}
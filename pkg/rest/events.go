@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"coding-challenge/pkg/model"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"encore.dev"
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+)
+
+// Keeps the idle connections open through proxies.
+const BillEventsHeartbeat = 15 * time.Second
+
+type BillEventResponse struct {
+	Kind          model.BillEventKind `json:"kind"`
+	LineItemId    string              `json:"line_item_id,omitempty"`
+	Status        model.BillStatus    `json:"status"`
+	CurrencyCode  model.CurrencyCode  `json:"currency_code"`
+	LineItemCount uint64              `json:"line_item_count"`
+	Total         string              `json:"total"` // Decimal string in minor units
+	At            time.Time           `json:"at"`
+}
+
+// Each event has the id of its sequence in the bill, so that a client sending it back as Last-Event-ID receives
+// the events it missed, among the latest ones kept by this process.
+func writeBillEvent(w io.Writer, event model.BillEvent) error {
+	data, err := json.Marshal(BillEventResponse{
+		Kind:          event.Kind,
+		LineItemId:    event.LineItemId,
+		Status:        event.Status,
+		CurrencyCode:  event.Total.CurrencyCode,
+		LineItemCount: event.LineItemCount,
+		Total:         event.Total.Number,
+		At:            event.At,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.Kind, data)
+	return err
+}
+
+// Streams the changes of the bill as Server-Sent Events until the client disconnects.
+// The stream also ends when the client is too slow, it can then resume with Last-Event-ID.
+//
+//encore:api auth raw method=GET path=/bill/:id/events
+func (s *BillingService) StreamBillEvents(w http.ResponseWriter, req *http.Request) {
+	customerId, err := authorize(req.Context(), model.PermissionViewBills)
+	if err != nil {
+		errs.HTTPError(w, err)
+		return
+	}
+	// Raw endpoints are skipped by the middleware, the headers are written here
+	decision, err := s.takeRateLimitToken(req.Context(), "StreamBillEvents")
+	setRateLimitHeaders(w.Header(), decision)
+	if err != nil {
+		errs.HTTPError(w, err)
+		return
+	}
+	id := encore.CurrentRequest().PathParams.Get("id")
+	var lastEventId uint64
+	if header := req.Header.Get("Last-Event-ID"); header != "" {
+		if lastEventId, err = strconv.ParseUint(header, 10, 64); err != nil {
+			errs.HTTPError(w, errs.WrapCode(err, errs.InvalidArgument, "invalid Last-Event-ID"))
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errs.HTTPError(w, errs.B().Code(errs.Internal).Msg("streaming is not supported").Err())
+		return
+	}
+	missed, events, unsubscribe := s.billEvents.Subscribe(model.BillId{CustomerId: *customerId, Id: id}, lastEventId)
+	defer unsubscribe()
+	rlog.Info("streaming bill events", "billId", id, "lastEventId", lastEventId, "missed", len(missed))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	for _, event := range missed {
+		if err = writeBillEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+	heartbeat := time.NewTicker(BillEventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				rlog.Warn("bill event stream too slow, closing", "billId", id)
+				return
+			}
+			err = writeBillEvent(w, event)
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
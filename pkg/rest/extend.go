@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"context"
+	"time"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+	"go.temporal.io/sdk/client"
+)
+
+type ExtendBillRequest struct {
+	// Later than the current close time.
+	CloseTime time.Time `json:"close_time"`
+}
+
+type ExtendBillResponse struct {
+	Id        string    `json:"id"`
+	CloseTime time.Time `json:"close_time"`
+	RateLimitHeaders
+}
+
+// The extension of a closing bill, or to an earlier close time, is refused.
+func extendBillError(err error, message string) error {
+	if isBillNotOpenError(err) {
+		return errs.WrapCode(err, errs.FailedPrecondition, "bill is not open")
+	} else if isApplicationErrorOfType(err, "CloseTimeNotLaterError") {
+		return errs.WrapCode(err, errs.InvalidArgument, "close time is not later than the current one")
+	}
+	return errs.WrapCode(err, errs.Internal, message)
+}
+
+// Postpones the close of an open bill.
+//
+//encore:api auth method=PUT path=/bill/:id/close-time
+func (s *BillingService) ExtendBill(ctx context.Context, id string, extendBillRequest *ExtendBillRequest) (*ExtendBillResponse, error) {
+	customerId, err := authorize(ctx, model.PermissionManageBills)
+	if err != nil {
+		return nil, err
+	}
+	updateId := s.billIdGenerator.New()
+	options := client.UpdateWorkflowOptions{
+		UpdateID:   updateId,
+		WorkflowID: CreateWorkflowId(id),
+		UpdateName: workflow.ExtendBillUpdate,
+		Args: []interface{}{
+			workflow.ExtendBillArgs{
+				CloseTime: extendBillRequest.CloseTime,
+				Actor:     getAuthenticatedActor(ctx, *customerId),
+				RequestId: updateId,
+			},
+		},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	}
+	updateHandle, err := s.client.UpdateWorkflow(ctx, options)
+	if err != nil {
+		rlog.Error("failed to extend bill", "billId", id, "err", err)
+		return nil, extendBillError(err, "failed to extend bill")
+	}
+	var updatedState workflow.BillingState
+	err = updateHandle.Get(ctx, &updatedState)
+	if err != nil {
+		rlog.Error("failed to get updated workflow state", "billId", id, "err", err)
+		return nil, extendBillError(err, "failed to get updated workflow state")
+	}
+	rlog.Info("extended workflow", "id", id, "close_time", updatedState.BillInfo.CloseTime)
+	return &ExtendBillResponse{
+		Id:        id,
+		CloseTime: updatedState.BillInfo.CloseTime,
+	}, nil
+}
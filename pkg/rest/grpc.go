@@ -0,0 +1,295 @@
+package rest
+
+import (
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/rpc"
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Serves the operations of the REST API to the gRPC callers, authenticated by the same tokens.
+func NewGrpcServer(s *BillingService) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(s.unaryGrpcInterceptor),
+		grpc.StreamInterceptor(s.streamGrpcInterceptor))
+	rpc.RegisterBillingServer(server, &billingGrpcServer{service: s})
+	return server
+}
+
+// Reads the token from the authorization metadata, as a bearer token like the REST API expects.
+func (s *BillingService) authenticateGrpc(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, errs.B().Code(errs.Unauthenticated).Msg("missing authorization metadata").Err()
+	}
+	return s.authenticate(ctx, strings.TrimPrefix(values[0], "Bearer "))
+}
+
+// The methods are limited as the endpoints of the same name, the RateLimit-* headers are sent as metadata. The calls
+// are traced as those of the REST API.
+func (s *BillingService) unaryGrpcInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx, span := startEndpointSpan(ctx, metadataCarrier(md), path.Base(info.FullMethod))
+	defer func() { endEndpointSpan(span, err) }()
+	if ctx, err = s.authenticateGrpc(ctx); err != nil {
+		return nil, grpcError(err)
+	}
+	decision, err := s.takeRateLimitToken(ctx, path.Base(info.FullMethod))
+	grpc.SetHeader(ctx, rateLimitMetadata(decision))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp, err = handler(ctx, req)
+	return resp, grpcError(err)
+}
+
+type authenticatedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *BillingService) streamGrpcInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticateGrpc(ss.Context())
+	if err != nil {
+		return grpcError(err)
+	}
+	decision, err := s.takeRateLimitToken(ctx, path.Base(info.FullMethod))
+	ss.SetHeader(rateLimitMetadata(decision))
+	if err != nil {
+		return grpcError(err)
+	}
+	return grpcError(handler(srv, &authenticatedServerStream{ss, ctx}))
+}
+
+// The encore error codes are the gRPC status codes, other errors are unknown unless they already have a status.
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var encoreError *errs.Error
+	if errors.As(err, &encoreError) {
+		return status.Error(codes.Code(encoreError.Code), encoreError.Message)
+	} else if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+type billingGrpcServer struct {
+	rpc.UnimplementedBillingServer
+	service *BillingService
+}
+
+func formatTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func formatAmount(amount model.Amount) *rpc.Amount {
+	return &rpc.Amount{Number: amount.Number, CurrencyCode: string(amount.CurrencyCode)}
+}
+
+func (g *billingGrpcServer) OpenNewBill(ctx context.Context, req *rpc.OpenNewBillRequest) (*rpc.OpenNewBillResponse, error) {
+	resp, err := g.service.OpenNewBill(ctx, &OpenNewBillRequest{
+		CurrencyCode:    model.CurrencyCode(req.CurrencyCode),
+		CloseTime:       req.CloseTime.AsTime(),
+		TaxJurisdiction: model.TaxJurisdiction(req.TaxJurisdiction),
+		SpendingCap:     req.SpendingCap,
+		AlertThresholds: req.AlertThresholds,
+		IdempotencyKey:  req.IdempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.OpenNewBillResponse{Id: resp.Id}, nil
+}
+
+func (g *billingGrpcServer) GetBill(ctx context.Context, req *rpc.GetBillRequest) (*rpc.GetBillResponse, error) {
+	resp, err := g.service.GetBill(ctx, req.Id, &GetBillRequest{})
+	if err != nil {
+		return nil, err
+	}
+	bill := &rpc.GetBillResponse{
+		Id:              resp.Id,
+		CurrencyCode:    string(resp.CurrencyCode),
+		Status:          resp.Status.String(),
+		LineItemCount:   resp.LineItemCount,
+		Total:           resp.Total,
+		CreatedAt:       timestamppb.New(resp.CreatedAt),
+		CloseTime:       timestamppb.New(resp.CloseTime),
+		ClosedAt:        formatTimestamp(resp.ClosedAt),
+		Subtotal:        resp.Subtotal,
+		TaxTotal:        resp.TaxTotal,
+		GrandTotal:      resp.GrandTotal,
+		DiscountTotal:   resp.DiscountTotal,
+		AmountDue:       resp.AmountDue,
+		SpendingCap:     resp.SpendingCap,
+		AlertThresholds: resp.AlertThresholds,
+	}
+	for _, payment := range resp.Payments {
+		bill.Payments = append(bill.Payments, &rpc.PaymentAttempt{
+			Number:        payment.Number,
+			Amount:        payment.Amount.Number,
+			Status:        string(payment.Status),
+			Reference:     payment.Reference,
+			FailureReason: payment.FailureReason,
+			AttemptedAt:   timestamppb.New(payment.AttemptedAt),
+		})
+	}
+	for _, notification := range resp.Notifications {
+		bill.Notifications = append(bill.Notifications, &rpc.DunningNotification{
+			Step:      notification.Step,
+			Kind:      string(notification.Kind),
+			AmountDue: notification.AmountDue.Number,
+			SentAt:    timestamppb.New(notification.SentAt),
+		})
+	}
+	for _, alert := range resp.SpendingAlerts {
+		bill.SpendingAlerts = append(bill.SpendingAlerts, &rpc.SpendingAlert{
+			Threshold: alert.Threshold,
+			Cap:       formatAmount(alert.Cap),
+			Total:     alert.Total.Number,
+			SentAt:    timestamppb.New(alert.SentAt),
+		})
+	}
+	return bill, nil
+}
+
+func (g *billingGrpcServer) AddBillLineItem(ctx context.Context, req *rpc.AddBillLineItemRequest) (*rpc.AddBillLineItemResponse, error) {
+	resp, err := g.service.AddBillLineItem(ctx, req.BillId, &AddBillLineItemRequest{
+		Description:    req.Description,
+		Amount:         req.Amount,
+		CurrencyCode:   model.CurrencyCode(req.CurrencyCode),
+		TaxCategory:    model.TaxCategory(req.TaxCategory),
+		TaxInclusive:   req.TaxInclusive,
+		Quantity:       req.Quantity,
+		UnitPrice:      req.UnitPrice,
+		Rounding:       model.RoundingMode(req.Rounding),
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.AddBillLineItemResponse{
+		Id:            resp.Id,
+		CurrencyCode:  string(resp.CurrencyCode),
+		LineItemCount: resp.LineItemCount,
+		Total:         resp.Total,
+	}, nil
+}
+
+func (g *billingGrpcServer) CloseBill(ctx context.Context, req *rpc.CloseBillRequest) (*rpc.CloseBillResponse, error) {
+	resp, err := g.service.CloseBill(ctx, req.Id, &CloseBillRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.CloseBillResponse{
+		CurrencyCode:  string(resp.CurrencyCode),
+		LineItemCount: resp.LineItemCount,
+		Total:         resp.Total,
+		CreatedAt:     timestamppb.New(resp.CreatedAt),
+		CloseTime:     timestamppb.New(resp.CloseTime),
+		ClosedAt:      formatTimestamp(resp.ClosedAt),
+		Subtotal:      resp.Subtotal,
+		TaxTotal:      resp.TaxTotal,
+		GrandTotal:    resp.GrandTotal,
+		DiscountTotal: resp.DiscountTotal,
+		AmountDue:     resp.AmountDue,
+	}, nil
+}
+
+func (g *billingGrpcServer) ListBillLineItems(ctx context.Context, req *rpc.ListBillLineItemsRequest) (*rpc.ListBillLineItemsResponse, error) {
+	resp, err := g.service.ListBillLineItems(ctx, req.BillId, &ListBillLineItemsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	lineItems := make([]*rpc.BillLineItem, 0, len(resp.LineItems))
+	for _, lineItem := range resp.LineItems {
+		var conversion *rpc.FxConversion
+		if lineItem.Conversion != nil {
+			conversion = &rpc.FxConversion{
+				OriginalAmount: &rpc.Amount{
+					Number:       lineItem.Conversion.OriginalAmount,
+					CurrencyCode: string(lineItem.Conversion.OriginalCurrencyCode),
+				},
+				Rate:     lineItem.Conversion.Rate,
+				RateAsOf: timestamppb.New(lineItem.Conversion.RateAsOf),
+			}
+		}
+		lineItems = append(lineItems, &rpc.BillLineItem{
+			Id:           lineItem.Id,
+			Description:  lineItem.Description,
+			Amount:       &rpc.Amount{Number: lineItem.Amount, CurrencyCode: string(lineItem.CurrencyCode)},
+			CreatedAt:    timestamppb.New(lineItem.CreatedAt),
+			Conversion:   conversion,
+			TaxCategory:  string(lineItem.TaxCategory),
+			TaxInclusive: lineItem.TaxInclusive,
+			Quantity:     lineItem.Quantity,
+			UnitPrice:    lineItem.UnitPrice,
+			Rounding:     string(lineItem.Rounding),
+		})
+	}
+	return &rpc.ListBillLineItemsResponse{BillId: resp.Id, LineItems: lineItems}, nil
+}
+
+func formatBillEvent(event model.BillEvent) *rpc.BillEvent {
+	return &rpc.BillEvent{
+		Sequence:      event.Sequence,
+		Kind:          string(event.Kind),
+		LineItemId:    event.LineItemId,
+		Status:        event.Status.String(),
+		CurrencyCode:  string(event.Total.CurrencyCode),
+		LineItemCount: event.LineItemCount,
+		Total:         event.Total.Number,
+		At:            timestamppb.New(event.At),
+	}
+}
+
+// Sends the events of the bill as the REST stream does, from the same broker.
+func (g *billingGrpcServer) StreamBillEvents(req *rpc.StreamBillEventsRequest, stream grpc.ServerStreamingServer[rpc.BillEvent]) error {
+	ctx := stream.Context()
+	customerId, err := authorize(ctx, model.PermissionViewBills)
+	if err != nil {
+		return err
+	}
+	missed, events, unsubscribe := g.service.billEvents.Subscribe(model.BillId{CustomerId: *customerId, Id: req.BillId}, req.AfterSequence)
+	defer unsubscribe()
+	rlog.Info("streaming bill events over grpc", "billId", req.BillId, "afterSequence", req.AfterSequence, "missed", len(missed))
+	for _, event := range missed {
+		if err = stream.Send(formatBillEvent(event)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				rlog.Warn("bill event stream too slow, closing", "billId", req.BillId)
+				return errs.B().Code(errs.ResourceExhausted).Msg("too slow to receive the bill events, resume after the last one").Err()
+			}
+			if err = stream.Send(formatBillEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
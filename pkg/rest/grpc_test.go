@@ -0,0 +1,199 @@
+package rest_test
+
+import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/rest"
+	"coding-challenge/pkg/rest/mocks"
+	"coding-challenge/pkg/rpc"
+	"coding-challenge/pkg/workflow"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"encore.dev/beta/errs"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.temporal.io/sdk/temporal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Serves the billing service in memory and returns a client of it.
+func serveGrpc(t *testing.T, s *rest.BillingService) rpc.BillingClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := rest.NewGrpcServer(s)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return rpc.NewBillingClient(conn)
+}
+
+// Alice owns the organization of the customer.
+func ownedOrganizationDb(customerId model.CustomerId) *db.InMemoryOrganizationDatabase {
+	return db.NewInMemoryOrganizationDatabase(model.Membership{OrgId: customerId, UserId: aliceUserId, Role: model.RoleOwner})
+}
+
+func withGrpcToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestGrpcOpenNewBill(t *testing.T) {
+	// Arrange
+	newBill := model.BillInfo{
+		Id: model.BillId{
+			CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+			Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+		},
+		CurrencyCode: "USD",
+		Status:       model.Open}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	_, client, tokenDb, billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase := createBasicMocks(ctrl, newBill)
+	tokenDb.EXPECT().
+		VerifyToken(gomock.Any(), "token-alice").
+		Return(rest.SessionInfo{UserId: string(aliceUserId), OrgId: string(newBill.Id.CustomerId)}, nil)
+	addGetExpectations(ctrl, client, workflow.BillingState{
+		BillInfo: newBill,
+		Total:    model.TotalAmount{Number: "0", CurrencyCode: newBill.CurrencyCode},
+	})
+	s := rest.NewBillingService(client, rest.TokenDb(tokenDb), billIdGenerator, billDatabase, ledgerDatabase, auditDatabase, taxDatabase, couponDatabase, paymentDatabase, usageDatabase)
+	s.SetOrganizationDb(ownedOrganizationDb(newBill.Id.CustomerId))
+	billingClient := serveGrpc(t, s)
+
+	// Act
+	resp, err := billingClient.OpenNewBill(withGrpcToken("token-alice"), &rpc.OpenNewBillRequest{
+		CurrencyCode: "USD",
+		CloseTime:    timestamppb.New(time.Now().Add(time.Minute)),
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, newBill.Id.Id, resp.Id)
+}
+
+func TestGrpcRejectsUnknownToken(t *testing.T) {
+	// Arrange
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tokenDb := mocks.NewMockTokenDb(ctrl)
+	tokenDb.EXPECT().
+		VerifyToken(gomock.Any(), "token-mallory").
+		Return(rest.SessionInfo{}, errs.B().Code(errs.NotFound).Msg("token not found").Err())
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		tokenDb,
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+	billingClient := serveGrpc(t, s)
+
+	// Act
+	_, err := billingClient.GetBill(withGrpcToken("token-mallory"), &rpc.GetBillRequest{Id: "fc03932f-2b53-4d07-ad55-24fc7d85e277"})
+	_, missingErr := billingClient.GetBill(context.Background(), &rpc.GetBillRequest{Id: "fc03932f-2b53-4d07-ad55-24fc7d85e277"})
+
+	// Assert
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, codes.Unauthenticated, status.Code(missingErr))
+}
+
+func TestGrpcAddLineItemOverSpendingCap(t *testing.T) {
+	// Arrange
+	billId := model.BillId{
+		CustomerId: model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633"),
+		Id:         "fc03932f-2b53-4d07-ad55-24fc7d85e277",
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tokenDb := mocks.NewMockTokenDb(ctrl)
+	tokenDb.EXPECT().
+		VerifyToken(gomock.Any(), "token-alice").
+		Return(rest.SessionInfo{UserId: string(aliceUserId), OrgId: string(billId.CustomerId)}, nil)
+	billIdGenerator := mocks.NewMockBillIdGenerator(ctrl)
+	billIdGenerator.EXPECT().New().Return("a8f2784e-a7e6-45b6-ad09-8186422a9261")
+	billIdGenerator.EXPECT().New().Return("a579a2e5-9c31-473e-94ed-577c7cd14acd")
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().UpdateWorkflow(gomock.Any(), gomock.Any()).Return(nil,
+		temporal.NewApplicationError("total 1100 USD would exceed the spending cap of 1000", "SpendingCapExceededError"))
+	s := rest.NewBillingService(
+		client,
+		tokenDb,
+		billIdGenerator,
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+	s.SetOrganizationDb(ownedOrganizationDb(billId.CustomerId))
+	billingClient := serveGrpc(t, s)
+
+	// Act
+	_, err := billingClient.AddBillLineItem(withGrpcToken("token-alice"), &rpc.AddBillLineItemRequest{
+		BillId:       billId.Id,
+		Description:  "Candle",
+		CurrencyCode: "USD",
+		Amount:       200,
+	})
+
+	// Assert
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, "line item would exceed the spending cap", status.Convert(err).Message())
+}
+
+func TestGrpcRefusesOverRateLimit(t *testing.T) {
+	// Arrange
+	customerId := model.CustomerId("aec31fe6-04b5-4dbf-a024-b5f45db6f633")
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tokenDb := mocks.NewMockTokenDb(ctrl)
+	tokenDb.EXPECT().
+		VerifyToken(gomock.Any(), "token-alice").
+		Return(rest.SessionInfo{UserId: string(aliceUserId), OrgId: string(customerId)}, nil)
+	s := rest.NewBillingService(
+		mocks.NewMockClient(ctrl),
+		tokenDb,
+		mocks.NewMockBillIdGenerator(ctrl),
+		mocks.NewMockBillDatabase(ctrl),
+		mocks.NewMockLedgerDatabase(ctrl),
+		mocks.NewMockAuditDatabase(ctrl),
+		mocks.NewMockTaxDatabase(ctrl),
+		mocks.NewMockCouponDatabase(ctrl),
+		mocks.NewMockPaymentDatabase(ctrl),
+		mocks.NewMockUsageDatabase(ctrl))
+	limit := model.RateLimit{Rate: 0.1, Burst: 1}
+	rateLimitDb := db.NewInMemoryRateLimitDatabase()
+	_, err := rateLimitDb.TakeRateLimitToken(context.Background(), string(customerId)+"/GetBill", limit, time.Now())
+	assert.NoError(t, err)
+	s.SetRateLimits(rateLimitDb, map[string]model.RateLimit{model.DefaultRateLimitEndpoint: limit})
+	s.SetOrganizationDb(ownedOrganizationDb(customerId))
+	billingClient := serveGrpc(t, s)
+
+	// Act
+	var header metadata.MD
+	_, err = billingClient.GetBill(withGrpcToken("token-alice"), &rpc.GetBillRequest{Id: "fc03932f-2b53-4d07-ad55-24fc7d85e277"}, grpc.Header(&header))
+
+	// Assert
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"1"}, header.Get("RateLimit-Limit"))
+	assert.Equal(t, []string{"0"}, header.Get("RateLimit-Remaining"))
+	assert.Equal(t, []string{"10"}, header.Get("Retry-After"))
+}
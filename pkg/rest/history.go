@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"time"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+)
+
+type GetBillHistoryRequest struct {
+}
+
+type BillHistoryEntry struct {
+	Action    model.AuditAction `json:"action"`
+	ActorType model.ActorType   `json:"actor_type"`
+	ActorId   string            `json:"actor_id"`
+	RequestId string            `json:"request_id"`
+	// Decimal strings in minor units. Empty for a total that overflowed before totals could not overflow.
+	TotalBefore  string    `json:"total_before"`
+	TotalAfter   string    `json:"total_after"`
+	WorkflowTime time.Time `json:"workflow_time"`
+}
+
+type GetBillHistoryResponse struct {
+	Id      string             `json:"id"`
+	Entries []BillHistoryEntry `json:"entries"`
+	RateLimitHeaders
+}
+
+//encore:api auth method=GET path=/bill/:id/history
+func (s *BillingService) GetBillHistory(ctx context.Context, id string, getBillHistoryRequest *GetBillHistoryRequest) (*GetBillHistoryResponse, error) {
+	customerId, err := authorize(ctx, model.PermissionViewBills)
+	if err != nil {
+		return nil, err
+	}
+	auditEntries, err := s.auditDb.GetEntries(ctx, model.BillId{CustomerId: *customerId, Id: id})
+	if err != nil {
+		rlog.Error("failed to get bill history", "id", id, "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to get bill history")
+	}
+	entries := make([]BillHistoryEntry, 0, len(auditEntries))
+	for _, auditEntry := range auditEntries {
+		entries = append(entries, BillHistoryEntry{
+			Action:       auditEntry.Action,
+			ActorType:    auditEntry.Actor.Type,
+			ActorId:      auditEntry.Actor.Id,
+			RequestId:    auditEntry.RequestId,
+			TotalBefore:  auditEntry.TotalBefore.Number,
+			TotalAfter:   auditEntry.TotalAfter.Number,
+			WorkflowTime: auditEntry.WorkflowTime,
+		})
+	}
+	return &GetBillHistoryResponse{Id: id, Entries: entries}, nil
+}
@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+)
+
+type GetBalanceRequest struct {
+}
+
+type GetBalanceResponse struct {
+	CurrencyCode      model.CurrencyCode `json:"currency_code"`
+	Receivable        string             `json:"receivable"`         // What is still owed on the closed bills, their amounts due less the payments, in minor units as a decimal integer string
+	AccruedReceivable string             `json:"accrued_receivable"` // Sum of the open bills, in minor units as a decimal integer string
+	RateLimitHeaders
+}
+
+//encore:api auth method=GET path=/balance/:currencyCode
+func (s *BillingService) GetBalance(ctx context.Context, currencyCode string, getBalanceRequest *GetBalanceRequest) (*GetBalanceResponse, error) {
+	customerId, err := authorize(ctx, model.PermissionViewBills)
+	if err != nil {
+		return nil, err
+	}
+	code := model.CurrencyCode(currencyCode)
+	if _, ok := model.GetDigits(code); !ok {
+		return nil, errs.WrapCode(model.InvalidCurrencyCodeError{CurrencyCode: code}, errs.InvalidArgument, "invalid currency code")
+	}
+	receivable, err := s.ledgerDb.GetBalance(ctx, model.LedgerAccount{CustomerId: *customerId, Type: model.Receivable, CurrencyCode: code})
+	if err != nil {
+		rlog.Error("failed to get receivable balance", "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to get receivable balance")
+	}
+	accruedReceivable, err := s.ledgerDb.GetBalance(ctx, model.LedgerAccount{CustomerId: *customerId, Type: model.AccruedReceivable, CurrencyCode: code})
+	if err != nil {
+		rlog.Error("failed to get accrued receivable balance", "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to get accrued receivable balance")
+	}
+	return &GetBalanceResponse{
+		CurrencyCode:      code,
+		Receivable:        receivable.Number,
+		AccruedReceivable: accruedReceivable.Number,
+	}, nil
+}
@@ -0,0 +1,29 @@
+-- Totals are NUMERIC so that they never overflow.
+ALTER TABLE Bill
+    ALTER COLUMN TotalAmount TYPE NUMERIC;
+
+-- Totals that overflowed before are recomputed from the line items.
+UPDATE Bill
+SET TotalAmount = (
+    SELECT COALESCE(SUM(LineItem.Amount), 0)
+    FROM LineItem
+    WHERE LineItem.CustomerId = Bill.CustomerId AND LineItem.BillId = Bill.Id
+)
+WHERE NOT TotalOk;
+
+ALTER TABLE Bill
+    DROP COLUMN TotalOk;
+
+-- Audited totals that overflowed cannot be recomputed, they are unknown.
+ALTER TABLE BillAudit
+    ALTER COLUMN TotalBefore TYPE NUMERIC,
+    ALTER COLUMN TotalBefore DROP NOT NULL,
+    ALTER COLUMN TotalAfter TYPE NUMERIC,
+    ALTER COLUMN TotalAfter DROP NOT NULL;
+
+UPDATE BillAudit SET TotalBefore = NULL WHERE NOT TotalBeforeOk;
+UPDATE BillAudit SET TotalAfter = NULL WHERE NOT TotalAfterOk;
+
+ALTER TABLE BillAudit
+    DROP COLUMN TotalBeforeOk,
+    DROP COLUMN TotalAfterOk;
@@ -0,0 +1,13 @@
+CREATE TABLE PaymentAttempt (
+    CustomerId TEXT NOT NULL,
+    BillId TEXT NOT NULL,
+    Number INT NOT NULL,
+    Amount BIGINT NOT NULL,
+    CurrencyCode TEXT NOT NULL,
+    Status TEXT NOT NULL CHECK (Status IN ('succeeded', 'declined', 'errored')),
+    -- The gateway id of the charge, empty unless it succeeded.
+    Reference TEXT NOT NULL DEFAULT '',
+    FailureReason TEXT NOT NULL DEFAULT '',
+    AttemptedAt TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (CustomerId, BillId, Number)
+);
@@ -0,0 +1,17 @@
+-- Customers without a row are in the standard tier.
+CREATE TABLE CustomerTier (
+    CustomerId TEXT NOT NULL,
+    Tier TEXT NOT NULL,
+    PRIMARY KEY (CustomerId)
+);
+
+CREATE TABLE DunningNotification (
+    CustomerId TEXT NOT NULL,
+    BillId TEXT NOT NULL,
+    Step INT NOT NULL,
+    Kind TEXT NOT NULL CHECK (Kind IN ('reminder', 'escalation')),
+    AmountDue BIGINT NOT NULL,
+    CurrencyCode TEXT NOT NULL,
+    SentAt TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (CustomerId, BillId, Step)
+);
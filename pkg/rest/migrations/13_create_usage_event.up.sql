@@ -0,0 +1,15 @@
+-- Aggregated into line items when the bill closes. Decimals, so that they are summed exactly.
+CREATE TABLE UsageEvent (
+    CustomerId TEXT NOT NULL,
+    -- Given by the emitter, an event sent again is ignored.
+    Id TEXT NOT NULL,
+    BillId TEXT NOT NULL,
+    Meter TEXT NOT NULL,
+    Quantity NUMERIC NOT NULL,
+    -- In major units of the bill currency.
+    UnitPrice NUMERIC NOT NULL,
+    RecordedAt TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (CustomerId, Id)
+);
+
+CREATE INDEX UsageEventBill ON UsageEvent (CustomerId, BillId);
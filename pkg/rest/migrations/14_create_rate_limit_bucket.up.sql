@@ -0,0 +1,7 @@
+-- The token buckets of the rate limits, shared by the API processes. A missing bucket is full.
+CREATE TABLE RateLimitBucket (
+    -- Such as the customer and the endpoint.
+    Key TEXT NOT NULL PRIMARY KEY,
+    Tokens DOUBLE PRECISION NOT NULL,
+    UpdatedAt TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,15 @@
+-- The users of the organizations, which are the customers the bills are keyed by.
+CREATE TABLE Membership (
+    OrgId TEXT NOT NULL,
+    UserId TEXT NOT NULL,
+    Role TEXT NOT NULL CHECK (Role IN ('owner', 'billing-admin', 'viewer')),
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (OrgId, UserId)
+);
+
+-- The users of the dummy tokens, see CreateFakeDummyTokenDb.
+INSERT INTO Membership (OrgId, UserId, Role) VALUES
+    ('aec31fe6-04b5-4dbf-a024-b5f45db6f633', '0b6f3c1e-5a2d-4e8f-9c7b-1d3e5f7a9b2c', 'owner'),
+    ('aec31fe6-04b5-4dbf-a024-b5f45db6f633', '7e4d2a9c-3b1f-4c6e-8a5d-9f2b4c6e8a1d', 'billing-admin'),
+    ('aec31fe6-04b5-4dbf-a024-b5f45db6f633', 'c2a8e6f4-1d3b-4f5a-b7c9-e1f3a5b7c9d2', 'viewer'),
+    ('b59c18af-50be-4f4d-91ad-b25c9c9d0581', '4f1a7c3e-9d2b-4a6f-8e5c-3b7d9f1a5c8e', 'owner');
@@ -0,0 +1,18 @@
+-- The tax, discounts and payments are NUMERIC like the totals they are computed from, so that they never overflow.
+ALTER TABLE BillTax
+    ALTER COLUMN Subtotal TYPE NUMERIC,
+    ALTER COLUMN TaxTotal TYPE NUMERIC,
+    ALTER COLUMN GrandTotal TYPE NUMERIC;
+
+ALTER TABLE BillTaxLine
+    ALTER COLUMN Taxable TYPE NUMERIC,
+    ALTER COLUMN Tax TYPE NUMERIC;
+
+ALTER TABLE BillDiscountLine
+    ALTER COLUMN Amount TYPE NUMERIC;
+
+ALTER TABLE PaymentAttempt
+    ALTER COLUMN Amount TYPE NUMERIC;
+
+ALTER TABLE DunningNotification
+    ALTER COLUMN AmountDue TYPE NUMERIC;
@@ -0,0 +1,4 @@
+-- Zero for an uncapped bill, in minor units of the bill currency.
+ALTER TABLE Bill
+    ADD COLUMN SpendingCap BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN AlertThresholds INTEGER[] NOT NULL DEFAULT '{}';
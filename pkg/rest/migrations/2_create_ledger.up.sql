@@ -0,0 +1,35 @@
+CREATE TABLE LedgerTransaction (
+    Id TEXT NOT NULL,
+    CustomerId TEXT NOT NULL,
+    BillId TEXT NOT NULL,
+    Description TEXT NOT NULL,
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (Id)
+);
+
+CREATE TABLE LedgerPosting (
+    TransactionId TEXT NOT NULL REFERENCES LedgerTransaction (Id),
+    Seq INT NOT NULL,
+    CustomerId TEXT NOT NULL,
+    Account TEXT NOT NULL,
+    CurrencyCode TEXT NOT NULL,
+    Amount BIGINT NOT NULL,
+    PRIMARY KEY (TransactionId, Seq)
+);
+
+CREATE INDEX LedgerPostingAccount ON LedgerPosting (CustomerId, Account, CurrencyCode);
+
+-- The ledger is append-only.
+CREATE FUNCTION ledger_reject_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER LedgerTransactionAppendOnly
+    BEFORE UPDATE OR DELETE ON LedgerTransaction
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
+
+CREATE TRIGGER LedgerPostingAppendOnly
+    BEFORE UPDATE OR DELETE ON LedgerPosting
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
@@ -0,0 +1,17 @@
+CREATE TABLE BillAudit (
+    Seq BIGSERIAL NOT NULL,
+    CustomerId TEXT NOT NULL,
+    BillId TEXT NOT NULL,
+    Action TEXT NOT NULL,
+    RequestId TEXT NOT NULL,
+    ActorType TEXT NOT NULL,
+    ActorId TEXT NOT NULL,
+    TotalBefore BIGINT NOT NULL,
+    TotalBeforeOk BOOLEAN NOT NULL,
+    TotalAfter BIGINT NOT NULL,
+    TotalAfterOk BOOLEAN NOT NULL,
+    WorkflowTime TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (CustomerId, BillId, Action, RequestId)
+);
+
+CREATE INDEX BillAuditSeq ON BillAudit (CustomerId, BillId, Seq);
@@ -0,0 +1,8 @@
+-- Nullable because bills created before this migration have no known times.
+ALTER TABLE Bill
+    ADD COLUMN CreatedAt TIMESTAMPTZ,
+    ADD COLUMN CloseTime TIMESTAMPTZ,
+    ADD COLUMN ClosedAt TIMESTAMPTZ;
+
+ALTER TABLE LineItem
+    ADD COLUMN CreatedAt TIMESTAMPTZ;
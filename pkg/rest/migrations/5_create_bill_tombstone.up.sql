@@ -0,0 +1,19 @@
+-- Archived bills are deleted from Bill and LineItem, and can be found in their segment.
+CREATE TABLE BillTombstone (
+    CustomerId TEXT NOT NULL,
+    Id TEXT NOT NULL,
+    SegmentKey TEXT NOT NULL,
+    ArchivedAt TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (CustomerId, Id)
+);
+
+CREATE INDEX BillClosedAt ON Bill (Status, ClosedAt);
+
+-- The audit trail outlives the Bill row.
+ALTER TABLE BillAudit
+    ADD COLUMN CurrencyCode TEXT NOT NULL DEFAULT '';
+
+UPDATE BillAudit a
+SET CurrencyCode = b.CurrencyCode
+FROM Bill b
+WHERE b.CustomerId = a.CustomerId AND b.Id = a.BillId;
@@ -0,0 +1,15 @@
+-- Rate is a decimal string so that conversions are reproducible.
+CREATE TABLE FxRate (
+    FromCurrencyCode TEXT NOT NULL,
+    ToCurrencyCode TEXT NOT NULL,
+    Rate TEXT NOT NULL,
+    AsOf TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (FromCurrencyCode, ToCurrencyCode, AsOf)
+);
+
+-- Nullable because most line items are in the bill currency.
+ALTER TABLE LineItem
+    ADD COLUMN OriginalAmount BIGINT,
+    ADD COLUMN OriginalCurrencyCode TEXT,
+    ADD COLUMN FxRate TEXT,
+    ADD COLUMN FxRateAsOf TIMESTAMPTZ;
@@ -0,0 +1,36 @@
+CREATE TABLE TaxRate (
+    Jurisdiction TEXT NOT NULL,
+    Category TEXT NOT NULL,
+    Rate TEXT NOT NULL,
+    PRIMARY KEY (Jurisdiction, Category)
+);
+
+-- Kept when the bill is archived.
+CREATE TABLE BillTax (
+    CustomerId TEXT NOT NULL,
+    BillId TEXT NOT NULL,
+    CurrencyCode TEXT NOT NULL,
+    Subtotal BIGINT NOT NULL,
+    TaxTotal BIGINT NOT NULL,
+    GrandTotal BIGINT NOT NULL,
+    PRIMARY KEY (CustomerId, BillId)
+);
+
+CREATE TABLE BillTaxLine (
+    CustomerId TEXT NOT NULL,
+    BillId TEXT NOT NULL,
+    Category TEXT NOT NULL,
+    Inclusive BOOLEAN NOT NULL,
+    Rate TEXT NOT NULL,
+    Taxable BIGINT NOT NULL,
+    Tax BIGINT NOT NULL,
+    PRIMARY KEY (CustomerId, BillId, Category, Inclusive),
+    FOREIGN KEY (CustomerId, BillId) REFERENCES BillTax (CustomerId, BillId)
+);
+
+ALTER TABLE Bill
+    ADD COLUMN TaxJurisdiction TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE LineItem
+    ADD COLUMN TaxCategory TEXT NOT NULL DEFAULT '',
+    ADD COLUMN TaxInclusive BOOLEAN NOT NULL DEFAULT FALSE;
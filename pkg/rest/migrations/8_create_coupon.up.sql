@@ -0,0 +1,29 @@
+-- Value is a percentage such as '15', or an amount in major units such as '5.00'.
+CREATE TABLE Coupon (
+    Code TEXT NOT NULL,
+    Type TEXT NOT NULL CHECK (Type IN ('percentage', 'fixed_amount')),
+    Value TEXT NOT NULL,
+    CurrencyCode TEXT NOT NULL DEFAULT '',
+    ValidFrom TIMESTAMPTZ NOT NULL,
+    ValidUntil TIMESTAMPTZ,
+    MaxRedemptions BIGINT NOT NULL DEFAULT 0,
+    Redemptions BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (Code)
+);
+
+CREATE TABLE CouponRedemption (
+    Code TEXT NOT NULL REFERENCES Coupon (Code),
+    CustomerId TEXT NOT NULL,
+    BillId TEXT NOT NULL,
+    PRIMARY KEY (Code, CustomerId, BillId)
+);
+
+CREATE TABLE BillDiscountLine (
+    CustomerId TEXT NOT NULL,
+    BillId TEXT NOT NULL,
+    Seq INT NOT NULL,
+    CouponCode TEXT NOT NULL,
+    Amount BIGINT NOT NULL,
+    CurrencyCode TEXT NOT NULL,
+    PRIMARY KEY (CustomerId, BillId, Seq)
+);
@@ -0,0 +1,5 @@
+-- Empty when the amount was given directly. Decimal strings, so that they are stored exactly.
+ALTER TABLE LineItem
+    ADD COLUMN Quantity TEXT NOT NULL DEFAULT '',
+    ADD COLUMN UnitPrice TEXT NOT NULL DEFAULT '',
+    ADD COLUMN Rounding TEXT NOT NULL DEFAULT '';
@@ -34,3 +34,21 @@ var _ model.BillIdGenerator = &MockBillIdGenerator{}
 
 //go:generate mockgen -destination=mock_bill_database.go -package=mocks -source=../../db/database.go
 var _ db.BillDatabase = &MockBillDatabase{}
+
+//go:generate mockgen -destination=mock_ledger_database.go -package=mocks -source=../../db/ledger.go
+var _ db.LedgerDatabase = &MockLedgerDatabase{}
+
+//go:generate mockgen -destination=mock_audit_database.go -package=mocks -source=../../db/audit.go
+var _ db.AuditDatabase = &MockAuditDatabase{}
+
+//go:generate mockgen -destination=mock_tax_database.go -package=mocks -source=../../db/tax.go
+var _ db.TaxDatabase = &MockTaxDatabase{}
+
+//go:generate mockgen -destination=mock_coupon_database.go -package=mocks -source=../../db/coupon.go
+var _ db.CouponDatabase = &MockCouponDatabase{}
+
+//go:generate mockgen -destination=mock_payment_database.go -package=mocks -source=../../db/payment.go
+var _ db.PaymentDatabase = &MockPaymentDatabase{}
+
+//go:generate mockgen -destination=mock_usage_database.go -package=mocks -source=../../db/usage.go
+var _ db.UsageDatabase = &MockUsageDatabase{}
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../db/audit.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "coding-challenge/pkg/model"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAuditDatabase is a mock of AuditDatabase interface.
+type MockAuditDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditDatabaseMockRecorder
+}
+
+// MockAuditDatabaseMockRecorder is the mock recorder for MockAuditDatabase.
+type MockAuditDatabaseMockRecorder struct {
+	mock *MockAuditDatabase
+}
+
+// NewMockAuditDatabase creates a new mock instance.
+func NewMockAuditDatabase(ctrl *gomock.Controller) *MockAuditDatabase {
+	mock := &MockAuditDatabase{ctrl: ctrl}
+	mock.recorder = &MockAuditDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditDatabase) EXPECT() *MockAuditDatabaseMockRecorder {
+	return m.recorder
+}
+
+// GetEntries mocks base method.
+func (m *MockAuditDatabase) GetEntries(ctx context.Context, billId model.BillId) ([]model.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntries", ctx, billId)
+	ret0, _ := ret[0].([]model.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEntries indicates an expected call of GetEntries.
+func (mr *MockAuditDatabaseMockRecorder) GetEntries(ctx, billId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntries", reflect.TypeOf((*MockAuditDatabase)(nil).GetEntries), ctx, billId)
+}
+
+// RecordEntry mocks base method.
+func (m *MockAuditDatabase) RecordEntry(ctx context.Context, entry model.AuditEntry) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordEntry", ctx, entry)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordEntry indicates an expected call of RecordEntry.
+func (mr *MockAuditDatabaseMockRecorder) RecordEntry(ctx, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordEntry", reflect.TypeOf((*MockAuditDatabase)(nil).RecordEntry), ctx, entry)
+}
@@ -7,7 +7,9 @@ package mocks
 import (
 	db "coding-challenge/pkg/db"
 	model "coding-challenge/pkg/model"
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -36,61 +38,151 @@ func (m *MockBillDatabase) EXPECT() *MockBillDatabaseMockRecorder {
 }
 
 // AddLineItem mocks base method.
-func (m *MockBillDatabase) AddLineItem(lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error) {
+func (m *MockBillDatabase) AddLineItem(ctx context.Context, lineItem model.BillLineItem, totalBefore model.TotalAmount) (uint64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddLineItem", lineItem, totalBefore)
+	ret := m.ctrl.Call(m, "AddLineItem", ctx, lineItem, totalBefore)
 	ret0, _ := ret[0].(uint64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddLineItem indicates an expected call of AddLineItem.
-func (mr *MockBillDatabaseMockRecorder) AddLineItem(lineItem, totalBefore interface{}) *gomock.Call {
+func (mr *MockBillDatabaseMockRecorder) AddLineItem(ctx, lineItem, totalBefore interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLineItem", reflect.TypeOf((*MockBillDatabase)(nil).AddLineItem), lineItem, totalBefore)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLineItem", reflect.TypeOf((*MockBillDatabase)(nil).AddLineItem), ctx, lineItem, totalBefore)
+}
+
+// AddLineItems mocks base method.
+func (m *MockBillDatabase) AddLineItems(ctx context.Context, billId model.BillId, lineItems []model.BillLineItem, totalBefore model.TotalAmount) ([]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLineItems", ctx, billId, lineItems, totalBefore)
+	ret0, _ := ret[0].([]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLineItems indicates an expected call of AddLineItems.
+func (mr *MockBillDatabaseMockRecorder) AddLineItems(ctx, billId, lineItems, totalBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLineItems", reflect.TypeOf((*MockBillDatabase)(nil).AddLineItems), ctx, billId, lineItems, totalBefore)
 }
 
 // CloseBill mocks base method.
-func (m *MockBillDatabase) CloseBill(billId model.BillId) (uint64, error) {
+func (m *MockBillDatabase) CloseBill(ctx context.Context, billId model.BillId, closedAt time.Time) (uint64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CloseBill", billId)
+	ret := m.ctrl.Call(m, "CloseBill", ctx, billId, closedAt)
 	ret0, _ := ret[0].(uint64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CloseBill indicates an expected call of CloseBill.
-func (mr *MockBillDatabaseMockRecorder) CloseBill(billId interface{}) *gomock.Call {
+func (mr *MockBillDatabaseMockRecorder) CloseBill(ctx, billId, closedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseBill", reflect.TypeOf((*MockBillDatabase)(nil).CloseBill), ctx, billId, closedAt)
+}
+
+// CountRunningBills mocks base method.
+func (m *MockBillDatabase) CountRunningBills(ctx context.Context, customerId model.CustomerId) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRunningBills", ctx, customerId)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRunningBills indicates an expected call of CountRunningBills.
+func (mr *MockBillDatabaseMockRecorder) CountRunningBills(ctx, customerId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseBill", reflect.TypeOf((*MockBillDatabase)(nil).CloseBill), billId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRunningBills", reflect.TypeOf((*MockBillDatabase)(nil).CountRunningBills), ctx, customerId)
 }
 
 // CreateBill mocks base method.
-func (m *MockBillDatabase) CreateBill(bill model.BillInfo) (uint64, error) {
+func (m *MockBillDatabase) CreateBill(ctx context.Context, bill model.BillInfo) (uint64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateBill", bill)
+	ret := m.ctrl.Call(m, "CreateBill", ctx, bill)
 	ret0, _ := ret[0].(uint64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateBill indicates an expected call of CreateBill.
-func (mr *MockBillDatabaseMockRecorder) CreateBill(bill interface{}) *gomock.Call {
+func (mr *MockBillDatabaseMockRecorder) CreateBill(ctx, bill interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBill", reflect.TypeOf((*MockBillDatabase)(nil).CreateBill), bill)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBill", reflect.TypeOf((*MockBillDatabase)(nil).CreateBill), ctx, bill)
 }
 
 // GetBill mocks base method.
-func (m *MockBillDatabase) GetBill(billId model.BillId) (db.BillInfoAndMetadata, error) {
+func (m *MockBillDatabase) GetBill(ctx context.Context, billId model.BillId) (db.BillInfoAndMetadata, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBill", billId)
+	ret := m.ctrl.Call(m, "GetBill", ctx, billId)
 	ret0, _ := ret[0].(db.BillInfoAndMetadata)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetBill indicates an expected call of GetBill.
-func (mr *MockBillDatabaseMockRecorder) GetBill(billId interface{}) *gomock.Call {
+func (mr *MockBillDatabaseMockRecorder) GetBill(ctx, billId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBill", reflect.TypeOf((*MockBillDatabase)(nil).GetBill), ctx, billId)
+}
+
+// GetLineItems mocks base method.
+func (m *MockBillDatabase) GetLineItems(ctx context.Context, billId model.BillId) ([]model.BillLineItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLineItems", ctx, billId)
+	ret0, _ := ret[0].([]model.BillLineItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLineItems indicates an expected call of GetLineItems.
+func (mr *MockBillDatabaseMockRecorder) GetLineItems(ctx, billId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLineItems", reflect.TypeOf((*MockBillDatabase)(nil).GetLineItems), ctx, billId)
+}
+
+// SetBillStatus mocks base method.
+func (m *MockBillDatabase) SetBillStatus(ctx context.Context, billId model.BillId, status model.BillStatus) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBillStatus", ctx, billId, status)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetBillStatus indicates an expected call of SetBillStatus.
+func (mr *MockBillDatabaseMockRecorder) SetBillStatus(ctx, billId, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBillStatus", reflect.TypeOf((*MockBillDatabase)(nil).SetBillStatus), ctx, billId, status)
+}
+
+// SetCloseTime mocks base method.
+func (m *MockBillDatabase) SetCloseTime(ctx context.Context, billId model.BillId, closeTime time.Time) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCloseTime", ctx, billId, closeTime)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetCloseTime indicates an expected call of SetCloseTime.
+func (mr *MockBillDatabaseMockRecorder) SetCloseTime(ctx, billId, closeTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCloseTime", reflect.TypeOf((*MockBillDatabase)(nil).SetCloseTime), ctx, billId, closeTime)
+}
+
+// SetSpendingCap mocks base method.
+func (m *MockBillDatabase) SetSpendingCap(ctx context.Context, billId model.BillId, spendingCap model.SpendingCap) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSpendingCap", ctx, billId, spendingCap)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetSpendingCap indicates an expected call of SetSpendingCap.
+func (mr *MockBillDatabaseMockRecorder) SetSpendingCap(ctx, billId, spendingCap interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBill", reflect.TypeOf((*MockBillDatabase)(nil).GetBill), billId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSpendingCap", reflect.TypeOf((*MockBillDatabase)(nil).SetSpendingCap), ctx, billId, spendingCap)
 }
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../db/coupon.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "coding-challenge/pkg/model"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCouponDatabase is a mock of CouponDatabase interface.
+type MockCouponDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockCouponDatabaseMockRecorder
+}
+
+// MockCouponDatabaseMockRecorder is the mock recorder for MockCouponDatabase.
+type MockCouponDatabaseMockRecorder struct {
+	mock *MockCouponDatabase
+}
+
+// NewMockCouponDatabase creates a new mock instance.
+func NewMockCouponDatabase(ctrl *gomock.Controller) *MockCouponDatabase {
+	mock := &MockCouponDatabase{ctrl: ctrl}
+	mock.recorder = &MockCouponDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCouponDatabase) EXPECT() *MockCouponDatabaseMockRecorder {
+	return m.recorder
+}
+
+// GetBillDiscounts mocks base method.
+func (m *MockCouponDatabase) GetBillDiscounts(ctx context.Context, billId model.BillId) ([]model.DiscountLine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBillDiscounts", ctx, billId)
+	ret0, _ := ret[0].([]model.DiscountLine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBillDiscounts indicates an expected call of GetBillDiscounts.
+func (mr *MockCouponDatabaseMockRecorder) GetBillDiscounts(ctx, billId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBillDiscounts", reflect.TypeOf((*MockCouponDatabase)(nil).GetBillDiscounts), ctx, billId)
+}
+
+// GetCoupon mocks base method.
+func (m *MockCouponDatabase) GetCoupon(ctx context.Context, code string) (model.Coupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCoupon", ctx, code)
+	ret0, _ := ret[0].(model.Coupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCoupon indicates an expected call of GetCoupon.
+func (mr *MockCouponDatabaseMockRecorder) GetCoupon(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCoupon", reflect.TypeOf((*MockCouponDatabase)(nil).GetCoupon), ctx, code)
+}
+
+// RedeemCoupon mocks base method.
+func (m *MockCouponDatabase) RedeemCoupon(ctx context.Context, code string, billId model.BillId) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemCoupon", ctx, code, billId)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeemCoupon indicates an expected call of RedeemCoupon.
+func (mr *MockCouponDatabaseMockRecorder) RedeemCoupon(ctx, code, billId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemCoupon", reflect.TypeOf((*MockCouponDatabase)(nil).RedeemCoupon), ctx, code, billId)
+}
+
+// SaveBillDiscounts mocks base method.
+func (m *MockCouponDatabase) SaveBillDiscounts(ctx context.Context, billId model.BillId, discounts []model.DiscountLine) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBillDiscounts", ctx, billId, discounts)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveBillDiscounts indicates an expected call of SaveBillDiscounts.
+func (mr *MockCouponDatabaseMockRecorder) SaveBillDiscounts(ctx, billId, discounts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBillDiscounts", reflect.TypeOf((*MockCouponDatabase)(nil).SaveBillDiscounts), ctx, billId, discounts)
+}
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../db/ledger.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "coding-challenge/pkg/model"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockLedgerDatabase is a mock of LedgerDatabase interface.
+type MockLedgerDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockLedgerDatabaseMockRecorder
+}
+
+// MockLedgerDatabaseMockRecorder is the mock recorder for MockLedgerDatabase.
+type MockLedgerDatabaseMockRecorder struct {
+	mock *MockLedgerDatabase
+}
+
+// NewMockLedgerDatabase creates a new mock instance.
+func NewMockLedgerDatabase(ctrl *gomock.Controller) *MockLedgerDatabase {
+	mock := &MockLedgerDatabase{ctrl: ctrl}
+	mock.recorder = &MockLedgerDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLedgerDatabase) EXPECT() *MockLedgerDatabaseMockRecorder {
+	return m.recorder
+}
+
+// GetBalance mocks base method.
+func (m *MockLedgerDatabase) GetBalance(ctx context.Context, account model.LedgerAccount) (model.TotalAmount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalance", ctx, account)
+	ret0, _ := ret[0].(model.TotalAmount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalance indicates an expected call of GetBalance.
+func (mr *MockLedgerDatabaseMockRecorder) GetBalance(ctx, account interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockLedgerDatabase)(nil).GetBalance), ctx, account)
+}
+
+// PostTransaction mocks base method.
+func (m *MockLedgerDatabase) PostTransaction(ctx context.Context, transaction model.LedgerTransaction) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostTransaction", ctx, transaction)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostTransaction indicates an expected call of PostTransaction.
+func (mr *MockLedgerDatabaseMockRecorder) PostTransaction(ctx, transaction interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostTransaction", reflect.TypeOf((*MockLedgerDatabase)(nil).PostTransaction), ctx, transaction)
+}
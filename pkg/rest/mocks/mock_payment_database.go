@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../db/payment.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "coding-challenge/pkg/model"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPaymentDatabase is a mock of PaymentDatabase interface.
+type MockPaymentDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentDatabaseMockRecorder
+}
+
+// MockPaymentDatabaseMockRecorder is the mock recorder for MockPaymentDatabase.
+type MockPaymentDatabaseMockRecorder struct {
+	mock *MockPaymentDatabase
+}
+
+// NewMockPaymentDatabase creates a new mock instance.
+func NewMockPaymentDatabase(ctrl *gomock.Controller) *MockPaymentDatabase {
+	mock := &MockPaymentDatabase{ctrl: ctrl}
+	mock.recorder = &MockPaymentDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentDatabase) EXPECT() *MockPaymentDatabaseMockRecorder {
+	return m.recorder
+}
+
+// GetDunningNotifications mocks base method.
+func (m *MockPaymentDatabase) GetDunningNotifications(ctx context.Context, billId model.BillId) ([]model.DunningNotification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDunningNotifications", ctx, billId)
+	ret0, _ := ret[0].([]model.DunningNotification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDunningNotifications indicates an expected call of GetDunningNotifications.
+func (mr *MockPaymentDatabaseMockRecorder) GetDunningNotifications(ctx, billId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDunningNotifications", reflect.TypeOf((*MockPaymentDatabase)(nil).GetDunningNotifications), ctx, billId)
+}
+
+// GetPaymentAttempts mocks base method.
+func (m *MockPaymentDatabase) GetPaymentAttempts(ctx context.Context, billId model.BillId) ([]model.PaymentAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentAttempts", ctx, billId)
+	ret0, _ := ret[0].([]model.PaymentAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentAttempts indicates an expected call of GetPaymentAttempts.
+func (mr *MockPaymentDatabaseMockRecorder) GetPaymentAttempts(ctx, billId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentAttempts", reflect.TypeOf((*MockPaymentDatabase)(nil).GetPaymentAttempts), ctx, billId)
+}
+
+// RecordDunningNotification mocks base method.
+func (m *MockPaymentDatabase) RecordDunningNotification(ctx context.Context, notification model.DunningNotification) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordDunningNotification", ctx, notification)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordDunningNotification indicates an expected call of RecordDunningNotification.
+func (mr *MockPaymentDatabaseMockRecorder) RecordDunningNotification(ctx, notification interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordDunningNotification", reflect.TypeOf((*MockPaymentDatabase)(nil).RecordDunningNotification), ctx, notification)
+}
+
+// RecordPaymentAttempt mocks base method.
+func (m *MockPaymentDatabase) RecordPaymentAttempt(ctx context.Context, attempt model.PaymentAttempt) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordPaymentAttempt", ctx, attempt)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordPaymentAttempt indicates an expected call of RecordPaymentAttempt.
+func (mr *MockPaymentDatabaseMockRecorder) RecordPaymentAttempt(ctx, attempt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordPaymentAttempt", reflect.TypeOf((*MockPaymentDatabase)(nil).RecordPaymentAttempt), ctx, attempt)
+}
@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../db/tax.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "coding-challenge/pkg/model"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTaxDatabase is a mock of TaxDatabase interface.
+type MockTaxDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaxDatabaseMockRecorder
+}
+
+// MockTaxDatabaseMockRecorder is the mock recorder for MockTaxDatabase.
+type MockTaxDatabaseMockRecorder struct {
+	mock *MockTaxDatabase
+}
+
+// NewMockTaxDatabase creates a new mock instance.
+func NewMockTaxDatabase(ctrl *gomock.Controller) *MockTaxDatabase {
+	mock := &MockTaxDatabase{ctrl: ctrl}
+	mock.recorder = &MockTaxDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTaxDatabase) EXPECT() *MockTaxDatabaseMockRecorder {
+	return m.recorder
+}
+
+// GetBillTax mocks base method.
+func (m *MockTaxDatabase) GetBillTax(ctx context.Context, billId model.BillId) (model.BillTax, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBillTax", ctx, billId)
+	ret0, _ := ret[0].(model.BillTax)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBillTax indicates an expected call of GetBillTax.
+func (mr *MockTaxDatabaseMockRecorder) GetBillTax(ctx, billId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBillTax", reflect.TypeOf((*MockTaxDatabase)(nil).GetBillTax), ctx, billId)
+}
+
+// GetTaxRate mocks base method.
+func (m *MockTaxDatabase) GetTaxRate(ctx context.Context, jurisdiction model.TaxJurisdiction, category model.TaxCategory) (model.TaxRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxRate", ctx, jurisdiction, category)
+	ret0, _ := ret[0].(model.TaxRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxRate indicates an expected call of GetTaxRate.
+func (mr *MockTaxDatabaseMockRecorder) GetTaxRate(ctx, jurisdiction, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxRate", reflect.TypeOf((*MockTaxDatabase)(nil).GetTaxRate), ctx, jurisdiction, category)
+}
+
+// SaveBillTax mocks base method.
+func (m *MockTaxDatabase) SaveBillTax(ctx context.Context, billId model.BillId, tax model.BillTax) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBillTax", ctx, billId, tax)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveBillTax indicates an expected call of SaveBillTax.
+func (mr *MockTaxDatabaseMockRecorder) SaveBillTax(ctx, billId, tax interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBillTax", reflect.TypeOf((*MockTaxDatabase)(nil).SaveBillTax), ctx, billId, tax)
+}
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../db/usage.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	model "coding-challenge/pkg/model"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUsageDatabase is a mock of UsageDatabase interface.
+type MockUsageDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockUsageDatabaseMockRecorder
+}
+
+// MockUsageDatabaseMockRecorder is the mock recorder for MockUsageDatabase.
+type MockUsageDatabaseMockRecorder struct {
+	mock *MockUsageDatabase
+}
+
+// NewMockUsageDatabase creates a new mock instance.
+func NewMockUsageDatabase(ctrl *gomock.Controller) *MockUsageDatabase {
+	mock := &MockUsageDatabase{ctrl: ctrl}
+	mock.recorder = &MockUsageDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUsageDatabase) EXPECT() *MockUsageDatabaseMockRecorder {
+	return m.recorder
+}
+
+// AggregateUsage mocks base method.
+func (m *MockUsageDatabase) AggregateUsage(ctx context.Context, billId model.BillId) ([]model.UsageAggregate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AggregateUsage", ctx, billId)
+	ret0, _ := ret[0].([]model.UsageAggregate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AggregateUsage indicates an expected call of AggregateUsage.
+func (mr *MockUsageDatabaseMockRecorder) AggregateUsage(ctx, billId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AggregateUsage", reflect.TypeOf((*MockUsageDatabase)(nil).AggregateUsage), ctx, billId)
+}
+
+// RecordUsageEvents mocks base method.
+func (m *MockUsageDatabase) RecordUsageEvents(ctx context.Context, billId model.BillId, events []model.UsageEvent) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordUsageEvents", ctx, billId, events)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordUsageEvents indicates an expected call of RecordUsageEvents.
+func (mr *MockUsageDatabaseMockRecorder) RecordUsageEvents(ctx, billId, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordUsageEvents", reflect.TypeOf((*MockUsageDatabase)(nil).RecordUsageEvents), ctx, billId, events)
+}
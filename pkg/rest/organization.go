@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+	"time"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+)
+
+// Replaces the in-memory memberships.
+func (s *BillingService) SetOrganizationDb(orgDb db.OrganizationDatabase) {
+	s.orgDb = orgDb
+}
+
+type MemberResponse struct {
+	UserId string `json:"user_id"`
+	// owner, billing-admin or viewer.
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func formatMember(membership model.Membership) MemberResponse {
+	return MemberResponse{UserId: string(membership.UserId), Role: string(membership.Role), CreatedAt: membership.CreatedAt}
+}
+
+type ListMembersResponse struct {
+	Members []MemberResponse `json:"members"`
+	RateLimitHeaders
+}
+
+// The users of the organization of the caller, in the order they joined. Owners only.
+//
+//encore:api auth method=GET path=/org/members
+func (s *BillingService) ListMembers(ctx context.Context) (*ListMembersResponse, error) {
+	orgId, err := authorize(ctx, model.PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.orgDb.ListMemberships(ctx, *orgId)
+	if err != nil {
+		rlog.Error("failed to list memberships", "orgId", *orgId, "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to list members")
+	}
+	members := make([]MemberResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		members = append(members, formatMember(membership))
+	}
+	return &ListMembersResponse{Members: members}, nil
+}
+
+type SetMemberRequest struct {
+	// owner, billing-admin or viewer.
+	Role string `json:"role"`
+}
+
+type SetMemberResponse struct {
+	Member MemberResponse `json:"member"`
+	RateLimitHeaders
+}
+
+// Adds the user to the organization of the caller, or changes its role. The last owner cannot lose its role. Owners
+// only.
+//
+//encore:api auth method=PUT path=/org/members/:userId
+func (s *BillingService) SetMember(ctx context.Context, userId string, setMemberRequest *SetMemberRequest) (*SetMemberResponse, error) {
+	orgId, err := authorize(ctx, model.PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+	role, err := model.ParseRole(setMemberRequest.Role)
+	if err != nil {
+		return nil, errs.WrapCode(err, errs.InvalidArgument, "invalid role")
+	}
+	membership := model.Membership{OrgId: *orgId, UserId: model.UserId(userId), Role: role, CreatedAt: time.Now()}
+	err = s.orgDb.SaveMembership(ctx, membership)
+	var lastOwner model.LastOwnerError
+	if errors.As(err, &lastOwner) {
+		return nil, errs.WrapCode(err, errs.FailedPrecondition, "the organization needs an owner")
+	} else if err != nil {
+		rlog.Error("failed to save membership", "orgId", *orgId, "userId", userId, "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to set member")
+	}
+	// The user may have joined before
+	membership, err = s.orgDb.GetMembership(ctx, *orgId, model.UserId(userId))
+	if err != nil {
+		rlog.Error("failed to get membership", "orgId", *orgId, "userId", userId, "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to get member")
+	}
+	rlog.Info("set member", "orgId", *orgId, "userId", userId, "role", role)
+	return &SetMemberResponse{Member: formatMember(membership)}, nil
+}
+
+type RemoveMemberResponse struct {
+	RateLimitHeaders
+}
+
+// Removes the user from the organization of the caller, its tokens are refused from then on. The last owner cannot be
+// removed. Owners only.
+//
+//encore:api auth method=DELETE path=/org/members/:userId
+func (s *BillingService) RemoveMember(ctx context.Context, userId string) (*RemoveMemberResponse, error) {
+	orgId, err := authorize(ctx, model.PermissionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+	err = s.orgDb.DeleteMembership(ctx, *orgId, model.UserId(userId))
+	var lastOwner model.LastOwnerError
+	if errors.Is(err, db.ErrMembershipNotFound) {
+		return nil, errs.WrapCode(err, errs.NotFound, "member not found")
+	} else if errors.As(err, &lastOwner) {
+		return nil, errs.WrapCode(err, errs.FailedPrecondition, "the organization needs an owner")
+	} else if err != nil {
+		rlog.Error("failed to delete membership", "orgId", *orgId, "userId", userId, "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to remove member")
+	}
+	rlog.Info("removed member", "orgId", *orgId, "userId", userId)
+	return &RemoveMemberResponse{}, nil
+}
@@ -0,0 +1,87 @@
+package rest
+
+import (
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"context"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+type RecordPaymentRequest struct {
+	// Of the payment received out of band, such as the id of a bank transfer.
+	Reference string `json:"reference"`
+}
+
+type RecordPaymentResponse struct {
+	Id       string                 `json:"id"`
+	Status   model.BillStatus       `json:"status"`
+	Payments []model.PaymentAttempt `json:"payments"`
+	RateLimitHeaders
+}
+
+func (s *BillingService) queryDunningState(ctx context.Context, billId string) (workflow.DunningState, error) {
+	var state workflow.DunningState
+	encodedResult, err := s.client.QueryWorkflow(ctx, workflow.DunningWorkflowId(model.BillId{Id: billId}), "", workflow.GetDunningStateQuery)
+	if err != nil {
+		return state, err
+	}
+	err = encodedResult.Get(&state)
+	return state, err
+}
+
+// A bill whose payment failed without being dunned, such as one too large to be charged, stays failed.
+func (s *BillingService) getDunningState(ctx context.Context, billId model.BillId) (workflow.DunningState, error) {
+	state, err := s.queryDunningState(ctx, billId.Id)
+	if _, ok := err.(*serviceerror.NotFound); ok {
+		return workflow.DunningState{BillId: billId, Status: model.PaymentFailed}, nil
+	} else if err != nil {
+		rlog.Error("failed to query dunning workflow", "billId", billId.Id, "err", err)
+		return state, errs.WrapCode(err, errs.Unavailable, "failed to query dunning")
+	}
+	return state, nil
+}
+
+// Records a payment received out of band for the whole amount due, which ends the dunning of the bill.
+//
+//encore:api auth method=POST path=/bill/:id/payments
+func (s *BillingService) RecordPayment(ctx context.Context, id string, recordPaymentRequest *RecordPaymentRequest) (*RecordPaymentResponse, error) {
+	customerId, err := authorize(ctx, model.PermissionManageBills)
+	if err != nil {
+		return nil, err
+	}
+	state, err := s.queryDunningState(ctx, id)
+	if _, ok := err.(*serviceerror.NotFound); ok {
+		return nil, errs.B().Code(errs.FailedPrecondition).Msgf("bill %q is not being dunned", id).Err()
+	} else if err != nil {
+		rlog.Error("failed to query dunning workflow", "billId", id, "err", err)
+		return nil, errs.WrapCode(err, errs.Unavailable, "failed to query dunning")
+	} else if state.BillId.CustomerId != *customerId {
+		return nil, errs.B().Code(errs.NotFound).Msgf("bill %q not found", id).Err()
+	}
+	options := client.UpdateWorkflowOptions{
+		UpdateID:   recordPaymentRequest.Reference,
+		WorkflowID: workflow.DunningWorkflowId(state.BillId),
+		UpdateName: workflow.RecordPaymentUpdate,
+		Args: []interface{}{
+			workflow.RecordPaymentArgs{Reference: recordPaymentRequest.Reference},
+		},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	}
+	updateHandle, err := s.client.UpdateWorkflow(ctx, options)
+	if err != nil {
+		rlog.Error("failed to record payment", "billId", id, "err", err)
+		return nil, errs.WrapCode(err, errs.FailedPrecondition, "failed to record payment")
+	}
+	var updatedState workflow.DunningState
+	err = updateHandle.Get(ctx, &updatedState)
+	if err != nil {
+		rlog.Error("failed to get updated dunning state", "billId", id, "err", err)
+		return nil, errs.WrapCode(err, errs.FailedPrecondition, "failed to record payment")
+	}
+	rlog.Info("recorded payment", "id", id, "reference", recordPaymentRequest.Reference)
+	return &RecordPaymentResponse{Id: id, Status: updatedState.Status, Payments: updatedState.Payments}, nil
+}
@@ -0,0 +1,194 @@
+package rest
+
+import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"encore.dev"
+	"encore.dev/beta/errs"
+	"encore.dev/middleware"
+	"encore.dev/rlog"
+	"google.golang.org/grpc/metadata"
+)
+
+const memoryRateLimitStore = "memory"
+const sqlRateLimitStore = "sql"
+
+var (
+	// Such as "*=20/s:40;OpenNewBill=30/m:10", see model.ParseRateLimits.
+	rateLimitsText = envOrDefault("BILLING_RATE_LIMITS", "")
+	// The buckets are shared by the API processes with sql.
+	rateLimitStore      = envOrDefault("BILLING_RATE_LIMIT_STORE", memoryRateLimitStore)
+	maxOpenBills        = envOrDefault("BILLING_MAX_OPEN_BILLS", "")
+	maxLineItemsPerBill = envOrDefault("BILLING_MAX_LINE_ITEMS_PER_BILL", "")
+)
+
+// Of the environment, on top of the defaults.
+func rateLimitsFromEnv() (db.RateLimitDatabase, map[string]model.RateLimit, model.Quotas, error) {
+	var rateLimitDb db.RateLimitDatabase
+	switch rateLimitStore {
+	case memoryRateLimitStore:
+		rateLimitDb = db.NewInMemoryRateLimitDatabase()
+	case sqlRateLimitStore:
+		rateLimitDb = db.NewSqlRateLimitDatabase(sqlDb.Stdlib())
+	default:
+		return nil, nil, model.Quotas{}, fmt.Errorf("unknown rate limit store %q, expected %s or %s", rateLimitStore, memoryRateLimitStore, sqlRateLimitStore)
+	}
+	limits, err := model.ParseRateLimits(rateLimitsText)
+	if err != nil {
+		return nil, nil, model.Quotas{}, err
+	}
+	quotas := model.DefaultQuotas()
+	for _, quota := range []struct {
+		text  string
+		value *uint64
+	}{{maxOpenBills, &quotas.MaxOpenBills}, {maxLineItemsPerBill, &quotas.MaxLineItemsPerBill}} {
+		if quota.text == "" {
+			continue
+		}
+		if *quota.value, err = strconv.ParseUint(quota.text, 10, 64); err != nil {
+			return nil, nil, model.Quotas{}, fmt.Errorf("invalid quota %q: %v", quota.text, err)
+		}
+	}
+	return rateLimitDb, limits, quotas, nil
+}
+
+// Replaces the in-memory buckets and the default limits.
+func (s *BillingService) SetRateLimits(rateLimitDb db.RateLimitDatabase, limits map[string]model.RateLimit) {
+	s.rateLimitDb = rateLimitDb
+	s.rateLimits = limits
+}
+
+// Replaces the default quotas.
+func (s *BillingService) SetQuotas(quotas model.Quotas) {
+	s.quotas = quotas
+}
+
+// Tells when to retry a refused request, in seconds.
+type RateLimitDetails struct {
+	Limit      uint32 `json:"limit"`
+	Remaining  uint32 `json:"remaining"`
+	Reset      uint64 `json:"reset"`
+	RetryAfter uint64 `json:"retry_after"`
+}
+
+func (RateLimitDetails) ErrDetails() {}
+
+// Rounded up, so that a caller waiting that long finds a token.
+func ceilSeconds(d time.Duration) uint64 {
+	return uint64(math.Ceil(d.Seconds()))
+}
+
+// Takes a token of the customer for the endpoint. A rate limit that cannot be read lets the request through, with a
+// zero decision.
+func (s *BillingService) takeRateLimitToken(ctx context.Context, endpoint string) (model.RateLimitDecision, error) {
+	customerId, err := getAuthenticatedCustomerId(ctx)
+	if err != nil {
+		return model.RateLimitDecision{}, err
+	}
+	key := string(*customerId) + "/" + endpoint
+	decision, err := s.rateLimitDb.TakeRateLimitToken(ctx, key, model.FindRateLimit(s.rateLimits, endpoint), time.Now())
+	if err != nil {
+		rlog.Error("failed to take rate limit token", "key", key, "err", err)
+		return model.RateLimitDecision{}, nil
+	}
+	if !decision.Allowed {
+		rlog.Warn("rate limit exceeded", "key", key, "retryAfter", decision.RetryAfter)
+		return decision, errs.B().Code(errs.ResourceExhausted).Msg("rate limit exceeded").Details(RateLimitDetails{
+			Limit:      decision.Limit,
+			Remaining:  decision.Remaining,
+			Reset:      ceilSeconds(decision.Reset),
+			RetryAfter: ceilSeconds(decision.RetryAfter),
+		}).Err()
+	}
+	return decision, nil
+}
+
+// The RateLimit-* headers of the IETF draft, set on the responses by the rate limiting middleware.
+type rateLimitedResponse interface {
+	setRateLimit(decision model.RateLimitDecision)
+}
+
+// Embedded in the responses of the rate limited endpoints.
+type RateLimitHeaders struct {
+	// The rate limit of the endpoint for the customer, see RateLimitMiddleware.
+	RateLimitLimit     uint32 `header:"RateLimit-Limit"`
+	RateLimitRemaining uint32 `header:"RateLimit-Remaining"`
+	RateLimitReset     uint64 `header:"RateLimit-Reset"`
+	// The seconds to wait, only set on a request refused over the rate limit.
+	RetryAfter string `header:"Retry-After"`
+}
+
+func (h *RateLimitHeaders) setRateLimit(decision model.RateLimitDecision) {
+	h.RateLimitLimit, h.RateLimitRemaining, h.RateLimitReset = decision.Limit, decision.Remaining, ceilSeconds(decision.Reset)
+	if !decision.Allowed {
+		h.RetryAfter = strconv.FormatUint(ceilSeconds(decision.RetryAfter), 10)
+	}
+}
+
+func setRateLimitHeaders(header http.Header, decision model.RateLimitDecision) {
+	if decision.Limit == 0 {
+		return
+	}
+	header.Set("RateLimit-Limit", strconv.FormatUint(uint64(decision.Limit), 10))
+	header.Set("RateLimit-Remaining", strconv.FormatUint(uint64(decision.Remaining), 10))
+	header.Set("RateLimit-Reset", strconv.FormatUint(ceilSeconds(decision.Reset), 10))
+	if !decision.Allowed {
+		header.Set("Retry-After", strconv.FormatUint(ceilSeconds(decision.RetryAfter), 10))
+	}
+}
+
+// The gRPC callers get the headers as metadata.
+func rateLimitMetadata(decision model.RateLimitDecision) metadata.MD {
+	header := http.Header{}
+	setRateLimitHeaders(header, decision)
+	md := metadata.MD{}
+	for name, values := range header {
+		md.Append(name, values...)
+	}
+	return md
+}
+
+// Limits the requests of each customer to each endpoint, the raw endpoints take their token themselves.
+//
+//encore:middleware target=all
+func (s *BillingService) RateLimitMiddleware(req middleware.Request, next middleware.Next) middleware.Response {
+	data := req.Data()
+	if data.API == nil || data.API.Raw {
+		return next(req)
+	}
+	decision, err := s.takeRateLimitToken(req.Context(), data.Endpoint)
+	if err != nil {
+		if refused, ok := refusedResponse(data.API, decision); ok {
+			return refused
+		}
+		return middleware.Response{Err: err}
+	}
+	resp := next(req)
+	if payload, ok := resp.Payload.(rateLimitedResponse); ok && decision.Limit != 0 {
+		payload.setRateLimit(decision)
+	}
+	return resp
+}
+
+// Encore writes the headers of a response payload but not of an error, so a request refused over the rate limit is
+// answered with the empty response of the endpoint, its headers set, as a 429. The Go client reads it as a
+// resource_exhausted error.
+func refusedResponse(api *encore.APIDesc, decision model.RateLimitDecision) (middleware.Response, bool) {
+	if decision.Allowed || decision.Limit == 0 || api.ResponseType == nil || api.ResponseType.Kind() != reflect.Pointer {
+		return middleware.Response{}, false
+	}
+	payload, ok := reflect.New(api.ResponseType.Elem()).Interface().(rateLimitedResponse)
+	if !ok {
+		return middleware.Response{}, false
+	}
+	payload.setRateLimit(decision)
+	return middleware.Response{Payload: payload, HTTPStatus: http.StatusTooManyRequests}, true
+}
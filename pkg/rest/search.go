@@ -0,0 +1,155 @@
+package rest
+
+import (
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+const defaultSearchPageSize = 20
+const maxSearchPageSize = 100
+
+type SearchBillsRequest struct {
+	// Draft, open or closing, open by default.
+	Status       string `query:"status"`
+	CurrencyCode string `query:"currency_code"`
+	// The bills closing at or after it, none when absent.
+	CloseAfter time.Time `query:"close_after"`
+	// The bills closing before it, none when absent.
+	CloseBefore time.Time `query:"close_before"`
+	// Up to 100, 20 when absent.
+	PageSize int32 `query:"page_size"`
+	// The next_page_token of the previous page.
+	PageToken string `query:"page_token"`
+}
+
+type BillSearchResult struct {
+	Id           string             `json:"id"`
+	CurrencyCode model.CurrencyCode `json:"currency_code"`
+	Status       model.BillStatus   `json:"status"`
+	CloseTime    time.Time          `json:"close_time"`
+	// A decimal string in minor units, as upserted by the workflow, so it may lag the total of GetBill.
+	Total string `json:"total"`
+}
+
+type SearchBillsResponse struct {
+	Bills []BillSearchResult `json:"bills"`
+	// Empty on the last page.
+	NextPageToken string `json:"next_page_token"`
+	RateLimitHeaders
+}
+
+// The visibility query of the bill workflows of the customer. The values are validated before, so none of them has
+// quotes to escape.
+func searchBillsQuery(customerId model.CustomerId, status model.BillStatus, request *SearchBillsRequest) string {
+	conditions := []string{
+		fmt.Sprintf("WorkflowType = '%s'", workflow.BillingWorkflowType),
+		fmt.Sprintf("%s = '%s'", workflow.CustomerIdSearchAttribute.GetName(), customerId),
+		fmt.Sprintf("%s = '%s'", workflow.StatusSearchAttribute.GetName(), status),
+	}
+	if request.CurrencyCode != "" {
+		conditions = append(conditions, fmt.Sprintf("%s = '%s'", workflow.CurrencyCodeSearchAttribute.GetName(), request.CurrencyCode))
+	}
+	if !request.CloseAfter.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("%s >= '%s'", workflow.CloseTimeSearchAttribute.GetName(), request.CloseAfter.UTC().Format(time.RFC3339Nano)))
+	}
+	if !request.CloseBefore.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("%s < '%s'", workflow.CloseTimeSearchAttribute.GetName(), request.CloseBefore.UTC().Format(time.RFC3339Nano)))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// Decodes the search attributes of a bill workflow, those that are missing are left zero.
+func billSearchResult(execution *common.WorkflowExecution, searchAttributes *common.SearchAttributes) (BillSearchResult, error) {
+	result := BillSearchResult{Id: strings.TrimPrefix(execution.GetWorkflowId(), workflow.BillingWorkflowId(""))}
+	fields := searchAttributes.GetIndexedFields()
+	var status string
+	var total int64
+	for name, value := range map[string]any{
+		workflow.CurrencyCodeSearchAttribute.GetName(): &result.CurrencyCode,
+		workflow.StatusSearchAttribute.GetName():       &status,
+		workflow.CloseTimeSearchAttribute.GetName():    &result.CloseTime,
+		workflow.TotalSearchAttribute.GetName():        &total,
+	} {
+		if payload, ok := fields[name]; ok {
+			if err := converter.GetDefaultDataConverter().FromPayload(payload, value); err != nil {
+				return BillSearchResult{}, fmt.Errorf("search attribute %s: %w", name, err)
+			}
+		}
+	}
+	var err error
+	if result.Status, err = model.ParseBillStatus(status); err != nil {
+		return BillSearchResult{}, err
+	}
+	result.Total = strconv.FormatInt(total, 10)
+	return result, nil
+}
+
+// Searches the bills of the caller in the Temporal visibility store rather than in the database, the most recently
+// opened first. The visibility store is eventually consistent, a bill just changed may show its previous state. Only
+// the drafts, open and closing bills are searched, since only their workflow keeps the status up to date.
+//
+//encore:api auth method=GET path=/bills
+func (s *BillingService) SearchBills(ctx context.Context, searchBillsRequest *SearchBillsRequest) (*SearchBillsResponse, error) {
+	customerId, err := authorize(ctx, model.PermissionViewBills)
+	if err != nil {
+		return nil, err
+	}
+	status := model.Open
+	if searchBillsRequest.Status != "" {
+		if status, err = model.ParseBillStatus(searchBillsRequest.Status); err != nil {
+			return nil, errs.WrapCode(err, errs.InvalidArgument, "invalid status")
+		}
+	}
+	// Once the bill workflow ends, the payment and dunning workflows settle the bill without its search attributes
+	if !status.IsRunning() {
+		return nil, errs.B().Code(errs.InvalidArgument).Msgf("bills cannot be searched by the status %s, only while their workflow runs", status).Err()
+	}
+	if searchBillsRequest.CurrencyCode != "" && !model.IsValid(model.CurrencyCode(searchBillsRequest.CurrencyCode)) {
+		return nil, errs.B().Code(errs.InvalidArgument).Msg("invalid currency code").Err()
+	}
+	pageSize := searchBillsRequest.PageSize
+	if pageSize == 0 {
+		pageSize = defaultSearchPageSize
+	} else if pageSize < 0 || maxSearchPageSize < pageSize {
+		return nil, errs.B().Code(errs.InvalidArgument).Msgf("page size must be between 1 and %d", maxSearchPageSize).Err()
+	}
+	pageToken, err := base64.RawURLEncoding.DecodeString(searchBillsRequest.PageToken)
+	if err != nil {
+		return nil, errs.WrapCode(err, errs.InvalidArgument, "invalid page token")
+	}
+	query := searchBillsQuery(*customerId, status, searchBillsRequest)
+	listResponse, err := s.client.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		PageSize:      pageSize,
+		NextPageToken: pageToken,
+		Query:         query,
+	})
+	if err != nil {
+		rlog.Error("failed to search bills", "query", query, "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to search bills")
+	}
+	response := &SearchBillsResponse{
+		Bills:         make([]BillSearchResult, 0, len(listResponse.GetExecutions())),
+		NextPageToken: base64.RawURLEncoding.EncodeToString(listResponse.GetNextPageToken()),
+	}
+	for _, execution := range listResponse.GetExecutions() {
+		result, err := billSearchResult(execution.GetExecution(), execution.GetSearchAttributes())
+		if err != nil {
+			rlog.Error("failed to decode bill search attributes", "workflowId", execution.GetExecution().GetWorkflowId(), "err", err)
+			return nil, errs.WrapCode(err, errs.Internal, "failed to search bills")
+		}
+		response.Bills = append(response.Bills, result)
+	}
+	return response, nil
+}
@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"context"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+	"go.temporal.io/sdk/client"
+)
+
+type SetSpendingCapRequest struct {
+	// In minor units of the bill currency, zero removes the cap.
+	SpendingCap int64 `json:"spending_cap"`
+	// In percents of the spending cap, such as [50, 80, 100].
+	AlertThresholds []uint32 `json:"alert_thresholds"`
+}
+
+type SetSpendingCapResponse struct {
+	Id              string   `json:"id"`
+	SpendingCap     int64    `json:"spending_cap"`
+	AlertThresholds []uint32 `json:"alert_thresholds"`
+	Total           string   `json:"total"`
+	// The alerts sent so far, including those of thresholds the total had already reached.
+	SpendingAlerts []model.SpendingAlert `json:"spending_alerts"`
+	RateLimitHeaders
+}
+
+// A cap below the total only refuses the next line items.
+//
+//encore:api auth method=PUT path=/bill/:id/spending-cap
+func (s *BillingService) SetSpendingCap(ctx context.Context, id string, setSpendingCapRequest *SetSpendingCapRequest) (*SetSpendingCapResponse, error) {
+	customerId, err := authorize(ctx, model.PermissionManageBills)
+	if err != nil {
+		return nil, err
+	}
+	updateId := s.billIdGenerator.New()
+	options := client.UpdateWorkflowOptions{
+		UpdateID:   updateId,
+		WorkflowID: CreateWorkflowId(id),
+		UpdateName: workflow.SetSpendingCapUpdate,
+		Args: []interface{}{
+			workflow.SetSpendingCapArgs{
+				Max:             setSpendingCapRequest.SpendingCap,
+				AlertThresholds: setSpendingCapRequest.AlertThresholds,
+				Actor:           getAuthenticatedActor(ctx, *customerId),
+				RequestId:       updateId,
+			},
+		},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	}
+	updateHandle, err := s.client.UpdateWorkflow(ctx, options)
+	if err != nil {
+		rlog.Error("failed to set spending cap", "billId", id, "err", err)
+		return nil, errs.WrapCode(err, errs.InvalidArgument, "failed to set spending cap")
+	}
+	var updatedState workflow.BillingState
+	err = updateHandle.Get(ctx, &updatedState)
+	if err != nil {
+		rlog.Error("failed to get updated workflow state", "billId", id, "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to set spending cap")
+	}
+	rlog.Info("set spending cap of workflow", "id", id, "spending_cap", setSpendingCapRequest.SpendingCap)
+	return &SetSpendingCapResponse{
+		Id:              id,
+		SpendingCap:     updatedState.BillInfo.SpendingCap.Max.Number,
+		AlertThresholds: updatedState.BillInfo.SpendingCap.AlertThresholds,
+		Total:           updatedState.Total.Number,
+		SpendingAlerts:  updatedState.SpendingAlerts,
+	}, nil
+}
@@ -0,0 +1,178 @@
+package rest
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"context"
+	"errors"
+	"fmt"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+type SplitChargeRequest struct {
+	Description  string             `json:"description"`
+	Amount       int64              `json:"amount"`
+	CurrencyCode model.CurrencyCode `json:"currency_code"`
+	BillIds      []string           `json:"bill_ids"`
+	// One weight per bill. Empty splits the amount evenly.
+	Ratios []uint64 `json:"ratios"`
+}
+
+type SplitChargeLineItem struct {
+	BillId     string `json:"bill_id"`
+	LineItemId string `json:"line_item_id"`
+	Amount     int64  `json:"amount"`
+}
+
+type SplitChargeResponse struct {
+	Id           string                `json:"id"`
+	CurrencyCode model.CurrencyCode    `json:"currency_code"`
+	LineItems    []SplitChargeLineItem `json:"line_items"`
+	RateLimitHeaders
+}
+
+// Adds a part of the amount to each bill, the parts adding up to the amount exactly. It is all or nothing: the bills
+// must all be open and in the currency of the charge, and when a bill refuses its part, the workflow of the split
+// charge reverses the parts already added with line items of the opposite amount. A part of zero is not added.
+//
+//encore:api auth method=POST path=/split-charges
+func (s *BillingService) SplitCharge(ctx context.Context, splitChargeRequest *SplitChargeRequest) (*SplitChargeResponse, error) {
+	customerId, err := authorize(ctx, model.PermissionManageBills)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := allocateSplitCharge(splitChargeRequest)
+	if err != nil {
+		return nil, errs.WrapCode(err, errs.InvalidArgument, "invalid split charge")
+	}
+	for _, id := range splitChargeRequest.BillIds {
+		if err := s.checkBillOpenIn(ctx, *customerId, id, splitChargeRequest.CurrencyCode); err != nil {
+			return nil, err
+		}
+	}
+
+	request := workflow.SplitChargeRequest{
+		SplitId: s.billIdGenerator.New(),
+		Actor:   getAuthenticatedActor(ctx, *customerId),
+	}
+	// The parts of zero are left out, and the others numbered among themselves
+	billIds, amounts := make([]string, 0, len(parts)), make([]model.Amount, 0, len(parts))
+	for i, part := range parts {
+		if part.Number != 0 {
+			billIds, amounts = append(billIds, splitChargeRequest.BillIds[i]), append(amounts, part)
+		}
+	}
+	response := &SplitChargeResponse{
+		Id:           request.SplitId,
+		CurrencyCode: splitChargeRequest.CurrencyCode,
+		LineItems:    make([]SplitChargeLineItem, 0, len(billIds)),
+	}
+	for i, id := range billIds {
+		lineItem := model.BillLineItem{
+			Id: model.BillLineItemId{
+				BillId: model.BillId{CustomerId: *customerId, Id: id},
+				Id:     s.billIdGenerator.New(),
+			},
+			Description: fmt.Sprintf("%s (%d/%d)", splitChargeRequest.Description, i+1, len(billIds)),
+			Amount:      amounts[i],
+		}
+		request.LineItems = append(request.LineItems, lineItem)
+		response.LineItems = append(response.LineItems, SplitChargeLineItem{
+			BillId:     id,
+			LineItemId: lineItem.Id.Id,
+			Amount:     lineItem.Amount.Number,
+		})
+	}
+
+	options := client.StartWorkflowOptions{
+		ID:        workflow.SplitChargeWorkflowId(request.SplitId),
+		TaskQueue: greetingTaskQueue,
+	}
+	wr, err := s.client.ExecuteWorkflow(ctx, options, workflow.SplitChargeWorkflow, request)
+	if err != nil {
+		rlog.Error("failed to execute split charge workflow", "splitId", request.SplitId, "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "split charge workflow failed to execute")
+	}
+	if err = wr.Get(ctx, nil); err != nil {
+		rlog.Error("failed to split charge", "splitId", request.SplitId, "err", err)
+		if isApplicationErrorOfType(err, "SplitChargeNotReversedError") {
+			return nil, errs.WrapCode(err, errs.Internal, "failed to reverse split charge")
+		} else if isLineItemValidationError(err) {
+			return nil, errs.WrapCode(err, errs.InvalidArgument, "invalid line item")
+		} else if isSpendingCapExceededError(err) {
+			return nil, errs.WrapCode(err, errs.FailedPrecondition, "line item would exceed the spending cap")
+		} else if isLineItemQuotaExceededError(err) {
+			return nil, errs.WrapCode(err, errs.ResourceExhausted, "too many line items")
+		} else if isBillNotOpenError(err) || isApplicationErrorOfType(err, activity.BillNotRunningErrorType) {
+			return nil, errs.WrapCode(err, errs.FailedPrecondition, "bill is not open")
+		}
+		return nil, errs.WrapCode(err, errs.Internal, "failed to split charge")
+	}
+	rlog.Info("added split charge", "splitId", request.SplitId, "bills", len(request.LineItems))
+	return response, nil
+}
+
+func allocateSplitCharge(splitChargeRequest *SplitChargeRequest) ([]model.Amount, error) {
+	billIds := splitChargeRequest.BillIds
+	if len(billIds) == 0 {
+		return nil, errors.New("no bill to split the charge across")
+	}
+	seen := make(map[string]bool, len(billIds))
+	for _, id := range billIds {
+		if seen[id] {
+			return nil, fmt.Errorf("bill %q is listed twice", id)
+		}
+		seen[id] = true
+	}
+	if _, ok := model.GetDigits(splitChargeRequest.CurrencyCode); !ok {
+		return nil, model.InvalidCurrencyCodeError{CurrencyCode: splitChargeRequest.CurrencyCode}
+	}
+	amount := model.Amount{Number: splitChargeRequest.Amount, CurrencyCode: splitChargeRequest.CurrencyCode}
+	if len(splitChargeRequest.Ratios) == 0 {
+		parts, _ := amount.Split(len(billIds))
+		return parts, nil
+	}
+	if len(splitChargeRequest.Ratios) != len(billIds) {
+		return nil, fmt.Errorf("%d ratios for %d bills", len(splitChargeRequest.Ratios), len(billIds))
+	}
+	parts, ok := amount.Allocate(splitChargeRequest.Ratios...)
+	if !ok {
+		return nil, errors.New("the ratios are all zero")
+	}
+	return parts, nil
+}
+
+// The charge is not converted, so that its parts add up exactly.
+func (s *BillingService) checkBillOpenIn(ctx context.Context, customerId model.CustomerId, id string, currencyCode model.CurrencyCode) error {
+	encodedState, err := s.client.QueryWorkflow(ctx, CreateWorkflowId(id), "", workflow.GetPendingBillStateQuery)
+	if _, ok := err.(*serviceerror.NotFound); ok {
+		return errs.WrapCode(err, errs.FailedPrecondition, fmt.Sprintf("bill %s is not open", id))
+	} else if err != nil {
+		rlog.Error("failed to query workflow", "billId", id, "err", err)
+		return errs.WrapCode(err, errs.Internal, "failed to query workflow")
+	}
+	var state workflow.BillingState
+	if err = encodedState.Get(&state); err != nil {
+		rlog.Error("failed to decode intermediate state", "billId", id, "err", err)
+		return errs.WrapCode(err, errs.Internal, "failed to decode intermediate state")
+	}
+	if state.BillInfo.Id.CustomerId != customerId {
+		rlog.Error("failed to query workflow of correct customer", "customerId", customerId, "state customer id", state.BillInfo.Id.CustomerId)
+		return errs.B().Code(errs.NotFound).Msgf("bill %s not found", id).Err()
+	}
+	if state.BillInfo.Status != model.Open {
+		return errs.B().Code(errs.FailedPrecondition).Msgf("bill %s is not open", id).Err()
+	}
+	if state.BillInfo.CurrencyCode != currencyCode {
+		return errs.WrapCode(
+			model.IncompatibleCurrencyCodesError{ExpectedCurrencyCode: state.BillInfo.CurrencyCode, ReceivedCurrencyCode: currencyCode},
+			errs.FailedPrecondition,
+			fmt.Sprintf("bill %s is in another currency", id))
+	}
+	return nil
+}
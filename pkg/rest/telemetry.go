@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"coding-challenge/pkg/telemetry"
+	"context"
+
+	"encore.dev/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// Such as stdout or otlp, see telemetry.Setup.
+var telemetryExporter = envOrDefault("BILLING_TELEMETRY_EXPORTER", telemetry.NoExporter)
+
+// Starts the span of a call to the endpoint, in the trace of the caller when it sent a traceparent.
+func startEndpointSpan(ctx context.Context, carrier propagation.TextMapCarrier, endpoint string) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	return telemetry.Tracer().Start(ctx, "BillingService."+endpoint, trace.WithSpanKind(trace.SpanKindServer))
+}
+
+func endEndpointSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Traces the calls to the endpoints. The workflows and the database calls of the endpoint are traced in its context,
+// so that they are children of its span.
+//
+//encore:middleware target=all
+func (s *BillingService) TracingMiddleware(req middleware.Request, next middleware.Next) middleware.Response {
+	data := req.Data()
+	if data.API == nil {
+		return next(req)
+	}
+	ctx, span := startEndpointSpan(req.Context(), propagation.HeaderCarrier(data.Headers), data.Endpoint)
+	resp := next(req.WithContext(ctx))
+	endEndpointSpan(span, resp.Err)
+	return resp
+}
+
+// The gRPC callers send the traceparent as metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"coding-challenge/pkg/db"
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+	"time"
+
+	"encore.dev/beta/errs"
+	"encore.dev/rlog"
+)
+
+// A service with more events sends several batches.
+const MaxUsageEventsPerBatch = 1000
+
+type UsageEventRequest struct {
+	// Unique per customer, an event sent again is ignored.
+	Id       string `json:"id"`
+	Meter    string `json:"meter"`
+	Quantity string `json:"quantity"`
+	// In major units of the bill currency.
+	UnitPrice  string    `json:"unit_price"`
+	RecordedAt time.Time `json:"recorded_at"` // Now when absent
+}
+
+type RecordUsageRequest struct {
+	Events []UsageEventRequest `json:"events"`
+}
+
+type RecordUsageResponse struct {
+	Id       string `json:"id"`
+	Received int    `json:"received"`
+	// The events sent before are not recorded again.
+	Recorded uint64 `json:"recorded"`
+	RateLimitHeaders
+}
+
+// The events are aggregated into line items per meter and unit price when the bill closes, so that they do not go
+// through the bill workflow one by one. A batch is recorded whole or refused whole.
+//
+//encore:api auth method=POST path=/bill/:id/usage
+func (s *BillingService) RecordUsage(ctx context.Context, id string, recordUsageRequest *RecordUsageRequest) (*RecordUsageResponse, error) {
+	customerId, err := authorize(ctx, model.PermissionManageBills)
+	if err != nil {
+		return nil, err
+	}
+	if len(recordUsageRequest.Events) == 0 || MaxUsageEventsPerBatch < len(recordUsageRequest.Events) {
+		return nil, errs.B().Code(errs.InvalidArgument).Msgf("a batch has between 1 and %d events", MaxUsageEventsPerBatch).Err()
+	}
+	billId := model.BillId{CustomerId: *customerId, Id: id}
+	now := time.Now()
+	events := make([]model.UsageEvent, 0, len(recordUsageRequest.Events))
+	for _, eventRequest := range recordUsageRequest.Events {
+		event := model.UsageEvent{
+			Id:         eventRequest.Id,
+			BillId:     billId,
+			Meter:      eventRequest.Meter,
+			Quantity:   eventRequest.Quantity,
+			UnitPrice:  eventRequest.UnitPrice,
+			RecordedAt: eventRequest.RecordedAt,
+		}
+		if event.RecordedAt.IsZero() {
+			event.RecordedAt = now
+		}
+		if err := event.Check(); err != nil {
+			return nil, errs.WrapCode(err, errs.InvalidArgument, "invalid usage event")
+		}
+		events = append(events, event)
+	}
+	recorded, err := s.usageDb.RecordUsageEvents(ctx, billId, events)
+	if errors.Is(err, db.ErrBillNotFound) {
+		return nil, errs.B().Code(errs.NotFound).Msgf("bill %q not found", id).Err()
+	} else if errors.Is(err, db.ErrBillClosed) {
+		return nil, errs.B().Code(errs.FailedPrecondition).Msgf("bill %q is not open", id).Err()
+	} else if err != nil {
+		rlog.Error("failed to record usage events", "billId", id, "err", err)
+		return nil, errs.WrapCode(err, errs.Internal, "failed to record usage events")
+	}
+	rlog.Info("recorded usage events", "id", id, "received", len(events), "recorded", recorded)
+	return &RecordUsageResponse{Id: id, Received: len(events), Recorded: recorded}, nil
+}
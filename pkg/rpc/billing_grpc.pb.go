@@ -0,0 +1,324 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: billing.proto
+
+// The operations of the REST API most used by the internal callers, on the same bills.
+// The requests are authenticated by the same tokens, sent as "authorization: Bearer <token>" metadata.
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Billing_OpenNewBill_FullMethodName       = "/billing.v1.Billing/OpenNewBill"
+	Billing_GetBill_FullMethodName           = "/billing.v1.Billing/GetBill"
+	Billing_AddBillLineItem_FullMethodName   = "/billing.v1.Billing/AddBillLineItem"
+	Billing_CloseBill_FullMethodName         = "/billing.v1.Billing/CloseBill"
+	Billing_ListBillLineItems_FullMethodName = "/billing.v1.Billing/ListBillLineItems"
+	Billing_StreamBillEvents_FullMethodName  = "/billing.v1.Billing/StreamBillEvents"
+)
+
+// BillingClient is the client API for Billing service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BillingClient interface {
+	// The bill closes at the close time unless closed before.
+	OpenNewBill(ctx context.Context, in *OpenNewBillRequest, opts ...grpc.CallOption) (*OpenNewBillResponse, error)
+	GetBill(ctx context.Context, in *GetBillRequest, opts ...grpc.CallOption) (*GetBillResponse, error)
+	AddBillLineItem(ctx context.Context, in *AddBillLineItemRequest, opts ...grpc.CallOption) (*AddBillLineItemResponse, error)
+	CloseBill(ctx context.Context, in *CloseBillRequest, opts ...grpc.CallOption) (*CloseBillResponse, error)
+	ListBillLineItems(ctx context.Context, in *ListBillLineItemsRequest, opts ...grpc.CallOption) (*ListBillLineItemsResponse, error)
+	// The changes of the bill until the call is cancelled. The stream also ends when the caller is too slow, it can
+	// then resume with the sequence of the last event received.
+	StreamBillEvents(ctx context.Context, in *StreamBillEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BillEvent], error)
+}
+
+type billingClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBillingClient(cc grpc.ClientConnInterface) BillingClient {
+	return &billingClient{cc}
+}
+
+func (c *billingClient) OpenNewBill(ctx context.Context, in *OpenNewBillRequest, opts ...grpc.CallOption) (*OpenNewBillResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OpenNewBillResponse)
+	err := c.cc.Invoke(ctx, Billing_OpenNewBill_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingClient) GetBill(ctx context.Context, in *GetBillRequest, opts ...grpc.CallOption) (*GetBillResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBillResponse)
+	err := c.cc.Invoke(ctx, Billing_GetBill_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingClient) AddBillLineItem(ctx context.Context, in *AddBillLineItemRequest, opts ...grpc.CallOption) (*AddBillLineItemResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddBillLineItemResponse)
+	err := c.cc.Invoke(ctx, Billing_AddBillLineItem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingClient) CloseBill(ctx context.Context, in *CloseBillRequest, opts ...grpc.CallOption) (*CloseBillResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CloseBillResponse)
+	err := c.cc.Invoke(ctx, Billing_CloseBill_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingClient) ListBillLineItems(ctx context.Context, in *ListBillLineItemsRequest, opts ...grpc.CallOption) (*ListBillLineItemsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBillLineItemsResponse)
+	err := c.cc.Invoke(ctx, Billing_ListBillLineItems_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingClient) StreamBillEvents(ctx context.Context, in *StreamBillEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BillEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Billing_ServiceDesc.Streams[0], Billing_StreamBillEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamBillEventsRequest, BillEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Billing_StreamBillEventsClient = grpc.ServerStreamingClient[BillEvent]
+
+// BillingServer is the server API for Billing service.
+// All implementations must embed UnimplementedBillingServer
+// for forward compatibility.
+type BillingServer interface {
+	// The bill closes at the close time unless closed before.
+	OpenNewBill(context.Context, *OpenNewBillRequest) (*OpenNewBillResponse, error)
+	GetBill(context.Context, *GetBillRequest) (*GetBillResponse, error)
+	AddBillLineItem(context.Context, *AddBillLineItemRequest) (*AddBillLineItemResponse, error)
+	CloseBill(context.Context, *CloseBillRequest) (*CloseBillResponse, error)
+	ListBillLineItems(context.Context, *ListBillLineItemsRequest) (*ListBillLineItemsResponse, error)
+	// The changes of the bill until the call is cancelled. The stream also ends when the caller is too slow, it can
+	// then resume with the sequence of the last event received.
+	StreamBillEvents(*StreamBillEventsRequest, grpc.ServerStreamingServer[BillEvent]) error
+	mustEmbedUnimplementedBillingServer()
+}
+
+// UnimplementedBillingServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBillingServer struct{}
+
+func (UnimplementedBillingServer) OpenNewBill(context.Context, *OpenNewBillRequest) (*OpenNewBillResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenNewBill not implemented")
+}
+func (UnimplementedBillingServer) GetBill(context.Context, *GetBillRequest) (*GetBillResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBill not implemented")
+}
+func (UnimplementedBillingServer) AddBillLineItem(context.Context, *AddBillLineItemRequest) (*AddBillLineItemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBillLineItem not implemented")
+}
+func (UnimplementedBillingServer) CloseBill(context.Context, *CloseBillRequest) (*CloseBillResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseBill not implemented")
+}
+func (UnimplementedBillingServer) ListBillLineItems(context.Context, *ListBillLineItemsRequest) (*ListBillLineItemsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBillLineItems not implemented")
+}
+func (UnimplementedBillingServer) StreamBillEvents(*StreamBillEventsRequest, grpc.ServerStreamingServer[BillEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamBillEvents not implemented")
+}
+func (UnimplementedBillingServer) mustEmbedUnimplementedBillingServer() {}
+func (UnimplementedBillingServer) testEmbeddedByValue()                 {}
+
+// UnsafeBillingServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BillingServer will
+// result in compilation errors.
+type UnsafeBillingServer interface {
+	mustEmbedUnimplementedBillingServer()
+}
+
+func RegisterBillingServer(s grpc.ServiceRegistrar, srv BillingServer) {
+	// If the following call pancis, it indicates UnimplementedBillingServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Billing_ServiceDesc, srv)
+}
+
+func _Billing_OpenNewBill_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenNewBillRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServer).OpenNewBill(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Billing_OpenNewBill_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServer).OpenNewBill(ctx, req.(*OpenNewBillRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Billing_GetBill_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBillRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServer).GetBill(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Billing_GetBill_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServer).GetBill(ctx, req.(*GetBillRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Billing_AddBillLineItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddBillLineItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServer).AddBillLineItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Billing_AddBillLineItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServer).AddBillLineItem(ctx, req.(*AddBillLineItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Billing_CloseBill_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseBillRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServer).CloseBill(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Billing_CloseBill_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServer).CloseBill(ctx, req.(*CloseBillRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Billing_ListBillLineItems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBillLineItemsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServer).ListBillLineItems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Billing_ListBillLineItems_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServer).ListBillLineItems(ctx, req.(*ListBillLineItemsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Billing_StreamBillEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamBillEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BillingServer).StreamBillEvents(m, &grpc.GenericServerStream[StreamBillEventsRequest, BillEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Billing_StreamBillEventsServer = grpc.ServerStreamingServer[BillEvent]
+
+// Billing_ServiceDesc is the grpc.ServiceDesc for Billing service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Billing_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "billing.v1.Billing",
+	HandlerType: (*BillingServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "OpenNewBill",
+			Handler:    _Billing_OpenNewBill_Handler,
+		},
+		{
+			MethodName: "GetBill",
+			Handler:    _Billing_GetBill_Handler,
+		},
+		{
+			MethodName: "AddBillLineItem",
+			Handler:    _Billing_AddBillLineItem_Handler,
+		},
+		{
+			MethodName: "CloseBill",
+			Handler:    _Billing_CloseBill_Handler,
+		},
+		{
+			MethodName: "ListBillLineItems",
+			Handler:    _Billing_ListBillLineItems_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamBillEvents",
+			Handler:       _Billing_StreamBillEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "billing.proto",
+}
@@ -0,0 +1,78 @@
+package telemetry
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"math/big"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const currencyCodeKey = attribute.Key("billing.currency_code")
+const activityTypeKey = attribute.Key("temporal.activity_type")
+
+// The instruments of the global meter provider forward to the provider of Setup, even when created before it. They can
+// only fail to be created for an invalid name, in which case they are no-ops.
+var (
+	meter = otel.Meter(instrumentationName)
+
+	billsOpened, _ = meter.Int64Counter("billing.bills.opened",
+		metric.WithDescription("The bills opened, by currency."),
+		metric.WithUnit("{bill}"))
+	billsClosed, _ = meter.Int64Counter("billing.bills.closed",
+		metric.WithDescription("The bills closed, by currency."),
+		metric.WithUnit("{bill}"))
+	lineItemsAdded, _ = meter.Int64Counter("billing.line_items.added",
+		metric.WithDescription("The line items added to the bills, by currency of the bill."),
+		metric.WithUnit("{line_item}"))
+	billTotals, _ = meter.Float64Histogram("billing.bills.closed.total",
+		metric.WithDescription("The totals of the bills closed, in the major unit of their currency, by currency."),
+		metric.WithExplicitBucketBoundaries(0, 1, 10, 50, 100, 500, 1000, 5000, 10000, 50000, 100000, 1000000))
+	activityDuration, _ = meter.Float64Histogram("billing.activity.duration",
+		metric.WithDescription("The latency of the activities, retries apart, by activity type."),
+		metric.WithUnit("s"))
+	activityErrors, _ = meter.Int64Counter("billing.activity.errors",
+		metric.WithDescription("The failed activity attempts, by activity type."),
+		metric.WithUnit("{error}"))
+)
+
+// Once per bill, not when a retried activity finds it created.
+func BillOpened(ctx context.Context, currencyCode model.CurrencyCode) {
+	billsOpened.Add(ctx, 1, metric.WithAttributes(currencyCodeKey.String(string(currencyCode))))
+}
+
+// Once per bill, not when a retried activity finds it closed.
+func BillClosed(ctx context.Context, total model.TotalAmount) {
+	currencyCode := metric.WithAttributes(currencyCodeKey.String(string(total.CurrencyCode)))
+	billsClosed.Add(ctx, 1, currencyCode)
+	billTotals.Record(ctx, majorUnits(total), currencyCode)
+}
+
+func LineItemsAdded(ctx context.Context, currencyCode model.CurrencyCode, count int) {
+	if count == 0 {
+		return
+	}
+	lineItemsAdded.Add(ctx, int64(count), metric.WithAttributes(currencyCodeKey.String(string(currencyCode))))
+}
+
+func recordActivity(ctx context.Context, activityType string, duration time.Duration, err error) {
+	activity := metric.WithAttributes(activityTypeKey.String(activityType))
+	activityDuration.Record(ctx, duration.Seconds(), activity)
+	if err != nil {
+		activityErrors.Add(ctx, 1, activity)
+	}
+}
+
+// Approximate beyond the precision of a float64, which is enough for a metric.
+func majorUnits(total model.TotalAmount) float64 {
+	units := new(big.Float).SetInt(total.BigInt())
+	digits, _ := model.GetDigits(total.CurrencyCode)
+	for range digits {
+		units.Quo(units, big.NewFloat(10))
+	}
+	value, _ := units.Float64()
+	return value
+}
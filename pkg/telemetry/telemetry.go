@@ -0,0 +1,103 @@
+package telemetry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The traces and metrics are not exported.
+const NoExporter = "none"
+
+// The traces and metrics are written to stdout as JSON, the metrics once a minute.
+const StdoutExporter = "stdout"
+
+// The traces and metrics are sent to a collector over gRPC, at OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317.
+// An http:// endpoint, such as that of a local collector, is reached without TLS.
+const OtlpExporter = "otlp"
+
+// The name of the instrumentation in the traces and metrics.
+const instrumentationName = "coding-challenge"
+
+// Exports the traces and metrics of the service with the exporter, and propagates the trace context in the W3C
+// headers. The trace context is propagated even without an exporter, so that a process that exports still gets whole
+// traces. The returned function flushes what was not exported yet.
+func Setup(ctx context.Context, serviceName string, exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if exporter == NoExporter || exporter == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK())
+	if err != nil {
+		return nil, err
+	}
+	spanExporter, metricExporter, err := newExporters(ctx, exporter)
+	if err != nil {
+		return nil, err
+	}
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(spanExporter), sdktrace.WithResource(res))
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)), sdkmetric.WithResource(res))
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+	return func(ctx context.Context) error {
+		return errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}, nil
+}
+
+func newExporters(ctx context.Context, exporter string) (sdktrace.SpanExporter, sdkmetric.Exporter, error) {
+	switch exporter {
+	case StdoutExporter:
+		spanExporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, nil, err
+		}
+		metricExporter, err := stdoutmetric.New(stdoutmetric.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, nil, err
+		}
+		return spanExporter, metricExporter, nil
+	case OtlpExporter:
+		spanExporter, err := otlptracegrpc.New(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		metricExporter, err := otlpmetricgrpc.New(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return spanExporter, metricExporter, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown telemetry exporter %q, expected %s, %s or %s", exporter, NoExporter, StdoutExporter, OtlpExporter)
+	}
+}
+
+// Opens a PostgreSQL database whose queries are spans of the trace of their context, such as that of an activity.
+func OpenPostgreSql(dataSourceName string) (*sql.DB, error) {
+	return otelsql.Open("postgres", dataSourceName,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}))
+}
+
+// The tracer of the spans started by the services themselves.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
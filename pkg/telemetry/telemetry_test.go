@@ -0,0 +1,173 @@
+package telemetry
+
+import (
+	"coding-challenge/pkg/model"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+// The global providers forward to the first providers set only, so they are set once for all the tests.
+var (
+	spanRecorder = tracetest.NewSpanRecorder()
+	metricReader = sdkmetric.NewManualReader()
+)
+
+func TestMain(m *testing.M) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(metricReader)))
+	if _, err := Setup(context.Background(), "billing-test", NoExporter); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func workerInterceptors() []interceptor.WorkerInterceptor {
+	interceptors := []interceptor.WorkerInterceptor{}
+	for _, clientInterceptor := range TemporalInterceptors() {
+		interceptors = append(interceptors, clientInterceptor.(interceptor.WorkerInterceptor))
+	}
+	return interceptors
+}
+
+// Runs the workflow as if started by a client in the context of the caller span.
+func executeTracedWorkflow(t *testing.T, caller context.Context, workflowFn interface{}, activityFn interface{}) error {
+	header := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(caller, header)
+	payload, err := converter.GetDefaultDataConverter().ToPayload(map[string]string(header))
+	assert.NoError(t, err)
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(worker.Options{Interceptors: workerInterceptors()})
+	env.SetHeader(&common.Header{Fields: map[string]*common.Payload{temporalTraceHeader: payload}})
+	env.RegisterActivityWithOptions(activityFn, activity.RegisterOptions{Name: "TracedActivity"})
+	env.RegisterWorkflowWithOptions(workflowFn, workflow.RegisterOptions{Name: "TracedWorkflow"})
+	env.ExecuteWorkflow("TracedWorkflow")
+	return env.GetWorkflowError()
+}
+
+func tracedWorkflow(ctx workflow.Context) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Second,
+		RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 1},
+	})
+	return workflow.ExecuteActivity(ctx, "TracedActivity").Get(ctx, nil)
+}
+
+// Nil when nothing was recorded yet.
+func findMetric(t *testing.T, name string) metricdata.Aggregation {
+	var resourceMetrics metricdata.ResourceMetrics
+	assert.NoError(t, metricReader.Collect(context.Background(), &resourceMetrics))
+	for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			if m.Name == name {
+				return m.Data
+			}
+		}
+	}
+	return nil
+}
+
+func counterValue(t *testing.T, name string, attr attribute.KeyValue) int64 {
+	sum, _ := findMetric(t, name).(metricdata.Sum[int64])
+	for _, point := range sum.DataPoints {
+		if value, ok := point.Attributes.Value(attr.Key); ok && value == attr.Value {
+			return point.Value
+		}
+	}
+	return 0
+}
+
+func histogramPoint(t *testing.T, name string, attr attribute.KeyValue) metricdata.HistogramDataPoint[float64] {
+	histogram, _ := findMetric(t, name).(metricdata.Histogram[float64])
+	for _, point := range histogram.DataPoints {
+		if value, ok := point.Attributes.Value(attr.Key); ok && value == attr.Value {
+			return point
+		}
+	}
+	return metricdata.HistogramDataPoint[float64]{}
+}
+
+func TestTemporalInterceptorsTraceActivitiesInTheTraceOfTheCaller(t *testing.T) {
+	// Arrange
+	caller, callerSpan := Tracer().Start(context.Background(), "BillingService.AddLineItem")
+	var activitySpan trace.SpanContext
+	tracedActivity := func(ctx context.Context) error {
+		activitySpan = trace.SpanContextFromContext(ctx)
+		return nil
+	}
+
+	// Act
+	err := executeTracedWorkflow(t, caller, tracedWorkflow, tracedActivity)
+	callerSpan.End()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, callerSpan.SpanContext().TraceID(), activitySpan.TraceID())
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spanRecorder.Ended() {
+		if span.SpanContext().TraceID() == activitySpan.TraceID() {
+			spans[span.Name()] = span
+		}
+	}
+	if assert.Contains(t, spans, "RunWorkflow:TracedWorkflow") {
+		assert.Equal(t, callerSpan.SpanContext().SpanID(), spans["RunWorkflow:TracedWorkflow"].Parent().SpanID())
+	}
+	if assert.Contains(t, spans, "RunActivity:TracedActivity") {
+		assert.Equal(t, activitySpan.SpanID(), spans["RunActivity:TracedActivity"].SpanContext().SpanID())
+	}
+}
+
+func TestTemporalInterceptorsMeasureActivities(t *testing.T) {
+	// Arrange
+	activityType := attribute.String(string(activityTypeKey), "TracedActivity")
+	errorsBefore := counterValue(t, "billing.activity.errors", activityType)
+	failingActivity := func(ctx context.Context) error {
+		return errors.New("database unavailable")
+	}
+
+	// Act
+	err := executeTracedWorkflow(t, context.Background(), tracedWorkflow, failingActivity)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, errorsBefore+1, counterValue(t, "billing.activity.errors", activityType))
+	assert.NotZero(t, histogramPoint(t, "billing.activity.duration", activityType).Count)
+}
+
+func TestBillClosedRecordsTotalInMajorUnits(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+
+	// Act
+	BillClosed(ctx, model.TotalAmount{Number: "12345", CurrencyCode: "USD"})
+	BillClosed(ctx, model.TotalAmount{Number: "-55", CurrencyCode: "USD"})
+	BillClosed(ctx, model.TotalAmount{Number: "700", CurrencyCode: "GEL"})
+
+	// Assert
+	usd := attribute.String(string(currencyCodeKey), "USD")
+	gel := attribute.String(string(currencyCodeKey), "GEL")
+	assert.Equal(t, int64(2), counterValue(t, "billing.bills.closed", usd))
+	assert.InDelta(t, 122.9, histogramPoint(t, "billing.bills.closed.total", usd).Sum, 1e-9)
+	assert.InDelta(t, 7, histogramPoint(t, "billing.bills.closed.total", gel).Sum, 1e-9)
+}
@@ -0,0 +1,121 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// The Temporal header that carries the trace context from the callers to the workflows and from the workflows to
+// the activities.
+const temporalTraceHeader = "_tracer-data"
+
+type temporalSpanContextKey struct{}
+
+// Traces the workflow starts, signals and updates of the client, then the workflows and activities of the
+// workers created from it, and measures the activities. The trace context of the caller goes in the Temporal headers.
+func TemporalInterceptors() []interceptor.ClientInterceptor {
+	return []interceptor.ClientInterceptor{
+		interceptor.NewTracingInterceptor(&temporalTracer{}),
+		&activityMetricsInterceptor{},
+	}
+}
+
+// Adapts OpenTelemetry to the tracing interceptor of the Temporal SDK. The spans are those of the global tracer
+// provider, read when they start so that Setup may run after the client is created.
+type temporalTracer struct {
+	interceptor.BaseTracer
+}
+
+type temporalSpan struct {
+	trace.Span
+}
+
+func (s *temporalSpan) Finish(options *interceptor.TracerFinishSpanOptions) {
+	if options.Error != nil {
+		s.RecordError(options.Error)
+		s.SetStatus(codes.Error, options.Error.Error())
+	}
+	s.End()
+}
+
+func (t *temporalTracer) Options() interceptor.TracerOptions {
+	return interceptor.TracerOptions{
+		SpanContextKey: temporalSpanContextKey{},
+		HeaderKey:      temporalTraceHeader,
+		// The queries of the REST API would each start a trace
+		DisableQueryTracing: true,
+		// The workflows started before tracing carry no span
+		AllowInvalidParentSpans: true,
+	}
+}
+
+func (t *temporalTracer) UnmarshalSpan(data map[string]string) (interceptor.TracerSpanRef, error) {
+	spanContext := trace.SpanContextFromContext(otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(data)))
+	if !spanContext.IsValid() {
+		return nil, errors.New("no valid span context in the temporal header")
+	}
+	return spanContext, nil
+}
+
+func (t *temporalTracer) MarshalSpan(span interceptor.TracerSpan) (map[string]string, error) {
+	data := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(trace.ContextWithSpan(context.Background(), span.(*temporalSpan).Span), data)
+	return data, nil
+}
+
+func (t *temporalTracer) SpanFromContext(ctx context.Context) interceptor.TracerSpan {
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return nil
+	}
+	return &temporalSpan{span}
+}
+
+func (t *temporalTracer) ContextWithSpan(ctx context.Context, span interceptor.TracerSpan) context.Context {
+	return trace.ContextWithSpan(ctx, span.(*temporalSpan).Span)
+}
+
+func (t *temporalTracer) StartSpan(options *interceptor.TracerStartSpanOptions) (interceptor.TracerSpan, error) {
+	ctx := context.Background()
+	switch parent := options.Parent.(type) {
+	case *temporalSpan:
+		ctx = trace.ContextWithSpan(ctx, parent.Span)
+	case trace.SpanContext:
+		ctx = trace.ContextWithRemoteSpanContext(ctx, parent)
+	}
+	attributes := make([]attribute.KeyValue, 0, len(options.Tags))
+	for key, value := range options.Tags {
+		attributes = append(attributes, attribute.String(key, value))
+	}
+	_, span := Tracer().Start(ctx, t.SpanName(options), trace.WithTimestamp(options.Time), trace.WithAttributes(attributes...))
+	return &temporalSpan{span}, nil
+}
+
+// Measures the latency and the errors of each activity type.
+type activityMetricsInterceptor struct {
+	interceptor.InterceptorBase
+}
+
+func (i *activityMetricsInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	return &activityMetricsInboundInterceptor{interceptor.ActivityInboundInterceptorBase{Next: next}}
+}
+
+type activityMetricsInboundInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+}
+
+func (i *activityMetricsInboundInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	start := time.Now()
+	result, err := i.Next.ExecuteActivity(ctx, in)
+	recordActivity(ctx, activity.GetInfo(ctx).ActivityType.Name, time.Since(start), err)
+	return result, err
+}
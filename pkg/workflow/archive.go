@@ -0,0 +1,89 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const ArchiveBillsWorkflowId = "archive-bills"
+const ArchiveBatchSizeDefault = 500
+
+// Keeps the history of a single run bounded.
+const archiveMaxBatchesPerRun = 100
+
+type ArchiveBillsResult struct {
+	ArchivedCount uint64
+	SegmentKeys   []string
+}
+
+func archiveActivityOptions() workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		StartToCloseTimeout: activity.ArchiveActivityTimeout,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    10,
+		},
+	}
+}
+
+// The key is unique per run and batch, so a retried activity rewrites the same segment.
+func ArchiveSegmentKey(archivedAt time.Time, runId string, batch int) string {
+	return fmt.Sprintf("%s/%s-%04d.jsonl.gz", archivedAt.UTC().Format("2006/01/02"), runId, batch)
+}
+
+// Moves the bills closed more than olderThan ago into archive segments of at most batchSize bills.
+func ArchiveBillsWorkflow(ctx workflow.Context, olderThan time.Duration, batchSize int) (result ArchiveBillsResult, e error) {
+	logger := workflow.GetLogger(ctx)
+	if olderThan < 0 {
+		return result, NegativeDurationError{olderThan}
+	}
+	if batchSize <= 0 {
+		batchSize = ArchiveBatchSizeDefault
+	}
+	now := workflow.Now(ctx)
+	closedBefore := now.Add(-olderThan)
+	runId := workflow.GetInfo(ctx).WorkflowExecution.RunID
+	logger.Info("Archiving bills", "ClosedBefore", closedBefore, "BatchSize", batchSize)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, archiveActivityOptions())
+
+	for batch := 0; batch < archiveMaxBatchesPerRun; batch++ {
+		var billIds []model.BillId
+		e = workflow.ExecuteActivity(
+			ctxWithOptions,
+			(&activity.DummyArchiveActivityHost{}).ListBillsToArchiveActivity,
+			closedBefore,
+			batchSize,
+		).Get(ctxWithOptions, &billIds)
+		if e != nil || len(billIds) == 0 {
+			return result, e
+		}
+		segmentKey := ArchiveSegmentKey(now, runId, batch)
+		var archivedCount uint64
+		e = workflow.ExecuteActivity(
+			ctxWithOptions,
+			(&activity.DummyArchiveActivityHost{}).ArchiveBillsActivity,
+			billIds,
+			segmentKey,
+			now,
+		).Get(ctxWithOptions, &archivedCount)
+		if e != nil {
+			return result, e
+		}
+		logger.Info("Archived bills", "Count", archivedCount, "Segment", segmentKey)
+		result.ArchivedCount += archivedCount
+		result.SegmentKeys = append(result.SegmentKeys, segmentKey)
+		if len(billIds) < batchSize {
+			return result, nil
+		}
+	}
+	logger.Info("More bills to archive, the next run will continue", "ArchivedCount", result.ArchivedCount)
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package workflow_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/testsuite"
+)
+
+type ArchiveWorkflowUnitTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+
+	env *testsuite.TestWorkflowEnvironment
+}
+
+func TestArchiveWorkflowUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ArchiveWorkflowUnitTestSuite))
+}
+
+func (s *ArchiveWorkflowUnitTestSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.env.SetStartTime(testStartTime)
+}
+
+func (s *ArchiveWorkflowUnitTestSuite) AfterTest(suiteName, testName string) {
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *ArchiveWorkflowUnitTestSuite) Test_Workflow_ArchivesInBatches() {
+	// Arrange
+	dummyArchiveActivityHost := activity.DummyArchiveActivityHost{}
+	firstBatch := []model.BillId{
+		{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"},
+		{CustomerId: "bob", Id: "91c05476-2ae1-4fcf-a25c-f1851847aafe"},
+	}
+	secondBatch := []model.BillId{
+		{CustomerId: "carol", Id: "fc03932f-2b53-4d07-ad55-24fc7d85e277"},
+	}
+	closedBefore := testStartTime.Add(-30 * 24 * time.Hour)
+	s.env.OnActivity(dummyArchiveActivityHost.ListBillsToArchiveActivity, mock.Anything, closedBefore, 2).Return(firstBatch, nil).Once()
+	s.env.OnActivity(dummyArchiveActivityHost.ListBillsToArchiveActivity, mock.Anything, closedBefore, 2).Return(secondBatch, nil).Once()
+	s.env.OnActivity(dummyArchiveActivityHost.ArchiveBillsActivity, mock.Anything, firstBatch, mock.AnythingOfType("string"), testStartTime).Return(uint64(2), nil).Once()
+	s.env.OnActivity(dummyArchiveActivityHost.ArchiveBillsActivity, mock.Anything, secondBatch, mock.AnythingOfType("string"), testStartTime).Return(uint64(1), nil).Once()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.ArchiveBillsWorkflow, 30*24*time.Hour, 2)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.ArchiveBillsResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(uint64(3), result.ArchivedCount)
+	s.Len(result.SegmentKeys, 2)
+	s.NotEqual(result.SegmentKeys[0], result.SegmentKeys[1])
+	s.Regexp(`^2025/03/01/.*-0000\.jsonl\.gz$`, result.SegmentKeys[0])
+}
+
+func (s *ArchiveWorkflowUnitTestSuite) Test_Workflow_NothingToArchive() {
+	// Arrange
+	dummyArchiveActivityHost := activity.DummyArchiveActivityHost{}
+	s.env.OnActivity(dummyArchiveActivityHost.ListBillsToArchiveActivity, mock.Anything, mock.Anything, mock.Anything).Return([]model.BillId{}, nil).Once()
+	s.env.OnActivity(dummyArchiveActivityHost.ArchiveBillsActivity, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint64(0), nil).Never()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.ArchiveBillsWorkflow, 30*24*time.Hour, 0)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.ArchiveBillsResult
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(workflow.ArchiveBillsResult{}, result)
+}
@@ -0,0 +1,221 @@
+package workflow
+
+import (
+	"fmt"
+
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+const AddBillLineItemsUpdate = "AddBillLineItems"
+
+// Larger batches are refused so that the update and its activity stay small.
+const MaxBillLineItemsPerBatch = 500
+
+type InvalidBatchSizeError struct {
+	Size int
+}
+
+func (e InvalidBatchSizeError) Error() string {
+	return fmt.Sprintf("batch of %d line items is not within 1 and %d", e.Size, MaxBillLineItemsPerBatch)
+}
+
+type DuplicateLineItemIdError struct {
+	Id string
+}
+
+func (e DuplicateLineItemIdError) Error() string {
+	return fmt.Sprintf("line item %q is given twice", e.Id)
+}
+
+// Refuses the whole batch when it is all or nothing.
+type BatchLineItemError struct {
+	Index  int
+	Id     string
+	Reason string
+}
+
+func (e BatchLineItemError) Error() string {
+	return fmt.Sprintf("line item %d %q: %s", e.Index, e.Id, e.Reason)
+}
+
+type AddBillLineItemsArgs struct {
+	LineItems []model.BillLineItem
+	// When set, no line item is added unless all of them can be. Otherwise those that can are added.
+	AllOrNothing bool
+	Actor        model.Actor
+	RequestId    string
+}
+
+type BillLineItemOutcome string
+
+const (
+	BillLineItemAdded BillLineItemOutcome = "added"
+	// By an earlier request with the same line item id.
+	BillLineItemAlreadyAdded BillLineItemOutcome = "already_added"
+	BillLineItemRefused      BillLineItemOutcome = "refused"
+)
+
+type BillLineItemResult struct {
+	Id      string
+	Outcome BillLineItemOutcome
+	// Empty unless refused.
+	Error string
+}
+
+type AddBillLineItemsResult struct {
+	State BillingState
+	// In the order of the line items.
+	Results []BillLineItemResult
+}
+
+// Every line item is checked when the batch is all or nothing, otherwise they are checked one by one when added.
+func (state *billingState) validateBillLineItems(ctx workflow.Context, args AddBillLineItemsArgs) error {
+	state.logger.Info("Validating bill line items", "Bill", state.BillInfo, "Count", len(args.LineItems), "AllOrNothing", args.AllOrNothing)
+	if e := state.checkOpen(); e != nil {
+		return e
+	} else if len(args.LineItems) == 0 || MaxBillLineItemsPerBatch < len(args.LineItems) {
+		return InvalidBatchSizeError{len(args.LineItems)}
+	}
+	ids := make(map[string]struct{}, len(args.LineItems))
+	for _, lineItem := range args.LineItems {
+		if _, ok := ids[lineItem.Id.Id]; ok {
+			return DuplicateLineItemIdError{lineItem.Id.Id}
+		}
+		ids[lineItem.Id.Id] = struct{}{}
+	}
+	if !args.AllOrNothing {
+		return nil
+	} else if e := state.checkLineItemCount(len(args.LineItems)); e != nil {
+		return e
+	}
+	var amounts []model.Amount
+	for i, lineItem := range args.LineItems {
+		lineItem, e := lineItem.WithComputedAmount()
+		if e == nil {
+			e = state.checkLineItemConvertible(lineItem)
+		}
+		if e != nil {
+			return BatchLineItemError{Index: i, Id: args.LineItems[i].Id.Id, Reason: e.Error()}
+		}
+		// Converted line items are checked against the cap once converted
+		if lineItem.Amount.CurrencyCode == state.BillInfo.CurrencyCode {
+			amounts = append(amounts, lineItem.Amount)
+		}
+	}
+	return state.checkSpendingCap(amounts...)
+}
+
+// Prices, converts and checks the line item against the spending cap and the maximum of line items, along with those
+// accepted before it, then reserves it.
+func (state *billingState) prepareBatchLineItem(ctx workflow.Context, lineItem model.BillLineItem) (model.BillLineItem, error) {
+	// The accepted line items are already reserved as being added
+	if e := state.checkLineItemCount(1); e != nil {
+		return lineItem, e
+	}
+	lineItem, e := lineItem.WithComputedAmount()
+	if e != nil {
+		return lineItem, e
+	}
+	lineItem.CreatedAt = workflow.Now(ctx)
+	// Reserved before it is converted so that the bill does not close meanwhile, its amount once converted
+	state.reserve(model.Amount{})
+	if lineItem.Amount.CurrencyCode != state.BillInfo.CurrencyCode {
+		if e = state.checkLineItemConvertible(lineItem); e == nil {
+			if lineItem, e = state.convertLineItemSyncActivity(ctx, lineItem); e == nil {
+				e = state.BillInfo.CheckLineItemCompatible(lineItem)
+			}
+		}
+		if e != nil {
+			state.unreserve(model.Amount{})
+			return lineItem, e
+		}
+	}
+	if e = state.checkSpendingCap(lineItem.Amount); e != nil {
+		state.unreserve(model.Amount{})
+		return lineItem, e
+	}
+	state.reserveAmount(lineItem.Amount)
+	return lineItem, nil
+}
+
+// The line items are saved by a single activity, and audited by a single entry.
+func (state *billingState) addBillLineItemsIfNotExistSyncActivity(ctx workflow.Context, args AddBillLineItemsArgs) (AddBillLineItemsResult, error) {
+	state.logger.Info("Adding bill line items if they do not exist", "Bill", state.BillInfo, "Count", len(args.LineItems), "Actor", args.Actor)
+	results := make([]BillLineItemResult, len(args.LineItems))
+	var accepted []model.BillLineItem
+	var acceptedIndexes []int
+	// The accepted line items are reserved against the spending cap while the others are converted
+	release := func() {
+		for _, lineItem := range accepted {
+			state.unreserve(lineItem.Amount)
+		}
+	}
+	for i, lineItem := range args.LineItems {
+		results[i].Id = lineItem.Id.Id
+		lineItem, e := state.prepareBatchLineItem(ctx, lineItem)
+		if e != nil && args.AllOrNothing {
+			release()
+			return AddBillLineItemsResult{State: state.Clone()}, BatchLineItemError{Index: i, Id: args.LineItems[i].Id.Id, Reason: e.Error()}
+		} else if e != nil {
+			results[i].Outcome = BillLineItemRefused
+			results[i].Error = e.Error()
+			continue
+		}
+		accepted = append(accepted, lineItem)
+		acceptedIndexes = append(acceptedIndexes, i)
+	}
+	if len(accepted) == 0 {
+		return AddBillLineItemsResult{State: state.Clone(), Results: results}, nil
+	} else if e := state.checkStillOpen(ctx); e != nil {
+		release()
+		return AddBillLineItemsResult{State: state.Clone()}, e
+	}
+
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	totalBefore := state.Total
+	var added []bool
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyActivityHost{}).AddBillLineItemsIfNotExistActivity,
+		state.BillInfo.Id,
+		accepted,
+		state.Total,
+	).Get(ctxWithOptions, &added)
+	release()
+	if e != nil {
+		return AddBillLineItemsResult{State: state.Clone()}, e
+	}
+	totalBeforeAdd := state.Total
+	addedCount := 0
+	for j, lineItem := range accepted {
+		i := acceptedIndexes[j]
+		if !added[j] {
+			results[i].Outcome = BillLineItemAlreadyAdded
+			continue
+		}
+		state.BillLineItemCount++
+		if e = state.Total.Add(lineItem.Amount); e != nil {
+			return AddBillLineItemsResult{State: state.Clone()}, e
+		}
+		results[i].Outcome = BillLineItemAdded
+		state.publishBillEventAsyncActivity(ctx, model.BillLineItemAdded, lineItem.Id.Id)
+		addedCount++
+	}
+	state.logger.Info("Bill line items added", "Total", state.Total)
+	if addedCount != 0 {
+		state.upsertSearchAttributes(ctx)
+	}
+	// Other updates may run while the audit entry is recorded
+	intermediateResult := AddBillLineItemsResult{State: state.Clone(), Results: results}
+	if addedCount == 0 {
+		return intermediateResult, nil
+	}
+	if _, e = state.recordAuditEntrySyncActivity(ctx, model.AuditAddLineItems, args.Actor, args.RequestId, totalBefore); e != nil {
+		return intermediateResult, e
+	}
+	crossed := state.BillInfo.SpendingCap.CrossedThresholds(totalBeforeAdd, intermediateResult.State.Total)
+	return intermediateResult, state.sendSpendingAlertsSyncActivity(ctx, crossed)
+}
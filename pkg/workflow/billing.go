@@ -2,7 +2,8 @@ package workflow
 
 import (
 	"fmt"
-
+	"math/big"
+	"slices"
 	"time"
 
 	"coding-challenge/pkg/activity"
@@ -15,7 +16,15 @@ import (
 
 const AddBillLineItemUpdate = "AddBillLineItem"
 const GetPendingBillStateQuery = "GetPendingBillState"
+
+// Sent by the API before the close update, still received from them.
 const CloseBillEarlySignal = "CloseBillEarly"
+const CloseBillUpdate = "CloseBill"
+const AttachCouponUpdate = "AttachCoupon"
+
+func BillingWorkflowId(billId string) string {
+	return fmt.Sprintf("create-bill-%v", billId)
+}
 
 type NegativeDurationError struct {
 	Duration time.Duration
@@ -25,15 +34,77 @@ func (e NegativeDurationError) Error() string {
 	return fmt.Sprintf("duration is negative %q", e.Duration)
 }
 
+type BillClosingError struct {
+	BillId model.BillId
+}
+
+func (e BillClosingError) Error() string {
+	return fmt.Sprintf("bill %q is closing", e.BillId.Id)
+}
+
+// No fx rates are configured, so a line item in another currency than the one of the bill cannot be converted.
+type FxNotAvailableError struct {
+	CurrencyCode model.CurrencyCode
+}
+
+func (e FxNotAvailableError) Error() string {
+	return fmt.Sprintf("line items in %q cannot be converted, no fx rates are configured", e.CurrencyCode)
+}
+
+type CouponAlreadyAttachedError struct {
+	Code string
+}
+
+func (e CouponAlreadyAttachedError) Error() string {
+	return fmt.Sprintf("coupon %q is already attached", e.Code)
+}
+
+type EmptyCouponCodeError struct {
+}
+
+func (e EmptyCouponCodeError) Error() string {
+	return "coupon code is empty"
+}
+
 type BillingState struct {
 	BillInfo          model.BillInfo
 	BillLineItemCount uint64
 	Total             model.TotalAmount
+	// Only computed at close, and only when the bill has a tax jurisdiction.
+	Tax model.BillTax
+	// In the order they were attached.
+	Coupons []model.Coupon
+	// Only computed at close, one per coupon.
+	Discounts []model.DiscountLine
+	// In the order they were attempted.
+	Payments []model.PaymentAttempt
+	// In the order they were sent.
+	SpendingAlerts []model.SpendingAlert
 }
 
 type billingState struct {
 	BillingState
 	logger log.Logger
+	// The amounts of the line items being added, counted against the spending cap.
+	reserved *big.Int
+	// The number of line items being added, counted against the maximum of the bill.
+	reservedLineItems uint64
+	// The number of coupons being redeemed, which the bill waits for before it computes the discounts.
+	redeemingCoupons uint64
+	// The sequence of the latest bill event.
+	eventSequence uint64
+	// The bill events that may not be published yet.
+	pendingEvents []workflow.Future
+	// Receives a value once the bill is extended, to restart the timer.
+	extended workflow.Channel
+	// Receives a value once a void is saved, or failed, to end the workflow.
+	voided workflow.Channel
+	// Holds the first close update until the workflow reads it, the later ones are dropped.
+	closeRequested workflow.Channel
+	// Held while an extension is saved.
+	extending workflow.Mutex
+	// Whether line items in another currency can be converted, asked once the bill is created.
+	fxAvailable bool
 }
 
 func (state *billingState) Clone() BillingState {
@@ -41,10 +112,49 @@ func (state *billingState) Clone() BillingState {
 		BillInfo:          state.BillInfo,
 		BillLineItemCount: state.BillLineItemCount,
 		Total:             state.Total,
+		Tax:               state.Tax,
+		Coupons:           slices.Clone(state.Coupons),
+		Discounts:         slices.Clone(state.Discounts),
+		Payments:          slices.Clone(state.Payments),
+		SpendingAlerts:    slices.Clone(state.SpendingAlerts),
 	}
 }
 
-type CloseSignalReceiveType string
+func (state *billingState) hasCoupon(code string) bool {
+	return slices.ContainsFunc(state.Coupons, func(coupon model.Coupon) bool {
+		return coupon.Code == code
+	})
+}
+
+type AddBillLineItemArgs struct {
+	LineItem  model.BillLineItem
+	Actor     model.Actor
+	RequestId string
+	// The reversal of a split charge part, exempt from the line item quota and the spending cap so that it is not refused
+	// by a bill that the part filled.
+	Reversal bool
+}
+
+type AttachCouponArgs struct {
+	Code  string
+	Actor model.Actor
+}
+
+type CloseBillEarlyArgs struct {
+	Actor     model.Actor
+	RequestId string
+}
+
+// Used as the request id of the audit entry when the bill closes at maturity, or a draft is voided then.
+const CloseAtMaturityRequestId = "maturity"
+
+// The bill closes once the workflow reads the request, the update does not wait for it. A bill already closing
+// ignores it.
+func (state *billingState) closeBillEarly(ctx workflow.Context, args CloseBillEarlyArgs) (BillingState, error) {
+	state.logger.Info("Requested to close bill early", "Actor", args.Actor, "RequestId", args.RequestId)
+	state.closeRequested.SendAsync(args)
+	return state.Clone(), nil
+}
 
 func defaultActivityOptions() workflow.ActivityOptions {
 	return workflow.ActivityOptions{
@@ -70,27 +180,223 @@ func (state *billingState) createBillIfNotExistSyncActivity(ctx workflow.Context
 	return updateCount, e
 }
 
-func (state *billingState) validateBillLineItem(ctv workflow.Context, lineItem model.BillLineItem) error {
-	state.logger.Info("Validating bill line item", "Bill", state.BillInfo, "Line item", lineItem)
-	return state.BillInfo.CheckLineItemCompatible(lineItem)
+func (state *billingState) recordAuditEntrySyncActivity(
+	ctx workflow.Context,
+	action model.AuditAction,
+	actor model.Actor,
+	requestId string,
+	totalBefore model.TotalAmount,
+) (uint64, error) {
+	entry := model.AuditEntry{
+		BillId:       state.BillInfo.Id,
+		Action:       action,
+		Actor:        actor,
+		RequestId:    requestId,
+		TotalBefore:  totalBefore,
+		TotalAfter:   state.Total,
+		WorkflowTime: workflow.Now(ctx),
+	}
+	state.logger.Info("Recording audit entry", "Entry", entry)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var updateCount uint64
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyActivityHost{}).RecordAuditEntryActivity,
+		entry,
+	).Get(ctxWithOptions, &updateCount)
+	return updateCount, e
 }
 
-func (state *billingState) addBillLineItemIfNotExistSyncActivity(ctx workflow.Context, lineItem model.BillLineItem) (intermediateState BillingState, e error) {
-	state.logger.Info("Adding bill line item if it does not exist", "Bill", state.BillInfo, "Line item", lineItem)
+func (state *billingState) validateBillLineItem(ctv workflow.Context, args AddBillLineItemArgs) error {
+	state.logger.Info("Validating bill line item", "Bill", state.BillInfo, "Line item", args.LineItem)
+	// Line items are refused once the bill starts closing so that they are all taxed.
+	if e := state.checkOpen(); e != nil {
+		return e
+	} else if e = state.checkLineItemQuota(args); e != nil {
+		return e
+	}
+	lineItem, e := args.LineItem.WithComputedAmount()
+	if e != nil {
+		return e
+	}
+	if e = state.checkLineItemConvertible(lineItem); e != nil {
+		return e
+	}
+	// Converted line items are checked against the cap once converted
+	if lineItem.Amount.CurrencyCode == state.BillInfo.CurrencyCode && !args.Reversal {
+		return state.checkSpendingCap(lineItem.Amount)
+	}
+	return nil
+}
+
+// A line item in another currency is refused up front, rather than failing once converted, when there are no fx rates.
+func (state *billingState) checkLineItemConvertible(lineItem model.BillLineItem) error {
+	if e := state.BillInfo.CheckLineItemConvertible(lineItem); e != nil {
+		return e
+	} else if lineItem.Amount.CurrencyCode != state.BillInfo.CurrencyCode && !state.fxAvailable {
+		return FxNotAvailableError{lineItem.Amount.CurrencyCode}
+	}
+	return nil
+}
+
+func (state *billingState) fxAvailableSyncActivity(ctx workflow.Context) (bool, error) {
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var available bool
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyFxActivityHost{}).FxAvailableActivity,
+	).Get(ctxWithOptions, &available)
+	return available, e
+}
+
+func (state *billingState) convertLineItemSyncActivity(ctx workflow.Context, lineItem model.BillLineItem) (model.BillLineItem, error) {
+	state.logger.Info("Converting bill line item", "Bill", state.BillInfo, "Line item", lineItem)
 	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var converted model.BillLineItem
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyFxActivityHost{}).ConvertLineItemActivity,
+		lineItem,
+		state.BillInfo.CurrencyCode,
+	).Get(ctxWithOptions, &converted)
+	return converted, e
+}
+
+func (state *billingState) addBillLineItemIfNotExistSyncActivity(ctx workflow.Context, args AddBillLineItemArgs) (intermediateState BillingState, e error) {
+	lineItem, e := args.LineItem.WithComputedAmount()
+	if e != nil {
+		return state.Clone(), e
+	}
+	lineItem.CreatedAt = workflow.Now(ctx)
+	if e = state.checkLineItemQuota(args); e != nil {
+		return state.Clone(), e
+	}
+	// Reserved before it is converted so that the bill does not close meanwhile, its amount once converted
+	state.reserve(model.Amount{})
+	if lineItem.Amount.CurrencyCode != state.BillInfo.CurrencyCode {
+		if lineItem, e = state.convertLineItemSyncActivity(ctx, lineItem); e != nil {
+			state.unreserve(model.Amount{})
+			return state.Clone(), e
+		}
+		if e = state.BillInfo.CheckLineItemCompatible(lineItem); e != nil {
+			state.unreserve(model.Amount{})
+			return state.Clone(), e
+		}
+	}
+	if e = state.checkStillOpen(ctx); e != nil {
+		state.unreserve(model.Amount{})
+		return state.Clone(), e
+	} else if e = state.checkLineItemSpendingCap(args, lineItem); e != nil {
+		state.unreserve(model.Amount{})
+		return state.Clone(), e
+	}
+	state.logger.Info("Adding bill line item if it does not exist", "Bill", state.BillInfo, "Line item", lineItem, "Actor", args.Actor)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	totalBefore := state.Total
 	var updateCount uint64
+	state.reserveAmount(lineItem.Amount)
 	e = workflow.ExecuteActivity(
 		ctxWithOptions,
 		(&activity.DummyActivityHost{}).AddBillLineItemIfNotExistActivity,
 		lineItem,
 		state.Total,
 	).Get(ctxWithOptions, &updateCount)
-	if e == nil && 0 < updateCount {
-		state.BillLineItemCount += updateCount
-		state.Total.Add(lineItem.Amount)
-		state.logger.Info("Bill line item added", "Total", state.Total, "Amount", lineItem.Amount)
+	state.unreserve(lineItem.Amount)
+	if e != nil || updateCount == 0 {
+		return state.Clone(), e
 	}
-	return state.Clone(), e
+	state.BillLineItemCount += updateCount
+	totalBeforeAdd := state.Total
+	if e = state.Total.Add(lineItem.Amount); e != nil {
+		return state.Clone(), e
+	}
+	state.logger.Info("Bill line item added", "Total", state.Total, "Amount", lineItem.Amount)
+	state.upsertSearchAttributes(ctx)
+	state.publishBillEventAsyncActivity(ctx, model.BillLineItemAdded, lineItem.Id.Id)
+	// Other updates may run while the audit entry is recorded
+	intermediateState = state.Clone()
+	if _, e = state.recordAuditEntrySyncActivity(ctx, model.AuditAddLineItem, args.Actor, args.RequestId, totalBefore); e != nil {
+		return intermediateState, e
+	}
+	crossed := state.BillInfo.SpendingCap.CrossedThresholds(totalBeforeAdd, intermediateState.Total)
+	return intermediateState, state.sendSpendingAlertsSyncActivity(ctx, crossed)
+}
+
+func (state *billingState) validateAttachCoupon(ctx workflow.Context, args AttachCouponArgs) error {
+	state.logger.Info("Validating coupon", "Bill", state.BillInfo, "Code", args.Code)
+	if e := state.checkOpen(); e != nil {
+		return e
+	} else if args.Code == "" {
+		return EmptyCouponCodeError{}
+	} else if state.hasCoupon(args.Code) {
+		return CouponAlreadyAttachedError{args.Code}
+	}
+	return nil
+}
+
+// The coupon code is the request id of the audit entry since a coupon is attached at most once.
+func (state *billingState) attachCouponSyncActivity(ctx workflow.Context, args AttachCouponArgs) (BillingState, error) {
+	state.logger.Info("Attaching coupon", "Bill", state.BillInfo, "Code", args.Code, "Actor", args.Actor)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var coupon model.Coupon
+	state.redeemingCoupons++
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyCouponActivityHost{}).RedeemCouponActivity,
+		state.BillInfo,
+		args.Code,
+		workflow.Now(ctx),
+	).Get(ctxWithOptions, &coupon)
+	// A bill that started closing meanwhile waits for the coupon before it computes the discounts
+	state.redeemingCoupons--
+	if e != nil {
+		return state.Clone(), e
+	}
+	// Another update may have attached it in the meantime
+	if state.hasCoupon(coupon.Code) {
+		return state.Clone(), nil
+	}
+	state.Coupons = append(state.Coupons, coupon)
+	intermediateState := state.Clone()
+	_, e = state.recordAuditEntrySyncActivity(ctx, model.AuditAttachCoupon, args.Actor, args.Code, state.Total)
+	return intermediateState, e
+}
+
+func (state *billingState) applyBillDiscountsSyncActivity(ctx workflow.Context) (uint64, error) {
+	state.logger.Info("Applying bill discounts", "Bill", state.BillInfo, "Discounts", state.Discounts)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var updateCount uint64
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyCouponActivityHost{}).ApplyBillDiscountsActivity,
+		state.BillInfo,
+		state.Discounts,
+	).Get(ctxWithOptions, &updateCount)
+	return updateCount, e
+}
+
+// What is due before discounts.
+func (state *billingState) amountDue() model.TotalAmount {
+	if state.Tax.IsComputed() {
+		return state.Tax.GrandTotal
+	}
+	return state.Total
+}
+
+// Retried until an operator adds the missing rate, rather than leaving the bill in closing.
+func taxActivityOptions() workflow.ActivityOptions {
+	options := defaultActivityOptions()
+	options.RetryPolicy.MaximumInterval = time.Hour
+	options.RetryPolicy.MaximumAttempts = 0 // Unlimited
+	return options
+}
+
+func (state *billingState) computeBillTaxSyncActivity(ctx workflow.Context) (model.BillTax, error) {
+	state.logger.Info("Computing bill tax", "Bill", state.BillInfo)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, taxActivityOptions())
+	var tax model.BillTax
+	e := workflow.ExecuteActivity(ctxWithOptions, (&activity.DummyTaxActivityHost{}).ComputeBillTaxActivity, state.BillInfo).Get(ctxWithOptions, &tax)
+	return tax, e
 }
 
 func (state *billingState) closeBillSyncActivity(ctx workflow.Context) (uint64, error) {
@@ -101,27 +407,91 @@ func (state *billingState) closeBillSyncActivity(ctx workflow.Context) (uint64,
 	return updateCount, e
 }
 
-func BillingWorkflow(ctx workflow.Context, billInfo model.BillInfo, duration time.Duration) (count BillingState, e error) {
+// The status changes before it is saved so that the validators see it while it is saved.
+func (state *billingState) setStatus(ctx workflow.Context, status model.BillStatus) error {
+	if e := state.BillInfo.Status.CheckTransition(status); e != nil {
+		return e
+	}
+	state.BillInfo.Status = status
+	if _, e := setBillStatusSyncActivity(ctx, state.BillInfo.Id, status); e != nil {
+		return e
+	}
+	state.upsertSearchAttributes(ctx)
+	state.publishBillEventAsyncActivity(ctx, model.BillStatusChanged, "")
+	return nil
+}
+
+// A bill with nothing to collect is paid right away.
+func (state *billingState) collectPayment(ctx workflow.Context) error {
+	status := model.Paid
+	if toCollect := model.AmountToCollect(state.amountDue(), state.Discounts); 0 < toCollect.BigInt().Sign() {
+		request := PaymentRequest{BillId: state.BillInfo.Id, Number: 1, Amount: toCollect}
+		attempt, e := executePaymentChildWorkflow(ctx, request)
+		if e != nil {
+			return e
+		}
+		state.Payments = append(state.Payments, attempt)
+		if attempt.Status != model.PaymentSucceeded {
+			status = model.PaymentFailed
+		}
+	}
+	if e := state.setStatus(ctx, status); e != nil {
+		return e
+	}
+	if status != model.PaymentFailed || len(state.Payments) == 0 {
+		return nil
+	}
+	failed := state.Payments[len(state.Payments)-1]
+	return startDunningChildWorkflow(ctx, DunningRequest{
+		BillId:     state.BillInfo.Id,
+		Amount:     failed.Amount,
+		FailedAt:   failed.AttemptedAt,
+		NextNumber: nextPaymentNumber(ctx, failed),
+	})
+}
+
+func BillingWorkflow(ctx workflow.Context, billInfo model.BillInfo, duration time.Duration, opener model.Actor) (count BillingState, e error) {
 	state := &billingState{
 		BillingState: BillingState{
 			BillInfo:          billInfo,
 			BillLineItemCount: 0,
-			Total: model.TotalAmount{
-				Total: model.Amount{Number: 0, CurrencyCode: billInfo.CurrencyCode},
-				Ok:    true,
-			},
+			Total:             model.NewTotalAmount(billInfo.CurrencyCode),
 		},
-		logger: workflow.GetLogger(ctx),
+		logger:         workflow.GetLogger(ctx),
+		reserved:       new(big.Int),
+		extended:       workflow.NewBufferedChannel(ctx, 1),
+		voided:         workflow.NewBufferedChannel(ctx, 1),
+		closeRequested: workflow.NewBufferedChannel(ctx, 1),
+		extending:      workflow.NewMutex(ctx),
 	}
 	state.logger.Info("Bill line items workflow started", "Bill", billInfo, "Duration", duration)
 
 	if duration < 0 {
 		return state.Clone(), NegativeDurationError{duration}
+	} else if !billInfo.Status.IsInitial() {
+		return state.Clone(), model.InvalidBillStatusError{Status: billInfo.Status.String()}
+	} else if e := billInfo.SpendingCap.Check(billInfo.CurrencyCode); e != nil {
+		return state.Clone(), e
 	}
+	state.BillInfo.CreatedAt = workflow.Now(ctx)
+	state.BillInfo.CloseTime = state.BillInfo.CreatedAt.Add(duration)
 
 	if _, e := state.createBillIfNotExistSyncActivity(ctx); e != nil {
 		return state.Clone(), e
 	}
+	// The workflow id is what makes the creation unique
+	createRequestId := workflow.GetInfo(ctx).WorkflowExecution.ID
+	if _, e := state.recordAuditEntrySyncActivity(ctx, model.AuditCreate, opener, createRequestId, state.Total); e != nil {
+		return state.Clone(), e
+	}
+	state.upsertSearchAttributes(ctx)
+	// The workflows started before it convert, or fail to, once the line item is accepted
+	state.fxAvailable = true
+	if hasChange(ctx, fxAvailableChangeId) {
+		if state.fxAvailable, e = state.fxAvailableSyncActivity(ctx); e != nil {
+			return state.Clone(), e
+		}
+	}
 
 	e = workflow.SetUpdateHandlerWithOptions(
 		ctx,
@@ -133,6 +503,70 @@ func BillingWorkflow(ctx workflow.Context, billInfo model.BillInfo, duration tim
 	if e != nil {
 		return state.Clone(), e
 	}
+	e = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		AddBillLineItemsUpdate,
+		state.addBillLineItemsIfNotExistSyncActivity,
+		workflow.UpdateHandlerOptions{
+			Validator: state.validateBillLineItems,
+		})
+	if e != nil {
+		return state.Clone(), e
+	}
+	e = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		AttachCouponUpdate,
+		state.attachCouponSyncActivity,
+		workflow.UpdateHandlerOptions{
+			Validator: state.validateAttachCoupon,
+		})
+	if e != nil {
+		return state.Clone(), e
+	}
+	e = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		SetSpendingCapUpdate,
+		state.setSpendingCapSyncActivity,
+		workflow.UpdateHandlerOptions{
+			Validator: state.validateSetSpendingCap,
+		})
+	if e != nil {
+		return state.Clone(), e
+	}
+	e = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		ExtendBillUpdate,
+		state.extendBillSyncActivity,
+		workflow.UpdateHandlerOptions{
+			Validator: state.validateExtendBill,
+		})
+	if e != nil {
+		return state.Clone(), e
+	}
+	e = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		PublishBillUpdate,
+		state.publishBillSyncActivity,
+		workflow.UpdateHandlerOptions{
+			Validator: state.validatePublishBill,
+		})
+	if e != nil {
+		return state.Clone(), e
+	}
+	e = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		VoidBillUpdate,
+		state.voidBillSyncActivity,
+		workflow.UpdateHandlerOptions{
+			Validator: state.validateVoidBill,
+		})
+	if e != nil {
+		return state.Clone(), e
+	}
+	e = workflow.SetUpdateHandler(ctx, CloseBillUpdate, state.closeBillEarly)
+	if e != nil {
+		return state.Clone(), e
+	}
 	e = workflow.SetQueryHandler(ctx, GetPendingBillStateQuery, func() (BillingState, error) {
 		return state.Clone(), nil
 	})
@@ -141,24 +575,119 @@ func BillingWorkflow(ctx workflow.Context, billInfo model.BillInfo, duration tim
 	}
 
 	// Create a selector to either end with timer or close the bill ahead of time
-	selector := workflow.NewSelector(ctx)
-	selector.AddFuture(
-		workflow.NewTimer(ctx, duration),
-		func(future workflow.Future) {
-			state.logger.Info("Bill arrived at maturity, closing")
-		})
-	selector.AddReceive(
-		workflow.GetSignalChannel(ctx, CloseBillEarlySignal),
-		func(channel workflow.ReceiveChannel, more bool) {
-			var receivedUpdate CloseSignalReceiveType
-			channel.Receive(ctx, &receivedUpdate)
-			state.logger.Info("Received signal to close bill early:", receivedUpdate)
-		})
-	selector.Select(ctx) // Wait until either the timer expires or the close signal is received
+	closeArgs := CloseBillEarlyArgs{Actor: model.NewSystemTimerActor(), RequestId: CloseAtMaturityRequestId}
+	voided := false
+	for closing := false; !closing && !voided; {
+		// An extension cancels the timer and waits again, until the new close time
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		selector := workflow.NewSelector(ctx)
+		selector.AddFuture(
+			workflow.NewTimer(timerCtx, duration),
+			func(future workflow.Future) {
+				state.logger.Info("Bill arrived at maturity, closing")
+				closing = true
+			})
+		selector.AddReceive(
+			workflow.GetSignalChannel(ctx, CloseBillEarlySignal),
+			func(channel workflow.ReceiveChannel, more bool) {
+				channel.Receive(ctx, &closeArgs)
+				state.logger.Info("Received signal to close bill early", "Actor", closeArgs.Actor, "RequestId", closeArgs.RequestId)
+				closing = true
+			})
+		selector.AddReceive(
+			state.closeRequested,
+			func(channel workflow.ReceiveChannel, more bool) {
+				channel.Receive(ctx, &closeArgs)
+				closing = true
+			})
+		selector.AddReceive(
+			state.extended,
+			func(channel workflow.ReceiveChannel, more bool) {
+				channel.Receive(ctx, nil)
+				state.logger.Info("Bill extended, waiting again", "CloseTime", state.BillInfo.CloseTime)
+				duration = max(state.BillInfo.CloseTime.Sub(workflow.Now(ctx)), 0)
+			})
+		selector.AddReceive(
+			state.voided,
+			func(channel workflow.ReceiveChannel, more bool) {
+				channel.Receive(ctx, nil)
+				// A void that failed leaves the bill as it was
+				voided = state.BillInfo.Status == model.Void
+				duration = max(state.BillInfo.CloseTime.Sub(workflow.Now(ctx)), 0)
+			})
+		selector.Select(ctx) // Wait until either the timer expires or the close signal is received
+		cancelTimer()
+	}
+	if !voided && state.BillInfo.Status == model.Void {
+		// Closed while a void is being saved
+		state.voided.Receive(ctx, nil)
+		voided = state.BillInfo.Status == model.Void
+	}
+	if !voided && state.BillInfo.Status == model.Draft {
+		state.logger.Info("Draft closed before it was published, voiding")
+		if _, e = state.voidBill(ctx, closeArgs.Actor, closeArgs.RequestId); e != nil {
+			return state.Clone(), e
+		}
+		voided = true
+	}
+	if voided {
+		state.awaitBillEvents(ctx)
+		return state.Clone(), nil
+	}
 
+	if e = state.setStatus(ctx, model.Closing); e != nil {
+		return state.Clone(), e
+	}
+	// The line items being saved are taxed too, those still being converted are refused once converted
+	if hasChange(ctx, awaitLineItemsChangeId) {
+		if e = workflow.Await(ctx, func() bool { return state.reservedLineItems == 0 }); e != nil {
+			return state.Clone(), e
+		}
+	}
+	state.BillInfo.ClosedAt = workflow.Now(ctx)
+	if e = state.addUsageLineItemsSyncActivity(ctx); e != nil {
+		state.BillInfo.ClosedAt = time.Time{}
+		return state.Clone(), e
+	}
+	if hasChange(ctx, awaitCouponsChangeId) {
+		if e = workflow.Await(ctx, func() bool { return state.redeemingCoupons == 0 }); e != nil {
+			state.BillInfo.ClosedAt = time.Time{}
+			return state.Clone(), e
+		}
+	}
+	if state.BillInfo.TaxJurisdiction != "" {
+		if state.Tax, e = state.computeBillTaxSyncActivity(ctx); e != nil {
+			state.BillInfo.ClosedAt = time.Time{}
+			return state.Clone(), e
+		}
+	}
+	if len(state.Coupons) != 0 {
+		if state.Discounts, e = model.ComputeDiscounts(state.amountDue(), state.Coupons); e != nil {
+			state.BillInfo.ClosedAt = time.Time{}
+			return state.Clone(), e
+		}
+		if _, e = state.applyBillDiscountsSyncActivity(ctx); e != nil {
+			state.BillInfo.ClosedAt = time.Time{}
+			return state.Clone(), e
+		}
+	}
+	if e = state.BillInfo.Status.CheckTransition(model.Closed); e != nil {
+		return state.Clone(), e
+	}
 	_, e = state.closeBillSyncActivity(ctx)
-	if e == nil {
-		state.BillInfo.Status = model.Closed
+	if e != nil {
+		state.BillInfo.ClosedAt = time.Time{}
+		return state.Clone(), e
+	}
+	state.BillInfo.Status = model.Closed
+	// With the usage added at close
+	state.upsertSearchAttributes(ctx)
+	state.publishBillEventAsyncActivity(ctx, model.BillClosed, "")
+	_, e = state.recordAuditEntrySyncActivity(ctx, model.AuditClose, closeArgs.Actor, closeArgs.RequestId, state.Total)
+	if e != nil {
+		return state.Clone(), e
 	}
+	e = state.collectPayment(ctx)
+	state.awaitBillEvents(ctx)
 	return state.Clone(), e
 }
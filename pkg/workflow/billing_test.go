@@ -4,6 +4,7 @@ import (
 	"coding-challenge/pkg/activity"
 	"coding-challenge/pkg/model"
 	"coding-challenge/pkg/workflow"
+	"context"
 	"errors"
 	"math"
 	"testing"
@@ -11,7 +12,9 @@ import (
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/testsuite"
+	sdkworkflow "go.temporal.io/sdk/workflow"
 )
 
 type BillingWorkflowUnitTestSuite struct {
@@ -25,8 +28,69 @@ func TestBillingWorkflowUnitTestSuite(t *testing.T) {
 	suite.Run(t, new(BillingWorkflowUnitTestSuite))
 }
 
+var testStartTime = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func scheduledBillInfo(billInfo model.BillInfo, duration time.Duration) model.BillInfo {
+	billInfo.CreatedAt = testStartTime
+	billInfo.CloseTime = testStartTime.Add(duration)
+	return billInfo
+}
+
 func (s *BillingWorkflowUnitTestSuite) SetupTest() {
 	s.env = s.NewTestWorkflowEnvironment()
+	s.env.SetStartTime(testStartTime)
+	s.env.OnActivity((&activity.DummyActivityHost{}).RecordAuditEntryActivity, mock.Anything, mock.AnythingOfType("AuditEntry")).Return(uint64(1), nil).Maybe()
+	s.setupSucceedingPayment()
+	s.setupNoUsage()
+	s.setupNoBillEventSubscribers()
+	s.setupNoBillEventSubscribers()
+	s.setupFxAvailable()
+}
+
+// Line items in another currency can be converted unless a test mocks it otherwise.
+func (s *BillingWorkflowUnitTestSuite) setupFxAvailable() {
+	s.env.OnActivity((&activity.DummyFxActivityHost{}).FxAvailableActivity, mock.Anything).Return(true, nil).Maybe()
+}
+
+// Bills have no usage unless a test mocks it otherwise.
+func (s *BillingWorkflowUnitTestSuite) setupNoUsage() {
+	s.env.OnActivity((&activity.DummyUsageActivityHost{}).AggregateUsageActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return([]model.BillLineItem{}, nil).Maybe()
+}
+
+// Bill events are dropped unless a test captures them.
+func (s *BillingWorkflowUnitTestSuite) setupNoBillEventSubscribers() {
+	s.env.OnActivity((&activity.DummyBillEventActivityHost{}).PublishBillEventActivity, mock.AnythingOfType("BillEvent")).Return(nil).Maybe()
+}
+
+// Payments succeed unless a test mocks them otherwise.
+func (s *BillingWorkflowUnitTestSuite) setupSucceedingPayment() {
+	s.env.OnActivity((&activity.DummyActivityHost{}).SetBillStatusActivity, mock.Anything, mock.AnythingOfType("BillId"), mock.Anything).Return(uint64(1), nil).Maybe()
+	s.env.RegisterWorkflow(workflow.PaymentWorkflow)
+	s.env.OnWorkflow(workflow.PaymentWorkflow, mock.Anything, mock.AnythingOfType("PaymentRequest")).Return(
+		func(ctx sdkworkflow.Context, request workflow.PaymentRequest) (model.PaymentAttempt, error) {
+			return succeededPayment(request, sdkworkflow.Now(ctx)), nil
+		}).Maybe()
+}
+
+// The payment collected when the bill closes.
+func paidOnClose(billInfo model.BillInfo, number int64) []model.PaymentAttempt {
+	request := workflow.PaymentRequest{
+		BillId: billInfo.Id,
+		Number: 1,
+		Amount: model.NewTotalAmountFromAmount(model.Amount{Number: number, CurrencyCode: billInfo.CurrencyCode}),
+	}
+	return []model.PaymentAttempt{succeededPayment(request, billInfo.ClosedAt)}
+}
+
+func succeededPayment(request workflow.PaymentRequest, at time.Time) model.PaymentAttempt {
+	return model.PaymentAttempt{
+		BillId:      request.BillId,
+		Number:      request.Number,
+		Amount:      request.Amount,
+		Status:      model.PaymentSucceeded,
+		Reference:   "fake-charge-1",
+		AttemptedAt: at,
+	}
 }
 
 func (s *BillingWorkflowUnitTestSuite) AfterTest(suiteName, testName string) {
@@ -56,20 +120,35 @@ func (s *BillingWorkflowUnitTestSuite) defaultBillAndItems() (billInfo model.Bil
 	return billInfo, lineItem1, lineItem2
 }
 
+func (s *BillingWorkflowUnitTestSuite) addLineItemArgs(lineItem model.BillLineItem, requestId string) workflow.AddBillLineItemArgs {
+	return workflow.AddBillLineItemArgs{
+		LineItem:  lineItem,
+		Actor:     model.NewCustomerActor(lineItem.Id.BillId.CustomerId),
+		RequestId: requestId,
+	}
+}
+
+func (s *BillingWorkflowUnitTestSuite) closeBillEarlyArgs() workflow.CloseBillEarlyArgs {
+	return workflow.CloseBillEarlyArgs{
+		Actor:     model.NewApiKeyActor("key-1"),
+		RequestId: "9c8f3a34-1f0b-4b9e-8d0c-2a3c1e5f7b90",
+	}
+}
+
 func (s *BillingWorkflowUnitTestSuite) Test_Workflow_Fails_NegativeDuration() {
 	// Arrange
 	billInfo, _, _ := s.defaultBillAndItems()
 	dummyActivityHost := activity.DummyActivityHost{}
-	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil).Never()
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil).Never()
 	s.env.OnActivity(
-		dummyActivityHost.AddBillLineItemIfNotExistActivity,
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
 		mock.AnythingOfType("BillLineItem"),
 		mock.AnythingOfType("TotalAmount"),
 	).Return(uint64(1), nil).Never()
-	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil).Never()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil).Never()
 
 	// Act
-	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour*-1)
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour*-1, model.NewCustomerActor(billInfo.Id.CustomerId))
 
 	// Assert
 	s.True(s.env.IsWorkflowCompleted())
@@ -80,77 +159,139 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_Fails_NegativeDuration() {
 	s.Equal(workflow.BillingState{}, result)
 }
 
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_Fails_NotOpen() {
+	// Arrange
+	billInfo, _, _ := s.defaultBillAndItems()
+	billInfo.Status = model.Closed
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil).Never()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.ErrorContains(s.env.GetWorkflowError(), `invalid bill status "closed"`)
+}
+
 func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_WithoutItems() {
 	// Arrange
 	billInfo, _, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Hour*24*30)
 	dummyActivityHost := activity.DummyActivityHost{}
-	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.OnActivity(
-		dummyActivityHost.AddBillLineItemIfNotExistActivity,
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
 		mock.AnythingOfType("BillLineItem"),
 		mock.AnythingOfType("TotalAmount"),
 	).Return(uint64(1), nil).Never()
-	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 
 	// Act
-	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour*24*30)
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour*24*30, model.NewCustomerActor(billInfo.Id.CustomerId))
 
 	// Assert
 	s.True(s.env.IsWorkflowCompleted())
 	s.NoError(s.env.GetWorkflowError())
 	var result workflow.BillingState
 	s.env.GetWorkflowResult(&result)
-	billInfo.Status = model.Closed
+	billInfo.Status = model.Paid
+	billInfo.ClosedAt = testStartTime.Add(time.Hour * 24 * 30)
 	s.Equal(workflow.BillingState{
 		BillInfo:          billInfo,
 		BillLineItemCount: 0,
-		Total:             model.TotalAmount{Total: model.Amount{Number: 0, CurrencyCode: "USD"}, Ok: true},
+		Total:             model.TotalAmount{Number: "0", CurrencyCode: "USD"},
 	}, result)
 }
 
 func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_WithoutItems() {
 	// Arrange
 	billInfo, _, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Hour*24*30)
 	dummyActivityHost := activity.DummyActivityHost{}
-	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.OnActivity(
-		dummyActivityHost.AddBillLineItemIfNotExistActivity,
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
 		mock.AnythingOfType("BillLineItem"),
 		mock.AnythingOfType("TotalAmount"),
 	).Return(uint64(1), nil).Never()
-	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.RegisterDelayedCallback(func() {
-		message := "Close bill"
-		s.env.SignalWorkflow(workflow.CloseBillEarlySignal, &message)
+		s.env.SignalWorkflow(workflow.CloseBillEarlySignal, s.closeBillEarlyArgs())
 	}, 2*time.Second)
 
 	// Act
-	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour*24*30)
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour*24*30, model.NewCustomerActor(billInfo.Id.CustomerId))
 
 	// Assert
 	s.True(s.env.IsWorkflowCompleted())
 	s.NoError(s.env.GetWorkflowError())
 	var result workflow.BillingState
 	s.env.GetWorkflowResult(&result)
-	billInfo.Status = model.Closed
+	billInfo.Status = model.Paid
+	billInfo.ClosedAt = testStartTime.Add(2 * time.Second)
 	s.Equal(workflow.BillingState{
 		BillInfo:          billInfo,
 		BillLineItemCount: 0,
-		Total:             model.TotalAmount{Total: model.Amount{Number: 0, CurrencyCode: "USD"}, Ok: true},
+		Total:             model.TotalAmount{Number: "0", CurrencyCode: "USD"},
 	}, result)
 }
 
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_ByUpdate_AuditsUpdateId() {
+	// Arrange
+	billInfo, _, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Hour*24*30)
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env = s.NewTestWorkflowEnvironment() // Without the default audit mock
+	s.env.SetStartTime(testStartTime)
+	s.setupFxAvailable()
+	s.setupSucceedingPayment()
+	s.setupNoUsage()
+	s.setupNoBillEventSubscribers()
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	var closeEntries []model.AuditEntry
+	s.env.OnActivity(dummyActivityHost.RecordAuditEntryActivity, mock.Anything, mock.AnythingOfType("AuditEntry")).
+		Return(func(_ context.Context, entry model.AuditEntry) (uint64, error) {
+			if entry.Action == model.AuditClose {
+				closeEntries = append(closeEntries, entry)
+			}
+			return uint64(1), nil
+		})
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.CloseBillUpdate, "9c8f3a34-1f0b-4b9e-8d0c-2a3c1e5f7b90", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+			OnReject:   func(err error) { s.FailNow("Should not reach here") },
+		}, s.closeBillEarlyArgs())
+	}, 2*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour*24*30, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(testStartTime.Add(2*time.Second), result.BillInfo.ClosedAt)
+	s.Len(closeEntries, 1)
+	s.Equal("9c8f3a34-1f0b-4b9e-8d0c-2a3c1e5f7b90", closeEntries[0].RequestId)
+	s.Equal(model.NewApiKeyActor("key-1"), closeEntries[0].Actor)
+}
+
 func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_WithFailedItem() {
 	// Arrange
 	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Hour)
 	dummyActivityHost := activity.DummyActivityHost{}
-	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.OnActivity(
-		dummyActivityHost.AddBillLineItemIfNotExistActivity,
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
 		mock.AnythingOfType("BillLineItem"),
 		mock.AnythingOfType("TotalAmount"),
 	).Return(uint64(0), errors.New("Fake error")).Times(10) // 10 attempts seem to be made by default
-	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.RegisterDelayedCallback(func() {
 		s.env.UpdateWorkflow(
 			workflow.AddBillLineItemUpdate,
@@ -160,15 +301,14 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_WithFailedItem()
 				OnComplete: func(result interface{}, err error) { s.Error(err) },
 				OnReject:   func(err error) { s.ErrorIs(err, errors.New("Fake error")) },
 			},
-			lineItem)
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
 	}, 1*time.Second)
 	s.env.RegisterDelayedCallback(func() {
-		message := "Close bill"
-		s.env.SignalWorkflow(workflow.CloseBillEarlySignal, &message)
+		s.env.SignalWorkflow(workflow.CloseBillEarlySignal, s.closeBillEarlyArgs())
 	}, time.Hour) // An hour to give time for the 10 attempts
 
 	// Act
-	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour) // An hour to give time for the 10 attempts
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour, model.NewCustomerActor(billInfo.Id.CustomerId)) // An hour to give time for the 10 attempts
 
 	// Assert
 	s.True(s.env.IsWorkflowCompleted())
@@ -181,25 +321,27 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_WithFailedItem()
 	s.Equal(uint64(0), receivedState.BillLineItemCount)
 	var result workflow.BillingState
 	s.env.GetWorkflowResult(&result)
-	billInfo.Status = model.Closed
+	billInfo.Status = model.Paid
+	billInfo.ClosedAt = testStartTime.Add(time.Hour)
 	s.Equal(workflow.BillingState{
 		BillInfo:          billInfo,
 		BillLineItemCount: 0,
-		Total:             model.TotalAmount{Total: model.Amount{Number: 0, CurrencyCode: "USD"}, Ok: true},
+		Total:             model.TotalAmount{Number: "0", CurrencyCode: "USD"},
 	}, result)
 }
 
 func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_With1Item() {
 	// Arrange
 	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
 	dummyActivityHost := activity.DummyActivityHost{}
-	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.OnActivity(
-		dummyActivityHost.AddBillLineItemIfNotExistActivity,
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
 		mock.AnythingOfType("BillLineItem"),
 		mock.AnythingOfType("TotalAmount"),
 	).Return(uint64(1), nil)
-	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.RegisterDelayedCallback(func() {
 		s.env.UpdateWorkflow(
 			workflow.AddBillLineItemUpdate,
@@ -212,20 +354,19 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_With1Item() {
 					s.Equal(workflow.BillingState{
 						BillInfo:          billInfo,
 						BillLineItemCount: 1,
-						Total:             model.TotalAmount{Total: model.Amount{Number: 100, CurrencyCode: "USD"}, Ok: true},
+						Total:             model.TotalAmount{Number: "100", CurrencyCode: "USD"},
 					}, intermediateState)
 				},
 				OnReject: func(err error) { s.FailNow("Should not reach here") },
 			},
-			lineItem)
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
 	}, 1*time.Second)
 	s.env.RegisterDelayedCallback(func() {
-		message := "Close bill"
-		s.env.SignalWorkflow(workflow.CloseBillEarlySignal, &message)
+		s.env.SignalWorkflow(workflow.CloseBillEarlySignal, s.closeBillEarlyArgs())
 	}, 2*time.Second)
 
 	// Act
-	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute)
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
 
 	// Assert
 	s.True(s.env.IsWorkflowCompleted())
@@ -238,25 +379,28 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_With1Item() {
 	s.Equal(uint64(1), receivedState.BillLineItemCount)
 	var result workflow.BillingState
 	s.env.GetWorkflowResult(&result)
-	billInfo.Status = model.Closed
+	billInfo.Status = model.Paid
+	billInfo.ClosedAt = testStartTime.Add(2 * time.Second)
 	s.Equal(workflow.BillingState{
 		BillInfo:          billInfo,
 		BillLineItemCount: 1,
-		Total:             model.TotalAmount{Total: model.Amount{Number: 100, CurrencyCode: "USD"}, Ok: true},
+		Total:             model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Payments:          paidOnClose(billInfo, 100),
 	}, result)
 }
 
 func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2ItemsTogether() {
 	// Arrange
 	billInfo, lineItem1, lineItem2 := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
 	dummyActivityHost := activity.DummyActivityHost{}
-	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.OnActivity(
-		dummyActivityHost.AddBillLineItemIfNotExistActivity,
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
 		mock.AnythingOfType("BillLineItem"),
 		mock.AnythingOfType("TotalAmount"),
 	).Return(uint64(1), nil).Twice()
-	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.RegisterDelayedCallback(func() {
 		nextCount := 1
 		updateCallback := testsuite.TestUpdateCallback{
@@ -269,12 +413,12 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2ItemsT
 			},
 			OnReject: func(err error) { s.FailNow("Should not reach here") },
 		}
-		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", &updateCallback, lineItem1)
-		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "ed20aa79-5ddc-4510-a5a3-cda08372e273", &updateCallback, lineItem2)
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", &updateCallback, s.addLineItemArgs(lineItem1, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "ed20aa79-5ddc-4510-a5a3-cda08372e273", &updateCallback, s.addLineItemArgs(lineItem2, "ed20aa79-5ddc-4510-a5a3-cda08372e273"))
 	}, 1*time.Second)
 
 	// Act
-	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute)
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
 
 	// Assert
 	s.True(s.env.IsWorkflowCompleted())
@@ -287,25 +431,28 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2ItemsT
 	s.Equal(uint64(2), receivedState.BillLineItemCount)
 	var result workflow.BillingState
 	s.env.GetWorkflowResult(&result)
-	billInfo.Status = model.Closed
+	billInfo.Status = model.Paid
+	billInfo.ClosedAt = testStartTime.Add(time.Minute)
 	s.Equal(workflow.BillingState{
 		BillInfo:          billInfo,
 		BillLineItemCount: 2,
-		Total:             model.TotalAmount{Total: model.Amount{Number: 300, CurrencyCode: "USD"}, Ok: true},
+		Total:             model.TotalAmount{Number: "300", CurrencyCode: "USD"},
+		Payments:          paidOnClose(billInfo, 300),
 	}, result)
 }
 
 func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2ItemsSpaced() {
 	// Arrange
 	billInfo, lineItem1, lineItem2 := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
 	dummyActivityHost := activity.DummyActivityHost{}
-	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.OnActivity(
-		dummyActivityHost.AddBillLineItemIfNotExistActivity,
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
 		mock.AnythingOfType("BillLineItem"),
 		mock.AnythingOfType("TotalAmount"),
 	).Return(uint64(1), nil).Twice()
-	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.RegisterDelayedCallback(func() {
 		s.env.UpdateWorkflow(
 			workflow.AddBillLineItemUpdate,
@@ -315,7 +462,7 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2ItemsS
 				OnComplete: func(result interface{}, err error) { s.NoError(err) },
 				OnReject:   func(err error) { s.FailNow("Should not reach here") },
 			},
-			lineItem1)
+			s.addLineItemArgs(lineItem1, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
 	}, 1*time.Second)
 	var intermediateState workflow.BillingState
 	s.env.RegisterDelayedCallback(func() {
@@ -325,7 +472,7 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2ItemsS
 		s.Equal(workflow.BillingState{
 			BillInfo:          billInfo,
 			BillLineItemCount: 1,
-			Total:             model.TotalAmount{Total: model.Amount{Number: 100, CurrencyCode: "USD"}, Ok: true},
+			Total:             model.TotalAmount{Number: "100", CurrencyCode: "USD"},
 		}, intermediateState)
 	}, 3*time.Second)
 	s.env.RegisterDelayedCallback(func() {
@@ -340,16 +487,16 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2ItemsS
 					s.Equal(workflow.BillingState{
 						BillInfo:          billInfo,
 						BillLineItemCount: 2,
-						Total:             model.TotalAmount{Total: model.Amount{Number: 300, CurrencyCode: "USD"}, Ok: true},
+						Total:             model.TotalAmount{Number: "300", CurrencyCode: "USD"},
 					}, intermediateState)
 				},
 				OnReject: func(err error) { s.FailNow("Should not reach here") },
 			},
-			lineItem2)
+			s.addLineItemArgs(lineItem2, "ed20aa79-5ddc-4510-a5a3-cda08372e273"))
 	}, 5*time.Second)
 
 	// Act
-	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute)
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
 
 	// Assert
 	s.True(s.env.IsWorkflowCompleted())
@@ -362,11 +509,13 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2ItemsS
 	s.Equal(uint64(2), receivedState.BillLineItemCount)
 	var result workflow.BillingState
 	s.env.GetWorkflowResult(&result)
-	billInfo.Status = model.Closed
+	billInfo.Status = model.Paid
+	billInfo.ClosedAt = testStartTime.Add(time.Minute)
 	s.Equal(workflow.BillingState{
 		BillInfo:          billInfo,
 		BillLineItemCount: 2,
-		Total:             model.TotalAmount{Total: model.Amount{Number: 300, CurrencyCode: "USD"}, Ok: true},
+		Total:             model.TotalAmount{Number: "300", CurrencyCode: "USD"},
+		Payments:          paidOnClose(billInfo, 300),
 	}, result)
 }
 
@@ -374,18 +523,19 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2ItemsS
 func (s *BillingWorkflowUnitTestSuite) Test_Workflow_AddSameUpdateId_OnlyFirstRecorded() {
 	// Arrange
 	billInfo, lineItem1, lineItem2 := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
 	dummyActivityHost := activity.DummyActivityHost{}
-	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.OnActivity(
-		dummyActivityHost.AddBillLineItemIfNotExistActivity,
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
 		mock.AnythingOfType("BillLineItem"),
 		mock.AnythingOfType("TotalAmount"),
-	).Return(func(lineItem model.BillLineItem, _ model.TotalAmount) (uint64, error) {
+	).Return(func(_ context.Context, lineItem model.BillLineItem, _ model.TotalAmount) (uint64, error) {
 		// Only the first will be called
 		s.Equal(lineItem1.Id.Id, lineItem.Id.Id)
 		return uint64(1), nil
 	})
-	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.RegisterDelayedCallback(func() {
 		s.env.UpdateWorkflow(
 			workflow.AddBillLineItemUpdate,
@@ -395,7 +545,7 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_AddSameUpdateId_OnlyFirstRe
 				OnComplete: func(result interface{}, err error) { s.NoError(err) },
 				OnReject:   func(err error) { s.FailNow("Should not reach here") },
 			},
-			lineItem1)
+			s.addLineItemArgs(lineItem1, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
 	}, 1*time.Second)
 	s.env.RegisterDelayedCallback(func() {
 		s.env.UpdateWorkflow(
@@ -411,16 +561,16 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_AddSameUpdateId_OnlyFirstRe
 					s.Equal(workflow.BillingState{
 						BillInfo:          billInfo,
 						BillLineItemCount: 1,
-						Total:             model.TotalAmount{Total: model.Amount{Number: 100, CurrencyCode: "USD"}, Ok: true},
+						Total:             model.TotalAmount{Number: "100", CurrencyCode: "USD"},
 					}, intermediateState)
 				},
 				OnReject: func(err error) { s.FailNow("Should not reach here") },
 			},
-			lineItem2)
+			s.addLineItemArgs(lineItem2, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
 	}, 2*time.Second)
 
 	// Act
-	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute)
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
 
 	// Assert
 	s.True(s.env.IsWorkflowCompleted())
@@ -433,31 +583,34 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_AddSameUpdateId_OnlyFirstRe
 	s.Equal(uint64(1), receivedState.BillLineItemCount)
 	var result workflow.BillingState
 	s.env.GetWorkflowResult(&result)
-	billInfo.Status = model.Closed
+	billInfo.Status = model.Paid
+	billInfo.ClosedAt = testStartTime.Add(time.Minute)
 	s.Equal(workflow.BillingState{
 		BillInfo:          billInfo,
 		BillLineItemCount: 1,
-		Total:             model.TotalAmount{Total: model.Amount{Number: 100, CurrencyCode: "USD"}, Ok: true},
+		Total:             model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Payments:          paidOnClose(billInfo, 100),
 	}, result)
 }
 
 func (s *BillingWorkflowUnitTestSuite) Test_Workflow_AddSameItemId_OnlyFirstRecorded() {
 	// Arrange
 	billInfo, lineItem1, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
 	dummyActivityHost := activity.DummyActivityHost{}
 	calledTimes := uint64(0)
-	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.OnActivity(
-		dummyActivityHost.AddBillLineItemIfNotExistActivity,
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
 		mock.AnythingOfType("BillLineItem"),
 		mock.AnythingOfType("TotalAmount"),
-	).Return(func(lineItem model.BillLineItem, _ model.TotalAmount) (uint64, error) {
+	).Return(func(_ context.Context, lineItem model.BillLineItem, _ model.TotalAmount) (uint64, error) {
 		// Mimic idempotence
 		updateCount := 1 - calledTimes
 		calledTimes = 1
 		return updateCount, nil
 	}).Twice()
-	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.RegisterDelayedCallback(func() {
 		s.env.UpdateWorkflow(
 			workflow.AddBillLineItemUpdate,
@@ -467,7 +620,7 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_AddSameItemId_OnlyFirstReco
 				OnComplete: func(result interface{}, err error) { s.NoError(err) },
 				OnReject:   func(err error) { s.FailNow("Should not reach here") },
 			},
-			lineItem1)
+			s.addLineItemArgs(lineItem1, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
 	}, 1*time.Second)
 	s.env.RegisterDelayedCallback(func() {
 		s.env.UpdateWorkflow(
@@ -479,11 +632,11 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_AddSameItemId_OnlyFirstReco
 				OnReject:   func(err error) { s.FailNow("Should not reach here") },
 			},
 			// Same line item
-			lineItem1)
+			s.addLineItemArgs(lineItem1, "ed20aa79-5ddc-4510-a5a3-cda08372e273"))
 	}, 2*time.Second)
 
 	// Act
-	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute)
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
 
 	// Assert
 	s.True(s.env.IsWorkflowCompleted())
@@ -496,27 +649,30 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_AddSameItemId_OnlyFirstReco
 	s.Equal(uint64(1), receivedState.BillLineItemCount)
 	var result workflow.BillingState
 	s.env.GetWorkflowResult(&result)
-	billInfo.Status = model.Closed
+	billInfo.Status = model.Paid
+	billInfo.ClosedAt = testStartTime.Add(time.Minute)
 	s.Equal(workflow.BillingState{
 		BillInfo:          billInfo,
 		BillLineItemCount: 1,
-		Total:             model.TotalAmount{Total: model.Amount{Number: 100, CurrencyCode: "USD"}, Ok: true},
+		Total:             model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Payments:          paidOnClose(billInfo, 100),
 	}, result)
 }
 
-func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2Items_TotalOverflow() {
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2Items_TotalBeyondInt64() {
 	// Arrange
 	billInfo, lineItem1, lineItem2 := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
 	dummyActivityHost := activity.DummyActivityHost{}
-	// Adding to it can only overflow
+	// Adding to it goes beyond an int64
 	lineItem1.Amount.Number = math.MaxInt64
-	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.OnActivity(
-		dummyActivityHost.AddBillLineItemIfNotExistActivity,
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
 		mock.AnythingOfType("BillLineItem"),
 		mock.AnythingOfType("TotalAmount"),
 	).Return(uint64(1), nil).Twice()
-	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.RegisterDelayedCallback(func() {
 		s.env.UpdateWorkflow(
 			workflow.AddBillLineItemUpdate,
@@ -529,12 +685,12 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2Items_
 					s.Equal(workflow.BillingState{
 						BillInfo:          billInfo,
 						BillLineItemCount: 1,
-						Total:             model.TotalAmount{Total: model.Amount{Number: math.MaxInt64, CurrencyCode: "USD"}, Ok: true},
+						Total:             model.TotalAmount{Number: "9223372036854775807", CurrencyCode: "USD"},
 					}, intermediateState)
 				},
 				OnReject: func(err error) { s.FailNow("Should not reach here") },
 			},
-			lineItem1)
+			s.addLineItemArgs(lineItem1, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
 	}, 1*time.Second)
 	var intermediateState workflow.BillingState
 	s.env.RegisterDelayedCallback(func() {
@@ -542,8 +698,7 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2Items_
 		s.NoError(err)
 		encodedState.Get(&intermediateState)
 		s.Equal(uint64(1), intermediateState.BillLineItemCount)
-		s.True(intermediateState.Total.Ok)
-		s.Equal(model.Amount{Number: math.MaxInt64, CurrencyCode: "USD"}, intermediateState.Total.Total)
+		s.Equal(model.TotalAmount{Number: "9223372036854775807", CurrencyCode: "USD"}, intermediateState.Total)
 	}, 3*time.Second)
 	s.env.RegisterDelayedCallback(func() {
 		s.env.UpdateWorkflow(
@@ -557,16 +712,16 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2Items_
 					s.Equal(workflow.BillingState{
 						BillInfo:          billInfo,
 						BillLineItemCount: 2,
-						Total:             model.TotalAmount{Total: model.Amount{}, Ok: false},
+						Total:             model.TotalAmount{Number: "9223372036854776007", CurrencyCode: "USD"},
 					}, intermediateState)
 				},
 				OnReject: func(err error) { s.FailNow("Should not reach here") },
 			},
-			lineItem2)
+			s.addLineItemArgs(lineItem2, "ed20aa79-5ddc-4510-a5a3-cda08372e273"))
 	}, 5*time.Second)
 
 	// Act
-	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute)
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
 
 	// Assert
 	s.True(s.env.IsWorkflowCompleted())
@@ -579,25 +734,31 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_With2Items_
 	s.Equal(uint64(2), receivedState.BillLineItemCount)
 	var result workflow.BillingState
 	s.env.GetWorkflowResult(&result)
-	billInfo.Status = model.Closed
+	billInfo.Status = model.Paid
+	billInfo.ClosedAt = testStartTime.Add(time.Minute)
+	total := model.TotalAmount{Number: "9223372036854776007", CurrencyCode: "USD"}
+	// Still charged at once
+	request := workflow.PaymentRequest{BillId: billInfo.Id, Number: 1, Amount: total}
 	s.Equal(workflow.BillingState{
 		BillInfo:          billInfo,
 		BillLineItemCount: 2,
-		Total:             model.TotalAmount{Total: model.Amount{}, Ok: false},
+		Total:             total,
+		Payments:          []model.PaymentAttempt{succeededPayment(request, billInfo.ClosedAt)},
 	}, result)
 }
 
 func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CannotAddItemAfterClose() {
 	// Arrange
 	billInfo, lineItem1, lineItem2 := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, 2*time.Second)
 	dummyActivityHost := activity.DummyActivityHost{}
-	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.OnActivity(
-		dummyActivityHost.AddBillLineItemIfNotExistActivity,
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
 		mock.AnythingOfType("BillLineItem"),
 		mock.AnythingOfType("TotalAmount"),
 	).Return(uint64(1), nil)
-	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
 	s.env.RegisterDelayedCallback(func() {
 		s.env.UpdateWorkflow(
 			workflow.AddBillLineItemUpdate,
@@ -607,7 +768,7 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CannotAddItemAfterClose() {
 				OnComplete: func(result interface{}, err error) { s.NoError(err) },
 				OnReject:   func(err error) { s.FailNow("Should not reach here") },
 			},
-			lineItem1)
+			s.addLineItemArgs(lineItem1, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
 	}, 1*time.Second)
 	s.env.RegisterDelayedCallback(func() {
 		s.True(s.env.IsWorkflowCompleted())
@@ -619,11 +780,11 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CannotAddItemAfterClose() {
 				OnComplete: func(result interface{}, err error) { s.FailNow("Should not reach here") },
 				OnReject:   func(err error) { s.FailNow("Should not reach here") },
 			},
-			lineItem2)
+			s.addLineItemArgs(lineItem2, "ed20aa79-5ddc-4510-a5a3-cda08372e273"))
 	}, 5*time.Second) // After maturity
 
 	// Act
-	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, 2*time.Second)
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, 2*time.Second, model.NewCustomerActor(billInfo.Id.CustomerId))
 
 	// Assert
 	s.True(s.env.IsWorkflowCompleted())
@@ -636,10 +797,1381 @@ func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CannotAddItemAfterClose() {
 	s.Equal(uint64(1), receivedState.BillLineItemCount)
 	var result workflow.BillingState
 	s.env.GetWorkflowResult(&result)
-	billInfo.Status = model.Closed
+	billInfo.Status = model.Paid
+	billInfo.ClosedAt = testStartTime.Add(2 * time.Second)
 	s.Equal(workflow.BillingState{
 		BillInfo:          billInfo,
 		BillLineItemCount: 1,
-		Total:             model.TotalAmount{Total: model.Amount{Number: 100, CurrencyCode: "USD"}, Ok: true},
+		Total:             model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Payments:          paidOnClose(billInfo, 100),
 	}, result)
 }
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_RecordsAuditTrail() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env = s.NewTestWorkflowEnvironment() // Without the default audit mock
+	s.env.SetStartTime(testStartTime)
+	s.setupFxAvailable()
+	s.setupSucceedingPayment()
+	s.setupNoUsage()
+	s.setupNoBillEventSubscribers()
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	var addedLineItem model.BillLineItem
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(func(_ context.Context, lineItem model.BillLineItem, _ model.TotalAmount) (uint64, error) {
+		addedLineItem = lineItem
+		return uint64(1), nil
+	})
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	var entries []model.AuditEntry
+	s.env.OnActivity(dummyActivityHost.RecordAuditEntryActivity, mock.Anything, mock.AnythingOfType("AuditEntry")).
+		Return(func(_ context.Context, entry model.AuditEntry) (uint64, error) {
+			entries = append(entries, entry)
+			return uint64(1), nil
+		}).
+		Times(3)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.NoError(err) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewApiKeyActor("key-1"))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	zero := model.TotalAmount{Number: "0", CurrencyCode: "USD"}
+	hundred := model.TotalAmount{Number: "100", CurrencyCode: "USD"}
+	s.Len(entries, 3)
+	s.Equal(model.AuditCreate, entries[0].Action)
+	s.Equal(model.NewApiKeyActor("key-1"), entries[0].Actor)
+	s.Equal(zero, entries[0].TotalAfter)
+	s.Equal(model.AuditAddLineItem, entries[1].Action)
+	s.Equal(model.NewCustomerActor("alice"), entries[1].Actor)
+	s.Equal("1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", entries[1].RequestId)
+	s.Equal(zero, entries[1].TotalBefore)
+	s.Equal(hundred, entries[1].TotalAfter)
+	s.Equal(model.AuditClose, entries[2].Action)
+	s.Equal(model.NewSystemTimerActor(), entries[2].Actor)
+	s.Equal(workflow.CloseAtMaturityRequestId, entries[2].RequestId)
+	s.Equal(hundred, entries[2].TotalAfter)
+	s.Equal(testStartTime, entries[0].WorkflowTime)
+	s.Equal(testStartTime.Add(time.Second), addedLineItem.CreatedAt)
+	s.Equal(testStartTime.Add(time.Minute), entries[2].WorkflowTime)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_PaymentDeclined() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	dummyActivityHost := activity.DummyActivityHost{}
+	dummyPaymentActivityHost := activity.DummyPaymentActivityHost{}
+	s.env = s.NewTestWorkflowEnvironment() // With the payment workflow itself
+	s.env.SetStartTime(testStartTime)
+	s.setupFxAvailable()
+	s.setupNoUsage()
+	s.env.RegisterWorkflow(workflow.PaymentWorkflow)
+	s.env.OnActivity(dummyActivityHost.RecordAuditEntryActivity, mock.Anything, mock.AnythingOfType("AuditEntry")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	declined := model.PaymentAttempt{
+		BillId:        billInfo.Id,
+		Number:        1,
+		Amount:        model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Status:        model.PaymentDeclined,
+		FailureReason: "insufficient funds",
+		AttemptedAt:   testStartTime.Add(time.Minute),
+	}
+	s.env.OnActivity(dummyPaymentActivityHost.ChargeBillActivity, mock.AnythingOfType("PaymentAttempt")).
+		Return(func(attempt model.PaymentAttempt) (model.PaymentAttempt, error) {
+			attempt.Status = model.PaymentDeclined
+			attempt.FailureReason = "insufficient funds"
+			return attempt, nil
+		}).
+		Once()
+	s.env.OnActivity(dummyPaymentActivityHost.RecordPaymentAttemptActivity, mock.Anything, declined).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.SetBillStatusActivity, mock.Anything, billInfo.Id, model.Closing).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.SetBillStatusActivity, mock.Anything, billInfo.Id, model.PaymentFailed).Return(uint64(1), nil).Once()
+	s.env.RegisterWorkflow(workflow.DunningWorkflow)
+	s.env.OnWorkflow(workflow.DunningWorkflow, mock.Anything, workflow.DunningRequest{
+		BillId:     billInfo.Id,
+		Amount:     declined.Amount,
+		FailedAt:   declined.AttemptedAt,
+		NextNumber: 2,
+	}).Return(workflow.DunningState{}, nil).Once()
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.NoError(err) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(model.PaymentFailed, result.BillInfo.Status)
+	s.Equal([]model.PaymentAttempt{declined}, result.Payments)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_PaymentErrored_DunningRetriesSameNumber() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	dummyActivityHost := activity.DummyActivityHost{}
+	dummyPaymentActivityHost := activity.DummyPaymentActivityHost{}
+	s.env = s.NewTestWorkflowEnvironment() // With the payment workflow itself
+	s.env.SetStartTime(testStartTime)
+	s.setupFxAvailable()
+	s.setupNoUsage()
+	s.env.RegisterWorkflow(workflow.PaymentWorkflow)
+	s.env.OnActivity(dummyActivityHost.RecordAuditEntryActivity, mock.Anything, mock.AnythingOfType("AuditEntry")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	errored := model.PaymentAttempt{
+		BillId:        billInfo.Id,
+		Number:        1,
+		Amount:        model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Status:        model.PaymentErrored,
+		FailureReason: "gateway unavailable",
+		AttemptedAt:   testStartTime.Add(time.Minute),
+	}
+	s.env.OnActivity(dummyPaymentActivityHost.ChargeBillActivity, mock.AnythingOfType("PaymentAttempt")).
+		Return(model.PaymentAttempt{}, errors.New("gateway unavailable"))
+	s.env.OnActivity(dummyPaymentActivityHost.RecordPaymentAttemptActivity, mock.Anything, mock.AnythingOfType("PaymentAttempt")).
+		Return(func(_ context.Context, attempt model.PaymentAttempt) (uint64, error) {
+			errored.FailureReason = attempt.FailureReason // The error of the last activity attempt
+			return uint64(1), nil
+		}).
+		Once()
+	s.env.OnActivity(dummyActivityHost.SetBillStatusActivity, mock.Anything, billInfo.Id, model.Closing).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.SetBillStatusActivity, mock.Anything, billInfo.Id, model.PaymentFailed).Return(uint64(1), nil).Once()
+	s.env.RegisterWorkflow(workflow.DunningWorkflow)
+	s.env.OnWorkflow(workflow.DunningWorkflow, mock.Anything, workflow.DunningRequest{
+		BillId:     billInfo.Id,
+		Amount:     errored.Amount,
+		FailedAt:   errored.AttemptedAt,
+		NextNumber: 1,
+	}).Return(workflow.DunningState{}, nil).Once()
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.NoError(err) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(model.PaymentFailed, result.BillInfo.Status)
+	s.Equal([]model.PaymentAttempt{errored}, result.Payments)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_With1ForeignItem() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	lineItem.Amount = model.Amount{Number: 270, CurrencyCode: "GEL"}
+	converted := lineItem
+	converted.CreatedAt = testStartTime.Add(time.Second)
+	converted.Amount = model.Amount{Number: 100, CurrencyCode: "USD"}
+	converted.Conversion = &model.FxConversion{OriginalAmount: lineItem.Amount, Rate: "0.37", AsOf: testStartTime}
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		(&activity.DummyFxActivityHost{}).ConvertLineItemActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		model.CurrencyCode("USD"),
+	).Return(converted, nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		converted,
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.NoError(err) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(workflow.CloseBillEarlySignal, s.closeBillEarlyArgs())
+	}, 2*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(uint64(1), result.BillLineItemCount)
+	s.Equal(model.TotalAmount{Number: "100", CurrencyCode: "USD"}, result.Total)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_WhileForeignItemConverted() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	lineItem.Amount = model.Amount{Number: 270, CurrencyCode: "GEL"}
+	converted := lineItem
+	converted.CreatedAt = testStartTime.Add(time.Second)
+	converted.Amount = model.Amount{Number: 100, CurrencyCode: "USD"}
+	converted.Conversion = &model.FxConversion{OriginalAmount: lineItem.Amount, Rate: "0.37", AsOf: testStartTime}
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		(&activity.DummyFxActivityHost{}).ConvertLineItemActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		model.CurrencyCode("USD"),
+	).After(10*time.Second).Return(converted, nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.ErrorAs(err, &workflow.BillClosingError{}) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(workflow.CloseBillEarlySignal, s.closeBillEarlyArgs())
+	}, 2*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(model.Paid, result.BillInfo.Status)
+	s.Equal(uint64(0), result.BillLineItemCount)
+	s.Equal(model.TotalAmount{Number: "0", CurrencyCode: "USD"}, result.Total)
+	s.env.AssertNotCalled(s.T(), "AddBillLineItemIfNotExistActivity", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_RejectsItemInUnknownCurrency() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	lineItem.Amount = model.Amount{Number: 100, CurrencyCode: "XXX"}
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() { s.FailNow("Should not reach here") },
+				OnComplete: func(result interface{}, err error) {},
+				OnReject:   func(err error) { s.Error(err) },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(uint64(0), result.BillLineItemCount)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_RejectsForeignItemWithoutFx() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	lineItem.Amount = model.Amount{Number: 270, CurrencyCode: "GEL"}
+	s.env = s.NewTestWorkflowEnvironment() // Without the default fx mock
+	s.env.SetStartTime(testStartTime)
+	s.env.OnActivity((&activity.DummyActivityHost{}).RecordAuditEntryActivity, mock.Anything, mock.AnythingOfType("AuditEntry")).Return(uint64(1), nil).Maybe()
+	s.setupSucceedingPayment()
+	s.setupNoUsage()
+	s.setupNoBillEventSubscribers()
+	s.env.OnActivity((&activity.DummyFxActivityHost{}).FxAvailableActivity, mock.Anything).Return(false, nil)
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	var rejectErr error
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() { s.FailNow("Should not reach here") },
+				OnComplete: func(result interface{}, err error) {},
+				OnReject:   func(err error) { rejectErr = err },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	s.ErrorAs(rejectErr, &workflow.FxNotAvailableError{})
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(uint64(0), result.BillLineItemCount)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_WithPricedItem() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	lineItem.Amount = model.Amount{CurrencyCode: "USD"}
+	lineItem.Quantity = "2.5"
+	lineItem.UnitPrice = "0.01"
+	lineItem.Rounding = model.RoundHalfUp
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.MatchedBy(func(lineItem model.BillLineItem) bool { return lineItem.Amount.Number == 3 }),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.NoError(err) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		lineItem.Id.Id = "f6c7a4b0-3d5e-4f1a-9b2c-8e7d6a5b4c3d"
+		lineItem.Quantity = "0.0000000001"
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"ed20aa79-5ddc-4510-a5a3-cda08372e273",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() { s.FailNow("Should not reach here") },
+				OnComplete: func(result interface{}, err error) { s.FailNow("Should not reach here") },
+				OnReject:   func(err error) { s.ErrorAs(err, &model.PrecisionError{}) },
+			},
+			s.addLineItemArgs(lineItem, "ed20aa79-5ddc-4510-a5a3-cda08372e273"))
+	}, 2*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(uint64(1), result.BillLineItemCount)
+	// 2.5 * 0.01 = 0.025 USD
+	s.Equal(model.TotalAmount{Number: "3", CurrencyCode: "USD"}, result.Total)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_ComputesTax() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo.TaxJurisdiction = "GE"
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	tax := model.BillTax{
+		Lines: []model.TaxLine{{
+			Category: model.TaxCategoryStandard,
+			Rate:     "0.18",
+			Taxable:  model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+			Tax:      model.TotalAmount{Number: "18", CurrencyCode: "USD"},
+		}},
+		Subtotal:   model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		TaxTotal:   model.TotalAmount{Number: "18", CurrencyCode: "USD"},
+		GrandTotal: model.TotalAmount{Number: "118", CurrencyCode: "USD"},
+	}
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil)
+	s.env.OnActivity((&activity.DummyTaxActivityHost{}).ComputeBillTaxActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(tax, nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.NoError(err) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	billInfo.Status = model.Paid
+	billInfo.ClosedAt = testStartTime.Add(time.Minute)
+	s.Equal(workflow.BillingState{
+		BillInfo:          billInfo,
+		BillLineItemCount: 1,
+		Total:             model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		Tax:               tax,
+		Payments:          paidOnClose(billInfo, 118),
+	}, result)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_AppliesCoupons() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	tenPercent := model.Coupon{Code: "TEN", Type: model.PercentageCoupon, Value: "10"}
+	fiveDollars := model.Coupon{Code: "FIVE", Type: model.FixedAmountCoupon, Value: "5.00", CurrencyCode: "USD"}
+	dummyActivityHost := activity.DummyActivityHost{}
+	dummyCouponActivityHost := activity.DummyCouponActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil)
+	s.env.OnActivity(dummyCouponActivityHost.RedeemCouponActivity, mock.Anything, mock.AnythingOfType("BillInfo"), "TEN", testStartTime.Add(2*time.Second)).Return(tenPercent, nil).Once()
+	s.env.OnActivity(dummyCouponActivityHost.RedeemCouponActivity, mock.Anything, mock.AnythingOfType("BillInfo"), "FIVE", testStartTime.Add(3*time.Second)).Return(fiveDollars, nil).Once()
+	expectedDiscounts := []model.DiscountLine{
+		{CouponCode: "TEN", Amount: model.TotalAmount{Number: "10", CurrencyCode: "USD"}},
+		{CouponCode: "FIVE", Amount: model.TotalAmount{Number: "90", CurrencyCode: "USD"}}, // Capped at what is left
+	}
+	s.env.OnActivity(dummyCouponActivityHost.ApplyBillDiscountsActivity, mock.Anything, mock.AnythingOfType("BillInfo"), expectedDiscounts).Return(uint64(2), nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.NoError(err) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+	for i, code := range []string{"TEN", "FIVE"} {
+		s.env.RegisterDelayedCallback(func() {
+			s.env.UpdateWorkflow(
+				workflow.AttachCouponUpdate,
+				"attach-"+code,
+				&testsuite.TestUpdateCallback{
+					OnAccept:   func() {},
+					OnComplete: func(result interface{}, err error) { s.NoError(err) },
+					OnReject:   func(err error) { s.FailNow("Should not reach here") },
+				},
+				workflow.AttachCouponArgs{Code: code, Actor: model.NewCustomerActor(billInfo.Id.CustomerId)})
+		}, time.Duration(i+2)*time.Second)
+	}
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AttachCouponUpdate,
+			"attach-TEN-again",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() { s.FailNow("Should not reach here") },
+				OnComplete: func(result interface{}, err error) {},
+				OnReject:   func(err error) { s.ErrorContains(err, "already attached") },
+			},
+			workflow.AttachCouponArgs{Code: "TEN", Actor: model.NewCustomerActor(billInfo.Id.CustomerId)})
+	}, 4*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal([]model.Coupon{tenPercent, fiveDollars}, result.Coupons)
+	s.Equal(expectedDiscounts, result.Discounts)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_WhileCouponRedeemed() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	tenPercent := model.Coupon{Code: "TEN", Type: model.PercentageCoupon, Value: "10"}
+	dummyActivityHost := activity.DummyActivityHost{}
+	dummyCouponActivityHost := activity.DummyCouponActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil)
+	s.env.OnActivity(dummyCouponActivityHost.RedeemCouponActivity, mock.Anything, mock.AnythingOfType("BillInfo"), "TEN", mock.Anything).After(10*time.Second).Return(tenPercent, nil).Once()
+	expectedDiscounts := []model.DiscountLine{
+		{CouponCode: "TEN", Amount: model.TotalAmount{Number: "10", CurrencyCode: "USD"}},
+	}
+	s.env.OnActivity(dummyCouponActivityHost.ApplyBillDiscountsActivity, mock.Anything, mock.AnythingOfType("BillInfo"), expectedDiscounts).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.NoError(err) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AttachCouponUpdate,
+			"attach-TEN",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.NoError(err) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			workflow.AttachCouponArgs{Code: "TEN", Actor: model.NewCustomerActor(billInfo.Id.CustomerId)})
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(workflow.CloseBillEarlySignal, s.closeBillEarlyArgs())
+	}, 3*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal([]model.Coupon{tenPercent}, result.Coupons)
+	s.Equal(expectedDiscounts, result.Discounts)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_SpendingCap_AlertsAndRejectsItemOverCap() {
+	// Arrange
+	billInfo, lineItem1, lineItem2 := s.defaultBillAndItems()
+	billInfo.SpendingCap = model.NewSpendingCap(250, "USD", []uint32{40, 100})
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	var alerts []model.SpendingAlert
+	s.env.OnActivity((&activity.DummySpendingActivityHost{}).NotifySpendingAlertActivity, mock.AnythingOfType("SpendingAlert")).
+		Return(func(alert model.SpendingAlert) error {
+			alerts = append(alerts, alert)
+			return nil
+		}).
+		Once()
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+			OnReject:   func(err error) { s.FailNow("Should not reach here") },
+		}, s.addLineItemArgs(lineItem1, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "ed20aa79-5ddc-4510-a5a3-cda08372e273", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.FailNow("Should not reach here") },
+			OnComplete: func(result interface{}, err error) {},
+			OnReject:   func(err error) { s.ErrorContains(err, "would exceed the spending cap of 250") },
+		}, s.addLineItemArgs(lineItem2, "ed20aa79-5ddc-4510-a5a3-cda08372e273"))
+	}, 2*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	expectedAlert := model.SpendingAlert{
+		BillId:    billInfo.Id,
+		Threshold: 40,
+		Cap:       billInfo.SpendingCap.Max,
+		Total:     model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		SentAt:    testStartTime.Add(time.Second),
+	}
+	s.Equal([]model.SpendingAlert{expectedAlert}, alerts)
+	s.Equal([]model.SpendingAlert{expectedAlert}, result.SpendingAlerts)
+	s.Equal(model.TotalAmount{Number: "100", CurrencyCode: "USD"}, result.Total)
+	s.Equal(uint64(1), result.BillLineItemCount)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_UpsertsSearchAttributes() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	var statuses []string
+	var totals []int64
+	s.env.OnUpsertTypedSearchAttributes(mock.Anything).Run(func(args mock.Arguments) {
+		attributes := args.Get(0).(temporal.SearchAttributes)
+		customerId, _ := attributes.GetKeyword(workflow.CustomerIdSearchAttribute)
+		s.Equal("alice", customerId)
+		closeTime, _ := attributes.GetTime(workflow.CloseTimeSearchAttribute)
+		s.Equal(billInfo.CloseTime, closeTime)
+		status, _ := attributes.GetKeyword(workflow.StatusSearchAttribute)
+		statuses = append(statuses, status)
+		total, _ := attributes.GetInt64(workflow.TotalSearchAttribute)
+		totals = append(totals, total)
+	}).Return(nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+			OnReject:   func(err error) { s.FailNow("Should not reach here") },
+		}, s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	s.Equal([]string{"open", "open", "closing", "closed", "paid"}, statuses)
+	s.Equal([]int64{0, 100, 100, 100, 100}, totals)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_LineItemQuota_RejectsItemOverQuota() {
+	// Arrange
+	billInfo, lineItem1, lineItem2 := s.defaultBillAndItems()
+	billInfo.MaxLineItems = 1
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+			OnReject:   func(err error) { s.FailNow("Should not reach here") },
+		}, s.addLineItemArgs(lineItem1, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "ed20aa79-5ddc-4510-a5a3-cda08372e273", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.FailNow("Should not reach here") },
+			OnComplete: func(result interface{}, err error) {},
+			OnReject:   func(err error) { s.ErrorContains(err, "the bill already has the maximum of 1 line items") },
+		}, s.addLineItemArgs(lineItem2, "ed20aa79-5ddc-4510-a5a3-cda08372e273"))
+	}, 2*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(uint64(1), result.BillLineItemCount)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_LineItemQuota_AcceptsReversalOverQuota() {
+	// Arrange
+	billInfo, lineItem1, _ := s.defaultBillAndItems()
+	billInfo.MaxLineItems = 1
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	reversedAmount, ok := lineItem1.Amount.Negate()
+	s.True(ok)
+	reversal := model.BillLineItem{
+		Id:          model.BillLineItemId{BillId: billInfo.Id, Id: "reversal-" + lineItem1.Id.Id},
+		Description: "Reversal of " + lineItem1.Description,
+		Amount:      reversedAmount,
+	}
+	reversalArgs := s.addLineItemArgs(reversal, reversal.Id.Id)
+	reversalArgs.Reversal = true
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil).Twice()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+			OnReject:   func(err error) { s.FailNow("Should not reach here") },
+		}, s.addLineItemArgs(lineItem1, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, reversal.Id.Id, &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+			OnReject:   func(err error) { s.FailNow("Should not reach here") },
+		}, reversalArgs)
+	}, 2*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(uint64(2), result.BillLineItemCount)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_SpendingCap_AlertFailureKeepsItem() {
+	// Arrange
+	billInfo, lineItem1, _ := s.defaultBillAndItems()
+	billInfo.SpendingCap = model.NewSpendingCap(250, "USD", []uint32{40, 100})
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity((&activity.DummySpendingActivityHost{}).NotifySpendingAlertActivity, mock.AnythingOfType("SpendingAlert")).
+		Return(temporal.NewNonRetryableApplicationError("unreachable", "NotifierError", nil)).
+		Once()
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+			OnReject:   func(err error) { s.FailNow("Should not reach here") },
+		}, s.addLineItemArgs(lineItem1, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(uint64(1), result.BillLineItemCount)
+	s.Len(result.SpendingAlerts, 1)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_SetSpendingCap_AlertsReachedThresholds() {
+	// Arrange
+	billInfo, lineItem1, lineItem2 := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env = s.NewTestWorkflowEnvironment() // Without the default audit mock
+	s.env.SetStartTime(testStartTime)
+	s.setupFxAvailable()
+	s.setupSucceedingPayment()
+	s.setupNoUsage()
+	s.setupNoBillEventSubscribers()
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	var actions []model.AuditAction
+	var requestIds []string
+	s.env.OnActivity(dummyActivityHost.RecordAuditEntryActivity, mock.Anything, mock.AnythingOfType("AuditEntry")).
+		Return(func(_ context.Context, entry model.AuditEntry) (uint64, error) {
+			actions = append(actions, entry.Action)
+			requestIds = append(requestIds, entry.RequestId)
+			return uint64(1), nil
+		})
+	s.env.OnActivity(dummyActivityHost.SetBillSpendingCapActivity, mock.Anything, billInfo.Id, model.NewSpendingCap(250, "USD", []uint32{50, 80})).Return(uint64(1), nil).Once()
+	s.env.OnActivity((&activity.DummySpendingActivityHost{}).NotifySpendingAlertActivity, mock.AnythingOfType("SpendingAlert")).Return(nil).Twice()
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "ed20aa79-5ddc-4510-a5a3-cda08372e273", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+			OnReject:   func(err error) { s.FailNow("Should not reach here") },
+		}, s.addLineItemArgs(lineItem2, "ed20aa79-5ddc-4510-a5a3-cda08372e273"))
+	}, 1*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.SetSpendingCapUpdate, "0d3e8d62-5b5c-4f0e-9a43-4b1f1c0f3b67", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				s.Len(result.(workflow.BillingState).SpendingAlerts, 2)
+			},
+			OnReject: func(err error) { s.FailNow("Should not reach here") },
+		}, workflow.SetSpendingCapArgs{
+			Max:             250,
+			AlertThresholds: []uint32{50, 80},
+			Actor:           model.NewApiKeyActor("key-1"),
+			RequestId:       "0d3e8d62-5b5c-4f0e-9a43-4b1f1c0f3b67",
+		})
+	}, 2*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.FailNow("Should not reach here") },
+			OnComplete: func(result interface{}, err error) {},
+			OnReject:   func(err error) { s.Error(err) },
+		}, s.addLineItemArgs(lineItem1, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 3*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(model.NewSpendingCap(250, "USD", []uint32{50, 80}), result.BillInfo.SpendingCap)
+	s.Equal(model.TotalAmount{Number: "200", CurrencyCode: "USD"}, result.Total)
+	s.Equal([]model.AuditAction{
+		model.AuditCreate,
+		model.AuditAddLineItem,
+		model.AuditSetSpendingCap,
+		model.AuditSpendingAlert,
+		model.AuditSpendingAlert,
+		model.AuditClose,
+	}, actions)
+	s.Equal([]string{"50%-of-250", "80%-of-250"}, requestIds[3:5])
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_Fails_InvalidSpendingCap() {
+	// Arrange
+	billInfo, _, _ := s.defaultBillAndItems()
+	billInfo.SpendingCap = model.NewSpendingCap(0, "USD", []uint32{50})
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.ErrorContains(s.env.GetWorkflowError(), "alert thresholds need a maximum")
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseAtMaturity_AddsUsage() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env = s.NewTestWorkflowEnvironment() // Without the default usage mock
+	s.env.SetStartTime(testStartTime)
+	s.setupFxAvailable()
+	s.env.OnActivity(dummyActivityHost.RecordAuditEntryActivity, mock.Anything, mock.AnythingOfType("AuditEntry")).Return(uint64(1), nil)
+	s.setupSucceedingPayment()
+	usage := []model.BillLineItem{
+		{
+			Id:          model.BillLineItemId{BillId: billInfo.Id, Id: "usage-api_calls-0.01"},
+			Description: "api_calls usage, 2 events",
+			Amount:      model.Amount{Number: 1234, CurrencyCode: "USD"},
+			Quantity:    "1234",
+			UnitPrice:   "0.01",
+		},
+		{
+			Id:          model.BillLineItemId{BillId: billInfo.Id, Id: "usage-storage_bytes-0.00001"},
+			Description: "storage_bytes usage, 1 events",
+			Amount:      model.Amount{Number: 10, CurrencyCode: "USD"},
+			Quantity:    "1000",
+			UnitPrice:   "0.00001",
+		},
+	}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity((&activity.DummyUsageActivityHost{}).AggregateUsageActivity, mock.Anything, mock.MatchedBy(func(bill model.BillInfo) bool {
+		return bill.Status == model.Closing
+	})).Return(usage, nil).Once()
+	var added []model.BillLineItem
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(func(_ context.Context, lineItem model.BillLineItem, _ model.TotalAmount) (uint64, error) {
+		added = append(added, lineItem)
+		return uint64(1), nil
+	}).Times(3)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+			OnReject:   func(err error) { s.FailNow("Should not reach here") },
+		}, s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Len(added, 3)
+	s.Equal(usage[0].Id, added[1].Id)
+	s.Equal(testStartTime.Add(time.Minute), added[1].CreatedAt)
+	s.Equal(usage[1].Id, added[2].Id)
+	s.Equal(uint64(3), result.BillLineItemCount)
+	s.Equal(model.TotalAmount{Number: "1344", CurrencyCode: "USD"}, result.Total)
+	s.Equal(paidOnClose(result.BillInfo, 1344), result.Payments)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_AddBatch_BestEffort() {
+	// Arrange
+	billInfo, lineItem1, lineItem2 := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	invalid := model.BillLineItem{
+		Id:       model.BillLineItemId{BillId: billInfo.Id, Id: "0f9b3f0e-5a47-4b43-b8a4-76f6b7c0c3c4"},
+		Amount:   model.Amount{CurrencyCode: "USD"},
+		Quantity: "-1",
+	}
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemsIfNotExistActivity, mock.Anything,
+		billInfo.Id,
+		mock.AnythingOfType("[]model.BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(func(_ context.Context, _ model.BillId, lineItems []model.BillLineItem, _ model.TotalAmount) ([]bool, error) {
+		s.Equal([]string{lineItem1.Id.Id, lineItem2.Id.Id}, []string{lineItems[0].Id.Id, lineItems[1].Id.Id})
+		s.Equal(testStartTime.Add(time.Second), lineItems[0].CreatedAt)
+		return []bool{true, false}, nil // The second one was added by an earlier request
+	}).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemsUpdate, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				s.Equal([]workflow.BillLineItemResult{
+					{Id: lineItem1.Id.Id, Outcome: workflow.BillLineItemAdded},
+					{Id: invalid.Id.Id, Outcome: workflow.BillLineItemRefused, Error: `invalid quantity "-1"`},
+					{Id: lineItem2.Id.Id, Outcome: workflow.BillLineItemAlreadyAdded},
+				}, result.(workflow.AddBillLineItemsResult).Results)
+			},
+			OnReject: func(err error) { s.FailNow("Should not reach here") },
+		}, workflow.AddBillLineItemsArgs{
+			LineItems: []model.BillLineItem{lineItem1, invalid, lineItem2},
+			Actor:     model.NewApiKeyActor("key-1"),
+			RequestId: "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+		})
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(uint64(1), result.BillLineItemCount)
+	s.Equal(model.TotalAmount{Number: "100", CurrencyCode: "USD"}, result.Total)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_AddBatch_AllOrNothing_UnderSpendingCap() {
+	// Arrange
+	billInfo, lineItem1, _ := s.defaultBillAndItems()
+	billInfo.SpendingCap = model.NewSpendingCap(250, "USD", nil)
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	lineItem2 := lineItem1
+	lineItem2.Id.Id = "0f9b3f0e-5a47-4b43-b8a4-76f6b7c0c3c4"
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemsIfNotExistActivity, mock.Anything,
+		billInfo.Id,
+		mock.AnythingOfType("[]model.BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return([]bool{true, true}, nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemsUpdate, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				s.Equal([]workflow.BillLineItemResult{
+					{Id: lineItem1.Id.Id, Outcome: workflow.BillLineItemAdded},
+					{Id: lineItem2.Id.Id, Outcome: workflow.BillLineItemAdded},
+				}, result.(workflow.AddBillLineItemsResult).Results)
+			},
+			OnReject: func(err error) { s.FailNow("Should not reach here") },
+		}, workflow.AddBillLineItemsArgs{
+			LineItems:    []model.BillLineItem{lineItem1, lineItem2},
+			AllOrNothing: true,
+			Actor:        model.NewApiKeyActor("key-1"),
+			RequestId:    "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+		})
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(uint64(2), result.BillLineItemCount)
+	s.Equal(model.TotalAmount{Number: "200", CurrencyCode: "USD"}, result.Total)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_AddBatch_AllOrNothing_Rejected() {
+	// Arrange
+	billInfo, lineItem1, lineItem2 := s.defaultBillAndItems()
+	billInfo.SpendingCap = model.NewSpendingCap(250, "USD", nil)
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	invalid := model.BillLineItem{
+		Id:       model.BillLineItemId{BillId: billInfo.Id, Id: "0f9b3f0e-5a47-4b43-b8a4-76f6b7c0c3c4"},
+		Amount:   model.Amount{CurrencyCode: "USD"},
+		Quantity: "-1",
+	}
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	var rejections []error
+	reject := func(args workflow.AddBillLineItemsArgs) {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemsUpdate, args.RequestId, &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.FailNow("Should not reach here") },
+			OnComplete: func(result interface{}, err error) {},
+			OnReject:   func(err error) { rejections = append(rejections, err) },
+		}, args)
+	}
+	s.env.RegisterDelayedCallback(func() {
+		reject(workflow.AddBillLineItemsArgs{LineItems: []model.BillLineItem{lineItem1, invalid}, AllOrNothing: true, RequestId: "r1"})
+		reject(workflow.AddBillLineItemsArgs{LineItems: []model.BillLineItem{lineItem1, lineItem2}, AllOrNothing: true, RequestId: "r2"})
+		reject(workflow.AddBillLineItemsArgs{LineItems: []model.BillLineItem{lineItem1, lineItem1}, RequestId: "r3"})
+		reject(workflow.AddBillLineItemsArgs{RequestId: "r4"})
+	}, 1*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	s.Len(rejections, 4)
+	s.ErrorContains(rejections[0], `line item 1 "0f9b3f0e-5a47-4b43-b8a4-76f6b7c0c3c4": invalid quantity "-1"`)
+	s.ErrorContains(rejections[1], "would exceed the spending cap of 250")
+	s.ErrorContains(rejections[2], "is given twice")
+	s.ErrorContains(rejections[3], "batch of 0 line items")
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(uint64(0), result.BillLineItemCount)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_CloseEarly_PublishesBillEvents() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Minute)
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env = s.NewTestWorkflowEnvironment() // Without the default bill event mock
+	s.env.SetStartTime(testStartTime)
+	s.setupFxAvailable()
+	s.env.OnActivity(dummyActivityHost.RecordAuditEntryActivity, mock.Anything, mock.AnythingOfType("AuditEntry")).Return(uint64(1), nil).Maybe()
+	s.setupSucceedingPayment()
+	s.setupNoUsage()
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(
+		dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything,
+		mock.AnythingOfType("BillLineItem"),
+		mock.AnythingOfType("TotalAmount"),
+	).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	var events []model.BillEvent
+	s.env.OnActivity((&activity.DummyBillEventActivityHost{}).PublishBillEventActivity, mock.AnythingOfType("BillEvent")).Return(
+		func(event model.BillEvent) error {
+			events = append(events, event)
+			return nil
+		})
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.AddBillLineItemUpdate,
+			"1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.NoError(err) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			s.addLineItemArgs(lineItem, "1d1209d3-e60d-4d9c-ae7c-3282f8f5c9b4"))
+	}, 1*time.Second)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(workflow.CloseBillEarlySignal, s.closeBillEarlyArgs())
+	}, 2*time.Second)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Minute, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	total := model.TotalAmount{Number: "100", CurrencyCode: "USD"}
+	addedAt := testStartTime.Add(time.Second)
+	closedAt := testStartTime.Add(2 * time.Second)
+	s.Equal([]model.BillEvent{
+		{BillId: billInfo.Id, Sequence: 1, Kind: model.BillLineItemAdded, LineItemId: lineItem.Id.Id, Status: model.Open, LineItemCount: 1, Total: total, At: addedAt},
+		{BillId: billInfo.Id, Sequence: 2, Kind: model.BillStatusChanged, Status: model.Closing, LineItemCount: 1, Total: total, At: closedAt},
+		{BillId: billInfo.Id, Sequence: 3, Kind: model.BillClosed, Status: model.Closed, LineItemCount: 1, Total: total, At: closedAt},
+		{BillId: billInfo.Id, Sequence: 4, Kind: model.BillStatusChanged, Status: model.Paid, LineItemCount: 1, Total: total, At: closedAt},
+	}, events)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_Extend_ClosesAtNewCloseTime() {
+	// Arrange
+	billInfo, _, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Hour)
+	dummyActivityHost := activity.DummyActivityHost{}
+	extendedCloseTime := testStartTime.Add(3 * time.Hour)
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.SetBillCloseTimeActivity, mock.Anything, billInfo.Id, extendedCloseTime).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.ExtendBillUpdate, "3f5c2a1e-7b8d-4e6f-9a0b-1c2d3e4f5a6b", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				s.Equal(extendedCloseTime, result.(workflow.BillingState).BillInfo.CloseTime)
+			},
+			OnReject: func(err error) { s.FailNow("Should not reach here") },
+		}, workflow.ExtendBillArgs{
+			CloseTime: extendedCloseTime,
+			Actor:     model.NewOperatorActor("oscar"),
+			RequestId: "3f5c2a1e-7b8d-4e6f-9a0b-1c2d3e4f5a6b",
+		})
+	}, 30*time.Minute)
+	s.env.RegisterDelayedCallback(func() {
+		encoded, err := s.env.QueryWorkflow(workflow.GetPendingBillStateQuery)
+		s.NoError(err)
+		var state workflow.BillingState
+		s.NoError(encoded.Get(&state))
+		s.Equal(model.Open, state.BillInfo.Status)
+	}, 2*time.Hour)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(model.Paid, result.BillInfo.Status)
+	s.Equal(extendedCloseTime, result.BillInfo.CloseTime)
+	s.Equal(extendedCloseTime, result.BillInfo.ClosedAt)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_Extend_RejectsEarlierCloseTime() {
+	// Arrange
+	billInfo, _, _ := s.defaultBillAndItems()
+	billInfo = scheduledBillInfo(billInfo, time.Hour)
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.SetBillCloseTimeActivity, mock.Anything, mock.AnythingOfType("BillId"), mock.Anything).Return(uint64(1), nil).Never()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.ExtendBillUpdate, "3f5c2a1e-7b8d-4e6f-9a0b-1c2d3e4f5a6b", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.FailNow("Should not reach here") },
+			OnComplete: func(result interface{}, err error) {},
+			OnReject: func(err error) {
+				s.ErrorAs(err, &workflow.CloseTimeNotLaterError{})
+			},
+		}, workflow.ExtendBillArgs{
+			CloseTime: testStartTime.Add(30 * time.Minute),
+			Actor:     model.NewOperatorActor("oscar"),
+			RequestId: "3f5c2a1e-7b8d-4e6f-9a0b-1c2d3e4f5a6b",
+		})
+	}, time.Minute)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(testStartTime.Add(time.Hour), result.BillInfo.ClosedAt)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_Draft_RejectsItemUntilPublished() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	billInfo.Status = model.Draft
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillLineItem"), mock.AnythingOfType("TotalAmount")).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "c1b2a3d4-0001-4e6f-9a0b-1c2d3e4f5a6b", &testsuite.TestUpdateCallback{
+			OnAccept:   func() { s.FailNow("Should not reach here") },
+			OnComplete: func(result interface{}, err error) {},
+			OnReject: func(err error) {
+				s.ErrorAs(err, &workflow.BillNotOpenError{})
+			},
+		}, s.addLineItemArgs(lineItem, "c1b2a3d4-0001-4e6f-9a0b-1c2d3e4f5a6b"))
+	}, time.Minute)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.PublishBillUpdate, "c1b2a3d4-0002-4e6f-9a0b-1c2d3e4f5a6b", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				s.Equal(model.Open, result.(workflow.BillingState).BillInfo.Status)
+			},
+			OnReject: func(err error) { s.FailNow("Should not reach here") },
+		}, workflow.PublishBillArgs{Actor: model.NewOperatorActor("oscar"), RequestId: "c1b2a3d4-0002-4e6f-9a0b-1c2d3e4f5a6b"})
+	}, 2*time.Minute)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "c1b2a3d4-0003-4e6f-9a0b-1c2d3e4f5a6b", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+			OnReject:   func(err error) { s.FailNow("Should not reach here") },
+		}, s.addLineItemArgs(lineItem, "c1b2a3d4-0003-4e6f-9a0b-1c2d3e4f5a6b"))
+	}, 3*time.Minute)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(model.Paid, result.BillInfo.Status)
+	s.Equal(uint64(1), result.BillLineItemCount)
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_Draft_VoidedAtMaturity() {
+	// Arrange
+	billInfo, _, _ := s.defaultBillAndItems()
+	billInfo.Status = model.Draft
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.VoidBillActivity, mock.Anything, billInfo.Id, model.NewTotalAmount("USD")).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil).Never()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(model.Void, result.BillInfo.Status)
+	s.True(result.BillInfo.ClosedAt.IsZero())
+}
+
+func (s *BillingWorkflowUnitTestSuite) Test_Workflow_Void_ReversesOpenBill() {
+	// Arrange
+	billInfo, lineItem, _ := s.defaultBillAndItems()
+	dummyActivityHost := activity.DummyActivityHost{}
+	s.env.OnActivity(dummyActivityHost.CreateBillIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil)
+	s.env.OnActivity(dummyActivityHost.AddBillLineItemIfNotExistActivity, mock.Anything, mock.AnythingOfType("BillLineItem"), mock.AnythingOfType("TotalAmount")).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.VoidBillActivity, mock.Anything, billInfo.Id, model.TotalAmount{Number: "100", CurrencyCode: "USD"}).Return(uint64(1), nil).Once()
+	s.env.OnActivity(dummyActivityHost.CloseBillActivity, mock.Anything, mock.AnythingOfType("BillInfo")).Return(uint64(1), nil).Never()
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.AddBillLineItemUpdate, "d1b2a3d4-0001-4e6f-9a0b-1c2d3e4f5a6b", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnComplete: func(result interface{}, err error) { s.NoError(err) },
+			OnReject:   func(err error) { s.FailNow("Should not reach here") },
+		}, s.addLineItemArgs(lineItem, "d1b2a3d4-0001-4e6f-9a0b-1c2d3e4f5a6b"))
+	}, time.Minute)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(workflow.VoidBillUpdate, "d1b2a3d4-0002-4e6f-9a0b-1c2d3e4f5a6b", &testsuite.TestUpdateCallback{
+			OnAccept: func() {},
+			OnComplete: func(result interface{}, err error) {
+				s.NoError(err)
+				s.Equal(model.Void, result.(workflow.BillingState).BillInfo.Status)
+			},
+			OnReject: func(err error) { s.FailNow("Should not reach here") },
+		}, workflow.VoidBillArgs{Actor: model.NewOperatorActor("oscar"), RequestId: "d1b2a3d4-0002-4e6f-9a0b-1c2d3e4f5a6b"})
+	}, 2*time.Minute)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.BillingWorkflow, billInfo, time.Hour, model.NewCustomerActor(billInfo.Id.CustomerId))
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.BillingState
+	s.env.GetWorkflowResult(&result)
+	s.Equal(model.Void, result.BillInfo.Status)
+	s.Equal(model.TotalAmount{Number: "100", CurrencyCode: "USD"}, result.Total)
+	s.Empty(result.Payments)
+}
@@ -0,0 +1,124 @@
+package workflow
+
+import (
+	"fmt"
+
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+const PublishBillUpdate = "PublishBill"
+const VoidBillUpdate = "VoidBill"
+
+// A draft, or a void bill, refuses what only an open bill accepts, such as line items.
+type BillNotOpenError struct {
+	BillId model.BillId
+	Status model.BillStatus
+}
+
+func (e BillNotOpenError) Error() string {
+	return fmt.Sprintf("bill %q is %s, not open", e.BillId.Id, e.Status)
+}
+
+type PublishBillArgs struct {
+	Actor     model.Actor
+	RequestId string
+}
+
+type VoidBillArgs struct {
+	Actor     model.Actor
+	RequestId string
+}
+
+// Line items, coupons, spending caps and extensions are only accepted while the bill is open.
+func (state *billingState) checkOpen() error {
+	switch state.BillInfo.Status {
+	case model.Open:
+		return nil
+	case model.Draft, model.Void:
+		return BillNotOpenError{BillId: state.BillInfo.Id, Status: state.BillInfo.Status}
+	}
+	return BillClosingError{state.BillInfo.Id}
+}
+
+// The bill may have started closing, or been voided, while an update waited for an activity.
+func (state *billingState) checkStillOpen(ctx workflow.Context) error {
+	if e := state.checkOpen(); e != nil && hasChange(ctx, awaitLineItemsChangeId) {
+		return e
+	}
+	return nil
+}
+
+// Publishing an open bill again is a no-op.
+func (state *billingState) validatePublishBill(ctx workflow.Context, args PublishBillArgs) error {
+	state.logger.Info("Validating bill publication", "Bill", state.BillInfo)
+	return state.BillInfo.Status.CheckTransition(model.Open)
+}
+
+// Opens a draft to line items.
+func (state *billingState) publishBillSyncActivity(ctx workflow.Context, args PublishBillArgs) (BillingState, error) {
+	// The bill may have been published, or voided, in the meantime
+	if e := state.validatePublishBill(ctx, args); e != nil {
+		return state.Clone(), e
+	} else if state.BillInfo.Status == model.Open {
+		return state.Clone(), nil
+	}
+	state.logger.Info("Publishing bill", "Bill", state.BillInfo, "Actor", args.Actor)
+	if e := state.setStatus(ctx, model.Open); e != nil {
+		return state.Clone(), e
+	}
+	intermediateState := state.Clone()
+	_, e := state.recordAuditEntrySyncActivity(ctx, model.AuditPublish, args.Actor, args.RequestId, state.Total)
+	return intermediateState, e
+}
+
+// Only a draft or an open bill is voided by its workflow, voiding a void bill again is a no-op.
+func (state *billingState) validateVoidBill(ctx workflow.Context, args VoidBillArgs) error {
+	state.logger.Info("Validating bill void", "Bill", state.BillInfo)
+	switch state.BillInfo.Status {
+	case model.Draft, model.Open, model.Void:
+		return nil
+	}
+	return BillClosingError{state.BillInfo.Id}
+}
+
+func (state *billingState) voidBillSyncActivity(ctx workflow.Context, args VoidBillArgs) (BillingState, error) {
+	// The bill may have started closing, or been voided, in the meantime
+	if e := state.validateVoidBill(ctx, args); e != nil {
+		return state.Clone(), e
+	} else if state.BillInfo.Status == model.Void {
+		return state.Clone(), nil
+	}
+	return state.voidBill(ctx, args.Actor, args.RequestId)
+}
+
+// The status changes first so that the line items are refused, then the line items being added are waited for so
+// that the ledger reverses them too. The workflow ends once the void is saved, state.voided tells it either way.
+func (state *billingState) voidBill(ctx workflow.Context, actor model.Actor, requestId string) (BillingState, error) {
+	defer state.voided.SendAsync(nil)
+	state.logger.Info("Voiding bill", "Bill", state.BillInfo, "Actor", actor)
+	status := state.BillInfo.Status
+	state.BillInfo.Status = model.Void
+	if e := workflow.Await(ctx, func() bool { return state.reservedLineItems == 0 }); e != nil {
+		state.BillInfo.Status = status
+		return state.Clone(), e
+	}
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyActivityHost{}).VoidBillActivity,
+		state.BillInfo.Id,
+		state.Total,
+	).Get(ctxWithOptions, nil)
+	if e != nil {
+		state.BillInfo.Status = status
+		return state.Clone(), e
+	}
+	state.upsertSearchAttributes(ctx)
+	state.publishBillEventAsyncActivity(ctx, model.BillStatusChanged, "")
+	intermediateState := state.Clone()
+	_, e = state.recordAuditEntrySyncActivity(ctx, model.AuditVoid, actor, requestId, state.Total)
+	return intermediateState, e
+}
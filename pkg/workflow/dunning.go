@@ -0,0 +1,281 @@
+package workflow
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/workflow"
+)
+
+const RecordPaymentUpdate = "RecordPayment"
+const GetDunningStateQuery = "GetDunningState"
+
+type DunningOverError struct {
+	BillId model.BillId
+}
+
+func (e DunningOverError) Error() string {
+	return fmt.Sprintf("bill %q is no longer dunned", e.BillId.Id)
+}
+
+type PaymentInProgressError struct {
+	BillId model.BillId
+}
+
+func (e PaymentInProgressError) Error() string {
+	return fmt.Sprintf("a payment of bill %q is in progress", e.BillId.Id)
+}
+
+type EmptyPaymentReferenceError struct {
+}
+
+func (e EmptyPaymentReferenceError) Error() string {
+	return "payment reference is empty"
+}
+
+func DunningWorkflowId(billId model.BillId) string {
+	return fmt.Sprintf("dunning-bill-%s", billId.Id)
+}
+
+type DunningRequest struct {
+	BillId model.BillId
+	Amount model.TotalAmount
+	// When the payment on close failed, the steps of the schedule are counted from it.
+	FailedAt time.Time
+	// The number of the first retry.
+	NextNumber uint32
+}
+
+type DunningState struct {
+	BillId model.BillId
+	Amount model.TotalAmount
+	// PaymentFailed while dunning, then Paid or Uncollectible.
+	Status model.BillStatus
+	// The retries and the payment received out of band, in order. A retry of an errored attempt replaces it.
+	Payments      []model.PaymentAttempt
+	Notifications []model.DunningNotification
+}
+
+type dunningState struct {
+	DunningState
+	logger     log.Logger
+	nextNumber uint32
+	// A retry or a payment received out of band is being recorded, another one could collect twice.
+	collecting bool
+}
+
+func (state *dunningState) Clone() DunningState {
+	return DunningState{
+		BillId:        state.BillId,
+		Amount:        state.Amount,
+		Status:        state.Status,
+		Payments:      slices.Clone(state.Payments),
+		Notifications: slices.Clone(state.Notifications),
+	}
+}
+
+func (state *dunningState) isOver() bool {
+	return state.Status != model.PaymentFailed
+}
+
+type RecordPaymentArgs struct {
+	// Of the payment received out of band, such as the id of a bank transfer.
+	Reference string
+}
+
+func (state *dunningState) getDunningScheduleSyncActivity(ctx workflow.Context) (model.DunningSchedule, error) {
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var schedule model.DunningSchedule
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyDunningActivityHost{}).GetDunningScheduleActivity,
+		state.BillId.CustomerId,
+	).Get(ctxWithOptions, &schedule)
+	return schedule, e
+}
+
+func (state *dunningState) notifyCustomerSyncActivity(ctx workflow.Context, step uint32, kind model.DunningNotificationKind) error {
+	notification := model.DunningNotification{
+		BillId:    state.BillId,
+		Step:      step,
+		Kind:      kind,
+		AmountDue: state.Amount,
+		SentAt:    workflow.Now(ctx),
+	}
+	state.logger.Info("Notifying customer", "Notification", notification)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var updateCount uint64
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyDunningActivityHost{}).NotifyCustomerActivity,
+		notification,
+	).Get(ctxWithOptions, &updateCount)
+	if e == nil {
+		state.Notifications = append(state.Notifications, notification)
+	}
+	return e
+}
+
+func (state *dunningState) setStatus(ctx workflow.Context, status model.BillStatus) error {
+	if e := state.Status.CheckTransition(status); e != nil {
+		return e
+	}
+	if _, e := setBillStatusSyncActivity(ctx, state.BillId, status); e != nil {
+		return e
+	}
+	state.Status = status
+	return nil
+}
+
+// The attempt with the number of an errored one takes its place, like in the database.
+func (state *dunningState) addPayment(attempt model.PaymentAttempt) {
+	if last := len(state.Payments) - 1; 0 <= last && state.Payments[last].Number == attempt.Number {
+		state.Payments[last] = attempt
+		return
+	}
+	state.Payments = append(state.Payments, attempt)
+}
+
+func (state *dunningState) retryPayment(ctx workflow.Context) (model.PaymentAttempt, error) {
+	state.collecting = true
+	defer func() { state.collecting = false }()
+	request := PaymentRequest{BillId: state.BillId, Number: state.nextNumber, Amount: state.Amount}
+	attempt, e := executePaymentChildWorkflow(ctx, request)
+	if e != nil {
+		return attempt, e
+	}
+	state.nextNumber = nextPaymentNumber(ctx, attempt)
+	state.addPayment(attempt)
+	if attempt.Status == model.PaymentSucceeded {
+		e = state.setStatus(ctx, model.Paid)
+	}
+	return attempt, e
+}
+
+func (state *dunningState) validateRecordPayment(ctx workflow.Context, args RecordPaymentArgs) error {
+	state.logger.Info("Validating payment received out of band", "BillId", state.BillId, "Reference", args.Reference)
+	if state.isOver() {
+		return DunningOverError{state.BillId}
+	} else if state.collecting {
+		return PaymentInProgressError{state.BillId}
+	} else if args.Reference == "" {
+		return EmptyPaymentReferenceError{}
+	}
+	return nil
+}
+
+// The payment settles the whole amount due and ends the dunning.
+func (state *dunningState) recordPaymentSyncActivity(ctx workflow.Context, args RecordPaymentArgs) (DunningState, error) {
+	state.collecting = true
+	defer func() { state.collecting = false }()
+	attempt := model.PaymentAttempt{
+		BillId:      state.BillId,
+		Number:      state.nextNumber,
+		Amount:      state.Amount,
+		Status:      model.PaymentSucceeded,
+		Reference:   args.Reference,
+		AttemptedAt: workflow.Now(ctx),
+	}
+	state.nextNumber++
+	state.logger.Info("Recording payment received out of band", "Attempt", attempt)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var updateCount uint64
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyPaymentActivityHost{}).RecordPaymentAttemptActivity,
+		attempt,
+	).Get(ctxWithOptions, &updateCount)
+	if e != nil {
+		return state.Clone(), e
+	}
+	state.addPayment(attempt)
+	e = state.setStatus(ctx, model.Paid)
+	return state.Clone(), e
+}
+
+// Dunning outlives the bill workflow, which completes once it started.
+func startDunningChildWorkflow(ctx workflow.Context, request DunningRequest) error {
+	workflow.GetLogger(ctx).Info("Starting dunning", "Request", request)
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:        DunningWorkflowId(request.BillId),
+		ParentClosePolicy: enumspb.PARENT_CLOSE_POLICY_ABANDON,
+	})
+	return workflow.ExecuteChildWorkflow(childCtx, DunningWorkflow, request).GetChildWorkflowExecution().Get(childCtx, nil)
+}
+
+// Retries the payment at each step of the schedule of the customer tier, and reminds the customer when it fails again.
+// The bill is uncollectible once the last step failed, unless it was paid out of band in the meantime.
+func DunningWorkflow(ctx workflow.Context, request DunningRequest) (DunningState, error) {
+	state := &dunningState{
+		DunningState: DunningState{
+			BillId: request.BillId,
+			Amount: request.Amount,
+			Status: model.PaymentFailed,
+		},
+		logger:     workflow.GetLogger(ctx),
+		nextNumber: request.NextNumber,
+	}
+	state.logger.Info("Dunning started", "Request", request)
+
+	e := workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		RecordPaymentUpdate,
+		state.recordPaymentSyncActivity,
+		workflow.UpdateHandlerOptions{
+			Validator: state.validateRecordPayment,
+		})
+	if e != nil {
+		return state.Clone(), e
+	}
+	e = workflow.SetQueryHandler(ctx, GetDunningStateQuery, func() (DunningState, error) {
+		return state.Clone(), nil
+	})
+	if e != nil {
+		return state.Clone(), e
+	}
+
+	schedule, e := state.getDunningScheduleSyncActivity(ctx)
+	if e != nil {
+		return state.Clone(), e
+	}
+	for i, step := range schedule.Steps {
+		if wait := request.FailedAt.Add(step).Sub(workflow.Now(ctx)); 0 < wait {
+			if _, e = workflow.AwaitWithTimeout(ctx, wait, state.isOver); e != nil {
+				return state.Clone(), e
+			}
+		}
+		// A payment received out of band may still be being recorded
+		if e = workflow.Await(ctx, func() bool { return !state.collecting }); e != nil {
+			return state.Clone(), e
+		}
+		if state.isOver() {
+			break
+		}
+		if _, e = state.retryPayment(ctx); e != nil {
+			return state.Clone(), e
+		}
+		if state.isOver() {
+			break
+		}
+		if e = state.notifyCustomerSyncActivity(ctx, uint32(i+1), model.DunningReminder); e != nil {
+			return state.Clone(), e
+		}
+	}
+	if !state.isOver() {
+		state.logger.Info("Bill is uncollectible", "BillId", state.BillId)
+		if e = state.setStatus(ctx, model.Uncollectible); e != nil {
+			return state.Clone(), e
+		}
+		if e = state.notifyCustomerSyncActivity(ctx, uint32(len(schedule.Steps)+1), model.DunningEscalation); e != nil {
+			return state.Clone(), e
+		}
+	}
+	e = workflow.Await(ctx, func() bool { return workflow.AllHandlersFinished(ctx) })
+	return state.Clone(), e
+}
@@ -0,0 +1,262 @@
+package workflow_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/testsuite"
+	sdkworkflow "go.temporal.io/sdk/workflow"
+)
+
+type DunningWorkflowUnitTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+
+	env *testsuite.TestWorkflowEnvironment
+}
+
+func TestDunningWorkflowUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(DunningWorkflowUnitTestSuite))
+}
+
+const day = 24 * time.Hour
+
+func (s *DunningWorkflowUnitTestSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.env.SetStartTime(testStartTime)
+	s.env.RegisterWorkflow(workflow.PaymentWorkflow)
+	s.env.OnActivity((&activity.DummyDunningActivityHost{}).GetDunningScheduleActivity, mock.Anything, model.CustomerId("alice")).
+		Return(model.DefaultDunningSchedules()[model.TierStandard], nil)
+}
+
+func (s *DunningWorkflowUnitTestSuite) AfterTest(suiteName, testName string) {
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *DunningWorkflowUnitTestSuite) dunningRequest() workflow.DunningRequest {
+	return workflow.DunningRequest{
+		BillId:     model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"},
+		Amount:     model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+		FailedAt:   testStartTime,
+		NextNumber: 2,
+	}
+}
+
+// The retries up to the given number are declined, the later ones succeed.
+func (s *DunningWorkflowUnitTestSuite) declinePaymentsUntil(lastDeclined uint32) {
+	s.env.OnWorkflow(workflow.PaymentWorkflow, mock.Anything, mock.AnythingOfType("PaymentRequest")).
+		Return(func(ctx sdkworkflow.Context, request workflow.PaymentRequest) (model.PaymentAttempt, error) {
+			if lastDeclined < request.Number {
+				return succeededPayment(request, sdkworkflow.Now(ctx)), nil
+			}
+			return declinedPayment(request, sdkworkflow.Now(ctx)), nil
+		})
+}
+
+func declinedPayment(request workflow.PaymentRequest, attemptedAt time.Time) model.PaymentAttempt {
+	return model.PaymentAttempt{
+		BillId:        request.BillId,
+		Number:        request.Number,
+		Amount:        request.Amount,
+		Status:        model.PaymentDeclined,
+		FailureReason: "insufficient funds",
+		AttemptedAt:   attemptedAt,
+	}
+}
+
+func reminder(request workflow.DunningRequest, step uint32, sentAt time.Time) model.DunningNotification {
+	return model.DunningNotification{
+		BillId:    request.BillId,
+		Step:      step,
+		Kind:      model.DunningReminder,
+		AmountDue: request.Amount,
+		SentAt:    sentAt,
+	}
+}
+
+func (s *DunningWorkflowUnitTestSuite) Test_Workflow_PaidAtThirdStep() {
+	// Arrange
+	request := s.dunningRequest()
+	s.declinePaymentsUntil(3)
+	dummyDunningActivityHost := activity.DummyDunningActivityHost{}
+	s.env.OnActivity(dummyDunningActivityHost.NotifyCustomerActivity, mock.Anything, mock.AnythingOfType("DunningNotification")).
+		Return(uint64(1), nil).
+		Twice()
+	s.env.OnActivity((&activity.DummyActivityHost{}).SetBillStatusActivity, mock.Anything, request.BillId, model.Paid).
+		Return(uint64(1), nil).
+		Once()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.DunningWorkflow, request)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.DunningState
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(model.Paid, result.Status)
+	s.Equal([]model.PaymentAttempt{
+		declinedPayment(workflow.PaymentRequest{BillId: request.BillId, Number: 2, Amount: request.Amount}, testStartTime.Add(day)),
+		declinedPayment(workflow.PaymentRequest{BillId: request.BillId, Number: 3, Amount: request.Amount}, testStartTime.Add(3*day)),
+		succeededPayment(workflow.PaymentRequest{BillId: request.BillId, Number: 4, Amount: request.Amount}, testStartTime.Add(7*day)),
+	}, result.Payments)
+	s.Equal([]model.DunningNotification{
+		reminder(request, 1, testStartTime.Add(day)),
+		reminder(request, 2, testStartTime.Add(3*day)),
+	}, result.Notifications)
+}
+
+func (s *DunningWorkflowUnitTestSuite) Test_Workflow_RetriesErroredPayment_WithSameNumber() {
+	// Arrange
+	request := s.dunningRequest()
+	var numbers []uint32
+	s.env.OnWorkflow(workflow.PaymentWorkflow, mock.Anything, mock.AnythingOfType("PaymentRequest")).
+		Return(func(ctx sdkworkflow.Context, paymentRequest workflow.PaymentRequest) (model.PaymentAttempt, error) {
+			numbers = append(numbers, paymentRequest.Number)
+			if len(numbers) == 1 {
+				errored := declinedPayment(paymentRequest, sdkworkflow.Now(ctx))
+				errored.Status, errored.FailureReason = model.PaymentErrored, "gateway unavailable"
+				return errored, nil
+			}
+			return succeededPayment(paymentRequest, sdkworkflow.Now(ctx)), nil
+		})
+	s.env.OnActivity((&activity.DummyDunningActivityHost{}).NotifyCustomerActivity, mock.Anything, mock.AnythingOfType("DunningNotification")).
+		Return(uint64(1), nil).
+		Once()
+	s.env.OnActivity((&activity.DummyActivityHost{}).SetBillStatusActivity, mock.Anything, request.BillId, model.Paid).
+		Return(uint64(1), nil).
+		Once()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.DunningWorkflow, request)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.DunningState
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(model.Paid, result.Status)
+	s.Equal([]uint32{2, 2}, numbers)
+	s.Equal([]model.PaymentAttempt{
+		succeededPayment(workflow.PaymentRequest{BillId: request.BillId, Number: 2, Amount: request.Amount}, testStartTime.Add(3*day)),
+	}, result.Payments)
+}
+
+func (s *DunningWorkflowUnitTestSuite) Test_Workflow_Uncollectible_AfterLastStep() {
+	// Arrange
+	request := s.dunningRequest()
+	s.declinePaymentsUntil(5)
+	dummyDunningActivityHost := activity.DummyDunningActivityHost{}
+	s.env.OnActivity(dummyDunningActivityHost.NotifyCustomerActivity, mock.Anything, mock.AnythingOfType("DunningNotification")).
+		Return(uint64(1), nil).
+		Times(5)
+	s.env.OnActivity((&activity.DummyActivityHost{}).SetBillStatusActivity, mock.Anything, request.BillId, model.Uncollectible).
+		Return(uint64(1), nil).
+		Once()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.DunningWorkflow, request)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.DunningState
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(model.Uncollectible, result.Status)
+	s.Len(result.Payments, 4)
+	s.Equal(uint32(5), result.Payments[3].Number)
+	s.Equal(testStartTime.Add(14*day), result.Payments[3].AttemptedAt)
+	s.Len(result.Notifications, 5)
+	s.Equal(reminder(request, 4, testStartTime.Add(14*day)), result.Notifications[3])
+	s.Equal(model.DunningNotification{
+		BillId:    request.BillId,
+		Step:      5,
+		Kind:      model.DunningEscalation,
+		AmountDue: request.Amount,
+		SentAt:    testStartTime.Add(14 * day),
+	}, result.Notifications[4])
+}
+
+func (s *DunningWorkflowUnitTestSuite) Test_Workflow_PaymentOutOfBand_EndsDunning() {
+	// Arrange
+	request := s.dunningRequest()
+	s.declinePaymentsUntil(5)
+	dummyDunningActivityHost := activity.DummyDunningActivityHost{}
+	s.env.OnActivity(dummyDunningActivityHost.NotifyCustomerActivity, mock.Anything, mock.AnythingOfType("DunningNotification")).
+		Return(uint64(1), nil).
+		Once()
+	received := model.PaymentAttempt{
+		BillId:      request.BillId,
+		Number:      3,
+		Amount:      request.Amount,
+		Status:      model.PaymentSucceeded,
+		Reference:   "wire-42",
+		AttemptedAt: testStartTime.Add(2 * day),
+	}
+	s.env.OnActivity((&activity.DummyPaymentActivityHost{}).RecordPaymentAttemptActivity, mock.Anything, received).
+		Return(uint64(1), nil).
+		Once()
+	s.env.OnActivity((&activity.DummyActivityHost{}).SetBillStatusActivity, mock.Anything, request.BillId, model.Paid).
+		Return(uint64(1), nil).
+		Once()
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.RecordPaymentUpdate,
+			"wire-42",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() {},
+				OnComplete: func(result interface{}, err error) { s.NoError(err) },
+				OnReject:   func(err error) { s.FailNow("Should not reach here") },
+			},
+			workflow.RecordPaymentArgs{Reference: "wire-42"})
+	}, 2*day)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.DunningWorkflow, request)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.DunningState
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(model.Paid, result.Status)
+	s.Len(result.Payments, 2)
+	s.Equal(received, result.Payments[1])
+	s.Equal([]model.DunningNotification{reminder(request, 1, testStartTime.Add(day))}, result.Notifications)
+}
+
+func (s *DunningWorkflowUnitTestSuite) Test_Workflow_RejectsPaymentWithoutReference() {
+	// Arrange
+	request := s.dunningRequest()
+	s.declinePaymentsUntil(1)
+	s.env.OnActivity((&activity.DummyActivityHost{}).SetBillStatusActivity, mock.Anything, request.BillId, model.Paid).
+		Return(uint64(1), nil).
+		Once()
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(
+			workflow.RecordPaymentUpdate,
+			"empty",
+			&testsuite.TestUpdateCallback{
+				OnAccept:   func() { s.FailNow("Should not reach here") },
+				OnComplete: func(result interface{}, err error) {},
+				OnReject:   func(err error) { s.ErrorContains(err, "payment reference is empty") },
+			},
+			workflow.RecordPaymentArgs{})
+	}, time.Hour)
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.DunningWorkflow, request)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result workflow.DunningState
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(model.Paid, result.Status)
+	s.Len(result.Payments, 1)
+}
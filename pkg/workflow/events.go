@@ -0,0 +1,53 @@
+package workflow
+
+import (
+	"slices"
+
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// The task queue polled by the processes of the live subscribers, next to the billing task queue.
+func BillEventsTaskQueue(billingTaskQueue string) string {
+	return billingTaskQueue + "-events"
+}
+
+// The workflow only waits for the event to be published before it completes, and an event that failed is not published again.
+// Subscribers get the whole state with each event, so they only miss the intermediate states.
+func (state *billingState) publishBillEventAsyncActivity(ctx workflow.Context, kind model.BillEventKind, lineItemId string) {
+	state.eventSequence++
+	event := model.BillEvent{
+		BillId:        state.BillInfo.Id,
+		Sequence:      state.eventSequence,
+		Kind:          kind,
+		LineItemId:    lineItemId,
+		Status:        state.BillInfo.Status,
+		LineItemCount: state.BillLineItemCount,
+		Total:         state.Total,
+		At:            workflow.Now(ctx),
+	}
+	ctxWithOptions := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		TaskQueue:              BillEventsTaskQueue(workflow.GetInfo(ctx).TaskQueueName),
+		ScheduleToCloseTimeout: activity.PublishBillEventTimeout,
+		RetryPolicy:            &temporal.RetryPolicy{MaximumAttempts: 1},
+	})
+	state.pendingEvents = slices.DeleteFunc(state.pendingEvents, workflow.Future.IsReady)
+	state.pendingEvents = append(state.pendingEvents, workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyBillEventActivityHost{}).PublishBillEventActivity,
+		event,
+	))
+}
+
+// The activities still scheduled when the workflow completes would be dropped.
+func (state *billingState) awaitBillEvents(ctx workflow.Context) {
+	for _, future := range state.pendingEvents {
+		if e := future.Get(ctx, nil); e != nil {
+			state.logger.Warn("Bill event not published", "Bill", state.BillInfo, "Error", e)
+		}
+	}
+	state.pendingEvents = nil
+}
@@ -0,0 +1,74 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+const ExtendBillUpdate = "ExtendBill"
+
+type CloseTimeNotLaterError struct {
+	CloseTime time.Time
+}
+
+func (e CloseTimeNotLaterError) Error() string {
+	return fmt.Sprintf("close time is not later than %v", e.CloseTime)
+}
+
+type ExtendBillArgs struct {
+	CloseTime time.Time
+	Actor     model.Actor
+	RequestId string
+}
+
+func (state *billingState) validateExtendBill(ctx workflow.Context, args ExtendBillArgs) error {
+	state.logger.Info("Validating bill extension", "Bill", state.BillInfo, "CloseTime", args.CloseTime)
+	if e := state.checkOpen(); e != nil {
+		return e
+	} else if !args.CloseTime.After(state.BillInfo.CloseTime) {
+		return CloseTimeNotLaterError{state.BillInfo.CloseTime}
+	}
+	return nil
+}
+
+func (state *billingState) setBillCloseTimeSyncActivity(ctx workflow.Context, closeTime time.Time) (uint64, error) {
+	state.logger.Info("Setting bill close time", "Bill", state.BillInfo, "CloseTime", closeTime)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var updateCount uint64
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyActivityHost{}).SetBillCloseTimeActivity,
+		state.BillInfo.Id,
+		closeTime,
+	).Get(ctxWithOptions, &updateCount)
+	return updateCount, e
+}
+
+// Postpones the close time of the bill, the timer of the bill restarts once it is saved.
+// The extensions are saved one at a time so that the latest close time is saved last.
+func (state *billingState) extendBillSyncActivity(ctx workflow.Context, args ExtendBillArgs) (BillingState, error) {
+	if e := state.extending.Lock(ctx); e != nil {
+		return state.Clone(), e
+	}
+	defer state.extending.Unlock()
+	// The bill may have started closing, or been extended further, in the meantime
+	if e := state.validateExtendBill(ctx, args); e != nil {
+		return state.Clone(), e
+	}
+	state.logger.Info("Extending bill", "Bill", state.BillInfo, "CloseTime", args.CloseTime, "Actor", args.Actor)
+	if _, e := state.setBillCloseTimeSyncActivity(ctx, args.CloseTime); e != nil {
+		return state.Clone(), e
+	}
+	state.BillInfo.CloseTime = args.CloseTime
+	state.upsertSearchAttributes(ctx)
+	// The timer reads the latest close time, a value already waiting is enough
+	state.extended.SendAsync(nil)
+	intermediateState := state.Clone()
+	_, e := state.recordAuditEntrySyncActivity(ctx, model.AuditExtend, args.Actor, args.RequestId, state.Total)
+	return intermediateState, e
+}
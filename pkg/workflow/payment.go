@@ -0,0 +1,103 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+type PaymentRequest struct {
+	BillId model.BillId
+	// From 1, a new number is a new charge for the gateway.
+	Number uint32
+	Amount model.TotalAmount
+}
+
+func PaymentWorkflowId(billId model.BillId, number uint32) string {
+	return fmt.Sprintf("payment-bill-%s-%d", billId.Id, number)
+}
+
+// Transient gateway errors are retried for a few minutes before the attempt is given up.
+func chargeActivityOptions() workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		StartToCloseTimeout: activity.ChargeActivityTimeout,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    5,
+		},
+	}
+}
+
+// Charges the amount once and records the attempt whatever its outcome.
+func PaymentWorkflow(ctx workflow.Context, request PaymentRequest) (model.PaymentAttempt, error) {
+	logger := workflow.GetLogger(ctx)
+	attempt := model.PaymentAttempt{
+		BillId:      request.BillId,
+		Number:      request.Number,
+		Amount:      request.Amount,
+		AttemptedAt: workflow.Now(ctx),
+	}
+	logger.Info("Charging bill", "Attempt", attempt)
+	chargeCtx := workflow.WithActivityOptions(ctx, chargeActivityOptions())
+	var charged model.PaymentAttempt
+	e := workflow.ExecuteActivity(
+		chargeCtx,
+		(&activity.DummyPaymentActivityHost{}).ChargeBillActivity,
+		attempt,
+	).Get(chargeCtx, &charged)
+	if e != nil {
+		logger.Error("Gateway could not charge the bill", "Attempt", attempt, "Error", e)
+		attempt.Status = model.PaymentErrored
+		attempt.FailureReason = e.Error()
+	} else {
+		attempt = charged
+	}
+	logger.Info("Recording payment attempt", "Attempt", attempt)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var updateCount uint64
+	e = workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyPaymentActivityHost{}).RecordPaymentAttemptActivity,
+		attempt,
+	).Get(ctxWithOptions, &updateCount)
+	return attempt, e
+}
+
+// An errored attempt has an unknown outcome, so it is retried with the same number, and idempotency key, for the
+// gateway to charge it at most once. Only a definite outcome such as a decline moves on to a new charge.
+func nextPaymentNumber(ctx workflow.Context, attempt model.PaymentAttempt) uint32 {
+	if attempt.Status == model.PaymentErrored && hasChange(ctx, retryErroredPaymentChangeId) {
+		return attempt.Number
+	}
+	return attempt.Number + 1
+}
+
+func executePaymentChildWorkflow(ctx workflow.Context, request PaymentRequest) (model.PaymentAttempt, error) {
+	workflow.GetLogger(ctx).Info("Collecting payment", "Request", request)
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID: PaymentWorkflowId(request.BillId, request.Number),
+	})
+	var attempt model.PaymentAttempt
+	e := workflow.ExecuteChildWorkflow(childCtx, PaymentWorkflow, request).Get(childCtx, &attempt)
+	return attempt, e
+}
+
+func setBillStatusSyncActivity(ctx workflow.Context, billId model.BillId, status model.BillStatus) (uint64, error) {
+	workflow.GetLogger(ctx).Info("Setting bill status", "BillId", billId, "Status", status)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var updateCount uint64
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyActivityHost{}).SetBillStatusActivity,
+		billId,
+		status,
+	).Get(ctxWithOptions, &updateCount)
+	return updateCount, e
+}
@@ -0,0 +1,96 @@
+package workflow_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/testsuite"
+)
+
+type PaymentWorkflowUnitTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+
+	env *testsuite.TestWorkflowEnvironment
+}
+
+func TestPaymentWorkflowUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(PaymentWorkflowUnitTestSuite))
+}
+
+func (s *PaymentWorkflowUnitTestSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.env.SetStartTime(testStartTime)
+}
+
+func (s *PaymentWorkflowUnitTestSuite) AfterTest(suiteName, testName string) {
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *PaymentWorkflowUnitTestSuite) paymentRequest() workflow.PaymentRequest {
+	return workflow.PaymentRequest{
+		BillId: model.BillId{CustomerId: "alice", Id: "ca06186a-1f96-4398-9244-fbddf4ef2642"},
+		Number: 1,
+		Amount: model.TotalAmount{Number: "100", CurrencyCode: "USD"},
+	}
+}
+
+func (s *PaymentWorkflowUnitTestSuite) Test_Workflow_RecordsSucceededAttempt() {
+	// Arrange
+	request := s.paymentRequest()
+	dummyPaymentActivityHost := activity.DummyPaymentActivityHost{}
+	s.env.OnActivity(dummyPaymentActivityHost.ChargeBillActivity, mock.AnythingOfType("PaymentAttempt")).
+		Return(func(attempt model.PaymentAttempt) (model.PaymentAttempt, error) {
+			attempt.Status = model.PaymentSucceeded
+			attempt.Reference = "fake-charge-1"
+			return attempt, nil
+		}).
+		Once()
+	expected := succeededPayment(request, testStartTime)
+	s.env.OnActivity(dummyPaymentActivityHost.RecordPaymentAttemptActivity, mock.Anything, expected).Return(uint64(1), nil).Once()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.PaymentWorkflow, request)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result model.PaymentAttempt
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(expected, result)
+}
+
+func (s *PaymentWorkflowUnitTestSuite) Test_Workflow_RecordsErroredAttempt_WhenGatewayUnreachable() {
+	// Arrange
+	request := s.paymentRequest()
+	dummyPaymentActivityHost := activity.DummyPaymentActivityHost{}
+	s.env.OnActivity(dummyPaymentActivityHost.ChargeBillActivity, mock.AnythingOfType("PaymentAttempt")).
+		Return(model.PaymentAttempt{}, errors.New("gateway unavailable")).
+		Times(5)
+	var recorded model.PaymentAttempt
+	s.env.OnActivity(dummyPaymentActivityHost.RecordPaymentAttemptActivity, mock.Anything, mock.AnythingOfType("PaymentAttempt")).
+		Return(func(_ context.Context, attempt model.PaymentAttempt) (uint64, error) {
+			recorded = attempt
+			return uint64(1), nil
+		}).
+		Once()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.PaymentWorkflow, request)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var result model.PaymentAttempt
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal(recorded, result)
+	s.Equal(model.PaymentErrored, result.Status)
+	s.Contains(result.FailureReason, "gateway unavailable")
+	s.Empty(result.Reference)
+}
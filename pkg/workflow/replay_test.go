@@ -0,0 +1,39 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/worker"
+)
+
+// The histories of bills recorded from a dev server with temporal workflow show. Each gated change has a history
+// recorded just before it and one just after it, named after its change id, and the payment and dunning workflows
+// have one from before the gates. A change of the workflows that is not gated by workflow.GetVersion replays them
+// with other commands than their events, which fails this test.
+const historiesDir = "testdata/histories"
+
+func TestReplayHistories(t *testing.T) {
+	// Arrange
+	replayer := worker.NewWorkflowReplayer()
+	replayer.RegisterWorkflow(BillingWorkflow)
+	replayer.RegisterWorkflow(PaymentWorkflow)
+	replayer.RegisterWorkflow(DunningWorkflow)
+	replayer.RegisterWorkflow(ArchiveBillsWorkflow)
+	entries, err := os.ReadDir(historiesDir)
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+
+	for _, entry := range entries {
+		t.Run(entry.Name(), func(t *testing.T) {
+			// Act
+			err := replayer.ReplayWorkflowHistoryFromJSONFile(nil, filepath.Join(historiesDir, entry.Name()))
+
+			// Assert
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package workflow
+
+import (
+	"math"
+
+	"coding-challenge/pkg/model"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// The type of the bill workflows in the visibility queries, the name BillingWorkflow is registered under.
+const BillingWorkflowType = "BillingWorkflow"
+
+// The search attributes of the bill workflows, to search the bills in the Temporal visibility store. They are
+// prefixed since CloseTime and others are already attributes of every workflow.
+var (
+	CustomerIdSearchAttribute   = temporal.NewSearchAttributeKeyKeyword("BillCustomerId")
+	CurrencyCodeSearchAttribute = temporal.NewSearchAttributeKeyKeyword("BillCurrencyCode")
+	// Such as open or closed, see model.BillStatus.
+	StatusSearchAttribute    = temporal.NewSearchAttributeKeyKeyword("BillStatus")
+	CloseTimeSearchAttribute = temporal.NewSearchAttributeKeyTime("BillCloseTime")
+	// In minor units, a total beyond int64 is saturated.
+	TotalSearchAttribute = temporal.NewSearchAttributeKeyInt64("BillTotal")
+)
+
+// To register on the namespace before the workflows start.
+func SearchAttributeTypes() map[string]enums.IndexedValueType {
+	return map[string]enums.IndexedValueType{
+		CustomerIdSearchAttribute.GetName():   enums.INDEXED_VALUE_TYPE_KEYWORD,
+		CurrencyCodeSearchAttribute.GetName(): enums.INDEXED_VALUE_TYPE_KEYWORD,
+		StatusSearchAttribute.GetName():       enums.INDEXED_VALUE_TYPE_KEYWORD,
+		CloseTimeSearchAttribute.GetName():    enums.INDEXED_VALUE_TYPE_DATETIME,
+		TotalSearchAttribute.GetName():        enums.INDEXED_VALUE_TYPE_INT,
+	}
+}
+
+func searchableTotal(total model.TotalAmount) int64 {
+	n := total.BigInt()
+	if n.IsInt64() {
+		return n.Int64()
+	} else if n.Sign() < 0 {
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
+
+// Called after each change of the attributes, all of them are upserted each time. The visibility store is only
+// eventually consistent, so a failure is logged and the next change upserts them again. The bills opened before the
+// attributes existed are left without them.
+func (state *billingState) upsertSearchAttributes(ctx workflow.Context) {
+	if !hasChange(ctx, searchAttributesChangeId) {
+		return
+	}
+	e := workflow.UpsertTypedSearchAttributes(
+		ctx,
+		CustomerIdSearchAttribute.ValueSet(string(state.BillInfo.Id.CustomerId)),
+		CurrencyCodeSearchAttribute.ValueSet(string(state.BillInfo.CurrencyCode)),
+		StatusSearchAttribute.ValueSet(state.BillInfo.Status.String()),
+		CloseTimeSearchAttribute.ValueSet(state.BillInfo.CloseTime),
+		TotalSearchAttribute.ValueSet(searchableTotal(state.Total)),
+	)
+	if e != nil {
+		state.logger.Warn("Search attributes not upserted", "Bill", state.BillInfo, "Error", e)
+	}
+}
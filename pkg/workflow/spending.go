@@ -0,0 +1,168 @@
+package workflow
+
+import (
+	"math/big"
+	"slices"
+
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+const SetSpendingCapUpdate = "SetSpendingCap"
+
+type SetSpendingCapArgs struct {
+	// In the bill currency, zero removes the cap.
+	Max             int64
+	AlertThresholds []uint32
+	Actor           model.Actor
+	RequestId       string
+}
+
+func (args SetSpendingCapArgs) spendingCap(currencyCode model.CurrencyCode) model.SpendingCap {
+	return model.NewSpendingCap(args.Max, currencyCode, args.AlertThresholds)
+}
+
+// The total once the line items being added and the given amounts are added.
+func (state *billingState) totalWithReserved(amounts ...model.Amount) model.TotalAmount {
+	total := new(big.Int).Add(state.Total.BigInt(), state.reserved)
+	for _, amount := range amounts {
+		total.Add(total, big.NewInt(amount.Number))
+	}
+	return model.NewTotalAmountFromBigInt(total, state.BillInfo.CurrencyCode)
+}
+
+// Line items being added are counted so that concurrent updates cannot exceed the cap together.
+func (state *billingState) checkSpendingCap(amounts ...model.Amount) error {
+	return state.BillInfo.SpendingCap.CheckAllows(state.totalWithReserved(amounts...))
+}
+
+// Line items being added are counted so that concurrent updates cannot exceed the maximum together.
+func (state *billingState) checkLineItemCount(count int) error {
+	return state.BillInfo.CheckLineItemCount(state.BillLineItemCount + state.reservedLineItems + uint64(count))
+}
+
+// A reversal of a split charge part is exempt from the quota and the cap, see AddBillLineItemArgs.
+func (state *billingState) checkLineItemQuota(args AddBillLineItemArgs) error {
+	if args.Reversal {
+		return nil
+	}
+	return state.checkLineItemCount(1)
+}
+
+func (state *billingState) checkLineItemSpendingCap(args AddBillLineItemArgs, lineItem model.BillLineItem) error {
+	if args.Reversal {
+		return nil
+	}
+	return state.checkSpendingCap(lineItem.Amount)
+}
+
+// Credits are not reserved against the cap since they may still fail to be added, they count as line items though.
+func (state *billingState) reserve(amount model.Amount) {
+	state.reservedLineItems++
+	state.reserveAmount(amount)
+}
+
+// A line item in another currency is reserved before it is converted, its amount once converted.
+func (state *billingState) reserveAmount(amount model.Amount) {
+	if 0 < amount.Number {
+		state.reserved.Add(state.reserved, big.NewInt(amount.Number))
+	}
+}
+
+func (state *billingState) unreserve(amount model.Amount) {
+	state.reservedLineItems--
+	if 0 < amount.Number {
+		state.reserved.Sub(state.reserved, big.NewInt(amount.Number))
+	}
+}
+
+func (state *billingState) hasSpendingAlert(threshold uint32) bool {
+	return slices.ContainsFunc(state.SpendingAlerts, func(alert model.SpendingAlert) bool {
+		return alert.Threshold == threshold && alert.Cap == state.BillInfo.SpendingCap.Max
+	})
+}
+
+// Each alert is recorded as an audit entry, then the customer is notified. The line items or the cap that crossed the
+// thresholds are saved already, so an alert that fails is logged rather than failing them.
+func (state *billingState) sendSpendingAlertsSyncActivity(ctx workflow.Context, thresholds []uint32) error {
+	for _, threshold := range thresholds {
+		if state.hasSpendingAlert(threshold) {
+			continue
+		}
+		alert := model.SpendingAlert{
+			BillId:    state.BillInfo.Id,
+			Threshold: threshold,
+			Cap:       state.BillInfo.SpendingCap.Max,
+			Total:     state.Total,
+			SentAt:    workflow.Now(ctx),
+		}
+		state.SpendingAlerts = append(state.SpendingAlerts, alert)
+		state.logger.Info("Spending alert", "Alert", alert)
+		if e := state.sendSpendingAlertSyncActivity(ctx, alert); e != nil {
+			if !hasChange(ctx, logSpendingAlertFailureChangeId) {
+				return e
+			}
+			state.logger.Error("Spending alert not sent", "Alert", alert, "Error", e)
+		}
+	}
+	return nil
+}
+
+func (state *billingState) sendSpendingAlertSyncActivity(ctx workflow.Context, alert model.SpendingAlert) error {
+	if _, e := state.recordAuditEntrySyncActivity(ctx, model.AuditSpendingAlert, model.NewSystemActor(), alert.RequestId(), state.Total); e != nil {
+		return e
+	}
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	return workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummySpendingActivityHost{}).NotifySpendingAlertActivity,
+		alert,
+	).Get(ctxWithOptions, nil)
+}
+
+func (state *billingState) setBillSpendingCapSyncActivity(ctx workflow.Context, spendingCap model.SpendingCap) (uint64, error) {
+	state.logger.Info("Saving spending cap", "Bill", state.BillInfo, "SpendingCap", spendingCap)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var updateCount uint64
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyActivityHost{}).SetBillSpendingCapActivity,
+		state.BillInfo.Id,
+		spendingCap,
+	).Get(ctxWithOptions, &updateCount)
+	return updateCount, e
+}
+
+func (state *billingState) validateSetSpendingCap(ctx workflow.Context, args SetSpendingCapArgs) error {
+	state.logger.Info("Validating spending cap", "Bill", state.BillInfo, "Max", args.Max, "Thresholds", args.AlertThresholds)
+	if e := state.checkOpen(); e != nil {
+		return e
+	}
+	return args.spendingCap(state.BillInfo.CurrencyCode).Check(state.BillInfo.CurrencyCode)
+}
+
+// A cap below the current total only refuses the next line items.
+// The thresholds that the total already reached are alerted right away.
+// The cap applies while it is saved, and the previous one again if it could not be saved.
+func (state *billingState) setSpendingCapSyncActivity(ctx workflow.Context, args SetSpendingCapArgs) (BillingState, error) {
+	state.logger.Info("Setting spending cap", "Bill", state.BillInfo, "Max", args.Max, "Actor", args.Actor)
+	previous := state.BillInfo.SpendingCap
+	state.BillInfo.SpendingCap = args.spendingCap(state.BillInfo.CurrencyCode)
+	if hasChange(ctx, saveSpendingCapChangeId) {
+		if _, e := state.setBillSpendingCapSyncActivity(ctx, state.BillInfo.SpendingCap); e != nil {
+			state.BillInfo.SpendingCap = previous
+			return state.Clone(), e
+		}
+	}
+	intermediateState := state.Clone()
+	if _, e := state.recordAuditEntrySyncActivity(ctx, model.AuditSetSpendingCap, args.Actor, args.RequestId, state.Total); e != nil {
+		return intermediateState, e
+	}
+	reached := state.BillInfo.SpendingCap.CrossedThresholds(model.NewTotalAmount(state.BillInfo.CurrencyCode), state.Total)
+	if e := state.sendSpendingAlertsSyncActivity(ctx, reached); e != nil {
+		return intermediateState, e
+	}
+	return state.Clone(), nil
+}
@@ -0,0 +1,124 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/workflow"
+)
+
+type SplitChargeRequest struct {
+	SplitId string
+	// One per bill, the parts of zero left out. Their ids are also the ids of the updates adding them.
+	LineItems []model.BillLineItem
+	Actor     model.Actor
+}
+
+type SplitChargeNotReversedError struct {
+	SplitId string
+	BillIds []string
+}
+
+func (e SplitChargeNotReversedError) Error() string {
+	return fmt.Sprintf("split charge %q could not be reversed on bills %v", e.SplitId, e.BillIds)
+}
+
+func SplitChargeWorkflowId(splitId string) string {
+	return fmt.Sprintf("split-charge-%s", splitId)
+}
+
+// Retried until the bill adds or refuses the line item, so that the parts added are known and reversed.
+func splitActivityOptions() workflow.ActivityOptions {
+	options := defaultActivityOptions()
+	options.RetryPolicy.MaximumInterval = time.Minute
+	options.RetryPolicy.MaximumAttempts = 0 // Unlimited
+	return options
+}
+
+// Adds the parts in order. When a bill refuses a part, the parts already added are reversed with line items of the
+// opposite amount, and the refusal is returned. The reversals are exempt from the line item quota and the spending cap,
+// which a part may have filled. A reversal refused in turn, such as by a bill closed since, fails the workflow with
+// SplitChargeNotReversedError for an operator.
+func SplitChargeWorkflow(ctx workflow.Context, request SplitChargeRequest) error {
+	logger := workflow.GetLogger(ctx)
+	added := make([]model.BillLineItem, 0, len(request.LineItems))
+	for _, lineItem := range request.LineItems {
+		logger.Info("Adding split charge part", "SplitId", request.SplitId, "Line item", lineItem)
+		if e := addSplitLineItemSyncActivity(ctx, lineItem, request.Actor, false); e != nil {
+			logger.Error("Bill refused split charge part, reversing", "SplitId", request.SplitId, "Line item", lineItem, "Error", e)
+			if reverseErr := reverseSplitCharge(ctx, request, added); reverseErr != nil {
+				return reverseErr
+			}
+			return e
+		}
+		added = append(added, lineItem)
+	}
+	return nil
+}
+
+func reverseSplitCharge(ctx workflow.Context, request SplitChargeRequest, added []model.BillLineItem) error {
+	logger := workflow.GetLogger(ctx)
+	notReversed := SplitChargeNotReversedError{SplitId: request.SplitId}
+	for _, lineItem := range added {
+		reversed, ok := lineItem.Amount.Negate()
+		if !ok {
+			logger.Error("Split charge part cannot be negated", "SplitId", request.SplitId, "Line item", lineItem)
+			notReversed.BillIds = append(notReversed.BillIds, lineItem.Id.BillId.Id)
+			continue
+		}
+		reversal := model.BillLineItem{
+			Id:          model.BillLineItemId{BillId: lineItem.Id.BillId, Id: "reversal-" + lineItem.Id.Id},
+			Description: "Reversal of " + lineItem.Description,
+			Amount:      reversed,
+		}
+		if e := addSplitLineItemSyncActivity(ctx, reversal, request.Actor, true); e != nil {
+			logger.Error("Bill refused split charge reversal", "SplitId", request.SplitId, "Line item", reversal, "Error", e)
+			notReversed.BillIds = append(notReversed.BillIds, lineItem.Id.BillId.Id)
+		}
+	}
+	if len(notReversed.BillIds) != 0 {
+		return notReversed
+	}
+	return nil
+}
+
+func addSplitLineItemSyncActivity(ctx workflow.Context, lineItem model.BillLineItem, actor model.Actor, reversal bool) error {
+	ctxWithOptions := workflow.WithActivityOptions(ctx, splitActivityOptions())
+	return workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummySplitActivityHost{}).AddSplitLineItemActivity,
+		lineItem,
+		actor,
+		reversal,
+	).Get(ctxWithOptions, nil)
+}
+
+type billLineItemUpdater struct {
+	client client.Client
+}
+
+// Sends the AddBillLineItemUpdate of the split charge activities.
+func NewBillLineItemAdder(c client.Client) activity.BillLineItemAdder {
+	return &billLineItemUpdater{client: c}
+}
+
+func (u *billLineItemUpdater) AddBillLineItem(ctx context.Context, updateId string, lineItem model.BillLineItem, actor model.Actor, reversal bool) error {
+	updateHandle, err := u.client.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		UpdateID:   updateId,
+		WorkflowID: BillingWorkflowId(lineItem.Id.BillId.Id),
+		UpdateName: AddBillLineItemUpdate,
+		Args: []interface{}{
+			AddBillLineItemArgs{LineItem: lineItem, Actor: actor, RequestId: updateId, Reversal: reversal},
+		},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		return err
+	}
+	return updateHandle.Get(ctx, nil)
+}
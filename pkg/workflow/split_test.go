@@ -0,0 +1,139 @@
+package workflow_test
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+	"coding-challenge/pkg/workflow"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+type SplitChargeWorkflowUnitTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+
+	env *testsuite.TestWorkflowEnvironment
+}
+
+func TestSplitChargeWorkflowUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(SplitChargeWorkflowUnitTestSuite))
+}
+
+func (s *SplitChargeWorkflowUnitTestSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.env.SetStartTime(testStartTime)
+}
+
+func (s *SplitChargeWorkflowUnitTestSuite) AfterTest(suiteName, testName string) {
+	s.env.AssertExpectations(s.T())
+}
+
+func (s *SplitChargeWorkflowUnitTestSuite) splitChargeRequest() workflow.SplitChargeRequest {
+	billId1 := model.BillId{CustomerId: "alice", Id: "fc03932f-2b53-4d07-ad55-24fc7d85e277"}
+	billId2 := model.BillId{CustomerId: "alice", Id: "0b6f1c1e-8d0e-4f57-9a2b-3c4d5e6f7a8b"}
+	return workflow.SplitChargeRequest{
+		SplitId: "split-1",
+		LineItems: []model.BillLineItem{
+			{
+				Id:          model.BillLineItemId{BillId: billId1, Id: "line-item-1"},
+				Description: "Dinner (1/2)",
+				Amount:      model.Amount{Number: 501, CurrencyCode: "USD"},
+			},
+			{
+				Id:          model.BillLineItemId{BillId: billId2, Id: "line-item-2"},
+				Description: "Dinner (2/2)",
+				Amount:      model.Amount{Number: 500, CurrencyCode: "USD"},
+			},
+		},
+		Actor: model.NewUserActor("alice"),
+	}
+}
+
+func reversalOf(lineItem model.BillLineItem) model.BillLineItem {
+	return model.BillLineItem{
+		Id:          model.BillLineItemId{BillId: lineItem.Id.BillId, Id: "reversal-" + lineItem.Id.Id},
+		Description: "Reversal of " + lineItem.Description,
+		Amount:      model.Amount{Number: -lineItem.Amount.Number, CurrencyCode: lineItem.Amount.CurrencyCode},
+	}
+}
+
+func (s *SplitChargeWorkflowUnitTestSuite) Test_Workflow_AddsEveryPart() {
+	// Arrange
+	request := s.splitChargeRequest()
+	dummySplitActivityHost := activity.DummySplitActivityHost{}
+	for _, lineItem := range request.LineItems {
+		s.env.OnActivity(dummySplitActivityHost.AddSplitLineItemActivity, mock.Anything, lineItem, request.Actor, false).Return(nil).Once()
+	}
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.SplitChargeWorkflow, request)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+}
+
+func (s *SplitChargeWorkflowUnitTestSuite) Test_Workflow_RetriesPart_UntilBillAnswers() {
+	// Arrange
+	request := s.splitChargeRequest()
+	dummySplitActivityHost := activity.DummySplitActivityHost{}
+	s.env.OnActivity(dummySplitActivityHost.AddSplitLineItemActivity, mock.Anything, request.LineItems[0], request.Actor, false).
+		Return(errors.New("unavailable")).
+		Times(9)
+	s.env.OnActivity(dummySplitActivityHost.AddSplitLineItemActivity, mock.Anything, request.LineItems[0], request.Actor, false).Return(nil).Once()
+	s.env.OnActivity(dummySplitActivityHost.AddSplitLineItemActivity, mock.Anything, request.LineItems[1], request.Actor, false).Return(nil).Once()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.SplitChargeWorkflow, request)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+}
+
+func (s *SplitChargeWorkflowUnitTestSuite) Test_Workflow_ReversesAddedParts_WhenBillRefusesPart() {
+	// Arrange
+	request := s.splitChargeRequest()
+	dummySplitActivityHost := activity.DummySplitActivityHost{}
+	s.env.OnActivity(dummySplitActivityHost.AddSplitLineItemActivity, mock.Anything, request.LineItems[0], request.Actor, false).Return(nil).Once()
+	s.env.OnActivity(dummySplitActivityHost.AddSplitLineItemActivity, mock.Anything, request.LineItems[1], request.Actor, false).
+		Return(temporal.NewNonRetryableApplicationError("refused", "SpendingCapExceededError", nil)).
+		Once()
+	s.env.OnActivity(dummySplitActivityHost.AddSplitLineItemActivity, mock.Anything, reversalOf(request.LineItems[0]), request.Actor, true).Return(nil).Once()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.SplitChargeWorkflow, request)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	var applicationError *temporal.ApplicationError
+	s.ErrorAs(s.env.GetWorkflowError(), &applicationError)
+	s.Equal("SpendingCapExceededError", applicationError.Type())
+}
+
+func (s *SplitChargeWorkflowUnitTestSuite) Test_Workflow_Fails_WhenBillRefusesReversal() {
+	// Arrange
+	request := s.splitChargeRequest()
+	dummySplitActivityHost := activity.DummySplitActivityHost{}
+	s.env.OnActivity(dummySplitActivityHost.AddSplitLineItemActivity, mock.Anything, request.LineItems[0], request.Actor, false).Return(nil).Once()
+	s.env.OnActivity(dummySplitActivityHost.AddSplitLineItemActivity, mock.Anything, request.LineItems[1], request.Actor, false).
+		Return(temporal.NewNonRetryableApplicationError("refused", "SpendingCapExceededError", nil)).
+		Once()
+	s.env.OnActivity(dummySplitActivityHost.AddSplitLineItemActivity, mock.Anything, reversalOf(request.LineItems[0]), request.Actor, true).
+		Return(temporal.NewNonRetryableApplicationError("closed", activity.BillNotRunningErrorType, nil)).
+		Once()
+
+	// Act
+	s.env.ExecuteWorkflow(workflow.SplitChargeWorkflow, request)
+
+	// Assert
+	s.True(s.env.IsWorkflowCompleted())
+	var applicationError *temporal.ApplicationError
+	s.ErrorAs(s.env.GetWorkflowError(), &applicationError)
+	s.Equal("SplitChargeNotReversedError", applicationError.Type())
+}
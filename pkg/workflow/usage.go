@@ -0,0 +1,54 @@
+package workflow
+
+import (
+	"coding-challenge/pkg/activity"
+	"coding-challenge/pkg/model"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// Usage events are refused once the bill is closing, so its usage is aggregated once and for all.
+// The usage is not refused by the spending cap since it was already consumed, but it is alerted.
+func (state *billingState) addUsageLineItemsSyncActivity(ctx workflow.Context) error {
+	state.logger.Info("Aggregating usage", "Bill", state.BillInfo)
+	ctxWithOptions := workflow.WithActivityOptions(ctx, defaultActivityOptions())
+	var lineItems []model.BillLineItem
+	e := workflow.ExecuteActivity(
+		ctxWithOptions,
+		(&activity.DummyUsageActivityHost{}).AggregateUsageActivity,
+		state.BillInfo,
+	).Get(ctxWithOptions, &lineItems)
+	if e != nil {
+		return e
+	}
+	for _, lineItem := range lineItems {
+		lineItem.CreatedAt = workflow.Now(ctx)
+		state.logger.Info("Adding usage line item", "Bill", state.BillInfo, "Line item", lineItem)
+		totalBefore := state.Total
+		var updateCount uint64
+		e = workflow.ExecuteActivity(
+			ctxWithOptions,
+			(&activity.DummyActivityHost{}).AddBillLineItemIfNotExistActivity,
+			lineItem,
+			state.Total,
+		).Get(ctxWithOptions, &updateCount)
+		if e != nil {
+			return e
+		} else if updateCount == 0 {
+			continue
+		}
+		state.BillLineItemCount += updateCount
+		if e = state.Total.Add(lineItem.Amount); e != nil {
+			return e
+		}
+		state.publishBillEventAsyncActivity(ctx, model.BillLineItemAdded, lineItem.Id.Id)
+		// The line item id is unique per meter and unit price
+		if _, e = state.recordAuditEntrySyncActivity(ctx, model.AuditAddLineItem, model.NewSystemActor(), lineItem.Id.Id, totalBefore); e != nil {
+			return e
+		}
+		if e = state.sendSpendingAlertsSyncActivity(ctx, state.BillInfo.SpendingCap.CrossedThresholds(totalBefore, state.Total)); e != nil {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package workflow
+
+import (
+	"go.temporal.io/sdk/workflow"
+)
+
+// The changes of the commands that the workflows send, each one behind workflow.GetVersion so that the workflows
+// started before it replay as they ran. Its id is never reused, and the branch of workflow.DefaultVersion is only
+// removed once no workflow started before the change runs anymore. TestReplayHistories replays the histories of
+// testdata/histories to catch a change that is not gated.
+//
+// The gates start with the search attributes. The changes before them are not gated, they also changed the
+// arguments and the statuses the workflows encode, so the workflows started before them cannot replay either way.
+const (
+	// The bill workflows upsert their search attributes, see upsertSearchAttributes.
+	searchAttributesChangeId = "search-attributes"
+	// An errored payment is retried with its number, see nextPaymentNumber.
+	retryErroredPaymentChangeId = "retry-errored-payment"
+	// The bill workflows ask whether line items can be converted, see fxAvailableSyncActivity.
+	fxAvailableChangeId = "fx-available"
+	// The spending cap that an update sets is saved, see setSpendingCapSyncActivity.
+	saveSpendingCapChangeId = "save-spending-cap"
+	// A spending alert that fails is logged rather than failing the update, see sendSpendingAlertsSyncActivity.
+	logSpendingAlertFailureChangeId = "log-spending-alert-failure"
+	// The bill waits for the line items being added before it is taxed, see checkStillOpen.
+	awaitLineItemsChangeId = "await-line-items"
+	// The bill waits for the coupons being redeemed before it computes the discounts, see attachCouponSyncActivity.
+	awaitCouponsChangeId = "await-coupons"
+)
+
+// Whether the change applies to the workflow: it does unless the workflow already ran past it without it.
+func hasChange(ctx workflow.Context, changeId string) bool {
+	return workflow.GetVersion(ctx, changeId, workflow.DefaultVersion, 1) != workflow.DefaultVersion
+}